@@ -0,0 +1,146 @@
+package codex
+
+import (
+	"context"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+// AccountInfo describes the account the app-server is logged in with.
+type AccountInfo struct {
+	// AuthMode is empty when the app-server is not logged in.
+	AuthMode AuthMode
+	// Email and PlanType describe a ChatGPT account.
+	Email    string
+	PlanType protocol.PlanType
+	// RequiresOpenAIAuth reports whether the configured model provider
+	// needs OpenAI credentials at all.
+	RequiresOpenAIAuth bool
+}
+
+// LoggedIn reports whether the app-server has credentials.
+func (a AccountInfo) LoggedIn() bool {
+	return a.AuthMode != ""
+}
+
+// AccountInfo reads the current account.
+func (c *Codex) AccountInfo(ctx context.Context) (*AccountInfo, error) {
+	if err := c.ensureReady(); err != nil {
+		return nil, err
+	}
+	var response struct {
+		Account *struct {
+			Type     AuthMode          `json:"type"`
+			Email    string            `json:"email"`
+			PlanType protocol.PlanType `json:"planType"`
+		} `json:"account"`
+		RequiresOpenAIAuth bool `json:"requiresOpenaiAuth"`
+	}
+	if _, err := c.call(ctx, "account/read", protocol.GetAccountParams{}, &response); err != nil {
+		return nil, err
+	}
+	info := &AccountInfo{RequiresOpenAIAuth: response.RequiresOpenAIAuth}
+	if account := response.Account; account != nil {
+		info.AuthMode = account.Type
+		info.Email = account.Email
+		info.PlanType = account.PlanType
+	}
+	return info, nil
+}
+
+// RateLimits reports the account's usage limits.
+type RateLimits struct {
+	// Default is the limit that applies to regular Codex usage.
+	Default RateLimitSnapshot
+	// ByLimitID holds every limit the server reported, including Default,
+	// keyed by limit id. It is nil when the server reports only one.
+	ByLimitID map[string]RateLimitSnapshot
+}
+
+// RateLimitSnapshot is the state of one usage limit.
+type RateLimitSnapshot struct {
+	LimitID   string
+	LimitName string
+	PlanType  protocol.PlanType
+	// Primary is the short usage window and Secondary the long one; either
+	// is nil when the limit has no such window.
+	Primary   *RateLimitWindow
+	Secondary *RateLimitWindow
+	// Credits is nil when the account has no credit balance.
+	Credits *protocol.CreditsSnapshot
+	// ReachedType names the limit that was hit, or is empty while usage is
+	// under every limit.
+	ReachedType string
+}
+
+// RateLimitWindow is the usage of one rolling window.
+type RateLimitWindow struct {
+	// UsedPercent is the share of the window's allowance used, 0-100.
+	UsedPercent int
+	// Duration is the window length, or zero when the server omits it.
+	Duration time.Duration
+	// ResetsAt is when usage drops out of the window, or the zero time when
+	// the server omits it.
+	ResetsAt time.Time
+}
+
+// GetRateLimits reads the account's current usage limits.
+func (c *Codex) GetRateLimits(ctx context.Context) (*RateLimits, error) {
+	if err := c.ensureReady(); err != nil {
+		return nil, err
+	}
+	var response struct {
+		RateLimits        rateLimitSnapshotJSON            `json:"rateLimits"`
+		RateLimitsByLimit map[string]rateLimitSnapshotJSON `json:"rateLimitsByLimitId"`
+	}
+	if _, err := c.call(ctx, "account/rateLimits/read", nil, &response); err != nil {
+		return nil, err
+	}
+	limits := &RateLimits{Default: response.RateLimits.snapshot()}
+	if len(response.RateLimitsByLimit) > 0 {
+		limits.ByLimitID = make(map[string]RateLimitSnapshot, len(response.RateLimitsByLimit))
+		for id, snapshot := range response.RateLimitsByLimit {
+			limits.ByLimitID[id] = snapshot.snapshot()
+		}
+	}
+	return limits, nil
+}
+
+// rateLimitSnapshotJSON is the wire form of a rate limit snapshot, whose
+// generated type leaves its nullable fields untyped.
+type rateLimitSnapshotJSON struct {
+	LimitID              string                    `json:"limitId"`
+	LimitName            string                    `json:"limitName"`
+	PlanType             protocol.PlanType         `json:"planType"`
+	Primary              *protocol.RateLimitWindow `json:"primary"`
+	Secondary            *protocol.RateLimitWindow `json:"secondary"`
+	Credits              *protocol.CreditsSnapshot `json:"credits"`
+	RateLimitReachedType string                    `json:"rateLimitReachedType"`
+}
+
+func (s rateLimitSnapshotJSON) snapshot() RateLimitSnapshot {
+	return RateLimitSnapshot{
+		LimitID:     s.LimitID,
+		LimitName:   s.LimitName,
+		PlanType:    s.PlanType,
+		Primary:     rateLimitWindow(s.Primary),
+		Secondary:   rateLimitWindow(s.Secondary),
+		Credits:     s.Credits,
+		ReachedType: s.RateLimitReachedType,
+	}
+}
+
+func rateLimitWindow(window *protocol.RateLimitWindow) *RateLimitWindow {
+	if window == nil {
+		return nil
+	}
+	result := &RateLimitWindow{UsedPercent: window.UsedPercent}
+	if window.WindowDurationMins != nil {
+		result.Duration = time.Duration(*window.WindowDurationMins) * time.Minute
+	}
+	if window.ResetsAt != nil {
+		result.ResetsAt = time.Unix(int64(*window.ResetsAt), 0)
+	}
+	return result
+}
@@ -0,0 +1,86 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestAccountInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		account any
+		want    AccountInfo
+	}{
+		{name: "logged out", account: nil, want: AccountInfo{RequiresOpenAIAuth: true}},
+		{name: "api key", account: map[string]any{"type": "apiKey"}, want: AccountInfo{AuthMode: AuthModeAPIKey, RequiresOpenAIAuth: true}},
+		{
+			name:    "chatgpt",
+			account: map[string]any{"type": "chatgpt", "email": "dev@example.com", "planType": "pro"},
+			want:    AccountInfo{AuthMode: AuthModeChatGPT, Email: "dev@example.com", PlanType: protocol.PlanTypePro, RequiresOpenAIAuth: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, codex := newFakeAppServer(t, map[string]fakeHandler{
+				"account/read": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+					return map[string]any{"account": tt.account, "requiresOpenaiAuth": true}, nil
+				},
+			})
+			info, err := codex.AccountInfo(context.Background())
+			if err != nil {
+				t.Fatalf("AccountInfo: %v", err)
+			}
+			if *info != tt.want {
+				t.Fatalf("info = %+v, want %+v", *info, tt.want)
+			}
+			if info.LoggedIn() != (tt.account != nil) {
+				t.Fatalf("LoggedIn = %v", info.LoggedIn())
+			}
+		})
+	}
+}
+
+func TestGetRateLimits(t *testing.T) {
+	primary := map[string]any{"usedPercent": 42, "windowDurationMins": 300, "resetsAt": 1760000000}
+	_, codex := newFakeAppServer(t, map[string]fakeHandler{
+		"account/rateLimits/read": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			return map[string]any{
+				"rateLimits": map[string]any{
+					"limitId":   "codex",
+					"planType":  "plus",
+					"primary":   primary,
+					"secondary": nil,
+					"credits":   map[string]any{"hasCredits": true, "unlimited": false, "balance": "12.50"},
+				},
+				"rateLimitsByLimitId": map[string]any{
+					"codex":       map[string]any{"limitId": "codex", "primary": primary},
+					"codex_other": map[string]any{"limitId": "codex_other", "rateLimitReachedType": "primary"},
+				},
+			}, nil
+		},
+	})
+	limits, err := codex.GetRateLimits(context.Background())
+	if err != nil {
+		t.Fatalf("GetRateLimits: %v", err)
+	}
+	wantWindow := &RateLimitWindow{UsedPercent: 42, Duration: 5 * time.Hour, ResetsAt: time.Unix(1760000000, 0)}
+	want := RateLimitSnapshot{
+		LimitID:  "codex",
+		PlanType: protocol.PlanTypePlus,
+		Primary:  wantWindow,
+		Credits:  &protocol.CreditsSnapshot{HasCredits: true, Balance: stringPtr("12.50")},
+	}
+	if !reflect.DeepEqual(limits.Default, want) {
+		t.Fatalf("Default = %+v, want %+v", limits.Default, want)
+	}
+	if len(limits.ByLimitID) != 2 || limits.ByLimitID["codex_other"].ReachedType != "primary" ||
+		!reflect.DeepEqual(limits.ByLimitID["codex"].Primary, wantWindow) {
+		t.Fatalf("ByLimitID = %+v", limits.ByLimitID)
+	}
+}
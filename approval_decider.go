@@ -0,0 +1,253 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+// ApprovalKind identifies what an approval request asks permission for.
+type ApprovalKind string
+
+const (
+	// ApprovalKindCommand asks to run a command.
+	ApprovalKindCommand ApprovalKind = "command"
+	// ApprovalKindFileChange asks to apply file changes.
+	ApprovalKindFileChange ApprovalKind = "fileChange"
+	// ApprovalKindPermissions asks for additional sandbox permissions.
+	ApprovalKindPermissions ApprovalKind = "permissions"
+)
+
+// ApprovalDecision is the outcome of an approval request.
+type ApprovalDecision string
+
+const (
+	ApprovalAccept           ApprovalDecision = "accept"
+	ApprovalAcceptForSession ApprovalDecision = "acceptForSession"
+	ApprovalDecline          ApprovalDecision = "decline"
+	ApprovalCancel           ApprovalDecision = "cancel"
+)
+
+// ApprovalRequest is a normalized view of the approval requests the
+// app-server sends, covering both the current item/* methods and the legacy
+// applyPatchApproval/execCommandApproval methods.
+type ApprovalRequest struct {
+	Kind ApprovalKind `json:"kind"`
+	// Method is the JSON-RPC method of the original request.
+	Method   string `json:"method"`
+	ThreadID string `json:"threadId,omitempty"`
+	TurnID   string `json:"turnId,omitempty"`
+	ItemID   string `json:"itemId,omitempty"`
+	// Command is the command line for command approvals.
+	Command string `json:"command,omitempty"`
+	Cwd     string `json:"cwd,omitempty"`
+	// Paths lists affected paths for file change approvals when the request
+	// includes them, plus any requested grant root.
+	Paths  []string `json:"paths,omitempty"`
+	Reason string   `json:"reason,omitempty"`
+	// Params holds the original request params.
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// ApprovalDecider decides approval requests.
+type ApprovalDecider interface {
+	DecideApproval(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error)
+}
+
+// ApprovalDeciderFunc adapts a function to ApprovalDecider.
+type ApprovalDeciderFunc func(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error)
+
+// DecideApproval calls f.
+func (f ApprovalDeciderFunc) DecideApproval(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+	return f(ctx, req)
+}
+
+// DecisionHandler adapts an ApprovalDecider to rpc.ServerRequestHandler.
+// Non-approval server requests (tool calls, user input, MCP elicitation, auth
+// refresh) return errors, matching AutoApproveHandler.
+// Logger controls approval logging. When nil, logs are discarded.
+type DecisionHandler struct {
+	Decider ApprovalDecider
+	Logger  *slog.Logger
+}
+
+// NewDecisionHandler returns a DecisionHandler for decider.
+func NewDecisionHandler(decider ApprovalDecider) *DecisionHandler {
+	return &DecisionHandler{Decider: decider}
+}
+
+// ItemCommandExecutionRequestApproval decides command execution requests.
+func (h *DecisionHandler) ItemCommandExecutionRequestApproval(ctx context.Context, params protocol.CommandExecutionRequestApprovalParams) (*protocol.CommandExecutionRequestApprovalResponse, error) {
+	req := ApprovalRequest{
+		Kind:     ApprovalKindCommand,
+		Method:   "item/commandExecution/requestApproval",
+		ThreadID: params.ThreadID,
+		TurnID:   params.TurnID,
+		ItemID:   params.ItemID,
+		Command:  derefString(params.Command),
+		Cwd:      derefString(params.Cwd),
+		Reason:   derefString(params.Reason),
+	}
+	decision, err := h.decide(ctx, req, params)
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.CommandExecutionRequestApprovalResponse{Decision: string(decision)}, nil
+}
+
+// ItemFileChangeRequestApproval decides file change requests.
+func (h *DecisionHandler) ItemFileChangeRequestApproval(ctx context.Context, params protocol.FileChangeRequestApprovalParams) (*protocol.FileChangeRequestApprovalResponse, error) {
+	req := ApprovalRequest{
+		Kind:     ApprovalKindFileChange,
+		Method:   "item/fileChange/requestApproval",
+		ThreadID: params.ThreadID,
+		TurnID:   params.TurnID,
+		ItemID:   params.ItemID,
+		Reason:   derefString(params.Reason),
+	}
+	if params.GrantRoot != nil {
+		req.Paths = []string{*params.GrantRoot}
+	}
+	decision, err := h.decide(ctx, req, params)
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.FileChangeRequestApprovalResponse{Decision: string(decision)}, nil
+}
+
+// ItemPermissionsRequestApproval grants the requested permissions when the
+// decision accepts them and grants nothing otherwise.
+func (h *DecisionHandler) ItemPermissionsRequestApproval(ctx context.Context, params protocol.PermissionsRequestApprovalParams) (*protocol.PermissionsRequestApprovalResponse, error) {
+	req := ApprovalRequest{
+		Kind:     ApprovalKindPermissions,
+		Method:   "item/permissions/requestApproval",
+		ThreadID: params.ThreadID,
+		TurnID:   params.TurnID,
+		ItemID:   params.ItemID,
+		Reason:   derefString(params.Reason),
+	}
+	decision, err := h.decide(ctx, req, params)
+	if err != nil {
+		return nil, err
+	}
+	if decision == ApprovalAccept || decision == ApprovalAcceptForSession {
+		return &protocol.PermissionsRequestApprovalResponse{Permissions: params.Permissions}, nil
+	}
+	return &protocol.PermissionsRequestApprovalResponse{Permissions: map[string]any{}}, nil
+}
+
+// ApplyPatchApproval decides legacy patch requests.
+func (h *DecisionHandler) ApplyPatchApproval(ctx context.Context, params protocol.ApplyPatchApprovalParams) (*protocol.ApplyPatchApprovalResponse, error) {
+	req := ApprovalRequest{
+		Kind:     ApprovalKindFileChange,
+		Method:   "applyPatchApproval",
+		ThreadID: string(params.ConversationID),
+		ItemID:   params.CallID,
+		Reason:   derefString(params.Reason),
+	}
+	for path := range params.FileChanges {
+		req.Paths = append(req.Paths, path)
+	}
+	sort.Strings(req.Paths)
+	if params.GrantRoot != nil {
+		req.Paths = append(req.Paths, *params.GrantRoot)
+	}
+	decision, err := h.decide(ctx, req, params)
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.ApplyPatchApprovalResponse{Decision: legacyDecision(decision)}, nil
+}
+
+// ExecCommandApproval decides legacy command requests.
+func (h *DecisionHandler) ExecCommandApproval(ctx context.Context, params protocol.ExecCommandApprovalParams) (*protocol.ExecCommandApprovalResponse, error) {
+	req := ApprovalRequest{
+		Kind:     ApprovalKindCommand,
+		Method:   "execCommandApproval",
+		ThreadID: string(params.ConversationID),
+		ItemID:   params.CallID,
+		Command:  strings.Join(params.Command, " "),
+		Cwd:      params.Cwd,
+		Reason:   derefString(params.Reason),
+	}
+	decision, err := h.decide(ctx, req, params)
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.ExecCommandApprovalResponse{Decision: legacyDecision(decision)}, nil
+}
+
+// ItemToolCall returns an error for dynamic tool calls.
+func (h *DecisionHandler) ItemToolCall(ctx context.Context, params protocol.DynamicToolCallParams) (*protocol.DynamicToolCallResponse, error) {
+	return nil, errors.New("tool calls require a custom handler")
+}
+
+// ItemToolRequestUserInput returns an error for tool user input prompts.
+func (h *DecisionHandler) ItemToolRequestUserInput(ctx context.Context, params protocol.ToolRequestUserInputParams) (*protocol.ToolRequestUserInputResponse, error) {
+	return nil, errors.New("tool user input requires a custom handler")
+}
+
+// McpServerElicitationRequest returns an error for MCP elicitation prompts.
+func (h *DecisionHandler) McpServerElicitationRequest(ctx context.Context, params protocol.McpServerElicitationRequestParams) (*protocol.McpServerElicitationRequestResponse, error) {
+	return nil, errors.New("mcp elicitation requires a custom handler")
+}
+
+// AccountChatgptAuthTokensRefresh returns an error for auth refresh requests.
+func (h *DecisionHandler) AccountChatgptAuthTokensRefresh(ctx context.Context, params protocol.ChatgptAuthTokensRefreshParams) (*protocol.ChatgptAuthTokensRefreshResponse, error) {
+	return nil, errors.New("chatgpt auth token refresh requires a custom handler")
+}
+
+func (h *DecisionHandler) decide(ctx context.Context, req ApprovalRequest, params any) (ApprovalDecision, error) {
+	if h == nil || h.Decider == nil {
+		return "", errors.New("approval decider is not configured")
+	}
+	if raw, err := json.Marshal(params); err == nil {
+		req.Params = raw
+	}
+	logger := resolveLogger(h.Logger)
+	decision, err := h.Decider.DecideApproval(ctx, req)
+	if err != nil {
+		logger.Error("codex approval decision failed", "method", req.Method, "thread_id", req.ThreadID, "item_id", req.ItemID, "error", err)
+		return "", err
+	}
+	if !validDecision(decision) {
+		return "", fmt.Errorf("unknown approval decision %q", decision)
+	}
+	logger.Info("codex approval decided", "method", req.Method, "thread_id", req.ThreadID, "item_id", req.ItemID, "decision", string(decision))
+	return decision, nil
+}
+
+func validDecision(decision ApprovalDecision) bool {
+	switch decision {
+	case ApprovalAccept, ApprovalAcceptForSession, ApprovalDecline, ApprovalCancel:
+		return true
+	default:
+		return false
+	}
+}
+
+func legacyDecision(decision ApprovalDecision) string {
+	switch decision {
+	case ApprovalAccept:
+		return "approved"
+	case ApprovalAcceptForSession:
+		return "approved_for_session"
+	case ApprovalCancel:
+		return "abort"
+	default:
+		return "denied"
+	}
+}
+
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,110 @@
+package codex
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+func TestDecisionHandlerNormalizesRequests(t *testing.T) {
+	var got []ApprovalRequest
+	handler := NewDecisionHandler(ApprovalDeciderFunc(func(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+		got = append(got, req)
+		return ApprovalDecline, nil
+	}))
+	ctx := context.Background()
+
+	cmdResp, err := handler.ItemCommandExecutionRequestApproval(ctx, protocol.CommandExecutionRequestApprovalParams{
+		ThreadID: "thr", TurnID: "turn", ItemID: "item", Command: stringPtr("rm -rf /"), Cwd: stringPtr("/tmp"),
+	})
+	if err != nil {
+		t.Fatalf("command approval error: %v", err)
+	}
+	assertEqual(t, "command decision", cmdResp.Decision, "decline")
+
+	patchResp, err := handler.ApplyPatchApproval(ctx, protocol.ApplyPatchApprovalParams{
+		CallID: "call", ConversationID: "thr", FileChanges: map[string]any{"b.go": nil, "a.go": nil},
+	})
+	if err != nil {
+		t.Fatalf("patch approval error: %v", err)
+	}
+	assertEqual(t, "patch decision", patchResp.Decision, "denied")
+
+	execResp, err := handler.ExecCommandApproval(ctx, protocol.ExecCommandApprovalParams{
+		CallID: "call", ConversationID: "thr", Command: []string{"go", "test"}, Cwd: "/src",
+	})
+	if err != nil {
+		t.Fatalf("exec approval error: %v", err)
+	}
+	assertEqual(t, "exec decision", execResp.Decision, "denied")
+
+	permResp, err := handler.ItemPermissionsRequestApproval(ctx, protocol.PermissionsRequestApprovalParams{
+		ThreadID: "thr", Permissions: map[string]any{"network": true},
+	})
+	if err != nil {
+		t.Fatalf("permissions approval error: %v", err)
+	}
+	assertEqual(t, "declined permissions", permResp.Permissions, map[string]any{})
+
+	if len(got) != 4 {
+		t.Fatalf("expected 4 decisions, got %d", len(got))
+	}
+	assertEqual(t, "command kind", got[0].Kind, ApprovalKindCommand)
+	assertEqual(t, "command", got[0].Command, "rm -rf /")
+	assertEqual(t, "cwd", got[0].Cwd, "/tmp")
+	assertEqual(t, "patch paths", got[1].Paths, []string{"a.go", "b.go"})
+	assertEqual(t, "exec command", got[2].Command, "go test")
+	if len(got[0].Params) == 0 {
+		t.Fatalf("expected raw params to be attached")
+	}
+}
+
+func TestDecisionHandlerLegacyDecisions(t *testing.T) {
+	tests := []struct {
+		decision ApprovalDecision
+		want     string
+	}{
+		{ApprovalAccept, "approved"},
+		{ApprovalAcceptForSession, "approved_for_session"},
+		{ApprovalDecline, "denied"},
+		{ApprovalCancel, "abort"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.decision), func(t *testing.T) {
+			handler := NewDecisionHandler(ApprovalDeciderFunc(func(context.Context, ApprovalRequest) (ApprovalDecision, error) {
+				return tt.decision, nil
+			}))
+			resp, err := handler.ExecCommandApproval(context.Background(), protocol.ExecCommandApprovalParams{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertEqual(t, "decision", resp.Decision, tt.want)
+		})
+	}
+}
+
+func TestDecisionHandlerErrors(t *testing.T) {
+	ctx := context.Background()
+	if _, err := (&DecisionHandler{}).ItemCommandExecutionRequestApproval(ctx, protocol.CommandExecutionRequestApprovalParams{}); err == nil {
+		t.Fatalf("expected missing decider error")
+	}
+
+	handler := NewDecisionHandler(ApprovalDeciderFunc(func(context.Context, ApprovalRequest) (ApprovalDecision, error) {
+		return "maybe", nil
+	}))
+	if _, err := handler.ItemFileChangeRequestApproval(ctx, protocol.FileChangeRequestApprovalParams{}); err == nil {
+		t.Fatalf("expected unknown decision error")
+	}
+
+	handler.Decider = ApprovalDeciderFunc(func(context.Context, ApprovalRequest) (ApprovalDecision, error) {
+		return "", errors.New("unreachable")
+	})
+	if _, err := handler.ItemFileChangeRequestApproval(ctx, protocol.FileChangeRequestApprovalParams{}); err == nil {
+		t.Fatalf("expected decider error")
+	}
+	if _, err := handler.ItemToolCall(ctx, protocol.DynamicToolCallParams{}); err == nil {
+		t.Fatalf("expected tool call error")
+	}
+}
@@ -0,0 +1,51 @@
+package codex
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+// AttachOptions locates an app-server that another process, such as an
+// IDE, started and keeps running. The SDK connects as an additional client
+// with its own ClientInfo and shares the server's threads: ResumeThread
+// picks up threads the other client started. Close and CloseContext only
+// disconnect; they never ask the server to shut down.
+type AttachOptions struct {
+	// SocketPath is the Unix domain socket the app-server listens on. It
+	// takes precedence over Port.
+	SocketPath string
+	// Port is the TCP port the app-server listens on, on Host.
+	Port int
+	// Host is the TCP host (defaults to 127.0.0.1).
+	Host string
+	// Dial configures the connection, for example TLS or a Handshake for
+	// servers that require a token.
+	Dial rpc.DialOptions
+}
+
+// address describes the attach target for logs.
+func (a *AttachOptions) address() string {
+	if a.SocketPath != "" {
+		return a.SocketPath
+	}
+	host := a.Host
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(a.Port))
+}
+
+func (a *AttachOptions) dial(ctx context.Context) (rpc.Transport, error) {
+	switch {
+	case a.SocketPath != "":
+		return rpc.DialUnix(ctx, a.SocketPath, a.Dial)
+	case a.Port > 0:
+		return rpc.DialTCP(ctx, a.address(), a.Dial)
+	default:
+		return nil, errors.New("attach requires SocketPath or Port")
+	}
+}
@@ -0,0 +1,101 @@
+package codex
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestAttachSharesRunningServer(t *testing.T) {
+	dir, err := os.MkdirTemp("", "codex")
+	if err != nil {
+		t.Fatalf("temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app-server.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets are unavailable: %v", err)
+	}
+	defer listener.Close()
+	accepted := make(chan *poolServer, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		accepted <- startPoolServer("ide", rpc.NewConnTransport(conn))
+	}()
+
+	client, err := New(context.Background(), Options{
+		Attach:     &AttachOptions{SocketPath: path},
+		ClientInfo: protocol.ClientInfo{Name: "review-bot", Version: "1.0.0"},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	server := <-accepted
+	if _, err := client.ResumeThread(context.Background(), ThreadResumeOptions{ThreadID: "thr_ide"}); err != nil {
+		t.Fatalf("ResumeThread: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var methods []string
+	for len(server.methods) > 0 {
+		methods = append(methods, <-server.methods)
+	}
+	if got := strings.Join(methods, ","); got != "initialize,thread/resume" {
+		t.Fatalf("server saw %s, want initialize and thread/resume without shutdown", got)
+	}
+}
+
+func TestAttachTCPPort(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		startPoolServer("ide", rpc.NewConnTransport(conn))
+	}()
+
+	client, err := New(context.Background(), Options{Attach: &AttachOptions{Port: listener.Addr().(*net.TCPAddr).Port}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer client.Close()
+	thread, err := client.StartThread(context.Background(), ThreadStartOptions{})
+	if err != nil || thread.ID() != "ide-1" {
+		t.Fatalf("StartThread = %v, %v", thread, err)
+	}
+}
+
+func TestAttachErrors(t *testing.T) {
+	_, transport := newPoolServer("i1")
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "no target", opts: Options{Attach: &AttachOptions{}}},
+		{name: "with transport", opts: Options{Attach: &AttachOptions{Port: 1}, Transport: transport}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(context.Background(), tt.opts); err == nil {
+				t.Fatalf("New succeeded")
+			}
+		})
+	}
+}
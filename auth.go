@@ -0,0 +1,213 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+// AuthMode is how the app-server authenticates to OpenAI.
+type AuthMode string
+
+const (
+	// AuthModeAPIKey authenticates with an OpenAI API key.
+	AuthModeAPIKey AuthMode = "apiKey"
+	// AuthModeChatGPT authenticates with a ChatGPT account.
+	AuthModeChatGPT AuthMode = "chatgpt"
+)
+
+// Auth manages the app-server's credentials. Use it to bootstrap a
+// headless deployment without running `codex login` by hand.
+type Auth struct {
+	codex *Codex
+}
+
+// Auth returns the account and login API of the client.
+func (c *Codex) Auth() *Auth {
+	return &Auth{codex: c}
+}
+
+// AuthStatus describes the account the app-server is logged in with.
+type AuthStatus struct {
+	// Mode is empty when the app-server is not logged in.
+	Mode AuthMode
+	// Email and PlanType describe a ChatGPT account.
+	Email    string
+	PlanType string
+	// RequiresOpenAIAuth reports whether the configured model provider
+	// needs OpenAI credentials at all.
+	RequiresOpenAIAuth bool
+}
+
+// LoggedIn reports whether the app-server has credentials.
+func (s AuthStatus) LoggedIn() bool {
+	return s.Mode != ""
+}
+
+// Status reads the current account. Codex.AccountInfo returns the same
+// details with a typed plan.
+func (a *Auth) Status(ctx context.Context) (*AuthStatus, error) {
+	info, err := a.codex.AccountInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthStatus{
+		Mode:               info.AuthMode,
+		Email:              info.Email,
+		PlanType:           string(info.PlanType),
+		RequiresOpenAIAuth: info.RequiresOpenAIAuth,
+	}, nil
+}
+
+// LoginWithAPIKey stores key as the app-server's credentials.
+func (a *Auth) LoginWithAPIKey(ctx context.Context, key string) error {
+	if err := a.codex.ensureReady(); err != nil {
+		return err
+	}
+	if key == "" {
+		return errors.New("api key is empty")
+	}
+	params := map[string]any{"type": "apiKey", "apiKey": key}
+	_, err := a.codex.call(ctx, "account/login/start", params, nil)
+	return err
+}
+
+// Logout removes the app-server's stored credentials.
+func (a *Auth) Logout(ctx context.Context) error {
+	if err := a.codex.ensureReady(); err != nil {
+		return err
+	}
+	_, err := a.codex.call(ctx, "account/logout", nil, nil)
+	return err
+}
+
+// StartDeviceLogin starts a ChatGPT device code login. Show the user
+// VerificationURL and UserCode, then call Wait or Poll to learn when they
+// have completed it. The app-server is kept running until the login ends,
+// even with Options.IdleShutdown.
+func (a *Auth) StartDeviceLogin(ctx context.Context) (*DeviceLogin, error) {
+	if err := a.codex.ensureReady(); err != nil {
+		return nil, err
+	}
+	client, release, err := a.codex.acquireClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Subscribe first so a quick completion is not missed.
+	iter := client.SubscribeNotifications(0)
+	var response struct {
+		LoginID         string `json:"loginId"`
+		VerificationURL string `json:"verificationUrl"`
+		UserCode        string `json:"userCode"`
+	}
+	const method = "account/login/start"
+	if err := client.Call(ctx, method, map[string]any{"type": "chatgptDeviceCode"}, &response); err != nil {
+		iter.Close()
+		release()
+		return nil, classifyError(client, method, err)
+	}
+	if response.LoginID == "" {
+		iter.Close()
+		release()
+		return nil, errors.New("login id not found in response")
+	}
+	login := &DeviceLogin{
+		LoginID:         response.LoginID,
+		VerificationURL: response.VerificationURL,
+		UserCode:        response.UserCode,
+		client:          client,
+		iter:            iter,
+		done:            make(chan struct{}),
+	}
+	go login.watch(release)
+	return login, nil
+}
+
+// DeviceLogin is a ChatGPT device code login in progress.
+type DeviceLogin struct {
+	LoginID         string
+	VerificationURL string
+	UserCode        string
+
+	client *rpc.Client
+	iter   *rpc.NotificationIterator
+	done   chan struct{}
+	once   sync.Once
+	err    error
+}
+
+// watch waits for the app-server to report the end of the login.
+func (l *DeviceLogin) watch(release func()) {
+	defer release()
+	defer l.iter.Close()
+	for {
+		note, err := l.iter.Next(context.Background())
+		if err != nil {
+			l.finish(fmt.Errorf("login %s did not complete: %w", l.LoginID, err))
+			return
+		}
+		if note.Method != "account/login/completed" {
+			continue
+		}
+		var payload protocol.AccountLoginCompletedNotification
+		if err := json.Unmarshal(note.Raw, &payload); err != nil {
+			continue
+		}
+		if payload.LoginID == nil || *payload.LoginID != l.LoginID {
+			continue
+		}
+		if payload.Success {
+			l.finish(nil)
+		} else if payload.Error != nil {
+			l.finish(fmt.Errorf("login failed: %s", *payload.Error))
+		} else {
+			l.finish(errors.New("login failed"))
+		}
+		return
+	}
+}
+
+func (l *DeviceLogin) finish(err error) {
+	l.once.Do(func() {
+		l.err = err
+		close(l.done)
+	})
+}
+
+// Wait blocks until the login ends and returns nil if it succeeded.
+func (l *DeviceLogin) Wait(ctx context.Context) error {
+	select {
+	case <-l.done:
+		return l.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Poll reports whether the login has ended and, if so, its result.
+func (l *DeviceLogin) Poll() (bool, error) {
+	select {
+	case <-l.done:
+		return true, l.err
+	default:
+		return false, nil
+	}
+}
+
+// Cancel abandons the login. Wait then returns an error.
+func (l *DeviceLogin) Cancel(ctx context.Context) error {
+	const method = "account/login/cancel"
+	var response protocol.CancelLoginAccountResponse
+	err := l.client.Call(ctx, method, protocol.CancelLoginAccountParams{LoginID: l.LoginID}, &response)
+	if err != nil {
+		return classifyError(l.client, method, err)
+	}
+	l.finish(fmt.Errorf("login %s was canceled", l.LoginID))
+	l.iter.Close()
+	return nil
+}
@@ -0,0 +1,122 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestAuthStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		account any
+		want    AuthStatus
+	}{
+		{name: "logged out", account: nil, want: AuthStatus{RequiresOpenAIAuth: true}},
+		{name: "api key", account: map[string]any{"type": "apiKey"}, want: AuthStatus{Mode: AuthModeAPIKey, RequiresOpenAIAuth: true}},
+		{
+			name:    "chatgpt",
+			account: map[string]any{"type": "chatgpt", "email": "dev@example.com", "planType": "pro"},
+			want:    AuthStatus{Mode: AuthModeChatGPT, Email: "dev@example.com", PlanType: "pro", RequiresOpenAIAuth: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, codex := newFakeAppServer(t, map[string]fakeHandler{
+				"account/read": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+					return map[string]any{"account": tt.account, "requiresOpenaiAuth": true}, nil
+				},
+			})
+			status, err := codex.Auth().Status(context.Background())
+			if err != nil {
+				t.Fatalf("Status: %v", err)
+			}
+			if *status != tt.want {
+				t.Fatalf("status = %+v, want %+v", *status, tt.want)
+			}
+			if status.LoggedIn() != (tt.account != nil) {
+				t.Fatalf("LoggedIn = %v", status.LoggedIn())
+			}
+		})
+	}
+}
+
+func TestAuthLoginWithAPIKeyAndLogout(t *testing.T) {
+	server, codex := newFakeAppServer(t, nil)
+	ctx := context.Background()
+	if err := codex.Auth().LoginWithAPIKey(ctx, "sk-test"); err != nil {
+		t.Fatalf("LoginWithAPIKey: %v", err)
+	}
+	if got := string(server.request(t, "account/login/start").Params); got != `{"apiKey":"sk-test","type":"apiKey"}` {
+		t.Fatalf("login params = %s", got)
+	}
+	if err := codex.Auth().LoginWithAPIKey(ctx, ""); err == nil {
+		t.Fatalf("LoginWithAPIKey accepted an empty key")
+	}
+	if err := codex.Auth().Logout(ctx); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	server.request(t, "account/logout")
+}
+
+func TestAuthDeviceLogin(t *testing.T) {
+	server, codex := newFakeAppServer(t, map[string]fakeHandler{
+		"account/login/start": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			return map[string]any{
+				"type":            "chatgptDeviceCode",
+				"loginId":         "login-1",
+				"verificationUrl": "https://auth.openai.com/codex/device",
+				"userCode":        "ABCD-EFGH",
+			}, nil
+		},
+	})
+	login, err := codex.Auth().StartDeviceLogin(context.Background())
+	if err != nil {
+		t.Fatalf("StartDeviceLogin: %v", err)
+	}
+	if login.LoginID != "login-1" || login.UserCode != "ABCD-EFGH" || login.VerificationURL == "" {
+		t.Fatalf("login = %+v", login)
+	}
+	if done, _ := login.Poll(); done {
+		t.Fatalf("login finished before completion")
+	}
+
+	server.notify("account/login/completed", map[string]any{"loginId": "other", "success": true})
+	server.notify("account/login/completed", map[string]any{"loginId": "login-1", "success": false, "error": "code expired"})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := login.Wait(ctx); err == nil || !strings.Contains(err.Error(), "code expired") {
+		t.Fatalf("Wait = %v, want the login error", err)
+	}
+	if done, err := login.Poll(); !done || err == nil {
+		t.Fatalf("Poll = %v, %v after completion", done, err)
+	}
+}
+
+func TestAuthDeviceLoginCancel(t *testing.T) {
+	server, codex := newFakeAppServer(t, map[string]fakeHandler{
+		"account/login/start": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			return map[string]any{"type": "chatgptDeviceCode", "loginId": "login-1"}, nil
+		},
+		"account/login/cancel": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			return map[string]any{"status": "canceled"}, nil
+		},
+	})
+	login, err := codex.Auth().StartDeviceLogin(context.Background())
+	if err != nil {
+		t.Fatalf("StartDeviceLogin: %v", err)
+	}
+	if err := login.Cancel(context.Background()); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if got := string(server.request(t, "account/login/cancel").Params); got != `{"loginId":"login-1"}` {
+		t.Fatalf("cancel params = %s", got)
+	}
+	if done, err := login.Poll(); !done || err == nil {
+		t.Fatalf("Poll = %v, %v after Cancel", done, err)
+	}
+}
@@ -0,0 +1,103 @@
+package codex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+// shutdownMethod asks the app-server to persist rollout files and session
+// state and exit. It is only sent to servers that advertise it at
+// initialize; Close and CloseContext stop other servers by closing stdin
+// and signalling.
+const shutdownMethod = "shutdown"
+
+// CloseContext shuts the client down gracefully. It interrupts turns that
+// are still running, waits for in-flight requests and approval handlers to
+// finish, asks the app-server to shut down, and then stops it, killing it if
+// it has not exited by the time ctx ends. Errors from every step are joined.
+func (c *Codex) CloseContext(ctx context.Context) error {
+	if err := c.ensureReady(); err != nil {
+		return err
+	}
+
+	client := c.markClosed()
+	if _, ok := c.stoppedForIdle(client); ok {
+		return nil
+	}
+	var errs []error
+	for _, turn := range c.turns.snapshot() {
+		if turn.turnID == "" || !client.SupportsMethod("turn/interrupt") {
+			continue
+		}
+		c.logger.Info("codex interrupting turn", "thread_id", turn.threadID, "turn_id", turn.turnID)
+		if _, err := client.TurnInterrupt(ctx, protocol.TurnInterruptParams{ThreadID: turn.threadID, TurnID: turn.turnID}); err != nil {
+			errs = append(errs, fmt.Errorf("interrupt turn %s: %w", turn.turnID, err))
+		}
+	}
+	if err := client.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("shutdown: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// turnRegistry tracks turns started through the facade so CloseContext can
+// interrupt them. A nil registry ignores all calls.
+type turnRegistry struct {
+	mu    sync.Mutex
+	turns map[*activeTurn]struct{}
+}
+
+type activeTurn struct {
+	threadID string
+	turnID   string
+}
+
+func newTurnRegistry() *turnRegistry {
+	return &turnRegistry{turns: make(map[*activeTurn]struct{})}
+}
+
+func (r *turnRegistry) add(threadID, turnID string) *activeTurn {
+	if r == nil {
+		return nil
+	}
+	turn := &activeTurn{threadID: threadID, turnID: turnID}
+	r.mu.Lock()
+	r.turns[turn] = struct{}{}
+	r.mu.Unlock()
+	return turn
+}
+
+func (r *turnRegistry) setTurnID(turn *activeTurn, turnID string) {
+	if r == nil || turn == nil || turnID == "" {
+		return
+	}
+	r.mu.Lock()
+	turn.turnID = turnID
+	r.mu.Unlock()
+}
+
+func (r *turnRegistry) remove(turn *activeTurn) {
+	if r == nil || turn == nil {
+		return
+	}
+	r.mu.Lock()
+	delete(r.turns, turn)
+	r.mu.Unlock()
+}
+
+func (r *turnRegistry) snapshot() []activeTurn {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	turns := make([]activeTurn, 0, len(r.turns))
+	for turn := range r.turns {
+		turns = append(turns, *turn)
+	}
+	return turns
+}
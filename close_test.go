@@ -0,0 +1,104 @@
+package codex
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestCloseContextInterruptsRunningTurns(t *testing.T) {
+	entries := append(initializeTranscript(),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(2), Method: "turn/start", Params: mustRaw(turnStartParams("hi"))}),
+		readLine(rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(2), Result: mustRaw(map[string]any{"turn": turnPayload("turn_1", "inProgress")})}),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(3), Method: "turn/interrupt", Params: mustRaw(map[string]any{"threadId": "thr_123", "turnId": "turn_1"})}),
+		readLine(rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(3), Result: mustRaw(map[string]any{})}),
+	)
+	client, thread := newCloseTestThread(t, entries)
+
+	stream, err := thread.RunStreamed(context.Background(), []Input{TextInput("hi")}, nil)
+	if err != nil {
+		t.Fatalf("run streamed error: %v", err)
+	}
+	defer stream.Close()
+
+	if err := client.CloseContext(context.Background()); err != nil {
+		t.Fatalf("close context error: %v", err)
+	}
+	if err := client.Client().Call(context.Background(), "model/list", nil, nil); err == nil {
+		t.Fatalf("expected calls to fail after close")
+	}
+}
+
+func TestCloseContextSkipsFinishedTurns(t *testing.T) {
+	client, thread := newRepairThread(t, []repairTurn{{prompt: "hi", response: "done"}})
+	thread.turns = client.turns
+	if _, err := thread.Run(context.Background(), "hi", nil); err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	if turns := client.turns.snapshot(); len(turns) != 0 {
+		t.Fatalf("expected no active turns, got %v", turns)
+	}
+	if err := client.CloseContext(context.Background()); err != nil {
+		t.Fatalf("close context error: %v", err)
+	}
+}
+
+func TestCloseContextAggregatesInterruptErrors(t *testing.T) {
+	entries := append(initializeTranscript(),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(2), Method: "turn/start", Params: mustRaw(turnStartParams("hi"))}),
+		readLine(rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(2), Result: mustRaw(map[string]any{})}),
+		readLine(rpc.JSONRPCNotification{Method: "turn/started", Params: mustRaw(map[string]any{"threadId": "thr_123", "turn": turnPayload("turn_9", "inProgress")})}),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(3), Method: "turn/interrupt", Params: mustRaw(map[string]any{"threadId": "thr_123", "turnId": "turn_9"})}),
+		readLine(rpc.JSONRPCError{ID: rpc.NewIntRequestID(3), Error: rpc.JSONRPCErrorError{Code: -32600, Message: "no such turn"}}),
+	)
+	client, thread := newCloseTestThread(t, entries)
+
+	stream, err := thread.RunStreamed(context.Background(), []Input{TextInput("hi")}, nil)
+	if err != nil {
+		t.Fatalf("run streamed error: %v", err)
+	}
+	defer stream.Close()
+	if _, err := stream.Next(context.Background()); err != nil {
+		t.Fatalf("next error: %v", err)
+	}
+
+	err = client.CloseContext(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "interrupt turn turn_9") {
+		t.Fatalf("expected interrupt error, got %v", err)
+	}
+}
+
+func TestCloseContextOnUninitializedClient(t *testing.T) {
+	if err := (&Codex{}).CloseContext(context.Background()); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestCodexDoneAndErr(t *testing.T) {
+	client, _ := newCloseTestThread(t, initializeTranscript())
+	if err := client.Err(); err != nil {
+		t.Fatalf("expected nil error while open, got %v", err)
+	}
+	_ = client.Close()
+	<-client.Done()
+	if err := client.Err(); err == nil {
+		t.Fatalf("expected error after close")
+	}
+
+	uninitialized := &Codex{}
+	<-uninitialized.Done()
+	if err := uninitialized.Err(); err == nil {
+		t.Fatalf("expected error for uninitialized client")
+	}
+}
+
+func newCloseTestThread(t *testing.T, entries []rpc.TranscriptEntry) (*Codex, *Thread) {
+	t.Helper()
+	client, err := New(context.Background(), Options{Transport: rpc.NewReplayTransport(entries)})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	return client, &Thread{client: client.Client(), id: "thr_123", turns: client.turns}
+}
@@ -1,11 +1,16 @@
 package codex
 
 import (
+	"cmp"
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"runtime/debug"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pmenglund/codex-sdk-go/protocol"
 	"github.com/pmenglund/codex-sdk-go/rpc"
@@ -13,21 +18,104 @@ import (
 
 // Codex is the main entrypoint for the Go SDK.
 type Codex struct {
-	client *rpc.Client
 	logger *slog.Logger
+	tracer rpc.Tracer
+	turns  *turnRegistry
+
+	// redial, clientOptions and initParams re-establish the connection after
+	// transport loss; see reconnect. With a reconnect policy, supervise
+	// reconnects automatically until lifecycle ends, then closes done.
+	redial        func(context.Context) (rpc.Transport, error)
+	clientOptions rpc.ClientOptions
+	initParams    protocol.InitializeParams
+	checkCompat   bool
+	// minServerVersion is Options.MinServerVersion, and spawn the options
+	// the app-server was spawned with, or nil when it was not.
+	minServerVersion string
+	spawn            *SpawnOptions
+	reconnectPolicy  *ReconnectPolicy
+	reconnectMu      sync.Mutex
+	reconnects       int
+	lifecycle        context.Context
+	cancel           context.CancelFunc
+	done             chan struct{}
+
+	// leases gates turn starts on thread ownership; see Thread.Acquire.
+	leases *LeaseOptions
+
+	// tools holds Options.Tools; nil when none are registered.
+	tools *toolRegistry
+
+	// idle tracks activity for Options.IdleShutdown; nil when it is off.
+	// It is guarded by mu.
+	idle *idleState
+
+	mu         sync.Mutex
+	client     *rpc.Client
+	closed     bool
+	threads    map[string]struct{}
+	provenance *Provenance
 }
 
 // New creates a new Codex client and performs the initialize handshake.
 func New(ctx context.Context, opts Options) (*Codex, error) {
+	if opts.InitTimeout <= 0 {
+		return newCodex(ctx, opts)
+	}
+	initCtx, cancel := context.WithTimeout(ctx, opts.InitTimeout)
+	defer cancel()
+	c, err := newCodex(initCtx, opts)
+	if err != nil && ctx.Err() == nil && errors.Is(initCtx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w: no answer to initialize within %s: %w", ErrStartupTimeout, opts.InitTimeout, err)
+	}
+	return c, err
+}
+
+func newCodex(ctx context.Context, opts Options) (*Codex, error) {
 	logger := resolveLogger(opts.Logger)
+	if opts.Leases != nil && opts.Leases.Store == nil {
+		return nil, errors.New("leases require a Store")
+	}
+	if opts.MinServerVersion != "" {
+		if _, ok := parseVersion(opts.MinServerVersion); !ok {
+			return nil, fmt.Errorf("invalid MinServerVersion %q: want major.minor.patch", opts.MinServerVersion)
+		}
+	}
+	tools, err := newToolRegistry(opts.Tools, logger)
+	if err != nil {
+		return nil, err
+	}
 
+	// c is assigned once the first connection is up; the respawn closure
+	// only runs after that.
+	var c *Codex
+	redial := opts.Redial
+	var provenance *Provenance
+	var spawned *SpawnOptions
 	transport := opts.Transport
-	if transport == nil {
+	if opts.Attach != nil {
+		if transport != nil {
+			return nil, errors.New("attach cannot be combined with Transport")
+		}
+		attach := *opts.Attach
+		logger.Info("codex attaching to app-server", "address", attach.address())
+		var err error
+		if transport, err = attach.dial(ctx); err != nil {
+			return nil, err
+		}
+		if redial == nil {
+			redial = attach.dial
+		}
+	} else if transport == nil {
 		spawn := opts.Spawn
 		if spawn.CodexPath == "" {
 			spawn.CodexPath = "codex"
 		}
+		spawned = &spawn
 		args := []string{"app-server"}
+		if spawn.Profile != "" {
+			args = append(args, profileArgs(spawn.Profile)...)
+		}
 		for _, override := range spawn.ConfigOverrides {
 			args = append(args, "--config", override)
 		}
@@ -36,57 +124,276 @@ func New(ctx context.Context, opts Options) (*Codex, error) {
 		logger.Info("codex starting app-server", "path", spawn.CodexPath, "args", strings.Join(args, " "))
 
 		var err error
-		if spawn.Stderr == nil {
+		if spawn.Stderr == nil && !spawn.LogStderr {
 			spawn.Stderr = rpc.DefaultStderr()
 		}
 		if err := ctx.Err(); err != nil {
 			return nil, err
 		}
+		if spawn.RecordProvenance {
+			var err error
+			if provenance, err = resolveProvenance(ctx, spawn); err != nil {
+				return nil, fmt.Errorf("resolve provenance: %w", err)
+			}
+		}
 		// The constructor context is only for initialization; process lifetime is managed by Close.
-		transport, err = rpc.SpawnStdio(context.WithoutCancel(ctx), spawn.CodexPath, args, spawn.Stderr)
+		transport, err = spawnStdio(ctx, spawn.withProvenance(provenance), args, logger)
 		if err != nil {
 			return nil, err
 		}
+		if redial == nil && (opts.Reconnect != nil || opts.IdleShutdown > 0) {
+			redial = func(ctx context.Context) (rpc.Transport, error) {
+				logger.Info("codex restarting app-server", "path", spawn.CodexPath, "args", strings.Join(args, " "))
+				if spawn.RecordProvenance {
+					provenance, err := resolveProvenance(ctx, spawn)
+					if err != nil {
+						return nil, fmt.Errorf("resolve provenance: %w", err)
+					}
+					c.setProvenance(provenance)
+					return spawnStdio(ctx, spawn.withProvenance(provenance), args, logger)
+				}
+				return spawnStdio(ctx, spawn, args, logger)
+			}
+		}
+	} else if opts.Reconnect != nil && redial == nil {
+		return nil, errors.New("reconnect requires Redial when Transport is set")
+	} else if opts.IdleShutdown > 0 && redial == nil {
+		return nil, errors.New("idle shutdown requires Redial when Transport is set")
 	} else {
 		logger.Info("codex using custom transport")
 	}
 
-	client := rpc.NewClient(transport, rpc.ClientOptions{
-		Logger:         logger,
-		RequestHandler: attachApprovalLogger(opts.ApprovalHandler, logger),
-	})
-
+	clientOptions := rpc.ClientOptions{
+		Logger:                logger,
+		RequestHandler:        tools.handler(attachApprovalLogger(opts.ApprovalHandler, logger)),
+		WireLog:               opts.WireLog,
+		WireRedactors:         opts.WireRedactors,
+		Keepalive:             opts.Keepalive,
+		AuditSink:             opts.AuditSink,
+		AuditQueue:            opts.AuditQueue,
+		HandlerTimeout:        opts.ApprovalTimeout,
+		MaxConcurrentHandlers: opts.MaxConcurrentApprovals,
+		WriteQueue:            opts.WriteQueue,
+		OnNotificationError:   opts.OnNotificationError,
+		OnMalformedMessage:    opts.OnMalformedMessage,
+		StrictEnvelope:        opts.StrictEnvelope,
+		RequestIDPrefix:       opts.RequestIDPrefix,
+		ReplayBuffer:          opts.ReplayBuffer,
+		RequestMutators:       opts.RequestMutators,
+		SizeLimits:            opts.SizeLimits,
+	}
+	if opts.Attach == nil {
+		// An attached server belongs to another process; leave it running.
+		clientOptions.ShutdownMethod = shutdownMethod
+	}
+	if opts.Tracer != nil {
+		clientOptions.Interceptors = []rpc.Interceptor{rpc.TracingInterceptor(opts.Tracer)}
+	}
 	info := opts.ClientInfo
 	if info.Name == "" {
 		info = defaultClientInfo()
 	}
 
-	if _, err := client.Initialize(ctx, protocol.InitializeParams{ClientInfo: info}); err != nil {
-		_ = client.Close()
+	initParams := tools.initializeParams(info)
+	client, err := connect(ctx, transport, clientOptions, initParams, !opts.SkipCompatibilityCheck)
+	if err != nil {
 		return nil, err
 	}
 
-	if err := client.Notify(ctx, "initialized", nil); err != nil {
+	c = &Codex{
+		client:           client,
+		logger:           logger,
+		tracer:           opts.Tracer,
+		turns:            newTurnRegistry(),
+		redial:           redial,
+		clientOptions:    clientOptions,
+		initParams:       initParams,
+		checkCompat:      !opts.SkipCompatibilityCheck,
+		minServerVersion: opts.MinServerVersion,
+		spawn:            spawned,
+		reconnectPolicy:  opts.Reconnect.normalized(),
+		leases:           opts.Leases.normalized(),
+		tools:            tools,
+		provenance:       provenance,
+	}
+	if err := c.checkServerVersion(ctx, client); err != nil {
 		_ = client.Close()
 		return nil, err
 	}
+	if err := c.checkProfile(ctx, client); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	if server := client.ServerInfo(); server != nil {
+		logger.Info("codex initialized", "server_name", server.Name, "server_version", server.Version, "protocol_version", server.ProtocolVersion)
+	} else {
+		logger.Info("codex initialized")
+	}
+	if c.reconnectPolicy != nil || opts.IdleShutdown > 0 {
+		c.lifecycle, c.cancel = context.WithCancel(context.Background())
+		c.done = make(chan struct{})
+		go c.supervise()
+	}
+	if opts.IdleShutdown > 0 {
+		c.idle = &idleState{timeout: opts.IdleShutdown, lastUse: time.Now()}
+		go c.watchIdle()
+	}
+	return c, nil
+}
 
-	logger.Info("codex initialized")
+// connect starts a client on transport and performs the initialize
+// handshake. With checkCompat it rejects servers too old for the SDK.
+func connect(ctx context.Context, transport rpc.Transport, opts rpc.ClientOptions, params protocol.InitializeParams, checkCompat bool) (*rpc.Client, error) {
+	client := rpc.NewClient(transport, opts)
+	fail := func(err error) (*rpc.Client, error) {
+		stdio, spawned := transport.(*rpc.StdioTransport)
+		if spawned && ctx.Err() != nil {
+			// The handshake was abandoned, so kill the process instead of
+			// giving it the usual grace periods to exit.
+			_ = stdio.CloseContext(ctx)
+		}
+		_ = client.Close()
+		if spawned {
+			err = stdio.AttachStderr(err)
+		}
+		return nil, err
+	}
+	if _, err := client.Initialize(ctx, params); err != nil {
+		return fail(err)
+	}
+	if checkCompat {
+		if err := checkCompatibility(client.ServerInfo()); err != nil {
+			_ = client.Close()
+			return nil, err
+		}
+	}
+	if err := client.Notify(ctx, "initialized", nil); err != nil {
+		return fail(err)
+	}
+	return client, nil
+}
 
-	return &Codex{client: client, logger: logger}, nil
+// spawnStdio starts the app-server process described by spawn. The process
+// outlives ctx; Close ends it.
+func spawnStdio(ctx context.Context, spawn SpawnOptions, args []string, logger *slog.Logger) (*rpc.StdioTransport, error) {
+	stderr, onExit := spawn.Stderr, spawn.OnProcessExit
+	if spawn.LogStderr {
+		// Each process gets its own writer, so a partial line from a
+		// crashed process is not joined with its replacement's output.
+		lines := newStderrLogWriter(logger, spawn.StderrLogLevel)
+		stderr = lines
+		if spawn.Stderr != nil {
+			stderr = io.MultiWriter(spawn.Stderr, lines)
+		}
+		// The exit callback runs after stderr has been copied, so the last
+		// line is complete by then.
+		onExit = func(err error, exitCode int) {
+			lines.Flush()
+			if spawn.OnProcessExit != nil {
+				spawn.OnProcessExit(err, exitCode)
+			}
+		}
+	}
+	return rpc.SpawnStdioWithOptions(context.WithoutCancel(ctx), spawn.CodexPath, args, rpc.StdioOptions{
+		Stderr:         stderr,
+		ReadBufferSize: spawn.ReadBufferSize,
+		Framing:        spawn.Framing,
+		Env:            spawn.environ(),
+		Dir:            spawn.Dir,
+		OnExit:         onExit,
+		ExitGrace:      spawn.ExitGrace,
+		TerminateGrace: spawn.TerminateGrace,
+		StderrTail:     spawn.StderrTail,
+		Limits:         spawn.Limits,
+	})
 }
 
 // Client exposes the underlying RPC client for low-level access.
+// The returned client is replaced when the connection is re-established.
 func (c *Codex) Client() *rpc.Client {
+	return c.currentClient()
+}
+
+// ServerInfo describes the app-server negotiated at initialize: its name,
+// version, protocol version and advertised capabilities. After a reconnect
+// it describes the replacement server. It returns nil before New succeeds.
+func (c *Codex) ServerInfo() *rpc.ServerInfo {
+	if c.ensureReady() != nil {
+		return nil
+	}
+	return c.currentClient().ServerInfo()
+}
+
+func (c *Codex) currentClient() *rpc.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return c.client
 }
 
-// Close closes the underlying transport.
+// Done returns a channel that is closed when the connection to the
+// app-server ends. Supervisors can select on it to detect a dead server.
+// With Options.Reconnect, it is closed only once reconnecting gives up or
+// the client is closed.
+func (c *Codex) Done() <-chan struct{} {
+	if c.ensureReady() != nil {
+		return closedDone
+	}
+	if c.done != nil {
+		return c.done
+	}
+	return c.currentClient().Done()
+}
+
+// Err returns nil while the connection is open and the reason it ended
+// after Done is closed.
+func (c *Codex) Err() error {
+	if err := c.ensureReady(); err != nil {
+		return err
+	}
+	if c.done != nil {
+		select {
+		case <-c.done:
+		default:
+			return nil
+		}
+	}
+	return c.currentClient().Err()
+}
+
+// Healthy probes the app-server and returns nil once it answers, so
+// supervisors and liveness checks can observe it without a failed Call.
+// Any response counts, including a JSON-RPC error. It fails at once when
+// the connection has ended or is being replaced by Options.Reconnect. An
+// app-server stopped by Options.IdleShutdown counts as healthy without
+// being woken.
+func (c *Codex) Healthy(ctx context.Context) error {
+	if err := c.ensureReady(); err != nil {
+		return err
+	}
+	client := c.currentClient()
+	if _, ok := c.stoppedForIdle(client); ok {
+		return nil
+	}
+	if err := client.Ping(ctx); err != nil {
+		return fmt.Errorf("app-server is unhealthy: %w", err)
+	}
+	return nil
+}
+
+// Close asks the app-server to persist its state and exit, waiting up to
+// 2s for it to answer, then closes the underlying transport, failing any
+// pending requests. A spawned process that does not exit is terminated.
+// Use CloseContext for a graceful shutdown.
 func (c *Codex) Close() error {
 	if err := c.ensureReady(); err != nil {
 		return err
 	}
-	return c.client.Close()
+	client := c.markClosed()
+	if _, ok := c.stoppedForIdle(client); ok {
+		return nil
+	}
+	_ = client.RequestShutdown(context.Background())
+	return client.Close()
 }
 
 // StartThread starts a new thread using the app-server.
@@ -99,15 +406,17 @@ func (c *Codex) StartThread(ctx context.Context, options ThreadStartOptions) (*T
 		return nil, err
 	}
 	var response protocol.ThreadStartResponse
-	if err := c.client.Call(ctx, "thread/start", params, &response); err != nil {
+	client, err := c.call(ctx, "thread/start", c.tools.startParams(params), &response)
+	if err != nil {
 		return nil, err
 	}
 	threadID, err := threadIDFromResponse(response.ThreadID, response.Thread)
 	if err != nil {
 		return nil, err
 	}
+	c.trackThread(threadID)
 	c.logger.Info("codex thread started", "thread_id", threadID)
-	return &Thread{client: c.client, id: threadID, logger: c.logger}, nil
+	return &Thread{client: client, owner: c, id: threadID, cwd: cmp.Or(response.Cwd, options.Cwd), logger: c.logger, tracer: c.tracer, turns: c.turns}, nil
 }
 
 // ResumeThread resumes an existing thread.
@@ -120,15 +429,17 @@ func (c *Codex) ResumeThread(ctx context.Context, options ThreadResumeOptions) (
 		return nil, err
 	}
 	var response protocol.ThreadResumeResponse
-	if err := c.client.Call(ctx, "thread/resume", params, &response); err != nil {
+	client, err := c.call(ctx, "thread/resume", params, &response)
+	if err != nil {
 		return nil, err
 	}
 	threadID, err := threadIDFromResponse(response.ThreadID, response.Thread)
 	if err != nil {
 		return nil, err
 	}
+	c.trackThread(threadID)
 	c.logger.Info("codex thread resumed", "thread_id", threadID)
-	return &Thread{client: c.client, id: threadID, logger: c.logger}, nil
+	return &Thread{client: client, owner: c, id: threadID, cwd: cmp.Or(response.Cwd, options.Cwd), logger: c.logger, tracer: c.tracer, turns: c.turns}, nil
 }
 
 func defaultClientInfo() protocol.ClientInfo {
@@ -157,11 +468,17 @@ func threadIDFromResponse(threadID string, thread *protocol.Thread) (string, err
 	return "", errors.New("thread id not found in response")
 }
 
+var closedDone = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
 func (c *Codex) ensureReady() error {
 	if c == nil {
 		return errors.New("codex client is nil")
 	}
-	if c.client == nil {
+	if c.currentClient() == nil {
 		return errors.New("codex client is not initialized")
 	}
 	return nil
@@ -3,12 +3,14 @@ package codex
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -488,6 +490,28 @@ func TestNewUsesDefaultClientInfo(t *testing.T) {
 	_ = client.Close()
 }
 
+func TestCodexServerInfo(t *testing.T) {
+	if (&Codex{}).ServerInfo() != nil {
+		t.Fatalf("expected nil server info for uninitialized client")
+	}
+
+	entries := initializeTranscript()
+	entries[1] = readLine(rpc.JSONRPCResponse{
+		ID:     rpc.NewIntRequestID(1),
+		Result: mustRaw(map[string]any{"userAgent": "codex_cli_rs/0.60.0 (Linux; x86_64)", "protocolVersion": "v2"}),
+	})
+	client, err := New(context.Background(), Options{Transport: rpc.NewReplayTransport(entries)})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	defer client.Close()
+
+	info := client.ServerInfo()
+	if info == nil || info.Name != "codex_cli_rs" || info.Version != "0.60.0" || info.ProtocolVersion != "v2" {
+		t.Fatalf("unexpected server info: %+v", info)
+	}
+}
+
 func TestNewSpawnError(t *testing.T) {
 	ctx := context.Background()
 	_, err := New(ctx, Options{
@@ -598,6 +622,11 @@ func writeFakeCodexBinary(t *testing.T) string {
 
 	path := filepath.Join(t.TempDir(), "fake-codex")
 	script := `#!/bin/sh
+if [ "$1" = "--version" ]; then
+	echo "codex-cli ${FAKE_CODEX_VERSION:-0.0.0-test}"
+	exit 0
+fi
+
 extract_id() {
 	printf '%s\n' "$1" | sed -n 's/.*"id":\([0-9][0-9]*\).*/\1/p'
 }
@@ -614,6 +643,13 @@ while IFS= read -r line; do
 			if [ -z "$id" ]; then id=2; fi
 			printf '{"jsonrpc":"2.0","id":%s,"result":{"threadId":"thr_test"}}\n' "$id"
 			;;
+		*'"method":"config/read"'*)
+			printf '{"jsonrpc":"2.0","id":%s,"result":{"config":%s}}\n' "$(extract_id "$line")" "${FAKE_CODEX_CONFIG:-{\}}"
+			;;
+		*'"method":"shutdown"'*)
+			printf '{"jsonrpc":"2.0","id":%s,"result":{}}\n' "$(extract_id "$line")"
+			exit 0
+			;;
 	esac
 done
 `
@@ -712,3 +748,202 @@ func assertRawEqual(t *testing.T, name string, got any, want json.RawMessage) {
 		t.Fatalf("unexpected %s: %s (want %s)", name, string(raw), string(want))
 	}
 }
+
+func TestSpawnOptionsEnviron(t *testing.T) {
+	t.Setenv("CODEX_SDK_TEST_PARENT", "parent")
+	tests := []struct {
+		name        string
+		spawn       SpawnOptions
+		wantInherit bool
+		want        []string
+	}{
+		{name: "default inherits", spawn: SpawnOptions{}, want: nil},
+		{name: "append", spawn: SpawnOptions{Env: []string{"CODEX_HOME=/tmp/codex"}}, wantInherit: true, want: []string{"CODEX_HOME=/tmp/codex"}},
+		{name: "replace", spawn: SpawnOptions{Env: []string{"CODEX_HOME=/tmp/codex"}, ReplaceEnv: true}, want: []string{"CODEX_HOME=/tmp/codex"}},
+		{name: "replace with nothing", spawn: SpawnOptions{ReplaceEnv: true}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := tt.spawn.environ()
+			if tt.want == nil && env != nil {
+				t.Fatalf("environ() = %v, want nil to inherit", env)
+			}
+			if tt.want != nil && env == nil {
+				t.Fatalf("environ() = nil, want a set environment")
+			}
+			inherited := slices.Contains(env, "CODEX_SDK_TEST_PARENT=parent")
+			if inherited != tt.wantInherit {
+				t.Fatalf("inherited parent environment = %v, want %v", inherited, tt.wantInherit)
+			}
+			for _, entry := range tt.want {
+				if !slices.Contains(env, entry) {
+					t.Fatalf("environ() = %v, missing %s", env, entry)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthy(t *testing.T) {
+	transport := rpc.NewReplayTransport(append(initializeTranscript(),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(2), Method: "ping"}),
+		readLine(rpc.JSONRPCError{ID: rpc.NewIntRequestID(2), Error: rpc.JSONRPCErrorError{Code: -32601, Message: "method not found"}}),
+	))
+	client, err := New(context.Background(), Options{Transport: transport})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	if err := client.Healthy(context.Background()); err != nil {
+		t.Fatalf("Healthy: %v", err)
+	}
+	_ = client.Close()
+	if err := client.Healthy(context.Background()); err == nil {
+		t.Fatalf("expected Healthy to fail after Close")
+	}
+}
+
+func TestSpawnOnProcessExit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("spawn script test is unix-only")
+	}
+	codes := make(chan int, 1)
+	client, err := New(context.Background(), Options{
+		Spawn: SpawnOptions{
+			CodexPath:     writeFakeCodexBinary(t),
+			OnProcessExit: func(err error, code int) { codes <- code },
+		},
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	_ = client.Close()
+	select {
+	case code := <-codes:
+		if code != 0 {
+			t.Fatalf("exit code = %d, want 0", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("OnProcessExit was not called")
+	}
+}
+
+func TestNewReportsStderrTailOnHandshakeFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("spawn script test is unix-only")
+	}
+	path := filepath.Join(t.TempDir(), "broken-codex")
+	script := "#!/bin/sh\necho 'Error: unknown config key model_x' >&2\nexit 2\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("write fake codex: %v", err)
+	}
+	_, err := New(context.Background(), Options{
+		Spawn:  SpawnOptions{CodexPath: path, Stderr: io.Discard, StderrTail: 4096},
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	var processErr *rpc.ProcessError
+	if !errors.As(err, &processErr) {
+		t.Fatalf("New error = %v, want a *rpc.ProcessError", err)
+	}
+	if !strings.Contains(err.Error(), "unknown config key model_x") {
+		t.Fatalf("error does not include stderr: %v", err)
+	}
+}
+
+func TestNewLogsStderr(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("spawn script test is unix-only")
+	}
+	path := filepath.Join(t.TempDir(), "broken-codex")
+	script := "#!/bin/sh\necho 'loading config' >&2\nprintf 'Error: missing auth' >&2\nexit 2\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("write fake codex: %v", err)
+	}
+	var logs syncBuffer
+	_, err := New(context.Background(), Options{
+		Spawn:  SpawnOptions{CodexPath: path, LogStderr: true, StderrLogLevel: slog.LevelError},
+		Logger: slog.New(slog.NewTextHandler(&logs, nil)),
+	})
+	if err == nil {
+		t.Fatalf("New succeeded against a server that exited")
+	}
+	waitForCondition(t, func() bool {
+		return strings.Contains(logs.String(), `level=ERROR msg="Error: missing auth" source=codex-app-server`)
+	})
+	if !strings.Contains(logs.String(), `level=ERROR msg="loading config" source=codex-app-server`) {
+		t.Fatalf("stderr line missing from logs:\n%s", logs.String())
+	}
+}
+
+func TestNewInitTimeoutKillsSilentServer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("spawn script test is unix-only")
+	}
+	path := filepath.Join(t.TempDir(), "silent-codex")
+	script := "#!/bin/sh\nwhile read -r line; do :; done\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("write fake codex: %v", err)
+	}
+	exited := make(chan struct{})
+	started := time.Now()
+	_, err := New(context.Background(), Options{
+		Spawn: SpawnOptions{
+			CodexPath:     path,
+			Stderr:        io.Discard,
+			OnProcessExit: func(error, int) { close(exited) },
+		},
+		InitTimeout: 100 * time.Millisecond,
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if !errors.Is(err, ErrStartupTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("New error = %v, want ErrStartupTimeout", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("New took %s to give up", elapsed)
+	}
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatalf("silent app-server was not killed")
+	}
+}
+
+func TestNewStrictEnvelope(t *testing.T) {
+	client, server := rpc.NewPipeTransports()
+	defer server.Close()
+	go func() {
+		line, err := server.ReadLine()
+		if err != nil {
+			return
+		}
+		var request rpc.JSONRPCRequest
+		_ = json.Unmarshal([]byte(line), &request)
+		_ = server.WriteLine(`{"jsonrpc":"2.0","id":` + string(mustRaw(request.ID)) + `,"result":{}}`)
+		_ = server.WriteLine(`{"method":"thread/started","params":{}}`)
+		for {
+			if _, err := server.ReadLine(); err != nil {
+				return
+			}
+		}
+	}()
+
+	malformed := make(chan *rpc.MalformedMessageError, 1)
+	codex, err := New(context.Background(), Options{
+		Transport:          client,
+		StrictEnvelope:     true,
+		OnMalformedMessage: func(err *rpc.MalformedMessageError) { malformed <- err },
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer codex.Close()
+
+	select {
+	case err := <-malformed:
+		if !errors.Is(err, rpc.ErrInvalidEnvelope) {
+			t.Fatalf("malformed error = %v, want ErrInvalidEnvelope", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("notification without jsonrpc was accepted")
+	}
+}
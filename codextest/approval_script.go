@@ -0,0 +1,161 @@
+package codextest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	codex "github.com/pmenglund/codex-sdk-go"
+)
+
+// ApprovalStep describes one expected approval request and its scripted
+// decision. Empty fields are not checked.
+type ApprovalStep struct {
+	Kind    codex.ApprovalKind
+	Method  string
+	Command string
+	Cwd     string
+	// Paths must match exactly, in order, when non-nil.
+	Paths []string
+	// Match runs additional assertions and fails the step when it returns an error.
+	Match func(req codex.ApprovalRequest) error
+
+	// Decision is returned to the server. When the script has a decider
+	// (see WithDecider), Decision is instead the expected outcome of that decider.
+	Decision codex.ApprovalDecision
+	// Err, when set, is returned instead of a decision.
+	Err error
+}
+
+// ApprovalScript is a codex.ApprovalDecider that asserts the sequence and
+// content of approval requests a scenario generates and answers them with
+// scripted decisions. Use Handler to plug it into codex.Options.
+//
+// Requests that do not match the next step, or arrive after the script is
+// exhausted, fail the test and are cancelled. NewApprovalScript registers a
+// cleanup that fails the test when steps remain unused.
+type ApprovalScript struct {
+	t testing.TB
+
+	mu       sync.Mutex
+	steps    []ApprovalStep
+	next     int
+	requests []codex.ApprovalRequest
+	decider  codex.ApprovalDecider
+}
+
+// NewApprovalScript returns a script expecting steps in order.
+func NewApprovalScript(t testing.TB, steps ...ApprovalStep) *ApprovalScript {
+	t.Helper()
+	s := &ApprovalScript{t: t, steps: slices.Clone(steps)}
+	t.Cleanup(s.AssertDone)
+	return s
+}
+
+// Expect appends a step to the script.
+func (s *ApprovalScript) Expect(step ApprovalStep) *ApprovalScript {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.steps = append(s.steps, step)
+	return s
+}
+
+// WithDecider makes the script delegate decisions to decider and assert that
+// each one equals the step's Decision. This regression-tests policy handlers
+// against a recorded scenario.
+func (s *ApprovalScript) WithDecider(decider codex.ApprovalDecider) *ApprovalScript {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.decider = decider
+	return s
+}
+
+// Handler returns a server request handler backed by the script.
+func (s *ApprovalScript) Handler() *codex.DecisionHandler {
+	return codex.NewDecisionHandler(s)
+}
+
+// DecideApproval implements codex.ApprovalDecider.
+func (s *ApprovalScript) DecideApproval(ctx context.Context, req codex.ApprovalRequest) (codex.ApprovalDecision, error) {
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	index := s.next
+	if index >= len(s.steps) {
+		s.mu.Unlock()
+		s.t.Errorf("codextest: unexpected approval request #%d: %s", index+1, describeRequest(req))
+		return codex.ApprovalCancel, nil
+	}
+	s.next++
+	step := s.steps[index]
+	decider := s.decider
+	s.mu.Unlock()
+
+	if err := step.check(req); err != nil {
+		s.t.Errorf("codextest: approval request #%d: %v (got %s)", index+1, err, describeRequest(req))
+		return codex.ApprovalCancel, nil
+	}
+	if step.Err != nil {
+		return "", step.Err
+	}
+	if decider == nil {
+		return step.Decision, nil
+	}
+	decision, err := decider.DecideApproval(ctx, req)
+	if err != nil {
+		s.t.Errorf("codextest: approval request #%d: decider failed: %v", index+1, err)
+		return "", err
+	}
+	if decision != step.Decision {
+		s.t.Errorf("codextest: approval request #%d: expected decision %q, decider returned %q (%s)", index+1, step.Decision, decision, describeRequest(req))
+	}
+	return decision, nil
+}
+
+// Requests returns the approval requests received so far.
+func (s *ApprovalScript) Requests() []codex.ApprovalRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// AssertDone fails the test when scripted steps have not been used.
+func (s *ApprovalScript) AssertDone() {
+	s.t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if remaining := len(s.steps) - s.next; remaining > 0 {
+		s.t.Errorf("codextest: %d expected approval request(s) not received, next: %s", remaining, s.steps[s.next].describe())
+	}
+}
+
+func (step ApprovalStep) check(req codex.ApprovalRequest) error {
+	if step.Kind != "" && req.Kind != step.Kind {
+		return fmt.Errorf("expected kind %q", step.Kind)
+	}
+	if step.Method != "" && req.Method != step.Method {
+		return fmt.Errorf("expected method %q", step.Method)
+	}
+	if step.Command != "" && req.Command != step.Command {
+		return fmt.Errorf("expected command %q", step.Command)
+	}
+	if step.Cwd != "" && req.Cwd != step.Cwd {
+		return fmt.Errorf("expected cwd %q", step.Cwd)
+	}
+	if step.Paths != nil && !slices.Equal(req.Paths, step.Paths) {
+		return fmt.Errorf("expected paths %q", step.Paths)
+	}
+	if step.Match != nil {
+		return step.Match(req)
+	}
+	return nil
+}
+
+func (step ApprovalStep) describe() string {
+	return fmt.Sprintf("kind=%q method=%q command=%q paths=%q", step.Kind, step.Method, step.Command, step.Paths)
+}
+
+func describeRequest(req codex.ApprovalRequest) string {
+	return fmt.Sprintf("kind=%q method=%q command=%q paths=%q", req.Kind, req.Method, req.Command, req.Paths)
+}
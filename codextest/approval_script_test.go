@@ -0,0 +1,194 @@
+package codextest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	codex "github.com/pmenglund/codex-sdk-go"
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestApprovalScriptDrivesServerRequests(t *testing.T) {
+	script := NewApprovalScript(t,
+		ApprovalStep{Kind: codex.ApprovalKindCommand, Command: "go test ./...", Decision: codex.ApprovalAccept},
+		ApprovalStep{Method: "applyPatchApproval", Paths: []string{"main.go"}, Decision: codex.ApprovalDecline},
+	)
+	transcript := []rpc.TranscriptEntry{
+		read(t, rpc.JSONRPCRequest{
+			ID:     rpc.NewIntRequestID(1),
+			Method: "item/commandExecution/requestApproval",
+			Params: raw(t, map[string]any{"threadId": "thr", "turnId": "turn", "itemId": "item", "command": "go test ./..."}),
+		}),
+		write(t, rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(1), Result: raw(t, map[string]any{"decision": "accept"})}),
+		read(t, rpc.JSONRPCRequest{
+			ID:     rpc.NewIntRequestID(2),
+			Method: "applyPatchApproval",
+			Params: raw(t, map[string]any{"callId": "c", "conversationId": "thr", "fileChanges": map[string]any{"main.go": map[string]any{}}}),
+		}),
+		write(t, rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(2), Result: raw(t, map[string]any{"decision": "denied"})}),
+	}
+	transport := &writeSignalTransport{ReplayTransport: rpc.NewReplayTransport(transcript), writes: make(chan error, 2)}
+	client := rpc.NewClient(transport, rpc.ClientOptions{RequestHandler: script.Handler()})
+	defer client.Close()
+
+	for range 2 {
+		select {
+		case err := <-transport.writes:
+			if err != nil {
+				t.Fatalf("unexpected response: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("scenario did not finish; requests: %d", len(script.Requests()))
+		}
+	}
+	if got := script.Requests()[0].ThreadID; got != "thr" {
+		t.Fatalf("unexpected thread id: %q", got)
+	}
+}
+
+func TestApprovalScriptFailures(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		steps []ApprovalStep
+		reqs  []codex.ApprovalRequest
+		want  string
+	}{
+		{
+			name:  "wrong command",
+			steps: []ApprovalStep{{Command: "ls", Decision: codex.ApprovalAccept}},
+			reqs:  []codex.ApprovalRequest{{Kind: codex.ApprovalKindCommand, Command: "rm"}},
+			want:  `expected command "ls"`,
+		},
+		{
+			name: "custom match",
+			steps: []ApprovalStep{{Match: func(req codex.ApprovalRequest) error {
+				return errors.New("missing reason")
+			}}},
+			reqs: []codex.ApprovalRequest{{}},
+			want: "missing reason",
+		},
+		{
+			name: "unexpected request",
+			reqs: []codex.ApprovalRequest{{Command: "ls"}},
+			want: "unexpected approval request #1",
+		},
+		{
+			name:  "unused steps",
+			steps: []ApprovalStep{{Kind: codex.ApprovalKindFileChange}},
+			want:  "1 expected approval request(s) not received",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recordingTB{TB: t}
+			script := NewApprovalScript(rec, tt.steps...)
+			for _, req := range tt.reqs {
+				decision, err := script.DecideApproval(ctx, req)
+				if err != nil || decision != codex.ApprovalCancel {
+					t.Fatalf("expected cancel on failure, got %q err=%v", decision, err)
+				}
+			}
+			rec.runCleanups()
+			if !strings.Contains(rec.failures(), tt.want) {
+				t.Fatalf("expected failure containing %q, got %q", tt.want, rec.failures())
+			}
+		})
+	}
+}
+
+func TestApprovalScriptWithDecider(t *testing.T) {
+	policy, err := codex.ParsePolicy([]byte(`{"rules":[{"when":"command.startsWith(\"git \")","decision":"accept"}]}`))
+	if err != nil {
+		t.Fatalf("parse policy: %v", err)
+	}
+	rec := &recordingTB{TB: t}
+	script := NewApprovalScript(rec,
+		ApprovalStep{Command: "git status", Decision: codex.ApprovalAccept},
+		ApprovalStep{Command: "curl example.com", Decision: codex.ApprovalAccept},
+	).WithDecider(policy)
+
+	ctx := context.Background()
+	if decision, _ := script.DecideApproval(ctx, codex.ApprovalRequest{Command: "git status"}); decision != codex.ApprovalAccept {
+		t.Fatalf("unexpected decision: %q", decision)
+	}
+	if decision, _ := script.DecideApproval(ctx, codex.ApprovalRequest{Command: "curl example.com"}); decision != codex.ApprovalDecline {
+		t.Fatalf("expected the policy decision to be returned, got %q", decision)
+	}
+	rec.runCleanups()
+	if got := rec.failures(); !strings.Contains(got, `expected decision "accept", decider returned "decline"`) {
+		t.Fatalf("unexpected failures: %q", got)
+	}
+	if len(rec.errors) != 1 {
+		t.Fatalf("expected exactly one failure, got %v", rec.errors)
+	}
+}
+
+func TestApprovalScriptStepError(t *testing.T) {
+	boom := errors.New("boom")
+	script := NewApprovalScript(t).Expect(ApprovalStep{Err: boom})
+	if _, err := script.DecideApproval(context.Background(), codex.ApprovalRequest{}); !errors.Is(err, boom) {
+		t.Fatalf("expected scripted error, got %v", err)
+	}
+}
+
+type recordingTB struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingTB) Cleanup(fn func()) {
+	r.cleanups = append(r.cleanups, fn)
+}
+
+func (r *recordingTB) runCleanups() {
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+}
+
+func (r *recordingTB) failures() string {
+	return strings.Join(r.errors, "\n")
+}
+
+type writeSignalTransport struct {
+	*rpc.ReplayTransport
+	writes chan error
+}
+
+func (t *writeSignalTransport) WriteLine(line string) error {
+	err := t.ReplayTransport.WriteLine(line)
+	t.writes <- err
+	return err
+}
+
+func read(t *testing.T, payload any) rpc.TranscriptEntry {
+	t.Helper()
+	return rpc.TranscriptEntry{Direction: rpc.TranscriptRead, Line: string(raw(t, payload))}
+}
+
+func write(t *testing.T, payload any) rpc.TranscriptEntry {
+	t.Helper()
+	return rpc.TranscriptEntry{Direction: rpc.TranscriptWrite, Line: string(raw(t, payload))}
+}
+
+func raw(t *testing.T, payload any) json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return data
+}
@@ -0,0 +1,2 @@
+// Package codextest provides helpers for testing code built on the Codex SDK.
+package codextest
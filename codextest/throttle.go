@@ -0,0 +1,131 @@
+package codextest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+// Link describes a simulated network link. Zero fields impose no limit.
+type Link struct {
+	// Latency delays every message in each direction.
+	Latency time.Duration
+	// BytesPerSecond limits bandwidth in each direction. Messages queue
+	// behind each other, as on a real link.
+	BytesPerSecond int
+}
+
+// transmit returns how long size bytes occupy the link.
+func (l Link) transmit(size int) time.Duration {
+	if l.BytesPerSecond <= 0 {
+		return 0
+	}
+	return time.Duration(float64(size) / float64(l.BytesPerSecond) * float64(time.Second))
+}
+
+// ThrottledTransport wraps a transport with the latency and bandwidth of a
+// slow link, for testing streaming UIs and timeout handling. Incoming
+// messages are read from the wrapped transport as fast as it delivers them
+// and released to ReadLine at the simulated arrival time, so latency does
+// not limit throughput. WriteLine blocks for the time the message takes to
+// cross the link.
+type ThrottledTransport struct {
+	base rpc.Transport
+	link Link
+
+	incoming chan throttledLine
+	closed   chan struct{}
+	once     sync.Once
+
+	writeMu   sync.Mutex
+	writeFree time.Time
+}
+
+type throttledLine struct {
+	line    string
+	err     error
+	arrival time.Time
+}
+
+// NewThrottledTransport starts reading from base through link.
+func NewThrottledTransport(base rpc.Transport, link Link) *ThrottledTransport {
+	t := &ThrottledTransport{
+		base:     base,
+		link:     link,
+		incoming: make(chan throttledLine, 1024),
+		closed:   make(chan struct{}),
+	}
+	go t.pump()
+	return t
+}
+
+func (t *ThrottledTransport) pump() {
+	var free time.Time
+	for {
+		line, err := t.base.ReadLine()
+		now := time.Now()
+		if free.Before(now) {
+			free = now
+		}
+		free = free.Add(t.link.transmit(len(line)))
+		select {
+		case t.incoming <- throttledLine{line: line, err: err, arrival: free.Add(t.link.Latency)}:
+		case <-t.closed:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// ReadLine returns the next message once it has crossed the link.
+func (t *ThrottledTransport) ReadLine() (string, error) {
+	select {
+	case next := <-t.incoming:
+		if err := t.waitUntil(next.arrival); err != nil {
+			return "", err
+		}
+		return next.line, next.err
+	case <-t.closed:
+		return "", rpc.ErrTransportClosed
+	}
+}
+
+// WriteLine waits while the message crosses the link, then writes it.
+// Concurrent writes queue behind each other.
+func (t *ThrottledTransport) WriteLine(line string) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	now := time.Now()
+	if t.writeFree.Before(now) {
+		t.writeFree = now
+	}
+	t.writeFree = t.writeFree.Add(t.link.transmit(len(line)))
+	if err := t.waitUntil(t.writeFree.Add(t.link.Latency)); err != nil {
+		return err
+	}
+	return t.base.WriteLine(line)
+}
+
+// Close closes the wrapped transport and wakes blocked reads and writes.
+func (t *ThrottledTransport) Close() error {
+	t.once.Do(func() { close(t.closed) })
+	return t.base.Close()
+}
+
+func (t *ThrottledTransport) waitUntil(deadline time.Time) error {
+	delay := time.Until(deadline)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-t.closed:
+		return rpc.ErrTransportClosed
+	}
+}
@@ -0,0 +1,91 @@
+package codextest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestThrottledTransportLatencyDoesNotLimitThroughput(t *testing.T) {
+	clientEnd, serverEnd := rpc.NewPipeTransports()
+	defer serverEnd.Close()
+	transport := NewThrottledTransport(clientEnd, Link{Latency: 100 * time.Millisecond})
+	defer transport.Close()
+
+	start := time.Now()
+	go func() {
+		for _, line := range []string{"a", "b", "c"} {
+			_ = serverEnd.WriteLine(line)
+		}
+	}()
+	for _, want := range []string{"a", "b", "c"} {
+		line, err := transport.ReadLine()
+		if err != nil || line != want {
+			t.Fatalf("ReadLine = %q, %v; want %q", line, err, want)
+		}
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Fatalf("%s arrived after %v, before the link latency", line, elapsed)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Fatalf("three messages took %v; latency should overlap", elapsed)
+	}
+}
+
+func TestThrottledTransportBandwidth(t *testing.T) {
+	clientEnd, serverEnd := rpc.NewPipeTransports()
+	defer serverEnd.Close()
+	transport := NewThrottledTransport(clientEnd, Link{BytesPerSecond: 2000})
+	defer transport.Close()
+
+	payload := strings.Repeat("x", 100)
+	go func() {
+		for range 3 {
+			_ = serverEnd.WriteLine(payload)
+		}
+	}()
+	start := time.Now()
+	for range 3 {
+		if _, err := transport.ReadLine(); err != nil {
+			t.Fatalf("ReadLine: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Fatalf("300 bytes at 2000 B/s took %v, want about 150ms", elapsed)
+	}
+
+	go func() { _, _ = serverEnd.ReadLine() }()
+	start = time.Now()
+	if err := transport.WriteLine(payload); err != nil {
+		t.Fatalf("WriteLine: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 45*time.Millisecond {
+		t.Fatalf("100 bytes at 2000 B/s written in %v, want about 50ms", elapsed)
+	}
+}
+
+func TestThrottledTransportCloseWakesReaders(t *testing.T) {
+	clientEnd, serverEnd := rpc.NewPipeTransports()
+	defer serverEnd.Close()
+	transport := NewThrottledTransport(clientEnd, Link{Latency: time.Hour})
+
+	go func() { _ = serverEnd.WriteLine("late") }()
+	read := make(chan error, 1)
+	go func() {
+		_, err := transport.ReadLine()
+		read <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	_ = transport.Close()
+	select {
+	case err := <-read:
+		if !errors.Is(err, rpc.ErrTransportClosed) {
+			t.Fatalf("ReadLine error = %v, want ErrTransportClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Close did not wake ReadLine")
+	}
+}
@@ -0,0 +1,135 @@
+package codex
+
+import (
+	"sort"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+// RunComparison summarizes how two turn results differ. It is intended for
+// A/B experiments where the same prompt runs on forked threads with different
+// prompts or configuration.
+type RunComparison struct {
+	// FinalResponseEqual reports whether both turns produced the same final response.
+	FinalResponseEqual bool
+	// MessagesA and MessagesB hold the agent message texts of each turn, in order.
+	MessagesA []string
+	MessagesB []string
+	// FileChanges lists every path touched by either turn whose diff differs.
+	// Paths with identical diffs in both turns are omitted.
+	FileChanges []FileChangeComparison
+	// UsageA and UsageB hold the last token usage reported during each turn, or
+	// nil when the turn did not report usage.
+	UsageA *protocol.ThreadTokenUsage
+	UsageB *protocol.ThreadTokenUsage
+	// TotalTokensDelta is UsageB's total token count minus UsageA's. It is zero
+	// when either side did not report usage.
+	TotalTokensDelta int
+}
+
+// FileChangeComparison describes a single path that differs between two turns.
+type FileChangeComparison struct {
+	Path string
+	// DiffA and DiffB are the unified diffs applied to Path in each turn. An
+	// empty diff means the turn did not touch the path.
+	DiffA string
+	DiffB string
+}
+
+// OnlyInA reports whether only the first turn touched the path.
+func (c FileChangeComparison) OnlyInA() bool {
+	return c.DiffA != "" && c.DiffB == ""
+}
+
+// OnlyInB reports whether only the second turn touched the path.
+func (c FileChangeComparison) OnlyInB() bool {
+	return c.DiffA == "" && c.DiffB != ""
+}
+
+// Identical reports whether the turns produced the same messages and file changes.
+func (c RunComparison) Identical() bool {
+	if !c.FinalResponseEqual || len(c.FileChanges) > 0 || len(c.MessagesA) != len(c.MessagesB) {
+		return false
+	}
+	for i := range c.MessagesA {
+		if c.MessagesA[i] != c.MessagesB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// CompareRuns builds a structured comparison of two turn results. Nil results
+// are treated as empty turns.
+func CompareRuns(a, b *TurnResult) RunComparison {
+	if a == nil {
+		a = &TurnResult{}
+	}
+	if b == nil {
+		b = &TurnResult{}
+	}
+
+	summaryA := summarizeRun(a)
+	summaryB := summarizeRun(b)
+
+	comparison := RunComparison{
+		FinalResponseEqual: a.FinalResponse == b.FinalResponse,
+		MessagesA:          summaryA.messages,
+		MessagesB:          summaryB.messages,
+		UsageA:             summaryA.usage,
+		UsageB:             summaryB.usage,
+	}
+	if summaryA.usage != nil && summaryB.usage != nil {
+		comparison.TotalTokensDelta = summaryB.usage.Total.TotalTokens - summaryA.usage.Total.TotalTokens
+	}
+
+	paths := make(map[string]struct{}, len(summaryA.diffs)+len(summaryB.diffs))
+	for path := range summaryA.diffs {
+		paths[path] = struct{}{}
+	}
+	for path := range summaryB.diffs {
+		paths[path] = struct{}{}
+	}
+	for path := range paths {
+		diffA, diffB := summaryA.diffs[path], summaryB.diffs[path]
+		if diffA == diffB {
+			continue
+		}
+		comparison.FileChanges = append(comparison.FileChanges, FileChangeComparison{Path: path, DiffA: diffA, DiffB: diffB})
+	}
+	sort.Slice(comparison.FileChanges, func(i, j int) bool {
+		return comparison.FileChanges[i].Path < comparison.FileChanges[j].Path
+	})
+
+	return comparison
+}
+
+type runSummary struct {
+	messages []string
+	diffs    map[string]string
+	usage    *protocol.ThreadTokenUsage
+}
+
+func summarizeRun(result *TurnResult) runSummary {
+	summary := runSummary{diffs: map[string]string{}}
+	for _, raw := range result.Items {
+		item, err := protocol.DecodeThreadItem(raw)
+		if err != nil {
+			continue
+		}
+		switch item := item.(type) {
+		case *protocol.AgentMessageItem:
+			summary.messages = append(summary.messages, item.Text)
+		case *protocol.FileChangeItem:
+			for _, change := range item.Changes {
+				summary.diffs[change.Path] += change.Diff
+			}
+		}
+	}
+	for _, note := range result.Notifications {
+		if payload, ok := tokenUsageParams(note); ok {
+			summary.usage = &payload.TokenUsage
+		}
+	}
+	return summary
+}
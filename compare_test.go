@@ -0,0 +1,80 @@
+package codex
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestCompareRuns(t *testing.T) {
+	a := &TurnResult{
+		FinalResponse: "done",
+		Items: []json.RawMessage{
+			MustJSON(map[string]any{"type": "agentMessage", "text": "done"}),
+			MustJSON(map[string]any{"type": "fileChange", "changes": []map[string]any{
+				{"path": "a.go", "kind": "update", "diff": "-a\n+b\n"},
+				{"path": "same.go", "kind": "update", "diff": "+x\n"},
+			}}),
+		},
+		Notifications: []rpc.Notification{
+			tokenUsageNotification(100),
+		},
+	}
+	b := &TurnResult{
+		FinalResponse: "done differently",
+		Items: []json.RawMessage{
+			MustJSON(map[string]any{"type": "agentMessage", "text": "done differently"}),
+			MustJSON(map[string]any{"type": "fileChange", "changes": []map[string]any{
+				{"path": "b.go", "kind": "add", "diff": "+new\n"},
+				{"path": "same.go", "kind": "update", "diff": "+x\n"},
+			}}),
+		},
+		Notifications: []rpc.Notification{
+			{Method: "thread/tokenUsage/updated", Raw: MustJSON(protocol.ThreadTokenUsageUpdatedNotification{
+				TokenUsage: protocol.ThreadTokenUsage{Total: protocol.TokenUsageBreakdown{TotalTokens: 140}},
+			})},
+		},
+	}
+
+	got := CompareRuns(a, b)
+	if got.FinalResponseEqual || got.Identical() {
+		t.Fatalf("expected runs to differ")
+	}
+	assertEqual(t, "messagesA", got.MessagesA, []string{"done"})
+	assertEqual(t, "messagesB", got.MessagesB, []string{"done differently"})
+	assertEqual(t, "fileChanges", got.FileChanges, []FileChangeComparison{
+		{Path: "a.go", DiffA: "-a\n+b\n"},
+		{Path: "b.go", DiffB: "+new\n"},
+	})
+	if !got.FileChanges[0].OnlyInA() || !got.FileChanges[1].OnlyInB() {
+		t.Fatalf("unexpected file change sides: %#v", got.FileChanges)
+	}
+	if got.TotalTokensDelta != 40 {
+		t.Fatalf("expected token delta 40, got %d", got.TotalTokensDelta)
+	}
+}
+
+func TestCompareRunsIdenticalAndNil(t *testing.T) {
+	if !CompareRuns(nil, nil).Identical() {
+		t.Fatalf("expected nil runs to be identical")
+	}
+	run := &TurnResult{FinalResponse: "ok", Items: []json.RawMessage{MustJSON(map[string]any{"type": "agentMessage", "text": "ok"})}}
+	got := CompareRuns(run, run)
+	if !got.Identical() {
+		t.Fatalf("expected identical runs, got %#v", got)
+	}
+	if got.UsageA != nil || got.TotalTokensDelta != 0 {
+		t.Fatalf("expected no usage, got %#v", got)
+	}
+}
+
+func tokenUsageNotification(total int) rpc.Notification {
+	return rpc.Notification{
+		Method: "thread/tokenUsage/updated",
+		Params: protocol.ThreadTokenUsageUpdatedNotification{
+			TokenUsage: protocol.ThreadTokenUsage{Total: protocol.TokenUsageBreakdown{TotalTokens: total}},
+		},
+	}
+}
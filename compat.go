@@ -0,0 +1,190 @@
+package codex
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+// MinimumCodexVersion is the oldest codex release whose app-server speaks
+// the thread/turn protocol this SDK is generated from.
+const MinimumCodexVersion = "0.58.0"
+
+// requiredMethods are the requests the facade cannot work without. Older
+// app-servers only offered the legacy conversation flow
+// (newConversation/sendUserMessage) in their place.
+var requiredMethods = []string{"thread/start", "turn/start"}
+
+// IncompatibleServerError reports an app-server that is too old for this
+// SDK. New returns it when the server's version or advertised methods rule it
+// out; requests return it when the server rejects a required method as
+// unknown.
+type IncompatibleServerError struct {
+	// Version is the version the server reported, if any.
+	Version string
+	// Missing lists required methods the server does not support.
+	Missing []string
+	// Required is Options.MinServerVersion when that check failed, and
+	// empty for the SDK's own MinimumCodexVersion.
+	Required string
+	// Err is the underlying error, when a request was rejected.
+	Err error
+}
+
+func (e *IncompatibleServerError) Error() string {
+	minimum := MinimumCodexVersion
+	if e.Required != "" {
+		minimum = e.Required
+	}
+	var b strings.Builder
+	b.WriteString("codex app-server")
+	if e.Version != "" {
+		b.WriteString(" " + e.Version)
+	}
+	_, known := parseVersion(e.Version)
+	switch {
+	case len(e.Missing) > 0:
+		b.WriteString(" does not support " + strings.Join(e.Missing, ", "))
+	case !known:
+		b.WriteString(" has no recognizable release version, so it cannot be checked against " + minimum)
+	default:
+		b.WriteString(" is older than " + minimum)
+	}
+	b.WriteString("; upgrade codex to >= " + minimum)
+	return b.String()
+}
+
+func (e *IncompatibleServerError) Unwrap() error {
+	return e.Err
+}
+
+// checkCompatibility rejects a server that advertises a method list without
+// the required methods, or reports a release older than
+// MinimumCodexVersion. Servers that report neither are assumed compatible;
+// compatError then catches a missing method on first use.
+func checkCompatibility(info *rpc.ServerInfo) error {
+	if info == nil {
+		return nil
+	}
+	if info.Capabilities.Methods != nil {
+		var missing []string
+		for _, method := range requiredMethods {
+			if !slices.Contains(info.Capabilities.Methods, method) {
+				missing = append(missing, method)
+			}
+		}
+		if len(missing) > 0 {
+			return &IncompatibleServerError{Version: info.Version, Missing: missing}
+		}
+		return nil
+	}
+	version, ok := parseVersion(info.Version)
+	if !ok {
+		return nil
+	}
+	minimum, _ := parseVersion(MinimumCodexVersion)
+	if slices.Compare(version[:], minimum[:]) < 0 {
+		return &IncompatibleServerError{Version: info.Version}
+	}
+	return nil
+}
+
+// checkMinimumVersion rejects a server whose version is older than
+// minimum. A version that is unknown also fails, since the requirement
+// cannot be verified.
+func checkMinimumVersion(version, minimum string) error {
+	required, _ := parseVersion(minimum)
+	got, ok := parseVersion(version)
+	if !ok || slices.Compare(got[:], required[:]) < 0 {
+		return &IncompatibleServerError{Version: version, Required: minimum}
+	}
+	return nil
+}
+
+// detectServerVersion returns the app-server's version: the one it reported
+// at initialize or, for a spawned binary, the one "codex --version" prints
+// when run with the spawn's environment and working directory. It returns
+// "" when neither is known.
+func detectServerVersion(ctx context.Context, info *rpc.ServerInfo, provenance *Provenance, spawn *SpawnOptions) string {
+	if info != nil {
+		if _, ok := parseVersion(info.Version); ok {
+			return info.Version
+		}
+	}
+	if provenance != nil {
+		return versionFromOutput(provenance.Version)
+	}
+	if spawn == nil {
+		return ""
+	}
+	cmd := exec.CommandContext(ctx, spawn.CodexPath, "--version")
+	cmd.Env = spawn.environ()
+	cmd.Dir = spawn.Dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return versionFromOutput(string(output))
+}
+
+// checkServerVersion applies Options.MinServerVersion to client, after New
+// and after every reconnect.
+func (c *Codex) checkServerVersion(ctx context.Context, client *rpc.Client) error {
+	if c.minServerVersion == "" {
+		return nil
+	}
+	version := detectServerVersion(ctx, client.ServerInfo(), c.currentProvenance(), c.spawn)
+	return checkMinimumVersion(version, c.minServerVersion)
+}
+
+// versionFromOutput extracts the version from "codex --version" output such
+// as "codex-cli 0.58.0".
+func versionFromOutput(output string) string {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[len(fields)-1]
+}
+
+// compatError turns a "method not found" reply to a required method into an
+// IncompatibleServerError, leaving other errors unchanged.
+func compatError(client *rpc.Client, method string, err error) error {
+	if !slices.Contains(requiredMethods, method) || !errors.Is(err, rpc.ErrMethodNotFound) {
+		return err
+	}
+	incompatible := &IncompatibleServerError{Missing: []string{method}, Err: err}
+	if info := client.ServerInfo(); info != nil {
+		incompatible.Version = info.Version
+	}
+	return incompatible
+}
+
+// parseVersion parses the numeric core of a "major.minor.patch" version,
+// ignoring any pre-release or build suffix. Development builds report
+// 0.0.0 and are treated as unknown.
+func parseVersion(version string) ([3]int, bool) {
+	var parts [3]int
+	core, _, _ := strings.Cut(version, "-")
+	core, _, _ = strings.Cut(core, "+")
+	fields := strings.Split(core, ".")
+	if len(fields) != 3 {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	if parts == [3]int{} {
+		return parts, false
+	}
+	return parts, true
+}
@@ -0,0 +1,222 @@
+package codex
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	tests := []struct {
+		name        string
+		info        *rpc.ServerInfo
+		wantMissing []string
+		wantErr     bool
+	}{
+		{name: "no info"},
+		{name: "unknown version", info: &rpc.ServerInfo{}},
+		{name: "development build", info: &rpc.ServerInfo{Version: "0.0.0"}},
+		{name: "current release", info: &rpc.ServerInfo{Version: MinimumCodexVersion}},
+		{name: "newer prerelease", info: &rpc.ServerInfo{Version: "1.2.0-alpha.3"}},
+		{name: "old release", info: &rpc.ServerInfo{Version: "0.40.1"}, wantErr: true},
+		{
+			name: "advertised methods",
+			info: &rpc.ServerInfo{Version: "0.40.1", Capabilities: rpc.Capabilities{Methods: []string{"thread/start", "turn/start"}}},
+		},
+		{
+			name:        "legacy methods only",
+			info:        &rpc.ServerInfo{Capabilities: rpc.Capabilities{Methods: []string{"newConversation", "sendUserMessage", "thread/start"}}},
+			wantMissing: []string{"turn/start"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkCompatibility(tt.info)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkCompatibility() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+			var incompatible *IncompatibleServerError
+			if !errors.As(err, &incompatible) {
+				t.Fatalf("expected IncompatibleServerError, got %T", err)
+			}
+			if strings.Join(incompatible.Missing, ",") != strings.Join(tt.wantMissing, ",") {
+				t.Fatalf("missing = %v, want %v", incompatible.Missing, tt.wantMissing)
+			}
+			if !strings.Contains(err.Error(), "upgrade codex to >= "+MinimumCodexVersion) {
+				t.Fatalf("expected upgrade hint, got %q", err)
+			}
+		})
+	}
+}
+
+func TestNewRejectsOldServer(t *testing.T) {
+	entries := initializeTranscript()
+	entries[1] = readLine(rpc.JSONRPCResponse{
+		ID:     rpc.NewIntRequestID(1),
+		Result: mustRaw(map[string]any{"userAgent": "codex_cli_rs/0.40.0 (Linux; x86_64)"}),
+	})
+
+	_, err := New(context.Background(), Options{Transport: rpc.NewReplayTransport(entries)})
+	var incompatible *IncompatibleServerError
+	if !errors.As(err, &incompatible) || incompatible.Version != "0.40.0" {
+		t.Fatalf("expected IncompatibleServerError for 0.40.0, got %v", err)
+	}
+	want := "codex app-server 0.40.0 is older than " + MinimumCodexVersion + "; upgrade codex to >= " + MinimumCodexVersion
+	if err.Error() != want {
+		t.Fatalf("error = %q, want %q", err, want)
+	}
+
+	client, err := New(context.Background(), Options{Transport: rpc.NewReplayTransport(entries), SkipCompatibilityCheck: true})
+	if err != nil {
+		t.Fatalf("expected SkipCompatibilityCheck to accept old server, got %v", err)
+	}
+	_ = client.Close()
+}
+
+func TestRunStreamedReportsMissingTurnStart(t *testing.T) {
+	client := rpc.NewClient(rpc.NewReplayTransport([]rpc.TranscriptEntry{
+		writeLine(rpc.JSONRPCRequest{
+			ID:     rpc.NewIntRequestID(1),
+			Method: "turn/start",
+			Params: mustRaw(turnStartParams("hello")),
+		}),
+		readLine(rpc.JSONRPCError{
+			ID:    rpc.NewIntRequestID(1),
+			Error: rpc.JSONRPCErrorError{Code: -32601, Message: "method not found"},
+		}),
+	}), rpc.ClientOptions{})
+	defer client.Close()
+
+	thread := &Thread{client: client, id: "thr_123"}
+	_, err := thread.RunStreamed(context.Background(), []Input{TextInput("hello")}, nil)
+	var incompatible *IncompatibleServerError
+	if !errors.As(err, &incompatible) || len(incompatible.Missing) != 1 || incompatible.Missing[0] != "turn/start" {
+		t.Fatalf("expected missing turn/start, got %v", err)
+	}
+	var respErr *rpc.ResponseError
+	if !errors.As(err, &respErr) {
+		t.Fatalf("expected wrapped response error, got %v", err)
+	}
+}
+
+func TestCheckMinimumVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		wantErr string
+	}{
+		{name: "equal", version: "0.60.0"},
+		{name: "newer", version: "0.61.2-alpha.1"},
+		{name: "older", version: "0.59.9", wantErr: "codex app-server 0.59.9 is older than 0.60.0; upgrade codex to >= 0.60.0"},
+		{name: "unknown", version: "", wantErr: "codex app-server has no recognizable release version, so it cannot be checked against 0.60.0; upgrade codex to >= 0.60.0"},
+		{name: "development build", version: "0.0.0", wantErr: "no recognizable release version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkMinimumVersion(tt.version, "0.60.0")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkMinimumVersion(%q) = %v", tt.version, err)
+				}
+				return
+			}
+			var incompatible *IncompatibleServerError
+			if !errors.As(err, &incompatible) || incompatible.Required != "0.60.0" {
+				t.Fatalf("expected IncompatibleServerError requiring 0.60.0, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %q, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewEnforcesMinServerVersion(t *testing.T) {
+	entries := initializeTranscript()
+	entries[1] = readLine(rpc.JSONRPCResponse{
+		ID:     rpc.NewIntRequestID(1),
+		Result: mustRaw(map[string]any{"userAgent": "codex_cli_rs/0.60.0 (Linux; x86_64)"}),
+	})
+
+	_, err := New(context.Background(), Options{Transport: rpc.NewReplayTransport(entries), MinServerVersion: "0.61.0"})
+	var incompatible *IncompatibleServerError
+	if !errors.As(err, &incompatible) || incompatible.Version != "0.60.0" {
+		t.Fatalf("expected IncompatibleServerError for 0.60.0, got %v", err)
+	}
+
+	client, err := New(context.Background(), Options{Transport: rpc.NewReplayTransport(entries), MinServerVersion: "0.60.0"})
+	if err != nil {
+		t.Fatalf("expected 0.60.0 to satisfy the minimum, got %v", err)
+	}
+	_ = client.Close()
+
+	if _, err := New(context.Background(), Options{Transport: rpc.NewReplayTransport(entries), MinServerVersion: "latest"}); err == nil || !strings.Contains(err.Error(), "invalid MinServerVersion") {
+		t.Fatalf("expected invalid MinServerVersion error, got %v", err)
+	}
+}
+
+func TestNewProbesSpawnedBinaryVersion(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("spawn script test is unix-only")
+	}
+	_, err := New(context.Background(), Options{
+		Spawn:            SpawnOptions{CodexPath: writeFakeCodexBinary(t)},
+		MinServerVersion: "0.60.0",
+	})
+	var incompatible *IncompatibleServerError
+	if !errors.As(err, &incompatible) || incompatible.Version != "0.0.0-test" {
+		t.Fatalf("expected the --version output to be checked, got %v", err)
+	}
+}
+
+func TestNewProbesVersionWithSpawnEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("spawn script test is unix-only")
+	}
+	client, err := New(context.Background(), Options{
+		Spawn: SpawnOptions{
+			CodexPath: writeFakeCodexBinary(t),
+			Env:       []string{"FAKE_CODEX_VERSION=0.61.0"},
+			Dir:       t.TempDir(),
+		},
+		MinServerVersion: "0.60.0",
+	})
+	if err != nil {
+		t.Fatalf("expected --version to run with Spawn.Env, got %v", err)
+	}
+	_ = client.Close()
+}
+
+func TestReconnectEnforcesMinServerVersion(t *testing.T) {
+	versioned := func(version string) rpc.Transport {
+		entries := initializeTranscript()
+		entries[1] = readLine(rpc.JSONRPCResponse{
+			ID:     rpc.NewIntRequestID(1),
+			Result: mustRaw(map[string]any{"userAgent": "codex_cli_rs/" + version + " (Linux; x86_64)"}),
+		})
+		return rpc.NewReplayTransport(entries)
+	}
+	client, err := New(context.Background(), Options{
+		Transport:        versioned("0.61.0"),
+		Redial:           func(context.Context) (rpc.Transport, error) { return versioned("0.60.0"), nil },
+		MinServerVersion: "0.61.0",
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer client.Close()
+
+	_, err = client.reconnect(context.Background(), client.Client(), 1)
+	var incompatible *IncompatibleServerError
+	if !errors.As(err, &incompatible) || incompatible.Version != "0.60.0" {
+		t.Fatalf("expected the replacement server to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,235 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+// ErrConfigKeyNotSet matches errors from Config.Decode for keys that are
+// not set.
+var ErrConfigKeyNotSet = errors.New("config key is not set")
+
+// Config is the effective codex configuration as the app-server resolves
+// it, merged from config.toml, profiles and project layers.
+type Config struct {
+	// Raw is the merged configuration as a JSON object, keyed like
+	// config.toml.
+	Raw RawJSON
+
+	values map[string]json.RawMessage
+}
+
+// ConfigReadOptions configures ReadConfig.
+type ConfigReadOptions struct {
+	// Cwd, when set, includes the project config layers that apply to that
+	// directory.
+	Cwd string
+}
+
+// ReadConfig reads the effective configuration.
+func (c *Codex) ReadConfig(ctx context.Context, options ConfigReadOptions) (*Config, error) {
+	if err := c.ensureReady(); err != nil {
+		return nil, err
+	}
+	params := protocol.ConfigReadParams{}
+	if options.Cwd != "" {
+		params.Cwd = stringPtr(options.Cwd)
+	}
+	var response struct {
+		Config json.RawMessage `json:"config"`
+	}
+	if _, err := c.call(ctx, "config/read", params, &response); err != nil {
+		return nil, err
+	}
+	return decodeConfig(response.Config)
+}
+
+// decodeConfig wraps the config of a config/read response.
+func decodeConfig(raw json.RawMessage) (*Config, error) {
+	config := &Config{Raw: raw}
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &config.values); err != nil {
+			return nil, fmt.Errorf("decode config: %w", err)
+		}
+	}
+	return config, nil
+}
+
+// Get returns the value at keyPath, a dotted path such as
+// "profiles.work.model", and reports whether it is set.
+func (c *Config) Get(keyPath string) (RawJSON, bool) {
+	if c == nil || keyPath == "" {
+		return nil, false
+	}
+	values := c.values
+	keys := strings.Split(keyPath, ".")
+	for i, key := range keys {
+		value, ok := values[key]
+		if !ok || string(value) == "null" {
+			return nil, false
+		}
+		if i == len(keys)-1 {
+			return value, true
+		}
+		values = nil
+		if err := json.Unmarshal(value, &values); err != nil {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// Decode unmarshals the value at keyPath into v. It returns an error
+// matching ErrConfigKeyNotSet when the key is not set.
+func (c *Config) Decode(keyPath string, v any) error {
+	value, ok := c.Get(keyPath)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrConfigKeyNotSet, keyPath)
+	}
+	if err := json.Unmarshal(value, v); err != nil {
+		return fmt.Errorf("decode config %s: %w", keyPath, err)
+	}
+	return nil
+}
+
+// String returns the string value at keyPath, or "" when it is not set or
+// not a string.
+func (c *Config) String(keyPath string) string {
+	var value string
+	if err := c.Decode(keyPath, &value); err != nil {
+		return ""
+	}
+	return value
+}
+
+// Model returns the configured default model.
+func (c *Config) Model() string {
+	return c.String("model")
+}
+
+// Profile returns the active profile, or "" when none is selected.
+func (c *Config) Profile() string {
+	return c.String("profile")
+}
+
+// Profiles returns the names of the defined profiles, sorted.
+func (c *Config) Profiles() []string {
+	var profiles map[string]json.RawMessage
+	if err := c.Decode("profiles", &profiles); err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ListProfiles returns the names of the profiles defined in the
+// configuration, sorted.
+func (c *Codex) ListProfiles(ctx context.Context) ([]string, error) {
+	config, err := c.ReadConfig(ctx, ConfigReadOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return config.Profiles(), nil
+}
+
+// ConfigEdit is one change to the user's config.toml.
+type ConfigEdit struct {
+	// KeyPath is the dotted path of the key, such as "model" or
+	// "profiles.work.model_reasoning_effort".
+	KeyPath string
+	// Value is marshaled as JSON and written as the TOML equivalent.
+	Value any
+	// Merge merges an object Value into the existing table instead of
+	// replacing it.
+	Merge bool
+}
+
+// ConfigWriteOptions configures WriteConfig.
+type ConfigWriteOptions struct {
+	// FilePath is the config file to write; the app-server defaults to the
+	// user's config.toml.
+	FilePath string
+	// ExpectedVersion, when set, makes the write fail if the file changed
+	// since the version returned by an earlier write.
+	ExpectedVersion string
+	// ReloadThreads applies the updated config to the threads the
+	// app-server has loaded.
+	ReloadThreads bool
+}
+
+// ConfigWriteResult describes a completed config write.
+type ConfigWriteResult struct {
+	// FilePath is the file that was written and Version its new version,
+	// for ConfigWriteOptions.ExpectedVersion.
+	FilePath string
+	Version  string
+	// Overridden reports that a higher-priority layer, such as a managed
+	// config, still overrides a written value.
+	Overridden bool
+}
+
+// SetConfigValue writes one value to the user's config.toml.
+func (c *Codex) SetConfigValue(ctx context.Context, keyPath string, value any) (*ConfigWriteResult, error) {
+	return c.WriteConfig(ctx, []ConfigEdit{{KeyPath: keyPath, Value: value}}, ConfigWriteOptions{})
+}
+
+// WriteConfig applies edits to the config file atomically.
+func (c *Codex) WriteConfig(ctx context.Context, edits []ConfigEdit, options ConfigWriteOptions) (*ConfigWriteResult, error) {
+	if err := c.ensureReady(); err != nil {
+		return nil, err
+	}
+	if len(edits) == 0 {
+		return nil, errors.New("no config edits")
+	}
+	params := protocol.ConfigBatchWriteParams{Edits: make([]protocol.ConfigEdit, 0, len(edits))}
+	for _, edit := range edits {
+		if edit.KeyPath == "" {
+			return nil, errors.New("config edit key path is empty")
+		}
+		value, err := normalizeJSONValue("config "+edit.KeyPath, edit.Value)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			value = json.RawMessage("null")
+		}
+		strategy := protocol.MergeStrategyReplace
+		if edit.Merge {
+			strategy = protocol.MergeStrategyUpsert
+		}
+		params.Edits = append(params.Edits, protocol.ConfigEdit{KeyPath: edit.KeyPath, MergeStrategy: strategy, Value: value})
+	}
+	if options.FilePath != "" {
+		params.FilePath = stringPtr(options.FilePath)
+	}
+	if options.ExpectedVersion != "" {
+		params.ExpectedVersion = stringPtr(options.ExpectedVersion)
+	}
+	if options.ReloadThreads {
+		reload := true
+		params.ReloadUserConfig = &reload
+	}
+	var response struct {
+		Status   string `json:"status"`
+		Version  string `json:"version"`
+		FilePath string `json:"filePath"`
+	}
+	if _, err := c.call(ctx, "config/batchWrite", params, &response); err != nil {
+		return nil, err
+	}
+	return &ConfigWriteResult{
+		FilePath:   response.FilePath,
+		Version:    response.Version,
+		Overridden: response.Status == "okOverridden",
+	}, nil
+}
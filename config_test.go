@@ -0,0 +1,93 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestReadConfig(t *testing.T) {
+	server, codex := newFakeAppServer(t, map[string]fakeHandler{
+		"config/read": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			return map[string]any{
+				"config": map[string]any{
+					"model":   "gpt-5-codex",
+					"profile": "work",
+					"profiles": map[string]any{
+						"work":     map[string]any{"model": "gpt-5", "model_reasoning_effort": "high"},
+						"personal": map[string]any{},
+					},
+					"sandbox_mode": nil,
+				},
+				"origins": map[string]any{},
+			}, nil
+		},
+	})
+	ctx := context.Background()
+	config, err := codex.ReadConfig(ctx, ConfigReadOptions{Cwd: "/repo"})
+	if err != nil {
+		t.Fatalf("ReadConfig: %v", err)
+	}
+	if got := string(server.request(t, "config/read").Params); got != `{"cwd":"/repo"}` {
+		t.Fatalf("config/read params = %s", got)
+	}
+	if config.Model() != "gpt-5-codex" || config.Profile() != "work" {
+		t.Fatalf("model = %q, profile = %q", config.Model(), config.Profile())
+	}
+	if got := config.String("profiles.work.model_reasoning_effort"); got != "high" {
+		t.Fatalf("nested value = %q", got)
+	}
+	if _, ok := config.Get("sandbox_mode"); ok {
+		t.Fatalf("null value reported as set")
+	}
+	var effort string
+	if err := config.Decode("profiles.personal.model", &effort); !errors.Is(err, ErrConfigKeyNotSet) {
+		t.Fatalf("Decode of a missing key = %v, want ErrConfigKeyNotSet", err)
+	}
+	if got, want := config.Profiles(), []string{"personal", "work"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Profiles = %v, want %v", got, want)
+	}
+	profiles, err := codex.ListProfiles(ctx)
+	if err != nil || len(profiles) != 2 {
+		t.Fatalf("ListProfiles = %v, %v", profiles, err)
+	}
+}
+
+func TestWriteConfig(t *testing.T) {
+	server, codex := newFakeAppServer(t, map[string]fakeHandler{
+		"config/batchWrite": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			return map[string]any{"status": "okOverridden", "version": "v2", "filePath": "/home/dev/.codex/config.toml"}, nil
+		},
+	})
+	ctx := context.Background()
+	result, err := codex.WriteConfig(ctx, []ConfigEdit{
+		{KeyPath: "model", Value: "gpt-5"},
+		{KeyPath: "profiles.work", Value: map[string]any{"model": "gpt-5"}, Merge: true},
+	}, ConfigWriteOptions{ExpectedVersion: "v1", ReloadThreads: true})
+	if err != nil {
+		t.Fatalf("WriteConfig: %v", err)
+	}
+	want := ConfigWriteResult{FilePath: "/home/dev/.codex/config.toml", Version: "v2", Overridden: true}
+	if *result != want {
+		t.Fatalf("result = %+v, want %+v", *result, want)
+	}
+	wantParams := `{"edits":[{"keyPath":"model","mergeStrategy":"replace","value":"gpt-5"},` +
+		`{"keyPath":"profiles.work","mergeStrategy":"upsert","value":{"model":"gpt-5"}}],"expectedVersion":"v1","reloadUserConfig":true}`
+	if got := string(server.request(t, "config/batchWrite").Params); got != wantParams {
+		t.Fatalf("config/batchWrite params = %s", got)
+	}
+
+	if _, err := codex.SetConfigValue(ctx, "model", nil); err != nil {
+		t.Fatalf("SetConfigValue: %v", err)
+	}
+	if got := string(server.request(t, "config/batchWrite").Params); got != `{"edits":[{"keyPath":"model","mergeStrategy":"replace","value":null}]}` {
+		t.Fatalf("SetConfigValue params = %s", got)
+	}
+	if _, err := codex.WriteConfig(ctx, nil, ConfigWriteOptions{}); err == nil {
+		t.Fatalf("WriteConfig accepted no edits")
+	}
+}
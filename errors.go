@@ -0,0 +1,60 @@
+package codex
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+var (
+	// ErrThreadNotFound matches errors from requests that name a thread the
+	// app-server does not know, for example resuming a deleted thread.
+	ErrThreadNotFound = errors.New("thread not found")
+	// ErrRateLimited matches request errors and TurnErrors caused by a usage
+	// limit or an upstream HTTP 429. It is rpc.ErrRateLimited.
+	ErrRateLimited = rpc.ErrRateLimited
+	// ErrStartupTimeout matches errors from New when the app-server was not
+	// spawned and initialized within Options.InitTimeout.
+	ErrStartupTimeout = errors.New("codex app-server startup timed out")
+)
+
+// threadNotFoundError marks an app-server error as ErrThreadNotFound while
+// keeping its message and the underlying *rpc.ResponseError.
+type threadNotFoundError struct {
+	err error
+}
+
+func (e *threadNotFoundError) Error() string {
+	return e.err.Error()
+}
+
+func (e *threadNotFoundError) Unwrap() error {
+	return e.err
+}
+
+func (e *threadNotFoundError) Is(target error) bool {
+	return target == ErrThreadNotFound
+}
+
+// classifyError maps a facade request error onto the package's typed
+// errors: IncompatibleServerError for required methods the server lacks and
+// ErrThreadNotFound for unknown threads. Other errors are returned as is.
+func classifyError(client *rpc.Client, method string, err error) error {
+	err = compatError(client, method, err)
+	var respErr *rpc.ResponseError
+	if errors.As(err, &respErr) && isThreadNotFound(respErr) {
+		return &threadNotFoundError{err: err}
+	}
+	return err
+}
+
+// isThreadNotFound recognizes the app-server's unknown-thread errors, which
+// it reports as invalid requests with a descriptive message.
+func isThreadNotFound(err *rpc.ResponseError) bool {
+	if err.Detail.Code != rpc.CodeInvalidRequest && err.Detail.Code != rpc.CodeInvalidParams {
+		return false
+	}
+	message := strings.ToLower(err.Detail.Message)
+	return containsAny(message, "thread not found", "no thread with id", "no rollout found")
+}
@@ -0,0 +1,49 @@
+package codex
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestResumeThreadNotFound(t *testing.T) {
+	entries := append(initializeTranscript(),
+		writeLine(rpc.JSONRPCRequest{
+			ID:     rpc.NewIntRequestID(2),
+			Method: "thread/resume",
+			Params: mustRaw(map[string]any{"threadId": "thr_missing"}),
+		}),
+		readLine(rpc.JSONRPCError{
+			ID:    rpc.NewIntRequestID(2),
+			Error: rpc.JSONRPCErrorError{Code: rpc.CodeInvalidRequest, Message: "thread not found: thr_missing"},
+		}),
+	)
+	client, err := New(context.Background(), Options{Transport: rpc.NewReplayTransport(entries)})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	defer client.Close()
+
+	_, err = client.ResumeThread(context.Background(), ThreadResumeOptions{ThreadID: "thr_missing"})
+	if !errors.Is(err, ErrThreadNotFound) {
+		t.Fatalf("expected ErrThreadNotFound, got %v", err)
+	}
+	var respErr *rpc.ResponseError
+	if !errors.As(err, &respErr) || !errors.Is(err, rpc.ErrInvalidRequest) {
+		t.Fatalf("expected wrapped response error, got %v", err)
+	}
+	if err.Error() != "json-rpc error -32600: thread not found: thr_missing" {
+		t.Fatalf("error = %q", err)
+	}
+}
+
+func TestTurnErrorIsRateLimited(t *testing.T) {
+	if !errors.Is(&TurnError{Reason: TurnFailureRateLimit}, ErrRateLimited) {
+		t.Fatalf("expected rate-limited turn to match ErrRateLimited")
+	}
+	if errors.Is(&TurnError{Reason: TurnFailureModel}, ErrRateLimited) {
+		t.Fatalf("expected model failure not to match ErrRateLimited")
+	}
+}
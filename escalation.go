@@ -0,0 +1,276 @@
+package codex
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultEscalationTimeout = 10 * time.Minute
+
+// EscalationSignatureHeader carries the signature of an EscalationReply
+// posted to HTTPEscalator. See SignEscalationReply.
+const EscalationSignatureHeader = "X-Codex-Signature"
+
+// ApprovalAudit records the outcome of one escalated approval.
+type ApprovalAudit struct {
+	EscalationID string
+	Request      ApprovalRequest
+	Decision     ApprovalDecision
+	// Err is set when the escalation could not be delivered or the wait was canceled.
+	Err         error
+	TimedOut    bool
+	RequestedAt time.Time
+	DecidedAt   time.Time
+}
+
+// EscalationMessage is the default JSON body posted by HTTPEscalator.
+type EscalationMessage struct {
+	ID      string          `json:"id"`
+	Request ApprovalRequest `json:"request"`
+}
+
+// EscalationReply is the JSON body HTTPEscalator accepts on its callback endpoint.
+type EscalationReply struct {
+	ID       string           `json:"id"`
+	Decision ApprovalDecision `json:"decision"`
+}
+
+// HTTPEscalator is an ApprovalDecider that forwards approvals to an external
+// system over HTTP and waits for an asynchronous decision. Each approval is
+// POSTed to URL with a unique escalation id; the external system answers by
+// POSTing an EscalationReply to the escalator's ServeHTTP endpoint, or the
+// application calls Resolve directly.
+//
+// Escalation ids are not credentials: they appear in the posted payload, for
+// example in Slack message text. ServeHTTP only accepts replies signed with
+// Secret.
+//
+// Use it with DecisionHandler:
+//
+//	escalator := &codex.HTTPEscalator{URL: webhookURL, Payload: codex.SlackPayload}
+//	http.Handle("/approvals", escalator)
+//	client, err := codex.New(ctx, codex.Options{ApprovalHandler: codex.NewDecisionHandler(escalator)})
+type HTTPEscalator struct {
+	// URL receives a POST for every pending approval.
+	URL string
+	// Client sends the POST (defaults to http.DefaultClient).
+	Client *http.Client
+	// Header is added to every POST, for example to carry credentials.
+	Header http.Header
+	// Payload builds the POST body (defaults to EscalationMessage).
+	Payload func(id string, req ApprovalRequest) any
+	// Timeout bounds the wait for a decision (defaults to 10 minutes).
+	Timeout time.Duration
+	// TimeoutDecision is returned when Timeout elapses (defaults to ApprovalDecline).
+	TimeoutDecision ApprovalDecision
+	// Audit receives a record for every escalation, including failures.
+	Audit func(ApprovalAudit)
+	// Secret is shared with the system that posts replies to ServeHTTP,
+	// which signs each reply body with it; see SignEscalationReply. When it
+	// is empty ServeHTTP rejects every reply.
+	Secret []byte
+
+	mu      sync.Mutex
+	pending map[string]chan ApprovalDecision
+}
+
+// DecideApproval posts the request and blocks until it is resolved, the
+// timeout elapses, or ctx ends.
+func (e *HTTPEscalator) DecideApproval(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+	record := ApprovalAudit{Request: req, RequestedAt: time.Now()}
+	decision, err := e.escalate(ctx, req, &record)
+	record.Decision = decision
+	record.Err = err
+	record.DecidedAt = time.Now()
+	if e.Audit != nil {
+		e.Audit(record)
+	}
+	return decision, err
+}
+
+func (e *HTTPEscalator) escalate(ctx context.Context, req ApprovalRequest, record *ApprovalAudit) (ApprovalDecision, error) {
+	if e.URL == "" {
+		return "", errors.New("escalation url is empty")
+	}
+	id, err := newEscalationID()
+	if err != nil {
+		return "", err
+	}
+	record.EscalationID = id
+
+	ch := make(chan ApprovalDecision, 1)
+	e.mu.Lock()
+	if e.pending == nil {
+		e.pending = make(map[string]chan ApprovalDecision)
+	}
+	e.pending[id] = ch
+	e.mu.Unlock()
+	defer func() {
+		e.mu.Lock()
+		delete(e.pending, id)
+		e.mu.Unlock()
+	}()
+
+	if err := e.post(ctx, id, req); err != nil {
+		return "", err
+	}
+
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = defaultEscalationTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case decision := <-ch:
+		return decision, nil
+	case <-timer.C:
+		record.TimedOut = true
+		if e.TimeoutDecision != "" {
+			return e.TimeoutDecision, nil
+		}
+		return ApprovalDecline, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (e *HTTPEscalator) post(ctx context.Context, id string, req ApprovalRequest) error {
+	var payload any = EscalationMessage{ID: id, Request: req}
+	if e.Payload != nil {
+		payload = e.Payload(id, req)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode escalation: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range e.Header {
+		for _, value := range values {
+			httpReq.Header.Add(key, value)
+		}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("post escalation: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("post escalation: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Resolve delivers a decision for a pending escalation.
+func (e *HTTPEscalator) Resolve(id string, decision ApprovalDecision) error {
+	e.mu.Lock()
+	ch := e.pending[id]
+	delete(e.pending, id)
+	e.mu.Unlock()
+	if ch == nil {
+		return fmt.Errorf("no pending escalation %q", id)
+	}
+	ch <- decision
+	return nil
+}
+
+// ServeHTTP accepts an EscalationReply via POST and resolves the matching
+// escalation. Replies without a valid EscalationSignatureHeader receive 401
+// and unknown ids 404.
+func (e *HTTPEscalator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "invalid reply", http.StatusBadRequest)
+		return
+	}
+	if !e.validSignature(body, r.Header.Get(EscalationSignatureHeader)) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	var reply EscalationReply
+	if err := json.Unmarshal(body, &reply); err != nil {
+		http.Error(w, "invalid reply", http.StatusBadRequest)
+		return
+	}
+	if !validDecision(reply.Decision) {
+		http.Error(w, "invalid decision", http.StatusBadRequest)
+		return
+	}
+	if err := e.Resolve(reply.ID, reply.Decision); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *HTTPEscalator) validSignature(body []byte, signature string) bool {
+	if len(e.Secret) == 0 || signature == "" {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(SignEscalationReply(e.Secret, body)))
+}
+
+// SignEscalationReply returns the EscalationSignatureHeader value for a
+// reply body: "sha256=" followed by the hex HMAC-SHA256 of body keyed with
+// secret.
+func SignEscalationReply(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SlackPayload formats an escalation as a Slack incoming-webhook message.
+func SlackPayload(id string, req ApprovalRequest) any {
+	var text strings.Builder
+	fmt.Fprintf(&text, "Codex approval requested (%s)\n", req.Kind)
+	if req.Command != "" {
+		fmt.Fprintf(&text, "Command: `%s`\n", req.Command)
+	}
+	if req.Cwd != "" {
+		fmt.Fprintf(&text, "Cwd: %s\n", req.Cwd)
+	}
+	if len(req.Paths) > 0 {
+		fmt.Fprintf(&text, "Paths: %s\n", strings.Join(req.Paths, ", "))
+	}
+	if req.Reason != "" {
+		fmt.Fprintf(&text, "Reason: %s\n", req.Reason)
+	}
+	fmt.Fprintf(&text, "Thread: %s\nEscalation: %s", req.ThreadID, id)
+	return map[string]string{"text": text.String()}
+}
+
+func newEscalationID() (string, error) {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf[:]), nil
+}
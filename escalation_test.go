@@ -0,0 +1,157 @@
+package codex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPEscalatorWaitsForCallback(t *testing.T) {
+	secret := []byte("shared secret")
+	escalator := &HTTPEscalator{Header: http.Header{"Authorization": []string{"Bearer token"}}, Secret: secret}
+	callback := httptest.NewServer(escalator)
+	defer callback.Close()
+
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("missing auth header")
+		}
+		var msg EscalationMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("decode escalation: %v", err)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		go func() {
+			body, _ := json.Marshal(EscalationReply{ID: msg.ID, Decision: ApprovalAccept})
+			req, _ := http.NewRequest(http.MethodPost, callback.URL, bytes.NewReader(body))
+			req.Header.Set(EscalationSignatureHeader, SignEscalationReply(secret, body))
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Errorf("post reply: %v", err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusNoContent {
+				t.Errorf("unexpected reply status: %s", resp.Status)
+			}
+		}()
+	}))
+	defer external.Close()
+	escalator.URL = external.URL
+
+	audits := make(chan ApprovalAudit, 1)
+	escalator.Audit = func(record ApprovalAudit) { audits <- record }
+
+	decision, err := escalator.DecideApproval(context.Background(), ApprovalRequest{Kind: ApprovalKindCommand, Command: "ls"})
+	if err != nil {
+		t.Fatalf("escalate error: %v", err)
+	}
+	if decision != ApprovalAccept {
+		t.Fatalf("unexpected decision: %s", decision)
+	}
+	record := <-audits
+	if record.EscalationID == "" || record.Decision != ApprovalAccept || record.TimedOut || record.Request.Command != "ls" {
+		t.Fatalf("unexpected audit record: %#v", record)
+	}
+}
+
+func TestHTTPEscalatorTimeout(t *testing.T) {
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer external.Close()
+
+	var record ApprovalAudit
+	escalator := &HTTPEscalator{
+		URL:             external.URL,
+		Timeout:         10 * time.Millisecond,
+		TimeoutDecision: ApprovalCancel,
+		Audit:           func(r ApprovalAudit) { record = r },
+	}
+	decision, err := escalator.DecideApproval(context.Background(), ApprovalRequest{})
+	if err != nil || decision != ApprovalCancel {
+		t.Fatalf("expected cancel on timeout, got %s err=%v", decision, err)
+	}
+	if !record.TimedOut {
+		t.Fatalf("expected timed out audit record")
+	}
+}
+
+func TestHTTPEscalatorPostFailure(t *testing.T) {
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer external.Close()
+
+	var record ApprovalAudit
+	escalator := &HTTPEscalator{URL: external.URL, Audit: func(r ApprovalAudit) { record = r }}
+	if _, err := escalator.DecideApproval(context.Background(), ApprovalRequest{}); err == nil {
+		t.Fatalf("expected post failure")
+	}
+	if record.Err == nil {
+		t.Fatalf("expected audit error")
+	}
+	if _, err := (&HTTPEscalator{}).DecideApproval(context.Background(), ApprovalRequest{}); err == nil {
+		t.Fatalf("expected empty url error")
+	}
+}
+
+func TestHTTPEscalatorContextCancel(t *testing.T) {
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer external.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	escalator := &HTTPEscalator{URL: external.URL}
+	if _, err := escalator.DecideApproval(ctx, ApprovalRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestHTTPEscalatorServeHTTPRejectsBadReplies(t *testing.T) {
+	secret := []byte("shared secret")
+	sign := func(body string) string { return SignEscalationReply(secret, []byte(body)) }
+	tests := []struct {
+		name      string
+		secret    []byte
+		method    string
+		body      string
+		signature string
+		want      int
+	}{
+		{name: "wrong method", secret: secret, method: http.MethodGet, want: http.StatusMethodNotAllowed},
+		{name: "unsigned", secret: secret, method: http.MethodPost, body: `{"id":"x","decision":"accept"}`, want: http.StatusUnauthorized},
+		{name: "wrong signature", secret: secret, method: http.MethodPost, body: `{"id":"x","decision":"accept"}`, signature: SignEscalationReply([]byte("guess"), []byte(`{"id":"x","decision":"accept"}`)), want: http.StatusUnauthorized},
+		{name: "no secret", method: http.MethodPost, body: `{"id":"x","decision":"accept"}`, signature: SignEscalationReply(nil, []byte(`{"id":"x","decision":"accept"}`)), want: http.StatusUnauthorized},
+		{name: "bad json", secret: secret, method: http.MethodPost, body: "{bad", signature: sign("{bad"), want: http.StatusBadRequest},
+		{name: "bad decision", secret: secret, method: http.MethodPost, body: `{"id":"x","decision":"maybe"}`, signature: sign(`{"id":"x","decision":"maybe"}`), want: http.StatusBadRequest},
+		{name: "unknown id", secret: secret, method: http.MethodPost, body: `{"id":"x","decision":"accept"}`, signature: sign(`{"id":"x","decision":"accept"}`), want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			escalator := &HTTPEscalator{Secret: tt.secret}
+			req := httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body))
+			if tt.signature != "" {
+				req.Header.Set(EscalationSignatureHeader, tt.signature)
+			}
+			rec := httptest.NewRecorder()
+			escalator.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
+
+func TestSlackPayload(t *testing.T) {
+	payload := SlackPayload("esc_1", ApprovalRequest{Kind: ApprovalKindCommand, Command: "make", ThreadID: "thr"})
+	text := payload.(map[string]string)["text"]
+	if !strings.Contains(text, "`make`") || !strings.Contains(text, "esc_1") {
+		t.Fatalf("unexpected slack text: %q", text)
+	}
+}
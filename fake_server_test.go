@@ -0,0 +1,125 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+// fakeHandler answers one request. A nil result is sent as {}.
+type fakeHandler func(params json.RawMessage) (any, *rpc.JSONRPCErrorError)
+
+// fakeAppServer is an in-process app-server that answers each method with
+// the handler registered for it, and every other method with {}.
+type fakeAppServer struct {
+	server   *rpc.ConnTransport
+	handlers map[string]fakeHandler
+	// requests receives every request, when there is room.
+	requests chan rpc.JSONRPCRequest
+	// replies receives the client's answers to server requests.
+	replies chan json.RawMessage
+}
+
+// newFakeAppServer starts a fake app-server and a Codex connected to it,
+// both closed when the test ends.
+func newFakeAppServer(t *testing.T, handlers map[string]fakeHandler) (*fakeAppServer, *Codex) {
+	t.Helper()
+	return newFakeAppServerOptions(t, handlers, Options{})
+}
+
+// newFakeAppServerOptions is newFakeAppServer with options for New; the
+// transport is set by the helper.
+func newFakeAppServerOptions(t *testing.T, handlers map[string]fakeHandler, opts Options) (*fakeAppServer, *Codex) {
+	t.Helper()
+	client, server := rpc.NewPipeTransports()
+	s := &fakeAppServer{
+		server:   server,
+		handlers: handlers,
+		requests: make(chan rpc.JSONRPCRequest, 32),
+		replies:  make(chan json.RawMessage, 8),
+	}
+	go s.serve()
+	opts.Transport = client
+	codex, err := New(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = codex.Close()
+		_ = server.Close()
+	})
+	return s, codex
+}
+
+func (s *fakeAppServer) serve() {
+	for {
+		line, err := s.server.ReadLine()
+		if err != nil {
+			return
+		}
+		var request rpc.JSONRPCRequest
+		if err := json.Unmarshal([]byte(line), &request); err != nil || request.ID.IsZero() {
+			continue
+		}
+		if request.Method == "" {
+			s.replies <- json.RawMessage(line)
+			continue
+		}
+		select {
+		case s.requests <- request:
+		default:
+		}
+		var (
+			result any
+			rpcErr *rpc.JSONRPCErrorError
+		)
+		if handle, ok := s.handlers[request.Method]; ok {
+			result, rpcErr = handle(request.Params)
+		}
+		if rpcErr != nil {
+			_ = s.server.WriteLine(string(mustRaw(rpc.JSONRPCError{ID: request.ID, Error: *rpcErr})))
+			continue
+		}
+		if result == nil {
+			result = map[string]any{}
+		}
+		_ = s.server.WriteLine(string(mustRaw(rpc.JSONRPCResponse{ID: request.ID, Result: mustRaw(result)})))
+	}
+}
+
+// notify sends a notification to the client.
+func (s *fakeAppServer) notify(method string, params any) {
+	_ = s.server.WriteLine(string(mustRaw(rpc.JSONRPCNotification{Method: method, Params: mustRaw(params)})))
+}
+
+// ask sends a server request to the client and returns its reply.
+func (s *fakeAppServer) ask(t *testing.T, method string, params any) json.RawMessage {
+	t.Helper()
+	id := rpc.NewStringRequestID("server-" + method)
+	_ = s.server.WriteLine(string(mustRaw(rpc.JSONRPCRequest{ID: id, Method: method, Params: mustRaw(params)})))
+	select {
+	case reply := <-s.replies:
+		return reply
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no reply to %s", method)
+		return nil
+	}
+}
+
+// request returns the next request for method, skipping others.
+func (s *fakeAppServer) request(t *testing.T, method string) rpc.JSONRPCRequest {
+	t.Helper()
+	for {
+		select {
+		case request := <-s.requests:
+			if request.Method == method {
+				return request
+			}
+		default:
+			t.Fatalf("no %s request was sent", method)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package codex
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+// GitInfo is the git state the app-server recorded for a thread's working
+// directory. Fields are empty when the directory is not a git repository or
+// the value is unknown.
+type GitInfo struct {
+	Branch string
+	// Sha is the commit HEAD pointed at.
+	Sha       string
+	OriginURL string
+}
+
+// GitInfo reads the git metadata the app-server stored for the thread, so
+// callers see the branch and commit the agent worked against rather than
+// the state of the directory now. It returns nil when none is recorded.
+func (t *Thread) GitInfo(ctx context.Context) (*GitInfo, error) {
+	if err := t.ensureReady(); err != nil {
+		return nil, err
+	}
+	var response struct {
+		Thread struct {
+			GitInfo *protocol.ThreadGitInfo `json:"gitInfo"`
+		} `json:"thread"`
+	}
+	if err := t.call(ctx, "thread/read", protocol.ThreadReadParams{ThreadID: t.id}, &response); err != nil {
+		return nil, err
+	}
+	return gitInfo(response.Thread.GitInfo), nil
+}
+
+func gitInfo(info *protocol.ThreadGitInfo) *GitInfo {
+	if info == nil {
+		return nil
+	}
+	var out GitInfo
+	if info.Branch != nil {
+		out.Branch = *info.Branch
+	}
+	if info.Sha != nil {
+		out.Sha = *info.Sha
+	}
+	if info.OriginURL != nil {
+		out.OriginURL = *info.OriginURL
+	}
+	return &out
+}
+
+// Dirty reports whether the turn changed any files, according to the diff
+// the app-server computed. The app-server does not report working-tree
+// status, so changes made outside the turn are not seen.
+func (r *TurnResult) Dirty() bool {
+	return r != nil && r.Diff != ""
+}
+
+// ChangedFiles returns the paths touched by the turn's Diff, in diff order.
+// A renamed file is listed under its new path.
+func (r *TurnResult) ChangedFiles() []string {
+	if r == nil {
+		return nil
+	}
+	var paths []string
+	for line := range strings.Lines(r.Diff) {
+		rest, ok := strings.CutPrefix(strings.TrimRight(line, "\r\n"), "diff --git ")
+		if !ok {
+			continue
+		}
+		// The header is "a/<old> b/<new>"; paths with spaces are ambiguous,
+		// so take the last " b/" separator.
+		if i := strings.LastIndex(rest, " b/"); i >= 0 {
+			paths = append(paths, rest[i+len(" b/"):])
+		}
+	}
+	return paths
+}
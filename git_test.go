@@ -0,0 +1,113 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestThreadGitInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		gitInfo any
+		want    *GitInfo
+	}{
+		{
+			name:    "recorded",
+			gitInfo: map[string]any{"branch": "main", "sha": "abc123", "originUrl": "git@example.com:repo.git"},
+			want:    &GitInfo{Branch: "main", Sha: "abc123", OriginURL: "git@example.com:repo.git"},
+		},
+		{name: "not a repository", gitInfo: nil, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, codex := newFakeAppServer(t, map[string]fakeHandler{
+				"thread/start": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+					return map[string]any{"thread": map[string]any{"id": "thr_1"}}, nil
+				},
+				"thread/read": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+					return map[string]any{"thread": map[string]any{"id": "thr_1", "gitInfo": tt.gitInfo}}, nil
+				},
+			})
+			ctx := context.Background()
+			thread, err := codex.StartThread(ctx, ThreadStartOptions{})
+			if err != nil {
+				t.Fatalf("StartThread: %v", err)
+			}
+			info, err := thread.GitInfo(ctx)
+			if err != nil {
+				t.Fatalf("GitInfo: %v", err)
+			}
+			if !reflect.DeepEqual(info, tt.want) {
+				t.Fatalf("GitInfo = %+v, want %+v", info, tt.want)
+			}
+			if got := string(server.request(t, "thread/read").Params); got != `{"threadId":"thr_1"}` {
+				t.Fatalf("thread/read params = %s", got)
+			}
+		})
+	}
+}
+
+func TestRunRecordsTurnDiff(t *testing.T) {
+	const diff = "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-old\n+new\n"
+	var server *fakeAppServer
+	server, codex := newFakeAppServer(t, map[string]fakeHandler{
+		"thread/start": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			return map[string]any{"thread": map[string]any{"id": "thr_1"}}, nil
+		},
+		"turn/start": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			server.notify("turn/started", map[string]any{"threadId": "thr_1", "turn": map[string]any{"id": "turn_1"}})
+			server.notify("turn/diff/updated", map[string]any{"threadId": "thr_1", "turnId": "turn_1", "diff": "partial"})
+			server.notify("turn/diff/updated", map[string]any{"threadId": "thr_1", "turnId": "turn_1", "diff": diff})
+			server.notify("turn/completed", map[string]any{"threadId": "thr_1", "turn": map[string]any{"id": "turn_1", "status": "completed"}})
+			return map[string]any{"turn": map[string]any{"id": "turn_1"}}, nil
+		},
+	})
+	ctx := context.Background()
+	thread, err := codex.StartThread(ctx, ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("StartThread: %v", err)
+	}
+	result, err := thread.Run(ctx, "edit main.go", nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Diff != diff {
+		t.Fatalf("Diff = %q, want the last update", result.Diff)
+	}
+	if !result.Dirty() {
+		t.Fatalf("Dirty = false for a turn with changes")
+	}
+	if got := result.ChangedFiles(); !reflect.DeepEqual(got, []string{"main.go"}) {
+		t.Fatalf("ChangedFiles = %q", got)
+	}
+}
+
+func TestTurnResultChangedFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+		want []string
+	}{
+		{name: "empty", diff: "", want: nil},
+		{
+			name: "several files",
+			diff: "diff --git a/a.go b/a.go\n+x\ndiff --git a/old name.txt b/new name.txt\nrename from old name.txt\ndiff --git a/dir/c.go b/dir/c.go\r\n",
+			want: []string{"a.go", "new name.txt", "dir/c.go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &TurnResult{Diff: tt.diff}
+			if got := result.ChangedFiles(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ChangedFiles = %q, want %q", got, tt.want)
+			}
+			if got, want := result.Dirty(), tt.diff != ""; got != want {
+				t.Fatalf("Dirty = %v, want %v", got, want)
+			}
+		})
+	}
+}
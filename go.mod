@@ -2,7 +2,10 @@ module github.com/pmenglund/codex-sdk-go
 
 go 1.25
 
-require github.com/atombender/go-jsonschema v0.20.0
+require (
+	github.com/atombender/go-jsonschema v0.20.0
+	golang.org/x/sys v0.37.0
+)
 
 require (
 	dario.cat/mergo v1.0.2 // indirect
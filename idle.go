@@ -0,0 +1,109 @@
+package codex
+
+import (
+	"context"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+// idleState tracks facade activity for Options.IdleShutdown.
+type idleState struct {
+	timeout time.Duration
+	lastUse time.Time
+	inUse   int
+	// stopped is the client shut down for idleness, until the next
+	// operation replaces it; woken is closed at that point.
+	stopped *rpc.Client
+	woken   chan struct{}
+}
+
+// acquireClient returns the client for a facade operation, respawning the
+// app-server first when it was stopped for idleness. The returned release
+// function must be called once the operation no longer needs the client.
+func (c *Codex) acquireClient(ctx context.Context) (*rpc.Client, func(), error) {
+	if c.idle == nil {
+		return c.currentClient(), func() {}, nil
+	}
+	c.mu.Lock()
+	c.idle.inUse++
+	client, stopped := c.client, c.idle.stopped
+	c.mu.Unlock()
+	release := func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.idle.inUse--
+		c.idle.lastUse = time.Now()
+	}
+	if stopped == nil {
+		return client, release, nil
+	}
+
+	c.logger.Info("codex respawning idle app-server")
+	next, err := c.reconnect(ctx, stopped, 1)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	c.mu.Lock()
+	if c.idle.stopped == stopped {
+		c.idle.stopped = nil
+		close(c.idle.woken)
+	}
+	c.mu.Unlock()
+	return next, release, nil
+}
+
+// watchIdle stops the app-server whenever it has been idle for the
+// configured timeout, until the client is closed.
+func (c *Codex) watchIdle() {
+	timer := time.NewTimer(c.idle.timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+		case <-c.lifecycle.Done():
+			return
+		}
+		timer.Reset(c.stopIfIdle())
+	}
+}
+
+// stopIfIdle stops the app-server if it is idle and returns how long to
+// wait before checking again.
+func (c *Codex) stopIfIdle() time.Duration {
+	busy := len(c.turns.snapshot()) > 0
+
+	c.mu.Lock()
+	idle := c.idle
+	if c.closed || idle.stopped != nil || idle.inUse > 0 || busy {
+		c.mu.Unlock()
+		return idle.timeout
+	}
+	if wait := idle.timeout - time.Since(idle.lastUse); wait > 0 {
+		c.mu.Unlock()
+		return wait
+	}
+	client := c.client
+	idle.stopped = client
+	idle.woken = make(chan struct{})
+	c.mu.Unlock()
+
+	c.logger.Info("codex stopping idle app-server", "idle", idle.timeout)
+	_ = client.RequestShutdown(context.Background())
+	if err := client.Close(); err != nil {
+		c.logger.Warn("codex idle app-server did not stop cleanly", "error", err)
+	}
+	return idle.timeout
+}
+
+// stoppedForIdle reports whether client was shut down for idleness and,
+// if so, returns a channel closed once the next operation replaces it.
+func (c *Codex) stoppedForIdle(client *rpc.Client) (<-chan struct{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.idle == nil || c.idle.stopped != client {
+		return nil, false
+	}
+	return c.idle.woken, true
+}
@@ -0,0 +1,96 @@
+package codex
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestIdleShutdownRespawnsOnNextOperation(t *testing.T) {
+	_, transport := newPoolServer("i1")
+	var redials atomic.Int64
+	respawned := make(chan *poolServer, 1)
+	client, err := New(context.Background(), Options{
+		Transport:    transport,
+		IdleShutdown: 20 * time.Millisecond,
+		Redial: func(context.Context) (rpc.Transport, error) {
+			server, transport := newPoolServer(fmt.Sprintf("i%d", redials.Add(1)+1))
+			respawned <- server
+			return transport, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	if _, err := client.StartThread(ctx, ThreadStartOptions{}); err != nil {
+		t.Fatalf("StartThread: %v", err)
+	}
+	waitForCondition(t, func() bool { return client.Client().Err() != nil })
+	select {
+	case <-client.Done():
+		t.Fatalf("Done closed after an idle shutdown")
+	default:
+	}
+	if err := client.Healthy(ctx); err != nil {
+		t.Fatalf("Healthy while idle: %v", err)
+	}
+	if redials.Load() != 0 {
+		t.Fatalf("respawned without an operation")
+	}
+
+	thread, err := client.StartThread(ctx, ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("StartThread after idle shutdown: %v", err)
+	}
+	if thread.ID() != "i2-1" {
+		t.Fatalf("thread id = %q, want one from the respawned server", thread.ID())
+	}
+	server := <-respawned
+	var methods []string
+	for len(methods) < 3 {
+		methods = append(methods, <-server.methods)
+	}
+	if methods[0] != "initialize" || methods[1] != "thread/resume" || methods[2] != "thread/start" {
+		t.Fatalf("respawned server saw %q, want initialize, thread/resume, thread/start", methods)
+	}
+}
+
+func TestIdleShutdownWaitsForRunningTurns(t *testing.T) {
+	_, transport := newPoolServer("i1")
+	client, err := New(context.Background(), Options{
+		Transport:    transport,
+		IdleShutdown: time.Hour,
+		Redial:       func(context.Context) (rpc.Transport, error) { return nil, fmt.Errorf("unexpected redial") },
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer client.Close()
+
+	client.mu.Lock()
+	client.idle.lastUse = time.Now().Add(-2 * time.Hour)
+	client.mu.Unlock()
+	turn := client.turns.add("thr_1", "turn_1")
+	if wait := client.stopIfIdle(); wait != time.Hour || client.Client().Err() != nil {
+		t.Fatalf("stopped with a running turn")
+	}
+	client.turns.remove(turn)
+	client.stopIfIdle()
+	if client.Client().Err() == nil {
+		t.Fatalf("did not stop once the turn finished")
+	}
+}
+
+func TestIdleShutdownRequiresRedialForCustomTransport(t *testing.T) {
+	_, transport := newPoolServer("i1")
+	if _, err := New(context.Background(), Options{Transport: transport, IdleShutdown: time.Minute}); err == nil {
+		t.Fatalf("New accepted IdleShutdown without Redial")
+	}
+}
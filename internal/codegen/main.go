@@ -230,6 +230,10 @@ func generateRPCStubs(schemaDir, repoRoot, codexCommit string) error {
 		return err
 	}
 
+	if err := writeFile(outDir, "notification_handlers_gen.go", renderNotificationHandlers(notifications, codexCommit)); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -332,12 +336,25 @@ func writeFallbackTypes(outDir string, titles []string, generated map[string]str
 	return os.WriteFile(fallbackPath, []byte(b.String()), 0o644)
 }
 
+// sanitizedFallbackTarget finds the sanitized type generated for name. The
+// generator spells initialisms in upper case (MCPServer for McpServer), so a
+// case-insensitive match is accepted when it is unique.
 func sanitizedFallbackTarget(name string, generated map[string]struct{}) (string, bool) {
 	target := "Sanitized" + name + "JSON"
 	if _, ok := generated[target]; ok {
 		return target, true
 	}
-	return "", false
+	var match string
+	for candidate := range generated {
+		if !strings.EqualFold(candidate, target) {
+			continue
+		}
+		if match != "" {
+			return "", false
+		}
+		match = candidate
+	}
+	return match, match != ""
 }
 
 func collectGeneratedTypes(sources map[string][]byte) map[string]struct{} {
@@ -706,6 +723,28 @@ func renderNotifications(notifications []rpcNotification, codexCommit string) []
 	return []byte(b.String())
 }
 
+func renderNotificationHandlers(notifications []rpcNotification, codexCommit string) []byte {
+	var b strings.Builder
+	b.WriteString(generatedHeader(codexCommit))
+	b.WriteString("package rpc\n\n")
+	b.WriteString("import \"github.com/pmenglund/codex-sdk-go/protocol\"\n")
+
+	for _, notification := range notifications {
+		name := methodName(notification.Method)
+		b.WriteString(fmt.Sprintf("\n// On%s registers fn for %q notifications.\n", name, notification.Method))
+		if notification.ParamsType == "" {
+			b.WriteString(fmt.Sprintf("func (d *NotificationDispatcher) On%s(fn func()) {\n", name))
+			b.WriteString(fmt.Sprintf("\td.On(%q, func(Notification) { fn() })\n}\n", notification.Method))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("func (d *NotificationDispatcher) On%s(fn func(protocol.%s)) {\n", name, notification.ParamsType))
+		b.WriteString(fmt.Sprintf("\td.On(%q, func(note Notification) {\n", notification.Method))
+		b.WriteString(fmt.Sprintf("\t\tif params, ok := note.Params.(protocol.%s); ok {\n\t\t\tfn(params)\n\t\t}\n\t})\n}\n", notification.ParamsType))
+	}
+
+	return []byte(b.String())
+}
+
 func paramsType(name string) string {
 	if name == "" {
 		return "struct{}"
@@ -715,24 +754,39 @@ func paramsType(name string) string {
 
 func manualProtocolTypes() map[string]struct{} {
 	return map[string]struct{}{
-		"ApplyPatchApprovalParams":                {},
-		"ApplyPatchApprovalResponse":              {},
-		"CommandExecutionRequestApprovalParams":   {},
-		"CommandExecutionRequestApprovalResponse": {},
-		"ErrorNotification":                       {},
-		"ExecCommandApprovalParams":               {},
-		"ExecCommandApprovalResponse":             {},
-		"FileChangeRequestApprovalParams":         {},
-		"FileChangeRequestApprovalResponse":       {},
-		"ItemCompletedNotification":               {},
-		"PermissionsRequestApprovalParams":        {},
-		"PermissionsRequestApprovalResponse":      {},
-		"ThreadResumeResponse":                    {},
-		"ThreadStartResponse":                     {},
-		"ToolRequestUserInputParams":              {},
-		"ToolRequestUserInputResponse":            {},
-		"TurnCompletedNotification":               {},
-		"TurnStartedNotification":                 {},
+		"ApplyPatchApprovalParams":                        {},
+		"ApplyPatchApprovalResponse":                      {},
+		"CommandExecOutputDeltaNotification":              {},
+		"CommandExecutionRequestApprovalParams":           {},
+		"CommandExecutionRequestApprovalResponse":         {},
+		"ErrorNotification":                               {},
+		"ExecCommandApprovalParams":                       {},
+		"ExecCommandApprovalResponse":                     {},
+		"ExternalAgentConfigImportCompletedNotification":  {},
+		"FileChangeRequestApprovalParams":                 {},
+		"FileChangeRequestApprovalResponse":               {},
+		"FsChangedNotification":                           {},
+		"HookCompletedNotification":                       {},
+		"HookStartedNotification":                         {},
+		"ItemCompletedNotification":                       {},
+		"ItemGuardianApprovalReviewCompletedNotification": {},
+		"ItemGuardianApprovalReviewStartedNotification":   {},
+		"ItemStartedNotification":                         {},
+		"PermissionsRequestApprovalParams":                {},
+		"PermissionsRequestApprovalResponse":              {},
+		"ReviewStartResponse":                             {},
+		"SkillsChangedNotification":                       {},
+		"ThreadArchiveResponse":                           {},
+		"ThreadListResponse":                              {},
+		"ThreadResumeResponse":                            {},
+		"ThreadStartResponse":                             {},
+		"ThreadStartedNotification":                       {},
+		"ThreadUnarchiveResponse":                         {},
+		"ToolRequestUserInputParams":                      {},
+		"ToolRequestUserInputResponse":                    {},
+		"TurnCompletedNotification":                       {},
+		"TurnInterruptResponse":                           {},
+		"TurnStartedNotification":                         {},
 	}
 }
 
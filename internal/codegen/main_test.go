@@ -245,6 +245,35 @@ func TestWriteFallbackTypesAndAliases(t *testing.T) {
 	}
 }
 
+func TestSanitizedFallbackTarget(t *testing.T) {
+	generated := map[string]struct{}{
+		"SanitizedMissingJSON":     {},
+		"SanitizedMCPServerJSON":   {},
+		"SanitizedAmbiguousJSON":   {},
+		"SanitizedAMBIGUOUSJSON":   {},
+		"SanitizedUnrelatedTypeJS": {},
+	}
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{name: "Missing", want: "SanitizedMissingJSON", wantOK: true},
+		{name: "McpServer", want: "SanitizedMCPServerJSON", wantOK: true},
+		{name: "Ambiguous", want: "SanitizedAmbiguousJSON", wantOK: true},
+		{name: "AmBiguous", wantOK: false},
+		{name: "Opaque", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := sanitizedFallbackTarget(tt.name, generated)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("sanitizedFallbackTarget(%q) = %q, %v; want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
 func TestRenderHelpers(t *testing.T) {
 	methods := []rpcMethod{
 		{Method: "foo/bar", ParamsType: "FooParams", ResponseType: "FooResponse"},
@@ -269,6 +298,17 @@ func TestRenderHelpers(t *testing.T) {
 	if !strings.Contains(notes, "turn/started") {
 		t.Fatalf("expected notification method")
 	}
+
+	handlers := string(renderNotificationHandlers([]rpcNotification{
+		{Method: "turn/started", ParamsType: "TurnStartedNotification"},
+		{Method: "thread/compacted"},
+	}, testCodexCommit))
+	if !strings.Contains(handlers, "func (d *NotificationDispatcher) OnTurnStarted(fn func(protocol.TurnStartedNotification))") {
+		t.Fatalf("expected typed handler registration, got:\n%s", handlers)
+	}
+	if !strings.Contains(handlers, "func (d *NotificationDispatcher) OnThreadCompacted(fn func())") {
+		t.Fatalf("expected handler registration without params, got:\n%s", handlers)
+	}
 }
 
 func TestSchemaTitleAndDefinitions(t *testing.T) {
@@ -723,6 +763,9 @@ func TestGenerateRPCStubs(t *testing.T) {
 	if !exists(filepath.Join(root, "rpc", "notifications_gen.go")) {
 		t.Fatalf("expected notifications_gen.go output")
 	}
+	if !exists(filepath.Join(root, "rpc", "notification_handlers_gen.go")) {
+		t.Fatalf("expected notification_handlers_gen.go output")
+	}
 	rpcData, err := os.ReadFile(filepath.Join(root, "rpc", "client_requests_gen.go"))
 	if err != nil {
 		t.Fatalf("read generated rpc file: %v", err)
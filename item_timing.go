@@ -0,0 +1,80 @@
+package codex
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+// ItemTiming records when a thread item started and completed during a turn.
+// StartedAt and CompletedAt are stamped when the item/started and
+// item/completed notifications arrive; the Server* fields are taken from the
+// item's startedAtMs/completedAtMs fields when the app-server provides them.
+type ItemTiming struct {
+	ItemID            string    `json:"itemId"`
+	Type              string    `json:"type,omitempty"`
+	StartedAt         time.Time `json:"startedAt,omitzero"`
+	CompletedAt       time.Time `json:"completedAt,omitzero"`
+	ServerStartedAt   time.Time `json:"serverStartedAt,omitzero"`
+	ServerCompletedAt time.Time `json:"serverCompletedAt,omitzero"`
+}
+
+// Duration returns how long the item ran. Server timestamps are preferred
+// when both are present; zero is returned when the item has not both started
+// and completed.
+func (t ItemTiming) Duration() time.Duration {
+	if !t.ServerStartedAt.IsZero() && !t.ServerCompletedAt.IsZero() {
+		return t.ServerCompletedAt.Sub(t.ServerStartedAt)
+	}
+	if !t.StartedAt.IsZero() && !t.CompletedAt.IsZero() {
+		return t.CompletedAt.Sub(t.StartedAt)
+	}
+	return 0
+}
+
+// recordItemTiming stamps item lifecycle notifications received at at.
+func recordItemTiming(result *TurnResult, note rpc.Notification, at time.Time) {
+	if note.Method != "item/started" && note.Method != "item/completed" {
+		return
+	}
+	payload, err := parseTurnNotification(note)
+	if err != nil || len(payload.Item) == 0 {
+		return
+	}
+	var item struct {
+		ID            string `json:"id"`
+		Type          string `json:"type"`
+		StartedAtMs   *int64 `json:"startedAtMs"`
+		CompletedAtMs *int64 `json:"completedAtMs"`
+	}
+	if err := json.Unmarshal(payload.Item, &item); err != nil || item.ID == "" {
+		return
+	}
+
+	timing := findItemTiming(result, item.ID)
+	if item.Type != "" {
+		timing.Type = item.Type
+	}
+	if note.Method == "item/started" {
+		timing.StartedAt = at
+	} else {
+		timing.CompletedAt = at
+	}
+	if item.StartedAtMs != nil {
+		timing.ServerStartedAt = time.UnixMilli(*item.StartedAtMs)
+	}
+	if item.CompletedAtMs != nil {
+		timing.ServerCompletedAt = time.UnixMilli(*item.CompletedAtMs)
+	}
+}
+
+func findItemTiming(result *TurnResult, itemID string) *ItemTiming {
+	for i := len(result.ItemTimings) - 1; i >= 0; i-- {
+		if result.ItemTimings[i].ItemID == itemID {
+			return &result.ItemTimings[i]
+		}
+	}
+	result.ItemTimings = append(result.ItemTimings, ItemTiming{ItemID: itemID})
+	return &result.ItemTimings[len(result.ItemTimings)-1]
+}
@@ -0,0 +1,78 @@
+package codex
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestRecordItemTiming(t *testing.T) {
+	start := time.Unix(100, 0)
+	result := &TurnResult{}
+	notes := []struct {
+		note rpc.Notification
+		at   time.Time
+	}{
+		{itemNote("item/started", map[string]any{"id": "a", "type": "commandExecution"}), start},
+		{itemNote("item/started", map[string]any{"id": "b", "type": "agentMessage", "startedAtMs": 5000}), start.Add(time.Second)},
+		{itemNote("item/completed", map[string]any{"id": "a", "type": "commandExecution"}), start.Add(3 * time.Second)},
+		{itemNote("item/completed", map[string]any{"id": "b", "startedAtMs": 5000, "completedAtMs": 5250}), start.Add(4 * time.Second)},
+		{itemNote("item/completed", map[string]any{"type": "reasoning"}), start},
+		{rpc.Notification{Method: "turn/started", Raw: MustJSON(map[string]any{"turn": turnPayload("turn_1", "inProgress")})}, start},
+	}
+	for _, n := range notes {
+		recordItemTiming(result, n.note, n.at)
+	}
+
+	if len(result.ItemTimings) != 2 {
+		t.Fatalf("expected 2 timings, got %#v", result.ItemTimings)
+	}
+	a, b := result.ItemTimings[0], result.ItemTimings[1]
+	assertEqual(t, "a type", a.Type, "commandExecution")
+	assertEqual(t, "a duration", a.Duration(), 3*time.Second)
+	assertEqual(t, "b type", b.Type, "agentMessage")
+	assertEqual(t, "b server start", b.ServerStartedAt, time.UnixMilli(5000))
+	assertEqual(t, "b duration", b.Duration(), 250*time.Millisecond)
+	assertEqual(t, "incomplete duration", ItemTiming{StartedAt: start}.Duration(), time.Duration(0))
+}
+
+func TestThreadRunRecordsItemTimings(t *testing.T) {
+	entries := repairTranscript([]repairTurn{{prompt: "hi", response: "done"}})
+	// Announce the item before its completion notification.
+	started := readLine(rpc.JSONRPCNotification{
+		Method: "item/started",
+		Params: mustRaw(map[string]any{"threadId": "thr_123", "item": map[string]any{"id": "msg_1", "type": "agentMessage"}}),
+	})
+	completed := readLine(rpc.JSONRPCNotification{
+		Method: "item/completed",
+		Params: mustRaw(protocol.ItemCompletedNotification{ThreadID: "thr_123", Item: mustRaw(map[string]any{"id": "msg_1", "type": "agentMessage", "text": "done"})}),
+	})
+	last := len(entries) - 2
+	entries = append(entries[:last], started, completed, entries[last+1])
+
+	client, err := New(context.Background(), Options{Transport: rpc.NewReplayTransport(entries)})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	defer client.Close()
+	thread := &Thread{client: client.Client(), id: "thr_123"}
+
+	result, err := thread.Run(context.Background(), "hi", nil)
+	if err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	if len(result.ItemTimings) != 1 {
+		t.Fatalf("expected one item timing, got %#v", result.ItemTimings)
+	}
+	timing := result.ItemTimings[0]
+	if timing.ItemID != "msg_1" || timing.StartedAt.IsZero() || timing.CompletedAt.Before(timing.StartedAt) {
+		t.Fatalf("unexpected timing: %#v", timing)
+	}
+}
+
+func itemNote(method string, item map[string]any) rpc.Notification {
+	return rpc.Notification{Method: method, Raw: MustJSON(map[string]any{"threadId": "thr_1", "item": item})}
+}
@@ -0,0 +1,399 @@
+package codex
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLeaseTTL           = 30 * time.Second
+	defaultLeaseRetryInterval = time.Second
+)
+
+var (
+	// ErrLeaseHeld is returned by LeaseStore.Acquire while another owner
+	// holds an unexpired lease on the thread.
+	ErrLeaseHeld = errors.New("thread lease is held by another owner")
+	// ErrLeaseLost reports that a lease expired and was taken over, or was
+	// otherwise removed from the store.
+	ErrLeaseLost = errors.New("thread lease lost")
+	// ErrLeaseRequired is returned when a turn is started on a thread that
+	// does not hold a lease while Options.Leases is configured.
+	ErrLeaseRequired = errors.New("thread lease required to start a turn")
+)
+
+// LeaseStore records which owner may drive each thread. It is shared by all
+// processes that resume the same persisted threads, so implementations must
+// be safe for concurrent use across processes. Tokens are opaque strings
+// unique to each Lease.
+type LeaseStore interface {
+	// Acquire makes token the owner of threadID for ttl. It returns
+	// ErrLeaseHeld when another token holds an unexpired lease. Expired
+	// leases may be taken over.
+	Acquire(ctx context.Context, threadID, token string, ttl time.Duration) error
+	// Renew extends token's lease by ttl, or returns ErrLeaseLost when token
+	// no longer owns threadID.
+	Renew(ctx context.Context, threadID, token string, ttl time.Duration) error
+	// Release gives up token's lease. Releasing a lease that was already
+	// lost is not an error.
+	Release(ctx context.Context, threadID, token string) error
+}
+
+// LeaseOptions enables thread leases. Once set, turns can only be started
+// on a Thread that holds a lease from Thread.Acquire, and every turn/start
+// first renews the lease, fencing out an owner whose lease was taken over.
+type LeaseOptions struct {
+	// Store coordinates leases between processes. Required.
+	Store LeaseStore
+	// TTL is how long a lease survives without renewal (defaults to 30s).
+	// A held lease is renewed every TTL/3 until it is released.
+	TTL time.Duration
+	// RetryInterval is how often Acquire retries a held lease (defaults to 1s).
+	RetryInterval time.Duration
+}
+
+func (o *LeaseOptions) normalized() *LeaseOptions {
+	if o == nil {
+		return nil
+	}
+	out := *o
+	if out.TTL <= 0 {
+		out.TTL = defaultLeaseTTL
+	}
+	if out.RetryInterval <= 0 {
+		out.RetryInterval = defaultLeaseRetryInterval
+	}
+	return &out
+}
+
+// Lease is exclusive ownership of a thread. It is renewed in the background
+// until Release is called or renewal reports the lease lost.
+type Lease struct {
+	thread *Thread
+	token  string
+	store  LeaseStore
+	ttl    time.Duration
+
+	mu       sync.Mutex
+	err      error
+	released bool
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// Acquire waits until this process owns the thread, or ctx ends. Turns can
+// be started on the thread until the returned lease is released or lost.
+func (t *Thread) Acquire(ctx context.Context) (*Lease, error) {
+	if err := t.ensureReady(); err != nil {
+		return nil, err
+	}
+	if t.owner == nil || t.owner.leases == nil {
+		return nil, errors.New("thread leases are not configured")
+	}
+	opts := t.owner.leases
+	token, err := newLeaseToken()
+	if err != nil {
+		return nil, err
+	}
+	logger := resolveLogger(t.logger)
+	for {
+		err := opts.Store.Acquire(ctx, t.id, token, opts.TTL)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrLeaseHeld) {
+			return nil, fmt.Errorf("acquire thread lease: %w", err)
+		}
+		logger.Debug("codex thread lease held, waiting", "thread_id", t.id)
+		timer := time.NewTimer(opts.RetryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Join(ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+
+	lease := &Lease{
+		thread:  t,
+		token:   token,
+		store:   opts.Store,
+		ttl:     opts.TTL,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go lease.keepAlive()
+	t.leaseMu.Lock()
+	t.lease = lease
+	t.leaseMu.Unlock()
+	logger.Info("codex thread lease acquired", "thread_id", t.id)
+	return lease, nil
+}
+
+// ThreadID returns the leased thread's id.
+func (l *Lease) ThreadID() string {
+	return l.thread.id
+}
+
+// Token returns the opaque token identifying this lease in the store.
+func (l *Lease) Token() string {
+	return l.token
+}
+
+// Err returns nil while the lease is held. It returns ErrLeaseLost once
+// renewal failed because another owner took the thread over, and an error
+// after Release.
+func (l *Lease) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return l.err
+	}
+	if l.released {
+		return errors.New("thread lease released")
+	}
+	return nil
+}
+
+// Release stops renewal and gives up the lease so another owner can
+// acquire the thread.
+func (l *Lease) Release(ctx context.Context) error {
+	l.mu.Lock()
+	if l.released {
+		l.mu.Unlock()
+		return nil
+	}
+	l.released = true
+	l.mu.Unlock()
+	close(l.stop)
+	<-l.stopped
+
+	l.thread.leaseMu.Lock()
+	if l.thread.lease == l {
+		l.thread.lease = nil
+	}
+	l.thread.leaseMu.Unlock()
+	if err := l.store.Release(ctx, l.thread.id, l.token); err != nil {
+		return fmt.Errorf("release thread lease: %w", err)
+	}
+	return nil
+}
+
+// fence renews the lease and fails if it is no longer held, so an owner
+// whose lease was taken over cannot start another turn.
+func (l *Lease) fence(ctx context.Context) error {
+	if err := l.Err(); err != nil {
+		return err
+	}
+	return l.renew(ctx)
+}
+
+func (l *Lease) renew(ctx context.Context) error {
+	err := l.store.Renew(ctx, l.thread.id, l.token, l.ttl)
+	if errors.Is(err, ErrLeaseLost) {
+		l.mu.Lock()
+		if l.err == nil {
+			l.err = err
+		}
+		l.mu.Unlock()
+	}
+	return err
+}
+
+func (l *Lease) keepAlive() {
+	defer close(l.stopped)
+	ticker := time.NewTicker(max(l.ttl/3, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), l.ttl)
+		err := l.renew(ctx)
+		cancel()
+		if errors.Is(err, ErrLeaseLost) {
+			resolveLogger(l.thread.logger).Error("codex thread lease lost", "thread_id", l.thread.id)
+			return
+		}
+		if err != nil {
+			resolveLogger(l.thread.logger).Warn("codex thread lease renewal failed", "thread_id", l.thread.id, "error", err)
+		}
+	}
+}
+
+func (t *Thread) currentLease() *Lease {
+	t.leaseMu.Lock()
+	defer t.leaseMu.Unlock()
+	return t.lease
+}
+
+// checkLease enforces the lease fence before a turn starts.
+func (t *Thread) checkLease(ctx context.Context) error {
+	if t.owner == nil || t.owner.leases == nil {
+		return nil
+	}
+	lease := t.currentLease()
+	if lease == nil {
+		return ErrLeaseRequired
+	}
+	return lease.fence(ctx)
+}
+
+func newLeaseToken() (string, error) {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", fmt.Errorf("generate lease token: %w", err)
+	}
+	return hex.EncodeToString(buf[:]), nil
+}
+
+// FileLeaseStore keeps one lease file per thread in Dir. It coordinates
+// processes that share a filesystem: each operation holds an exclusive
+// file lock (flock, or LockFileEx on Windows) on a companion .lock file
+// while it reads and replaces the lease, so a takeover cannot race with a
+// renewal or another takeover. Network filesystems that do not honour these
+// locks are not supported.
+type FileLeaseStore struct {
+	Dir string
+}
+
+type leaseRecord struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Acquire implements LeaseStore.
+func (s FileLeaseStore) Acquire(_ context.Context, threadID, token string, ttl time.Duration) error {
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return err
+	}
+	path := s.path(threadID)
+	unlock, err := lockLeaseFile(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	current, err := readLeaseRecord(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err == nil && current.Token != token && time.Now().Before(current.ExpiresAt) {
+		return ErrLeaseHeld
+	}
+	return s.write(path, leaseRecord{Token: token, ExpiresAt: time.Now().Add(ttl)})
+}
+
+// Renew implements LeaseStore.
+func (s FileLeaseStore) Renew(_ context.Context, threadID, token string, ttl time.Duration) error {
+	path := s.path(threadID)
+	unlock, err := lockLeaseFile(path + ".lock")
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrLeaseLost
+	}
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	current, err := readLeaseRecord(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrLeaseLost
+	}
+	if err != nil {
+		return err
+	}
+	if current.Token != token {
+		return ErrLeaseLost
+	}
+	return s.write(path, leaseRecord{Token: token, ExpiresAt: time.Now().Add(ttl)})
+}
+
+// Release implements LeaseStore.
+func (s FileLeaseStore) Release(_ context.Context, threadID, token string) error {
+	path := s.path(threadID)
+	unlock, err := lockLeaseFile(path + ".lock")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	current, err := readLeaseRecord(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current.Token != token {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s FileLeaseStore) path(threadID string) string {
+	return filepath.Join(s.Dir, url.PathEscape(threadID)+".lease")
+}
+
+// write replaces the lease at path. The record is written to a temporary
+// file first and renamed into place, so readers never see a partially
+// written lease.
+func (s FileLeaseStore) write(path string, record leaseRecord) error {
+	tmp, err := s.writeTemp(record)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (s FileLeaseStore) writeTemp(record leaseRecord) (string, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	file, err := os.CreateTemp(s.Dir, ".lease-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		_ = os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+func readLeaseRecord(path string) (leaseRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return leaseRecord{}, err
+	}
+	var record leaseRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return leaseRecord{}, fmt.Errorf("decode lease %s: %w", path, err)
+	}
+	return record, nil
+}
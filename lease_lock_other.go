@@ -0,0 +1,15 @@
+//go:build !unix && !windows
+
+package codex
+
+import "os"
+
+// lockLeaseFile only checks that path can be created: this platform has no
+// file locks, so FileLeaseStore cannot order concurrent takeovers.
+func lockLeaseFile(path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return func() { _ = file.Close() }, nil
+}
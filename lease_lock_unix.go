@@ -0,0 +1,29 @@
+//go:build unix
+
+package codex
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockLeaseFile takes an exclusive lock on path, creating it if needed
+// unless its directory is missing, and returns the function releasing it.
+func lockLeaseFile(path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		err = unix.Flock(int(file.Fd()), unix.LOCK_EX)
+		if err != unix.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return func() { _ = file.Close() }, nil
+}
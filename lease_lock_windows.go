@@ -0,0 +1,22 @@
+package codex
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockLeaseFile takes an exclusive lock on path, creating it if needed
+// unless its directory is missing, and returns the function releasing it.
+func lockLeaseFile(path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	var overlapped windows.Overlapped
+	if err := windows.LockFileEx(windows.Handle(file.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, &overlapped); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return func() { _ = file.Close() }, nil
+}
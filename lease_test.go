@@ -0,0 +1,180 @@
+package codex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestFileLeaseStore(t *testing.T) {
+	ctx := context.Background()
+	store := FileLeaseStore{Dir: t.TempDir()}
+
+	if err := store.Acquire(ctx, "thr/1", "a", time.Minute); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if err := store.Acquire(ctx, "thr/1", "b", time.Minute); !errors.Is(err, ErrLeaseHeld) {
+		t.Fatalf("expected ErrLeaseHeld, got %v", err)
+	}
+	if err := store.Acquire(ctx, "thr/1", "a", time.Minute); err != nil {
+		t.Fatalf("re-acquire by owner: %v", err)
+	}
+	if err := store.Renew(ctx, "thr/1", "b", time.Minute); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("expected ErrLeaseLost for non-owner renew, got %v", err)
+	}
+	if err := store.Release(ctx, "thr/1", "b"); err != nil {
+		t.Fatalf("release by non-owner: %v", err)
+	}
+	if err := store.Acquire(ctx, "thr/1", "b", time.Minute); !errors.Is(err, ErrLeaseHeld) {
+		t.Fatalf("expected non-owner release to keep lease, got %v", err)
+	}
+	if err := store.Release(ctx, "thr/1", "a"); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if err := store.Acquire(ctx, "thr/1", "b", time.Minute); err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+}
+
+func TestFileLeaseStoreTakesOverExpiredLease(t *testing.T) {
+	ctx := context.Background()
+	store := FileLeaseStore{Dir: t.TempDir()}
+
+	if err := store.Acquire(ctx, "thr_1", "a", -time.Second); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if err := store.Acquire(ctx, "thr_1", "b", time.Minute); err != nil {
+		t.Fatalf("take over expired lease: %v", err)
+	}
+	if err := store.Renew(ctx, "thr_1", "a", time.Minute); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("expected previous owner to lose lease, got %v", err)
+	}
+}
+
+func TestFileLeaseStoreConcurrentTakeover(t *testing.T) {
+	ctx := context.Background()
+	store := FileLeaseStore{Dir: t.TempDir()}
+	if err := store.Acquire(ctx, "thr_1", "old", -time.Second); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	const contenders = 8
+	var wg sync.WaitGroup
+	won := make(chan string, contenders)
+	for i := range contenders {
+		token := fmt.Sprintf("owner-%d", i)
+		wg.Go(func() {
+			if err := store.Acquire(ctx, "thr_1", token, time.Minute); err == nil {
+				won <- token
+			} else if !errors.Is(err, ErrLeaseHeld) {
+				t.Errorf("acquire %s: %v", token, err)
+			}
+		})
+	}
+	wg.Wait()
+	close(won)
+	var winners []string
+	for token := range won {
+		winners = append(winners, token)
+	}
+	if len(winners) != 1 {
+		t.Fatalf("winners = %v, want exactly one", winners)
+	}
+	if err := store.Renew(ctx, "thr_1", winners[0], time.Minute); err != nil {
+		t.Fatalf("winner lost its lease: %v", err)
+	}
+}
+
+func TestThreadAcquireFencesTurns(t *testing.T) {
+	store := FileLeaseStore{Dir: t.TempDir()}
+	first := newLeaseTestThread(t, store)
+	second := newLeaseTestThread(t, store)
+
+	if _, err := first.RunStreamed(context.Background(), []Input{TextInput("hi")}, nil); !errors.Is(err, ErrLeaseRequired) {
+		t.Fatalf("expected ErrLeaseRequired, got %v", err)
+	}
+
+	lease, err := first.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := second.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrLeaseHeld) {
+		t.Fatalf("expected held lease to block acquire, got %v", err)
+	}
+
+	// Simulate the lease expiring and another process taking it over.
+	if err := store.Release(context.Background(), first.id, lease.Token()); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if err := store.Acquire(context.Background(), first.id, "other", time.Minute); err != nil {
+		t.Fatalf("take over: %v", err)
+	}
+	if _, err := first.RunStreamed(context.Background(), []Input{TextInput("hi")}, nil); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("expected ErrLeaseLost, got %v", err)
+	}
+	if err := lease.Err(); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("expected lease to report loss, got %v", err)
+	}
+	if err := lease.Release(context.Background()); err != nil {
+		t.Fatalf("release lost lease: %v", err)
+	}
+	if first.currentLease() != nil {
+		t.Fatalf("expected released lease to be cleared")
+	}
+}
+
+func TestLeaseRenewsInBackground(t *testing.T) {
+	store := FileLeaseStore{Dir: t.TempDir()}
+	thread := newLeaseTestThread(t, store)
+	thread.owner.leases.TTL = 30 * time.Millisecond
+
+	lease, err := thread.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := store.Acquire(context.Background(), thread.id, "other", time.Minute); !errors.Is(err, ErrLeaseHeld) {
+		t.Fatalf("expected renewed lease to stay held, got %v", err)
+	}
+	if err := lease.Release(context.Background()); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if err := store.Acquire(context.Background(), thread.id, "other", time.Minute); err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+}
+
+func TestThreadAcquireWithoutLeases(t *testing.T) {
+	client, err := New(context.Background(), Options{Transport: rpc.NewReplayTransport(initializeTranscript())})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	defer client.Close()
+	thread := &Thread{client: client.Client(), owner: client, id: "thr_1"}
+	if _, err := thread.Acquire(context.Background()); err == nil {
+		t.Fatalf("expected error when leases are not configured")
+	}
+	if _, err := New(context.Background(), Options{Leases: &LeaseOptions{}}); err == nil {
+		t.Fatalf("expected error for leases without a store")
+	}
+}
+
+func newLeaseTestThread(t *testing.T, store LeaseStore) *Thread {
+	t.Helper()
+	client, err := New(context.Background(), Options{
+		Transport: rpc.NewReplayTransport(initializeTranscript()),
+		Leases:    &LeaseOptions{Store: store, RetryInterval: 5 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return &Thread{client: client.Client(), owner: client, id: "thr_1"}
+}
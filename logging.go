@@ -1,8 +1,11 @@
 package codex
 
 import (
+	"bytes"
+	"context"
 	"io"
 	"log/slog"
+	"sync"
 
 	"github.com/pmenglund/codex-sdk-go/rpc"
 )
@@ -26,7 +29,75 @@ func attachApprovalLogger(handler rpc.ServerRequestHandler, logger *slog.Logger)
 			value.Logger = logger
 		}
 		return value
+	case *DecisionHandler:
+		if value != nil && value.Logger == nil {
+			value.Logger = logger
+		}
+		return value
 	default:
 		return handler
 	}
 }
+
+// stderrLogSource is the source attribute of log records for app-server
+// stderr lines.
+const stderrLogSource = "codex-app-server"
+
+// maxStderrLogLine bounds a buffered stderr line; longer lines are logged
+// in pieces.
+const maxStderrLogLine = 64 << 10
+
+// stderrLogWriter logs each line written to it through logger. os/exec
+// copies stderr from one goroutine, but Flush runs from the exit callback,
+// so writes are locked.
+type stderrLogWriter struct {
+	logger *slog.Logger
+	level  slog.Level
+
+	mu      sync.Mutex
+	pending []byte
+}
+
+func newStderrLogWriter(logger *slog.Logger, level slog.Level) *stderrLogWriter {
+	return &stderrLogWriter{logger: logger, level: level}
+}
+
+func (w *stderrLogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		w.log(w.pending[:i])
+		w.pending = w.pending[i+1:]
+	}
+	for len(w.pending) >= maxStderrLogLine {
+		w.log(w.pending[:maxStderrLogLine])
+		w.pending = w.pending[maxStderrLogLine:]
+	}
+	if len(w.pending) == 0 {
+		w.pending = nil
+	}
+	return len(p), nil
+}
+
+// Flush logs a final line that did not end in a newline.
+func (w *stderrLogWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.pending) > 0 {
+		w.log(w.pending)
+		w.pending = nil
+	}
+}
+
+func (w *stderrLogWriter) log(line []byte) {
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	if len(line) == 0 {
+		return
+	}
+	w.logger.Log(context.Background(), w.level, string(line), "source", stderrLogSource)
+}
@@ -0,0 +1,54 @@
+package codex
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStderrLogWriterSplitsLines(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	w := newStderrLogWriter(logger, slog.LevelWarn)
+	for _, chunk := range []string{"first li", "ne\r\nsecond\n\nthi", "rd"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	w.Flush()
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var record struct {
+			Level  string `json:"level"`
+			Msg    string `json:"msg"`
+			Source string `json:"source"`
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("decode record %q: %v", line, err)
+		}
+		if record.Level != "WARN" || record.Source != stderrLogSource {
+			t.Fatalf("record = %+v, want WARN from %s", record, stderrLogSource)
+		}
+		messages = append(messages, record.Msg)
+	}
+	if want := []string{"first line", "second", "third"}; !slices.Equal(messages, want) {
+		t.Fatalf("messages = %q, want %q", messages, want)
+	}
+}
+
+func TestStderrLogWriterSplitsLongLines(t *testing.T) {
+	var out bytes.Buffer
+	w := newStderrLogWriter(slog.New(slog.NewTextHandler(&out, nil)), slog.LevelInfo)
+	_, _ = w.Write(bytes.Repeat([]byte("x"), maxStderrLogLine+10))
+	if got := strings.Count(out.String(), "source="+stderrLogSource); got != 1 {
+		t.Fatalf("logged %d records before the newline, want 1", got)
+	}
+	w.Flush()
+	if got := strings.Count(out.String(), "source="+stderrLogSource); got != 2 {
+		t.Fatalf("logged %d records after Flush, want 2", got)
+	}
+}
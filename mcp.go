@@ -0,0 +1,113 @@
+package codex
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+// MCPAuthStatus is how the app-server authenticates to an MCP server.
+type MCPAuthStatus = protocol.MCPAuthStatus
+
+const (
+	MCPAuthStatusUnsupported MCPAuthStatus = protocol.MCPAuthStatusUnsupported
+	MCPAuthStatusNotLoggedIn MCPAuthStatus = protocol.MCPAuthStatusNotLoggedIn
+	MCPAuthStatusBearerToken MCPAuthStatus = protocol.MCPAuthStatusBearerToken
+	MCPAuthStatusOAuth       MCPAuthStatus = protocol.MCPAuthStatusOAuth
+)
+
+// MCPServer describes an MCP server configured in the app-server.
+type MCPServer struct {
+	Name       string
+	AuthStatus MCPAuthStatus
+	// Tools are the tools the server exposes, sorted by name.
+	Tools []MCPTool
+}
+
+// NeedsLogin reports whether the server requires an OAuth login before the
+// agent can use its tools.
+func (s MCPServer) NeedsLogin() bool {
+	return s.AuthStatus == MCPAuthStatusNotLoggedIn
+}
+
+// MCPTool describes a tool the agent can call through an MCP server.
+type MCPTool struct {
+	// Server is the name of the MCP server exposing the tool.
+	Server      string
+	Name        string
+	Title       string
+	Description string
+	// InputSchema is the JSON schema of the tool's arguments.
+	InputSchema RawJSON
+}
+
+// ListMCPServers returns the configured MCP servers with their tools and
+// auth status, following pagination cursors until the list is complete.
+func (c *Codex) ListMCPServers(ctx context.Context) ([]MCPServer, error) {
+	if err := c.ensureReady(); err != nil {
+		return nil, err
+	}
+	const method = "mcpServerStatus/list"
+	var servers []MCPServer
+	err := listAll(method, func(cursor *string) (*string, error) {
+		params := protocol.SanitizedListMCPServerStatusParamsJSON{
+			Cursor: cursor,
+			Detail: protocol.MCPServerStatusDetailToolsAndAuthOnly,
+		}
+		var response protocol.SanitizedListMCPServerStatusResponseJSON
+		if _, err := c.call(ctx, method, params, &response); err != nil {
+			return nil, err
+		}
+		for _, status := range response.Data {
+			servers = append(servers, mcpServer(status))
+		}
+		return response.NextCursor, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return servers, nil
+}
+
+// ListMCPTools returns the tools of every configured MCP server, sorted by
+// server and tool name.
+func (c *Codex) ListMCPTools(ctx context.Context) ([]MCPTool, error) {
+	servers, err := c.ListMCPServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var tools []MCPTool
+	for _, server := range servers {
+		tools = append(tools, server.Tools...)
+	}
+	slices.SortStableFunc(tools, func(a, b MCPTool) int {
+		return strings.Compare(a.Server, b.Server)
+	})
+	return tools, nil
+}
+
+func mcpServer(status protocol.MCPServerStatus) MCPServer {
+	server := MCPServer{Name: status.Name, AuthStatus: status.AuthStatus}
+	for key, tool := range status.Tools {
+		name := tool.Name
+		if name == "" {
+			name = key
+		}
+		descriptor := MCPTool{Server: status.Name, Name: name}
+		if tool.Title != nil {
+			descriptor.Title = *tool.Title
+		}
+		if tool.Description != nil {
+			descriptor.Description = *tool.Description
+		}
+		// The schema was decoded from JSON, so it always re-encodes.
+		descriptor.InputSchema, _ = JSON(tool.InputSchema)
+		server.Tools = append(server.Tools, descriptor)
+	}
+	slices.SortFunc(server.Tools, func(a, b MCPTool) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return server
+}
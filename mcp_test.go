@@ -0,0 +1,82 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestListMCPServersAndTools(t *testing.T) {
+	pages := map[string]any{
+		"": map[string]any{
+			"data": []any{map[string]any{
+				"name":       "github",
+				"authStatus": "notLoggedIn",
+				"tools": map[string]any{
+					"search_issues": map[string]any{"name": "search_issues", "description": "Search issues", "inputSchema": map[string]any{"type": "object"}},
+					"create_issue":  map[string]any{"name": "create_issue", "title": "Create issue", "inputSchema": map[string]any{"type": "object"}},
+				},
+				"resources":         []any{},
+				"resourceTemplates": []any{},
+			}},
+			"nextCursor": "next",
+		},
+		"next": map[string]any{
+			"data": []any{map[string]any{
+				"name":       "docs",
+				"authStatus": "unsupported",
+				"tools": map[string]any{
+					"lookup": map[string]any{"name": "lookup", "inputSchema": map[string]any{"type": "object"}},
+				},
+			}},
+		},
+	}
+	server, codex := newFakeAppServer(t, map[string]fakeHandler{
+		"mcpServerStatus/list": func(params json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			var request struct {
+				Cursor string `json:"cursor"`
+			}
+			_ = json.Unmarshal(params, &request)
+			return pages[request.Cursor], nil
+		},
+	})
+	ctx := context.Background()
+	servers, err := codex.ListMCPServers(ctx)
+	if err != nil {
+		t.Fatalf("ListMCPServers: %v", err)
+	}
+	if got := string(server.request(t, "mcpServerStatus/list").Params); got != `{"detail":"toolsAndAuthOnly"}` {
+		t.Fatalf("first page params = %s", got)
+	}
+	schema := RawJSON(`{"type":"object"}`)
+	want := []MCPServer{
+		{Name: "github", AuthStatus: MCPAuthStatusNotLoggedIn, Tools: []MCPTool{
+			{Server: "github", Name: "create_issue", Title: "Create issue", InputSchema: schema},
+			{Server: "github", Name: "search_issues", Description: "Search issues", InputSchema: schema},
+		}},
+		{Name: "docs", AuthStatus: MCPAuthStatusUnsupported, Tools: []MCPTool{
+			{Server: "docs", Name: "lookup", InputSchema: schema},
+		}},
+	}
+	if !reflect.DeepEqual(servers, want) {
+		t.Fatalf("servers = %+v, want %+v", servers, want)
+	}
+	if !servers[0].NeedsLogin() || servers[1].NeedsLogin() {
+		t.Fatalf("NeedsLogin does not follow the auth status")
+	}
+
+	tools, err := codex.ListMCPTools(ctx)
+	if err != nil {
+		t.Fatalf("ListMCPTools: %v", err)
+	}
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Server+"/"+tool.Name)
+	}
+	if wantNames := []string{"docs/lookup", "github/create_issue", "github/search_issues"}; !reflect.DeepEqual(names, wantNames) {
+		t.Fatalf("tools = %v, want %v", names, wantNames)
+	}
+}
@@ -0,0 +1,68 @@
+package codex
+
+import (
+	"context"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+// Model describes a model the app-server can run turns with.
+type Model struct {
+	// ID is the value to pass as ThreadStartOptions.Model or TurnOptions.Model.
+	ID          string
+	DisplayName string
+	Description string
+	// IsDefault marks the model the app-server uses when none is set.
+	IsDefault bool
+	// SupportedEfforts lists the reasoning efforts the model accepts, and
+	// DefaultEffort the one it uses when none is set.
+	SupportedEfforts []ReasoningEffort
+	DefaultEffort    ReasoningEffort
+	// ContextWindow is the model's context window in tokens, or zero when
+	// the app-server does not report it.
+	ContextWindow int64
+}
+
+// ListModels returns every model the app-server offers for its picker,
+// following pagination cursors until the list is complete.
+func (c *Codex) ListModels(ctx context.Context) ([]Model, error) {
+	if err := c.ensureReady(); err != nil {
+		return nil, err
+	}
+	const method = "model/list"
+	var models []Model
+	err := listAll(method, func(cursor *string) (*string, error) {
+		var response struct {
+			Data []struct {
+				protocol.Model
+				ContextWindow int64 `json:"contextWindow"`
+			} `json:"data"`
+			NextCursor *string `json:"nextCursor"`
+		}
+		if _, err := c.call(ctx, method, protocol.ModelListParams{Cursor: cursor}, &response); err != nil {
+			return nil, err
+		}
+		for _, entry := range response.Data {
+			model := Model{
+				ID:            entry.ID,
+				DisplayName:   entry.DisplayName,
+				Description:   entry.Description,
+				IsDefault:     entry.IsDefault,
+				DefaultEffort: entry.DefaultReasoningEffort,
+				ContextWindow: entry.ContextWindow,
+			}
+			if model.ID == "" {
+				model.ID = entry.Model.Model
+			}
+			for _, option := range entry.SupportedReasoningEfforts {
+				model.SupportedEfforts = append(model.SupportedEfforts, option.ReasoningEffort)
+			}
+			models = append(models, model)
+		}
+		return response.NextCursor, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return models, nil
+}
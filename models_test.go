@@ -0,0 +1,80 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestListModelsFollowsCursors(t *testing.T) {
+	pages := map[string]any{
+		"": map[string]any{
+			"data": []any{map[string]any{
+				"id":                     "gpt-5-codex",
+				"model":                  "gpt-5-codex",
+				"displayName":            "GPT-5 Codex",
+				"isDefault":              true,
+				"defaultReasoningEffort": "medium",
+				"supportedReasoningEfforts": []any{
+					map[string]any{"reasoningEffort": "low", "description": "Fast"},
+					map[string]any{"reasoningEffort": "medium", "description": "Balanced"},
+				},
+				"contextWindow": 272000,
+			}},
+			"nextCursor": "page-2",
+		},
+		"page-2": map[string]any{
+			"data": []any{map[string]any{
+				"model":                  "gpt-5-mini",
+				"displayName":            "GPT-5 Mini",
+				"defaultReasoningEffort": "low",
+			}},
+		},
+	}
+	server, codex := newFakeAppServer(t, map[string]fakeHandler{
+		"model/list": func(params json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			var request struct {
+				Cursor string `json:"cursor"`
+			}
+			_ = json.Unmarshal(params, &request)
+			return pages[request.Cursor], nil
+		},
+	})
+	models, err := codex.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels: %v", err)
+	}
+	want := []Model{
+		{
+			ID:               "gpt-5-codex",
+			DisplayName:      "GPT-5 Codex",
+			IsDefault:        true,
+			SupportedEfforts: []ReasoningEffort{ReasoningEffortLow, ReasoningEffortMedium},
+			DefaultEffort:    ReasoningEffortMedium,
+			ContextWindow:    272000,
+		},
+		{ID: "gpt-5-mini", DisplayName: "GPT-5 Mini", DefaultEffort: ReasoningEffortLow},
+	}
+	if !reflect.DeepEqual(models, want) {
+		t.Fatalf("models = %+v, want %+v", models, want)
+	}
+	server.request(t, "model/list")
+	if got := string(server.request(t, "model/list").Params); got != `{"cursor":"page-2"}` {
+		t.Fatalf("second page params = %s", got)
+	}
+}
+
+func TestListModelsRejectsRepeatedCursor(t *testing.T) {
+	_, codex := newFakeAppServer(t, map[string]fakeHandler{
+		"model/list": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			return map[string]any{"data": []any{}, "nextCursor": "again"}, nil
+		},
+	})
+	if _, err := codex.ListModels(context.Background()); err == nil || !strings.Contains(err.Error(), "twice") {
+		t.Fatalf("ListModels = %v, want a repeated cursor error", err)
+	}
+}
@@ -1,8 +1,11 @@
 package codex
 
 import (
+	"context"
 	"io"
 	"log/slog"
+	"os"
+	"time"
 
 	"github.com/pmenglund/codex-sdk-go/protocol"
 	"github.com/pmenglund/codex-sdk-go/rpc"
@@ -12,6 +15,9 @@ import (
 type Options struct {
 	// Transport overrides the default stdio spawn.
 	Transport rpc.Transport
+	// Attach, when set, connects to an app-server another process already
+	// runs instead of spawning one. See AttachOptions.
+	Attach *AttachOptions
 
 	// Spawn controls how the default stdio process is launched.
 	Spawn SpawnOptions
@@ -19,11 +25,121 @@ type Options struct {
 	// Logger receives SDK logs. If nil, logging is disabled.
 	Logger *slog.Logger
 
+	// WireLog logs every JSON-RPC line at debug level through Logger.
+	WireLog bool
+	// WireRedactors rewrite logged lines (defaults to rpc.DefaultWireRedactors).
+	WireRedactors []rpc.Redactor
+
 	// ClientInfo identifies this SDK to the app-server.
 	ClientInfo protocol.ClientInfo
 
+	// InitTimeout, when positive, bounds spawning or dialing the
+	// app-server and the initialize handshake. On expiry New kills a
+	// spawned process and returns an error matching ErrStartupTimeout,
+	// instead of waiting for ctx.
+	InitTimeout time.Duration
+
+	// SkipCompatibilityCheck disables rejecting, at New, an app-server that
+	// reports a version older than MinimumCodexVersion or advertises a
+	// method list without thread/start and turn/start.
+	SkipCompatibilityCheck bool
+	// MinServerVersion, when set, fails New with an
+	// *IncompatibleServerError unless the app-server is at least this
+	// release ("major.minor.patch"). The version comes from initialize or,
+	// for a spawned binary, from "codex --version" run with Spawn.Env and
+	// Spawn.Dir; an app-server whose version cannot be determined, such as
+	// a development build, is rejected too. Reconnects check the
+	// replacement server the same way.
+	MinServerVersion string
+
 	// ApprovalHandler handles server approval requests.
 	ApprovalHandler rpc.ServerRequestHandler
+	// ApprovalTimeout bounds each ApprovalHandler call. When it elapses the
+	// handler's context is canceled and the app-server receives an error.
+	// Zero means no limit.
+	ApprovalTimeout time.Duration
+	// MaxConcurrentApprovals caps how many ApprovalHandler calls run at once.
+	// Approvals for the same thread are always handled in order, one at a
+	// time. Zero means unlimited.
+	MaxConcurrentApprovals int
+	// Tools are application functions the agent can call. They are
+	// advertised on every thread started through the client and their calls
+	// are handled here; other tool calls still go to ApprovalHandler.
+	Tools []Tool
+
+	// Redial, when set, opens a replacement transport after the connection to
+	// the app-server is lost. A TurnStream interrupted by the loss reconnects,
+	// resumes its thread and re-attaches to the running turn instead of
+	// returning the transport error.
+	Redial func(ctx context.Context) (rpc.Transport, error)
+
+	// Reconnect, when set, reconnects automatically whenever the connection
+	// is lost, using Redial or, when Transport is nil, by respawning the
+	// app-server. See ReconnectPolicy.
+	Reconnect *ReconnectPolicy
+
+	// IdleShutdown, when positive, stops the app-server after it has had no
+	// facade calls and no running turns for this long. The next StartThread,
+	// ResumeThread or turn respawns it, using Redial or, when Transport is
+	// nil, by spawning a new process, and resumes the known threads first.
+	// Requests sent directly through Client do not count as activity.
+	IdleShutdown time.Duration
+
+	// Keepalive enables periodic liveness probes so a hung app-server is
+	// detected and, with Reconnect, replaced. See rpc.KeepalivePolicy.
+	Keepalive *rpc.KeepalivePolicy
+
+	// Leases, when set, requires a Thread to hold a lease from Acquire before
+	// it starts turns, so processes sharing persisted threads do not drive
+	// the same thread at once. See LeaseOptions.
+	Leases *LeaseOptions
+
+	// WriteQueue queues outgoing messages for a writer goroutine so
+	// notifications and approval replies never block on the pipe to the
+	// app-server. See rpc.WriteQueuePolicy.
+	WriteQueue *rpc.WriteQueuePolicy
+
+	// OnNotificationError reports notifications the SDK does not recognize
+	// or cannot decode. See rpc.ClientOptions.OnNotificationError.
+	OnNotificationError func(*rpc.NotificationDecodeError)
+
+	// OnMalformedMessage reports incoming lines dropped as invalid JSON-RPC.
+	// See rpc.ClientOptions.OnMalformedMessage and StrictEnvelope.
+	OnMalformedMessage func(*rpc.MalformedMessageError)
+
+	// StrictEnvelope rejects incoming lines that are not JSON-RPC 2.0
+	// envelopes. Leave it off for the app-server, which omits the jsonrpc
+	// field. See rpc.ClientOptions.StrictEnvelope.
+	StrictEnvelope bool
+
+	// RequestIDPrefix makes the client send string request IDs such as
+	// "go-sdk-1:42" instead of integers. After a reconnect the prefix gains
+	// a connection number ("go-sdk-1.2:1") so IDs never repeat.
+	RequestIDPrefix string
+
+	// AuditSink archives every notification from the app-server, including
+	// those no consumer reads. It survives reconnects. See rpc.AuditSink.
+	AuditSink rpc.AuditSink
+	// AuditQueue bounds the queue in front of AuditSink. See
+	// rpc.ClientOptions.AuditQueue.
+	AuditQueue *rpc.AuditQueuePolicy
+
+	// ReplayBuffer retains the last ReplayBuffer notifications for
+	// late subscribers; see rpc.Client.SubscribeNotificationsReplay. The
+	// buffer starts empty after a reconnect.
+	ReplayBuffer int
+
+	// RequestMutators edit the params of every request the client sends,
+	// for example to default the cwd of every turn/start; see
+	// rpc.RequestMutator and rpc.DefaultParams.
+	RequestMutators []rpc.RequestMutator
+
+	// SizeLimits bounds incoming lines and results; see rpc.SizeLimits.
+	SizeLimits *rpc.SizeLimits
+
+	// Tracer, when set, creates spans for every RPC call and turn and injects
+	// trace context into request params. See rpc.Tracer.
+	Tracer rpc.Tracer
 }
 
 // SpawnOptions configures the spawned codex app-server process.
@@ -32,8 +148,79 @@ type SpawnOptions struct {
 	CodexPath string
 	// ConfigOverrides are passed as --config key=value flags.
 	ConfigOverrides []string
+	// Profile selects a named profile from config.toml, as codex --profile
+	// does. New reads the app-server's configuration after initialize and
+	// fails unless it defines the profile.
+	Profile string
 	// ExtraArgs are appended to the command line.
 	ExtraArgs []string
 	// Stderr captures stderr from the codex process (defaults to os.Stderr).
 	Stderr io.Writer
+	// RecordProvenance resolves the binary's path, version and SHA-256 when
+	// it is spawned and attaches them to every TurnResult as Provenance.
+	RecordProvenance bool
+	// ReadBufferSize sizes the stdout read buffer (defaults to 64 KiB).
+	// Raise it when turns routinely carry multi-megabyte diffs or images.
+	// Options.SizeLimits caps the size of a single line.
+	ReadBufferSize int
+	// Framing selects the wire framing on stdin/stdout (defaults to
+	// rpc.FramingLines, which the app-server speaks). Use
+	// rpc.FramingContentLength when CodexPath points at a wrapper that
+	// speaks LSP-style Content-Length framing.
+	Framing rpc.Framing
+	// Env sets environment variables ("KEY=value") for the codex process,
+	// such as CODEX_HOME, proxy settings or API keys, without changing the
+	// parent's environment. They are added to the inherited environment,
+	// replacing variables of the same name.
+	Env []string
+	// ReplaceEnv starts the process with only Env instead of the inherited
+	// environment.
+	ReplaceEnv bool
+	// Dir is the working directory of the codex process. Config discovery
+	// and sandbox roots are resolved relative to it, so point it at the
+	// target repository when the service runs elsewhere. Empty uses the
+	// parent's working directory.
+	Dir string
+	// OnProcessExit, when set, is called whenever a spawned codex process
+	// exits, including after Close and for processes replaced by
+	// Options.Reconnect. err is nil for a clean exit; exitCode is -1 when
+	// the process was killed by a signal.
+	OnProcessExit func(err error, exitCode int)
+	// ExitGrace is how long Close waits for the codex process to exit after
+	// closing its stdin, before sending SIGTERM (defaults to 2s).
+	ExitGrace time.Duration
+	// TerminateGrace is how long Close waits after SIGTERM before killing
+	// the process (defaults to 2s). Raise it when the app-server needs more
+	// time to flush rollout files and persist thread state.
+	TerminateGrace time.Duration
+	// StderrTail, when positive, keeps the last StderrTail bytes of the
+	// codex process's stderr in memory, still writing it to Stderr. A
+	// failed handshake or a process that dies then reports an
+	// *rpc.ProcessError carrying them, instead of a bare EOF.
+	StderrTail int
+	// LogStderr emits each line of the codex process's stderr through
+	// Options.Logger at StderrLogLevel, with a source=codex-app-server
+	// attribute. Stderr then defaults to nowhere rather than os.Stderr;
+	// set it as well to keep a raw copy.
+	LogStderr bool
+	// StderrLogLevel is the level of logged stderr lines (defaults to
+	// slog.LevelInfo).
+	StderrLogLevel slog.Level
+	// Limits caps the memory, CPU time and process count of the codex
+	// process and the commands it runs, so a runaway app-server or sandbox
+	// cannot take down the host. Spawning fails when the limits cannot be
+	// applied; they are only supported on Linux.
+	Limits rpc.ResourceLimits
+}
+
+// environ returns the environment for the codex process, in exec.Cmd.Env
+// form.
+func (s SpawnOptions) environ() []string {
+	if s.ReplaceEnv {
+		return append([]string{}, s.Env...)
+	}
+	if len(s.Env) == 0 {
+		return nil
+	}
+	return append(os.Environ(), s.Env...)
 }
@@ -0,0 +1,25 @@
+package codex
+
+import "fmt"
+
+// listAll fetches every page of a paginated list method. page requests the
+// page at cursor, nil for the first one, and returns the cursor of the
+// next page, nil or empty after the last.
+func listAll(method string, page func(cursor *string) (*string, error)) error {
+	var cursor *string
+	seen := map[string]bool{}
+	for {
+		next, err := page(cursor)
+		if err != nil {
+			return err
+		}
+		if next == nil || *next == "" {
+			return nil
+		}
+		if seen[*next] {
+			return fmt.Errorf("%s returned cursor %q twice", method, *next)
+		}
+		seen[*next] = true
+		cursor = next
+	}
+}
@@ -0,0 +1,119 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Policy decides approvals from data instead of code. Rules are evaluated in
+// order and the first rule whose When expression is true supplies the
+// decision; Default applies when no rule matches (defaults to ApprovalDecline).
+//
+// When expressions use a small CEL-like language over the ApprovalRequest
+// fields kind, method, threadId, turnId, itemId, command, cwd, reason (strings)
+// and paths (list of strings):
+//
+//	kind == "command" && command.startsWith("git ") && !command.contains("push")
+//	kind == "fileChange" && paths.all(p, p.startsWith("/repo/"))
+//	method in ["execCommandApproval", "applyPatchApproval"]
+//	command.matches("^go (test|vet)( |$)")
+//
+// Supported operators are ==, !=, in, !, && and ||. Strings support
+// startsWith, endsWith, contains and matches (regular expression literal);
+// lists support contains, all and exists.
+//
+// Policies are plain JSON, so they can be shipped and reloaded without
+// recompiling:
+//
+//	{"default": "decline", "rules": [{"name": "git", "when": "...", "decision": "accept"}]}
+type Policy struct {
+	Default ApprovalDecision `json:"default,omitempty"`
+	Rules   []PolicyRule     `json:"rules"`
+}
+
+// PolicyRule is a single policy entry.
+type PolicyRule struct {
+	Name     string           `json:"name,omitempty"`
+	When     string           `json:"when"`
+	Decision ApprovalDecision `json:"decision"`
+
+	match func(*ApprovalRequest) bool
+}
+
+// ParsePolicy decodes and compiles a JSON policy.
+func ParsePolicy(data []byte) (*Policy, error) {
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("decode policy: %w", err)
+	}
+	if err := policy.Compile(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// LoadPolicyFile reads and compiles a JSON policy from path.
+func LoadPolicyFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePolicy(data)
+}
+
+// Compile validates decisions and compiles every rule expression. Policies
+// built in code must be compiled before use; ParsePolicy compiles for you.
+func (p *Policy) Compile() error {
+	if p.Default != "" && !validDecision(p.Default) {
+		return fmt.Errorf("policy default: unknown approval decision %q", p.Default)
+	}
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if !validDecision(rule.Decision) {
+			return fmt.Errorf("policy rule %s: unknown approval decision %q", rule.label(i), rule.Decision)
+		}
+		match, err := compilePolicyExpr(rule.When)
+		if err != nil {
+			return fmt.Errorf("policy rule %s: %w", rule.label(i), err)
+		}
+		rule.match = match
+	}
+	return nil
+}
+
+// Evaluate returns the decision for req and the matching rule's name (or
+// "#index" for unnamed rules). The rule name is empty when the default applied.
+func (p *Policy) Evaluate(req ApprovalRequest) (ApprovalDecision, string, error) {
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.match == nil {
+			return "", "", fmt.Errorf("policy rule %s is not compiled", rule.label(i))
+		}
+		if rule.match(&req) {
+			if rule.Name != "" {
+				return rule.Decision, rule.Name, nil
+			}
+			return rule.Decision, "#" + strconv.Itoa(i), nil
+		}
+	}
+	if p.Default != "" {
+		return p.Default, "", nil
+	}
+	return ApprovalDecline, "", nil
+}
+
+// DecideApproval implements ApprovalDecider.
+func (p *Policy) DecideApproval(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+	decision, _, err := p.Evaluate(req)
+	return decision, err
+}
+
+func (r *PolicyRule) label(index int) string {
+	if r.Name != "" {
+		return strconv.Quote(r.Name)
+	}
+	return "#" + strconv.Itoa(index)
+}
@@ -0,0 +1,82 @@
+package codex
+
+import "fmt"
+
+// policyType is the static type of a When expression node.
+type policyType int
+
+const (
+	policyBool policyType = iota
+	policyString
+	policyList
+)
+
+func (t policyType) String() string {
+	switch t {
+	case policyBool:
+		return "bool"
+	case policyString:
+		return "string"
+	default:
+		return "list"
+	}
+}
+
+type policyEnv struct {
+	req  *ApprovalRequest
+	vars map[string]string
+}
+
+// policyExpr is a type-checked expression node.
+type policyExpr struct {
+	typ  policyType
+	eval func(env *policyEnv) any
+}
+
+var policyFields = map[string]policyExpr{
+	"kind":     stringField(func(r *ApprovalRequest) string { return string(r.Kind) }),
+	"method":   stringField(func(r *ApprovalRequest) string { return r.Method }),
+	"threadId": stringField(func(r *ApprovalRequest) string { return r.ThreadID }),
+	"turnId":   stringField(func(r *ApprovalRequest) string { return r.TurnID }),
+	"itemId":   stringField(func(r *ApprovalRequest) string { return r.ItemID }),
+	"command":  stringField(func(r *ApprovalRequest) string { return r.Command }),
+	"cwd":      stringField(func(r *ApprovalRequest) string { return r.Cwd }),
+	"reason":   stringField(func(r *ApprovalRequest) string { return r.Reason }),
+	"paths": {typ: policyList, eval: func(env *policyEnv) any {
+		return env.req.Paths
+	}},
+}
+
+func stringField(get func(*ApprovalRequest) string) policyExpr {
+	return policyExpr{typ: policyString, eval: func(env *policyEnv) any { return get(env.req) }}
+}
+
+func compilePolicyExpr(src string) (func(*ApprovalRequest) bool, error) {
+	tokens, err := lexPolicy(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &policyParser{tokens: tokens, scope: map[string]bool{}}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+	}
+	if expr.typ != policyBool {
+		return nil, fmt.Errorf("expression must be bool, got %s", expr.typ)
+	}
+	return func(req *ApprovalRequest) bool {
+		return expr.eval(&policyEnv{req: req, vars: map[string]string{}}).(bool)
+	}, nil
+}
+
+func requireTypes(op string, want policyType, operands ...policyExpr) error {
+	for _, operand := range operands {
+		if operand.typ != want {
+			return fmt.Errorf("%s requires %s operands, got %s", op, want, operand.typ)
+		}
+	}
+	return nil
+}
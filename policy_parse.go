@@ -0,0 +1,391 @@
+package codex
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// lexPolicy and policyParser turn a When expression into type-checked
+// policyExpr nodes.
+type policyTokenKind int
+
+const (
+	tokEOF policyTokenKind = iota
+	tokIdent
+	tokString
+	tokPunct
+)
+
+type policyToken struct {
+	kind policyTokenKind
+	text string
+	pos  int
+}
+
+func lexPolicy(src string) ([]policyToken, error) {
+	var tokens []policyToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(src) && src[end] != c {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			value, err := unquotePolicyString(src[i+1:end], c)
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at offset %d: %w", i, err)
+			}
+			tokens = append(tokens, policyToken{kind: tokString, text: value, pos: i})
+			i = end + 1
+		case c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z'):
+			start := i
+			for i < len(src) && (src[i] == '_' || ('a' <= src[i] && src[i] <= 'z') || ('A' <= src[i] && src[i] <= 'Z') || ('0' <= src[i] && src[i] <= '9')) {
+				i++
+			}
+			tokens = append(tokens, policyToken{kind: tokIdent, text: src[start:i], pos: start})
+		default:
+			if i+1 < len(src) {
+				switch two := src[i : i+2]; two {
+				case "&&", "||", "==", "!=":
+					tokens = append(tokens, policyToken{kind: tokPunct, text: two, pos: i})
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("()[],.!", rune(c)) {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+			tokens = append(tokens, policyToken{kind: tokPunct, text: string(c), pos: i})
+			i++
+		}
+	}
+	return append(tokens, policyToken{kind: tokEOF, text: "end of expression", pos: len(src)}), nil
+}
+
+// unquotePolicyString unescapes the body of a string literal delimited by
+// quote. Both quote characters may be escaped in either kind of literal.
+func unquotePolicyString(body string, quote byte) (string, error) {
+	var out strings.Builder
+	for body != "" {
+		if len(body) >= 2 && body[0] == '\\' && (body[1] == '"' || body[1] == '\'') {
+			out.WriteByte(body[1])
+			body = body[2:]
+			continue
+		}
+		r, multibyte, tail, err := strconv.UnquoteChar(body, quote)
+		if err != nil {
+			return "", err
+		}
+		if r < utf8.RuneSelf || !multibyte {
+			out.WriteByte(byte(r))
+		} else {
+			out.WriteRune(r)
+		}
+		body = tail
+	}
+	return out.String(), nil
+}
+
+type policyParser struct {
+	tokens []policyToken
+	pos    int
+	scope  map[string]bool
+}
+
+func (p *policyParser) peek() policyToken {
+	return p.tokens[p.pos]
+}
+
+func (p *policyParser) next() policyToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *policyParser) accept(text string) bool {
+	if tok := p.peek(); tok.kind != tokString && tok.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *policyParser) expect(text string) error {
+	if p.accept(text) {
+		return nil
+	}
+	tok := p.peek()
+	return fmt.Errorf("expected %q at offset %d, got %q", text, tok.pos, tok.text)
+}
+
+func (p *policyParser) parseOr() (policyExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return policyExpr{}, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return policyExpr{}, err
+		}
+		if err := requireTypes("||", policyBool, left, right); err != nil {
+			return policyExpr{}, err
+		}
+		l, r := left.eval, right.eval
+		left = policyExpr{typ: policyBool, eval: func(env *policyEnv) any {
+			return l(env).(bool) || r(env).(bool)
+		}}
+	}
+	return left, nil
+}
+
+func (p *policyParser) parseAnd() (policyExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return policyExpr{}, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return policyExpr{}, err
+		}
+		if err := requireTypes("&&", policyBool, left, right); err != nil {
+			return policyExpr{}, err
+		}
+		l, r := left.eval, right.eval
+		left = policyExpr{typ: policyBool, eval: func(env *policyEnv) any {
+			return l(env).(bool) && r(env).(bool)
+		}}
+	}
+	return left, nil
+}
+
+func (p *policyParser) parseUnary() (policyExpr, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return policyExpr{}, err
+		}
+		if err := requireTypes("!", policyBool, operand); err != nil {
+			return policyExpr{}, err
+		}
+		eval := operand.eval
+		return policyExpr{typ: policyBool, eval: func(env *policyEnv) any { return !eval(env).(bool) }}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *policyParser) parseComparison() (policyExpr, error) {
+	left, err := p.parsePostfix()
+	if err != nil {
+		return policyExpr{}, err
+	}
+	switch {
+	case p.accept("=="), p.accept("!="):
+		op := p.tokens[p.pos-1].text
+		right, err := p.parsePostfix()
+		if err != nil {
+			return policyExpr{}, err
+		}
+		if left.typ != right.typ || left.typ == policyList {
+			return policyExpr{}, fmt.Errorf("cannot compare %s %s %s", left.typ, op, right.typ)
+		}
+		l, r, negate := left.eval, right.eval, op == "!="
+		return policyExpr{typ: policyBool, eval: func(env *policyEnv) any {
+			return (l(env) == r(env)) != negate
+		}}, nil
+	case p.accept("in"):
+		right, err := p.parsePostfix()
+		if err != nil {
+			return policyExpr{}, err
+		}
+		if left.typ != policyString || right.typ != policyList {
+			return policyExpr{}, fmt.Errorf("in requires string in list, got %s in %s", left.typ, right.typ)
+		}
+		l, r := left.eval, right.eval
+		return policyExpr{typ: policyBool, eval: func(env *policyEnv) any {
+			return slices.Contains(r(env).([]string), l(env).(string))
+		}}, nil
+	}
+	return left, nil
+}
+
+func (p *policyParser) parsePostfix() (policyExpr, error) {
+	expr, err := p.parsePrimary()
+	if err != nil {
+		return policyExpr{}, err
+	}
+	for p.accept(".") {
+		tok := p.next()
+		if tok.kind != tokIdent {
+			return policyExpr{}, fmt.Errorf("expected method name at offset %d", tok.pos)
+		}
+		if err := p.expect("("); err != nil {
+			return policyExpr{}, err
+		}
+		if expr.typ == policyList && (tok.text == "all" || tok.text == "exists") {
+			expr, err = p.parseMacro(tok.text, expr)
+		} else {
+			expr, err = p.parseMethod(tok, expr)
+		}
+		if err != nil {
+			return policyExpr{}, err
+		}
+	}
+	return expr, nil
+}
+
+func (p *policyParser) parseMethod(name policyToken, receiver policyExpr) (policyExpr, error) {
+	argStart := p.pos
+	arg, err := p.parseOr()
+	if err != nil {
+		return policyExpr{}, err
+	}
+	if err := p.expect(")"); err != nil {
+		return policyExpr{}, err
+	}
+	if arg.typ != policyString {
+		return policyExpr{}, fmt.Errorf("%s expects a string argument, got %s", name.text, arg.typ)
+	}
+	recv, argEval := receiver.eval, arg.eval
+	if receiver.typ == policyList {
+		if name.text != "contains" {
+			return policyExpr{}, fmt.Errorf("unknown list method %q", name.text)
+		}
+		return policyExpr{typ: policyBool, eval: func(env *policyEnv) any {
+			return slices.Contains(recv(env).([]string), argEval(env).(string))
+		}}, nil
+	}
+	if receiver.typ != policyString {
+		return policyExpr{}, fmt.Errorf("%s is not a method on %s", name.text, receiver.typ)
+	}
+	var fn func(s, arg string) bool
+	switch name.text {
+	case "startsWith":
+		fn = strings.HasPrefix
+	case "endsWith":
+		fn = strings.HasSuffix
+	case "contains":
+		fn = strings.Contains
+	case "matches":
+		argTok := p.tokens[argStart]
+		if argTok.kind != tokString || p.pos != argStart+2 {
+			return policyExpr{}, fmt.Errorf("matches requires a string literal at offset %d", argTok.pos)
+		}
+		re, err := regexp.Compile(argTok.text)
+		if err != nil {
+			return policyExpr{}, fmt.Errorf("matches: %w", err)
+		}
+		return policyExpr{typ: policyBool, eval: func(env *policyEnv) any {
+			return re.MatchString(recv(env).(string))
+		}}, nil
+	default:
+		return policyExpr{}, fmt.Errorf("unknown string method %q", name.text)
+	}
+	return policyExpr{typ: policyBool, eval: func(env *policyEnv) any {
+		return fn(recv(env).(string), argEval(env).(string))
+	}}, nil
+}
+
+func (p *policyParser) parseMacro(name string, receiver policyExpr) (policyExpr, error) {
+	tok := p.next()
+	if tok.kind != tokIdent {
+		return policyExpr{}, fmt.Errorf("%s expects a variable name at offset %d", name, tok.pos)
+	}
+	if _, ok := policyFields[tok.text]; ok {
+		return policyExpr{}, fmt.Errorf("%s variable %q shadows a request field", name, tok.text)
+	}
+	variable := tok.text
+	if err := p.expect(","); err != nil {
+		return policyExpr{}, err
+	}
+	shadowed := p.scope[variable]
+	p.scope[variable] = true
+	body, err := p.parseOr()
+	p.scope[variable] = shadowed
+	if err != nil {
+		return policyExpr{}, err
+	}
+	if err := p.expect(")"); err != nil {
+		return policyExpr{}, err
+	}
+	if body.typ != policyBool {
+		return policyExpr{}, fmt.Errorf("%s body must be bool, got %s", name, body.typ)
+	}
+	recv, eval, all := receiver.eval, body.eval, name == "all"
+	return policyExpr{typ: policyBool, eval: func(env *policyEnv) any {
+		prev, hadPrev := env.vars[variable]
+		defer func() {
+			if hadPrev {
+				env.vars[variable] = prev
+			} else {
+				delete(env.vars, variable)
+			}
+		}()
+		for _, item := range recv(env).([]string) {
+			env.vars[variable] = item
+			if eval(env).(bool) != all {
+				return !all
+			}
+		}
+		return all
+	}}, nil
+}
+
+func (p *policyParser) parsePrimary() (policyExpr, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokString:
+		value := tok.text
+		return policyExpr{typ: policyString, eval: func(*policyEnv) any { return value }}, nil
+	case tok.kind == tokIdent && (tok.text == "true" || tok.text == "false"):
+		value := tok.text == "true"
+		return policyExpr{typ: policyBool, eval: func(*policyEnv) any { return value }}, nil
+	case tok.kind == tokIdent:
+		if p.scope[tok.text] {
+			name := tok.text
+			return policyExpr{typ: policyString, eval: func(env *policyEnv) any { return env.vars[name] }}, nil
+		}
+		if field, ok := policyFields[tok.text]; ok {
+			return field, nil
+		}
+		return policyExpr{}, fmt.Errorf("unknown identifier %q at offset %d", tok.text, tok.pos)
+	case tok.text == "(":
+		expr, err := p.parseOr()
+		if err != nil {
+			return policyExpr{}, err
+		}
+		return expr, p.expect(")")
+	case tok.text == "[":
+		var items []string
+		for !p.accept("]") {
+			if len(items) > 0 {
+				if err := p.expect(","); err != nil {
+					return policyExpr{}, err
+				}
+			}
+			item := p.next()
+			if item.kind != tokString {
+				return policyExpr{}, fmt.Errorf("list literals may only contain strings (offset %d)", item.pos)
+			}
+			items = append(items, item.text)
+		}
+		return policyExpr{typ: policyList, eval: func(*policyEnv) any { return items }}, nil
+	}
+	return policyExpr{}, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+}
@@ -0,0 +1,201 @@
+package codex
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+func TestPolicyExpressions(t *testing.T) {
+	req := ApprovalRequest{
+		Kind:     ApprovalKindFileChange,
+		Method:   "applyPatchApproval",
+		ThreadID: "thr_1",
+		Command:  "git status --short",
+		Cwd:      "/repo",
+		Paths:    []string{"/repo/a.go", "/repo/b.go"},
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`kind == "fileChange"`, true},
+		{`kind != 'fileChange'`, false},
+		{`command.startsWith("git ") && !command.contains("push")`, true},
+		{`command.endsWith("--short")`, true},
+		{`command.matches("^git (status|diff)")`, true},
+		{`method in ["execCommandApproval", "applyPatchApproval"]`, true},
+		{`threadId in []`, false},
+		{`paths.all(p, p.startsWith("/repo/"))`, true},
+		{`paths.exists(p, p.endsWith("b.go"))`, true},
+		{`paths.exists(p, p == "/etc/passwd")`, false},
+		{`paths.contains("/repo/a.go")`, true},
+		{`"/repo/a.go" in paths || false`, true},
+		{`paths.all(p, paths.exists(q, q == p))`, true},
+		{`(reason == "" || reason == "x") && true`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			match, err := compilePolicyExpr(tt.expr)
+			if err != nil {
+				t.Fatalf("compile failed: %v", err)
+			}
+			if got := match(&req); got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLexPolicyStrings(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`'a\"b'`, `a"b`},
+		{`'it\'s'`, `it's`},
+		{`'say "hi"'`, `say "hi"`},
+		{`"a\"b"`, `a"b`},
+		{`"it\'s"`, `it's`},
+		{`"tab\there"`, "tab\there"},
+		{`'caf\u00e9'`, "café"},
+		{`"\x41"`, "A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			tokens, err := lexPolicy(tt.src)
+			if err != nil {
+				t.Fatalf("lex failed: %v", err)
+			}
+			if len(tokens) != 2 || tokens[0].kind != tokString || tokens[0].text != tt.want {
+				t.Fatalf("tokens = %+v, want string %q", tokens, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyCompileErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{`command`, "must be bool"},
+		{`unknown == "x"`, "unknown identifier"},
+		{`command == paths`, "cannot compare"},
+		{`paths in paths`, "in requires"},
+		{`command && true`, "requires bool"},
+		{`command.matches(cwd)`, "string literal"},
+		{`command.matches("(")`, "matches"},
+		{`command.size("x")`, "unknown string method"},
+		{`paths.all(command, true)`, "shadows"},
+		{`paths.all(p, p)`, "body must be bool"},
+		{`kind == "command`, "unterminated"},
+		{`kind == "command" )`, "unexpected"},
+		{`kind = "command"`, "unexpected character"},
+		{`command.startsWith(cwd + "/")`, "unexpected character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := compilePolicyExpr(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`{
+		"default": "cancel",
+		"rules": [
+			{"name": "no-push", "when": "command.contains(\"git push\")", "decision": "decline"},
+			{"when": "kind == \"command\" && command.startsWith(\"git \")", "decision": "accept"},
+			{"name": "repo-edits", "when": "kind == \"fileChange\" && paths.all(p, p.startsWith(\"/repo/\"))", "decision": "acceptForSession"}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		req      ApprovalRequest
+		decision ApprovalDecision
+		rule     string
+	}{
+		{"push", ApprovalRequest{Kind: ApprovalKindCommand, Command: "git push origin"}, ApprovalDecline, "no-push"},
+		{"status", ApprovalRequest{Kind: ApprovalKindCommand, Command: "git status"}, ApprovalAccept, "#1"},
+		{"edit", ApprovalRequest{Kind: ApprovalKindFileChange, Paths: []string{"/repo/x"}}, ApprovalAcceptForSession, "repo-edits"},
+		{"other", ApprovalRequest{Kind: ApprovalKindCommand, Command: "rm -rf /"}, ApprovalCancel, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, rule, err := policy.Evaluate(tt.req)
+			if err != nil {
+				t.Fatalf("evaluate failed: %v", err)
+			}
+			assertEqual(t, "decision", decision, tt.decision)
+			assertEqual(t, "rule", rule, tt.rule)
+		})
+	}
+}
+
+func TestPolicyValidation(t *testing.T) {
+	if _, err := ParsePolicy([]byte(`{"default":"maybe"}`)); err == nil {
+		t.Fatalf("expected invalid default error")
+	}
+	if _, err := ParsePolicy([]byte(`{"rules":[{"name":"r","when":"true","decision":"yes"}]}`)); err == nil || !strings.Contains(err.Error(), `"r"`) {
+		t.Fatalf("expected invalid rule decision error, got %v", err)
+	}
+	if _, err := ParsePolicy([]byte(`{`)); err == nil {
+		t.Fatalf("expected decode error")
+	}
+
+	uncompiled := &Policy{Rules: []PolicyRule{{When: "true", Decision: ApprovalAccept}}}
+	if _, err := uncompiled.DecideApproval(context.Background(), ApprovalRequest{}); err == nil {
+		t.Fatalf("expected uncompiled policy error")
+	}
+	if err := uncompiled.Compile(); err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+	decision, err := uncompiled.DecideApproval(context.Background(), ApprovalRequest{})
+	if err != nil || decision != ApprovalAccept {
+		t.Fatalf("expected accept, got %s err=%v", decision, err)
+	}
+	empty := &Policy{}
+	if decision, _, _ := empty.Evaluate(ApprovalRequest{}); decision != ApprovalDecline {
+		t.Fatalf("expected decline default, got %s", decision)
+	}
+}
+
+func TestLoadPolicyFileWithDecisionHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	data := `{"rules":[{"when":"command.matches(\"^go (test|vet)\")","decision":"accept"}]}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	policy, err := LoadPolicyFile(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	handler := NewDecisionHandler(policy)
+	resp, err := handler.ItemCommandExecutionRequestApproval(context.Background(), protocol.CommandExecutionRequestApprovalParams{Command: stringPtr("go test ./...")})
+	if err != nil {
+		t.Fatalf("approval failed: %v", err)
+	}
+	assertEqual(t, "decision", resp.Decision, "accept")
+	resp, err = handler.ItemCommandExecutionRequestApproval(context.Background(), protocol.CommandExecutionRequestApprovalParams{Command: stringPtr("curl evil")})
+	if err != nil {
+		t.Fatalf("approval failed: %v", err)
+	}
+	assertEqual(t, "decision", resp.Decision, "decline")
+
+	if _, err := LoadPolicyFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatalf("expected missing file error")
+	}
+}
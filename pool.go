@@ -0,0 +1,339 @@
+package codex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const defaultPoolRestartBackoff = time.Second
+
+// ErrNoInstance is returned by Pool when no app-server instance is
+// connected, for example while every instance is being replaced.
+var ErrNoInstance = errors.New("no app-server instance is available")
+
+// PoolOptions configures NewPool.
+type PoolOptions struct {
+	// Size is the number of app-server instances kept running (defaults
+	// to 1).
+	Size int
+	// Options configures each instance, as for New. Options.Transport must
+	// be nil, since every instance needs a connection of its own; use New
+	// to create instances in other ways.
+	Options Options
+	// New, when set, creates each instance instead of New(ctx, Options),
+	// for example to dial app-servers running elsewhere.
+	New func(ctx context.Context) (*Codex, error)
+	// RestartBackoff is the delay before replacing an instance that died
+	// or failed to start (defaults to 1s).
+	RestartBackoff time.Duration
+}
+
+// Pool keeps a fixed number of warm app-server instances for backends that
+// serve many concurrent conversations. Threads are checked out to the
+// instance with the fewest checked-out threads and must be released when
+// the conversation ends. An instance whose connection ends is replaced in
+// the background; threads checked out to it fail their calls and should be
+// released and resumed.
+type Pool struct {
+	create  func(ctx context.Context) (*Codex, error)
+	backoff time.Duration
+
+	lifecycle context.Context
+	cancel    context.CancelFunc
+	watchers  sync.WaitGroup
+
+	mu        sync.Mutex
+	slots     []*poolInstance
+	closed    bool
+	checkouts int64
+	restarts  int64
+	failures  int64
+}
+
+// poolInstance is one connected app-server and its load.
+type poolInstance struct {
+	codex   *Codex
+	threads int
+}
+
+// PoolStats is a snapshot of pool measurements.
+type PoolStats struct {
+	// Size is the configured number of instances and Live the number
+	// currently connected.
+	Size int
+	Live int
+	// Threads is the number of threads checked out, and Load the number
+	// per instance slot, in slot order. A slot whose instance is being
+	// replaced reports zero.
+	Threads int
+	Load    []int
+	// Checkouts counts the threads checked out since the pool started.
+	Checkouts int64
+	// Restarts counts instances replaced after their connection ended,
+	// and StartFailures the failed attempts to create a replacement.
+	Restarts      int64
+	StartFailures int64
+}
+
+// NewPool starts Size instances and returns once all are initialized. If
+// any fails to start, the others are closed and the error is returned.
+func NewPool(ctx context.Context, opts PoolOptions) (*Pool, error) {
+	size := opts.Size
+	if size <= 0 {
+		size = 1
+	}
+	create := opts.New
+	if create == nil {
+		if opts.Options.Transport != nil {
+			return nil, errors.New("pool instances cannot share Options.Transport; set PoolOptions.New instead")
+		}
+		instanceOptions := opts.Options
+		create = func(ctx context.Context) (*Codex, error) {
+			return New(ctx, instanceOptions)
+		}
+	}
+	backoff := opts.RestartBackoff
+	if backoff <= 0 {
+		backoff = defaultPoolRestartBackoff
+	}
+
+	instances := make([]*Codex, size)
+	errs := make([]error, size)
+	var started sync.WaitGroup
+	for i := range instances {
+		started.Add(1)
+		go func() {
+			defer started.Done()
+			instances[i], errs[i] = create(ctx)
+		}()
+	}
+	started.Wait()
+	if err := errors.Join(errs...); err != nil {
+		for _, instance := range instances {
+			if instance != nil {
+				_ = instance.Close()
+			}
+		}
+		return nil, fmt.Errorf("start pool: %w", err)
+	}
+
+	p := &Pool{create: create, backoff: backoff, slots: make([]*poolInstance, size)}
+	p.lifecycle, p.cancel = context.WithCancel(context.Background())
+	for i, instance := range instances {
+		p.slots[i] = &poolInstance{codex: instance}
+		p.watchers.Add(1)
+		go p.watch(i)
+	}
+	return p, nil
+}
+
+// StartThread starts a thread on the least-loaded instance and checks it
+// out.
+func (p *Pool) StartThread(ctx context.Context, options ThreadStartOptions) (*PoolThread, error) {
+	return p.checkout(func(c *Codex) (*Thread, error) {
+		return c.StartThread(ctx, options)
+	})
+}
+
+// ResumeThread resumes a thread on the least-loaded instance and checks it
+// out. Use it to move a thread whose instance died to a live one.
+func (p *Pool) ResumeThread(ctx context.Context, options ThreadResumeOptions) (*PoolThread, error) {
+	return p.checkout(func(c *Codex) (*Thread, error) {
+		return c.ResumeThread(ctx, options)
+	})
+}
+
+func (p *Pool) checkout(open func(*Codex) (*Thread, error)) (*PoolThread, error) {
+	instance, err := p.acquire()
+	if err != nil {
+		return nil, err
+	}
+	thread, err := open(instance.codex)
+	if err != nil {
+		p.release(instance)
+		return nil, err
+	}
+	return &PoolThread{Thread: thread, pool: p, instance: instance}, nil
+}
+
+// acquire reserves a thread on the least-loaded live instance. The load is
+// counted before the thread is opened, so concurrent checkouts spread out.
+func (p *Pool) acquire() (*poolInstance, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, errClosed
+	}
+	var best *poolInstance
+	for _, instance := range p.slots {
+		if instance == nil || instance.codex.Err() != nil {
+			continue
+		}
+		if best == nil || instance.threads < best.threads {
+			best = instance
+		}
+	}
+	if best == nil {
+		return nil, ErrNoInstance
+	}
+	best.threads++
+	p.checkouts++
+	return best, nil
+}
+
+func (p *Pool) release(instance *poolInstance) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	instance.threads--
+}
+
+// watch replaces the instance in slot i whenever its connection ends,
+// until the pool is closed.
+func (p *Pool) watch(i int) {
+	defer p.watchers.Done()
+	for {
+		p.mu.Lock()
+		dead := p.slots[i].codex
+		p.mu.Unlock()
+		select {
+		case <-dead.Done():
+		case <-p.lifecycle.Done():
+			return
+		}
+		p.mu.Lock()
+		if p.closed {
+			// Close owns the instances from here on.
+			p.mu.Unlock()
+			return
+		}
+		p.slots[i] = nil
+		p.mu.Unlock()
+		dead.logger.Warn("codex pool instance died", "slot", i, "error", dead.Err())
+		_ = dead.Close()
+
+		replacement, ok := p.replace(i)
+		if !ok {
+			return
+		}
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			_ = replacement.Close()
+			return
+		}
+		p.slots[i] = &poolInstance{codex: replacement}
+		p.restarts++
+		p.mu.Unlock()
+	}
+}
+
+// replace creates an instance for slot i, retrying after RestartBackoff.
+// It reports false once the pool is closed.
+func (p *Pool) replace(i int) (*Codex, bool) {
+	for {
+		timer := time.NewTimer(p.backoff)
+		select {
+		case <-timer.C:
+		case <-p.lifecycle.Done():
+			timer.Stop()
+			return nil, false
+		}
+		replacement, err := p.create(p.lifecycle)
+		if err == nil {
+			return replacement, true
+		}
+		if p.lifecycle.Err() != nil {
+			return nil, false
+		}
+		p.mu.Lock()
+		p.failures++
+		p.mu.Unlock()
+	}
+}
+
+// Stats returns a snapshot of pool measurements.
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := PoolStats{
+		Size:          len(p.slots),
+		Load:          make([]int, len(p.slots)),
+		Checkouts:     p.checkouts,
+		Restarts:      p.restarts,
+		StartFailures: p.failures,
+	}
+	for i, instance := range p.slots {
+		if instance == nil {
+			continue
+		}
+		if instance.codex.Err() == nil {
+			stats.Live++
+		}
+		stats.Load[i] = instance.threads
+		stats.Threads += instance.threads
+	}
+	return stats
+}
+
+// Close closes every instance immediately and stops replacing them.
+func (p *Pool) Close() error {
+	return p.close(func(c *Codex) error { return c.Close() })
+}
+
+// CloseContext shuts every instance down gracefully, as Codex.CloseContext
+// does, and stops replacing them. Errors from every instance are joined.
+func (p *Pool) CloseContext(ctx context.Context) error {
+	return p.close(func(c *Codex) error { return c.CloseContext(ctx) })
+}
+
+func (p *Pool) close(closeInstance func(*Codex) error) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.cancel()
+	instances := make([]*Codex, 0, len(p.slots))
+	for _, instance := range p.slots {
+		if instance != nil {
+			instances = append(instances, instance.codex)
+		}
+	}
+	p.mu.Unlock()
+	p.watchers.Wait()
+
+	errs := make([]error, len(instances))
+	var closing sync.WaitGroup
+	for i, instance := range instances {
+		closing.Add(1)
+		go func() {
+			defer closing.Done()
+			errs[i] = closeInstance(instance)
+		}()
+	}
+	closing.Wait()
+	return errors.Join(errs...)
+}
+
+// PoolThread is a thread checked out from a Pool. Release returns it when
+// the conversation ends.
+type PoolThread struct {
+	*Thread
+	pool     *Pool
+	instance *poolInstance
+	once     sync.Once
+}
+
+// Release checks the thread back in, so its instance counts one less
+// thread and no longer resumes it on reconnect. It does not end the thread
+// on the server. Calling it again has no effect.
+func (t *PoolThread) Release() {
+	t.once.Do(func() {
+		t.instance.codex.untrackThread(t.ID())
+		t.pool.release(t.instance)
+	})
+}
@@ -0,0 +1,214 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+// poolServer is an in-process app-server that answers initialize and
+// thread/start.
+type poolServer struct {
+	name   string
+	server *rpc.ConnTransport
+	// methods receives the method of every request, when there is room.
+	methods chan string
+}
+
+func newPoolServer(name string) (*poolServer, rpc.Transport) {
+	client, server := rpc.NewPipeTransports()
+	return startPoolServer(name, server), client
+}
+
+// startPoolServer serves one client connected through server.
+func startPoolServer(name string, server *rpc.ConnTransport) *poolServer {
+	s := &poolServer{name: name, server: server, methods: make(chan string, 16)}
+	go s.serve()
+	return s
+}
+
+func (s *poolServer) serve() {
+	threads := 0
+	for {
+		line, err := s.server.ReadLine()
+		if err != nil {
+			return
+		}
+		var request rpc.JSONRPCRequest
+		if err := json.Unmarshal([]byte(line), &request); err != nil || request.ID.IsZero() {
+			continue
+		}
+		select {
+		case s.methods <- request.Method:
+		default:
+		}
+		result := map[string]any{}
+		switch request.Method {
+		case "thread/start":
+			threads++
+			result["thread"] = map[string]any{"id": fmt.Sprintf("%s-%d", s.name, threads)}
+		case "thread/resume":
+			var params struct {
+				ThreadID string `json:"threadId"`
+			}
+			_ = json.Unmarshal(request.Params, &params)
+			result["thread"] = map[string]any{"id": params.ThreadID}
+		}
+		_ = s.server.WriteLine(string(mustRaw(rpc.JSONRPCResponse{ID: request.ID, Result: mustRaw(result)})))
+	}
+}
+
+// kill ends the connection, the way a crashed app-server would.
+func (s *poolServer) kill() { _ = s.server.Close() }
+
+type poolServers struct {
+	created atomic.Int64
+	servers chan *poolServer
+	fail    atomic.Bool
+}
+
+func (p *poolServers) new(ctx context.Context) (*Codex, error) {
+	if p.fail.Load() {
+		return nil, errors.New("spawn failed")
+	}
+	server, transport := newPoolServer(fmt.Sprintf("i%d", p.created.Add(1)))
+	p.servers <- server
+	return New(ctx, Options{Transport: transport})
+}
+
+func TestPoolChecksOutToLeastLoadedInstance(t *testing.T) {
+	servers := &poolServers{servers: make(chan *poolServer, 8)}
+	pool, err := NewPool(context.Background(), PoolOptions{Size: 2, New: servers.new})
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	defer pool.Close()
+
+	ctx := context.Background()
+	var threads []*PoolThread
+	for range 3 {
+		thread, err := pool.StartThread(ctx, ThreadStartOptions{})
+		if err != nil {
+			t.Fatalf("StartThread: %v", err)
+		}
+		threads = append(threads, thread)
+	}
+	stats := pool.Stats()
+	load := slices.Clone(stats.Load)
+	slices.Sort(load)
+	if stats.Live != 2 || stats.Threads != 3 || stats.Checkouts != 3 || !slices.Equal(load, []int{1, 2}) {
+		t.Fatalf("stats = %+v", stats)
+	}
+
+	// Releasing both threads of the busier instance makes it the
+	// least-loaded one.
+	busy := threads[0].instance
+	if threads[1].instance == busy {
+		t.Fatalf("second thread went to the busy instance")
+	}
+	threads[0].Release()
+	threads[0].Release()
+	threads[2].Release()
+	if threads[0].instance.codex.tracksThread(threads[0].ID()) {
+		t.Fatalf("released thread is still resumed on reconnect")
+	}
+	next, err := pool.StartThread(ctx, ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("StartThread: %v", err)
+	}
+	if next.instance != busy {
+		t.Fatalf("thread was not checked out to the least-loaded instance")
+	}
+	if got := pool.Stats().Threads; got != 2 {
+		t.Fatalf("Threads = %d, want 2", got)
+	}
+}
+
+func TestPoolReplacesDeadInstance(t *testing.T) {
+	servers := &poolServers{servers: make(chan *poolServer, 8)}
+	pool, err := NewPool(context.Background(), PoolOptions{Size: 1, New: servers.new, RestartBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	defer pool.Close()
+	first := <-servers.servers
+
+	thread, err := pool.StartThread(context.Background(), ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("StartThread: %v", err)
+	}
+	servers.fail.Store(true)
+	first.kill()
+	waitForCondition(t, func() bool { return pool.Stats().StartFailures > 0 })
+	if _, err := pool.StartThread(context.Background(), ThreadStartOptions{}); !errors.Is(err, ErrNoInstance) {
+		t.Fatalf("StartThread without instances = %v, want ErrNoInstance", err)
+	}
+	thread.Release()
+
+	servers.fail.Store(false)
+	waitForCondition(t, func() bool { return pool.Stats().Live == 1 })
+	replacement, err := pool.StartThread(context.Background(), ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("StartThread after restart: %v", err)
+	}
+	if replacement.ID() != "i2-1" {
+		t.Fatalf("thread id = %q, want one from the replacement", replacement.ID())
+	}
+	if stats := pool.Stats(); stats.Restarts != 1 || stats.Threads != 1 {
+		t.Fatalf("stats = %+v", stats)
+	}
+
+	if err := pool.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := pool.StartThread(context.Background(), ThreadStartOptions{}); err == nil {
+		t.Fatalf("StartThread after Close succeeded")
+	}
+}
+
+func TestNewPoolClosesStartedInstancesOnFailure(t *testing.T) {
+	servers := &poolServers{servers: make(chan *poolServer, 8)}
+	var (
+		mu      sync.Mutex
+		started []*Codex
+		calls   atomic.Int64
+	)
+	_, err := NewPool(context.Background(), PoolOptions{Size: 3, New: func(ctx context.Context) (*Codex, error) {
+		if calls.Add(1) == 2 {
+			return nil, errors.New("spawn failed")
+		}
+		instance, err := servers.new(ctx)
+		if err == nil {
+			mu.Lock()
+			started = append(started, instance)
+			mu.Unlock()
+		}
+		return instance, err
+	}})
+	if err == nil {
+		t.Fatalf("NewPool succeeded with a failing instance")
+	}
+	if len(started) != 2 {
+		t.Fatalf("started %d instances, want 2", len(started))
+	}
+	for _, instance := range started {
+		if instance.Err() == nil {
+			t.Fatalf("instance was left open")
+		}
+	}
+}
+
+func TestNewPoolRejectsSharedTransport(t *testing.T) {
+	client, _ := rpc.NewPipeTransports()
+	if _, err := NewPool(context.Background(), PoolOptions{Options: Options{Transport: client}}); err == nil {
+		t.Fatalf("NewPool accepted a shared transport")
+	}
+}
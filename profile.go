@@ -0,0 +1,51 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+// profileArgs returns the flags that select profile on the app-server
+// command line. The app-server has no --profile flag of its own, but the
+// top-level profile key of config.toml selects the active profile, and
+// --config overrides it.
+func profileArgs(profile string) []string {
+	return []string{"--config", "profile=" + strconv.Quote(profile)}
+}
+
+// checkProfile reports an error when the spawned app-server's configuration
+// defines no profile named SpawnOptions.Profile. The app-server parses
+// config.toml itself and returns it from config/read, so the check sees
+// exactly what codex does. When the configuration cannot be read it cannot
+// tell and returns nil, leaving the app-server to report the problem.
+func (c *Codex) checkProfile(ctx context.Context, client *rpc.Client) error {
+	if c.spawn == nil || c.spawn.Profile == "" {
+		return nil
+	}
+	var response struct {
+		Config json.RawMessage `json:"config"`
+	}
+	if err := client.Call(ctx, "config/read", protocol.ConfigReadParams{}, &response); err != nil {
+		c.logger.Warn("codex could not read config to check profile", "profile", c.spawn.Profile, "error", err)
+		return nil
+	}
+	config, err := decodeConfig(response.Config)
+	if err != nil {
+		return err
+	}
+	defined := config.Profiles()
+	if slices.Contains(defined, c.spawn.Profile) {
+		return nil
+	}
+	if len(defined) == 0 {
+		return fmt.Errorf("profile %q is not defined in the codex config, which defines no profiles", c.spawn.Profile)
+	}
+	return fmt.Errorf("profile %q is not defined in the codex config (defined: %s)", c.spawn.Profile, strings.Join(defined, ", "))
+}
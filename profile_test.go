@@ -0,0 +1,49 @@
+package codex
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestProfileArgs(t *testing.T) {
+	if got, want := profileArgs("work"), []string{"--config", `profile="work"`}; !slices.Equal(got, want) {
+		t.Fatalf("profileArgs = %q, want %q", got, want)
+	}
+}
+
+func TestNewChecksProfileAgainstServerConfig(t *testing.T) {
+	// The profiles come from config/read, so names the app-server's TOML
+	// parser accepts, such as quoted dotted keys, are found as written.
+	config := `{"profiles":{"work":{"model":"gpt-5-codex"},"team.alpha":{},"quick":{"model":"gpt-5-mini"}}}`
+	tests := []struct {
+		profile string
+		config  string
+		wantErr string
+	}{
+		{profile: "work", config: config},
+		{profile: "team.alpha", config: config},
+		{profile: "play", config: config, wantErr: `profile "play" is not defined in the codex config (defined: quick, team.alpha, work)`},
+		{profile: "play", config: `{"model":"gpt-5"}`, wantErr: "which defines no profiles"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			client, err := New(context.Background(), Options{Spawn: SpawnOptions{
+				CodexPath: writeFakeCodexBinary(t),
+				Profile:   tt.profile,
+				Env:       []string{"FAKE_CODEX_CONFIG=" + tt.config},
+			}})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("New: %v", err)
+				}
+				_ = client.Close()
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("New error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
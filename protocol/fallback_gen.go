@@ -38,6 +38,18 @@ type FuzzyFileSearchSessionCompletedNotification = SanitizedFuzzyFileSearchSessi
 type FuzzyFileSearchSessionUpdatedNotification = SanitizedFuzzyFileSearchSessionUpdatedNotificationJSON
 type GetAccountResponse = SanitizedGetAccountResponseJSON
 type GuardianWarningNotification = SanitizedGuardianWarningNotificationJSON
+type ListMcpServerStatusParams = SanitizedListMCPServerStatusParamsJSON
+type ListMcpServerStatusResponse = SanitizedListMCPServerStatusResponseJSON
+type McpResourceReadParams = SanitizedMCPResourceReadParamsJSON
+type McpResourceReadResponse = SanitizedMCPResourceReadResponseJSON
+type McpServerElicitationRequestResponse = SanitizedMCPServerElicitationRequestResponseJSON
+type McpServerOauthLoginCompletedNotification = SanitizedMCPServerOauthLoginCompletedNotificationJSON
+type McpServerOauthLoginParams = SanitizedMCPServerOauthLoginParamsJSON
+type McpServerOauthLoginResponse = SanitizedMCPServerOauthLoginResponseJSON
+type McpServerStatusUpdatedNotification = SanitizedMCPServerStatusUpdatedNotificationJSON
+type McpServerToolCallParams = SanitizedMCPServerToolCallParamsJSON
+type McpServerToolCallResponse = SanitizedMCPServerToolCallResponseJSON
+type McpToolCallProgressNotification = SanitizedMCPToolCallProgressNotificationJSON
 type ModelListResponse = SanitizedModelListResponseJSON
 type ModelReroutedNotification = SanitizedModelReroutedNotificationJSON
 type ModelVerificationNotification = SanitizedModelVerificationNotificationJSON
@@ -77,16 +89,13 @@ type WindowsWorldWritableWarningNotification = SanitizedWindowsWorldWritableWarn
 type ClientNotification interface{}
 type ClientRequest interface{}
 type CodexAppServerProtocolV2 interface{}
-type CommandExecOutputDeltaNotification interface{}
 type CommandExecResizeResponse interface{}
 type CommandExecTerminateResponse interface{}
 type CommandExecWriteResponse interface{}
 type ConfigReadResponse interface{}
 type ConfigWriteResponse interface{}
 type ExperimentalFeatureListResponse interface{}
-type ExternalAgentConfigImportCompletedNotification interface{}
 type ExternalAgentConfigImportResponse interface{}
-type FsChangedNotification interface{}
 type FsCopyResponse interface{}
 type FsCreateDirectoryResponse interface{}
 type FsRemoveResponse interface{}
@@ -94,52 +103,28 @@ type FsUnwatchResponse interface{}
 type FsWatchResponse interface{}
 type FsWriteFileResponse interface{}
 type GetAccountRateLimitsResponse interface{}
-type HookCompletedNotification interface{}
-type HookStartedNotification interface{}
 type InitializeResponse interface{}
-type ItemGuardianApprovalReviewCompletedNotification interface{}
-type ItemGuardianApprovalReviewStartedNotification interface{}
-type ItemStartedNotification interface{}
-type ListMcpServerStatusParams interface{}
-type ListMcpServerStatusResponse interface{}
 type LoginAccountResponse interface{}
 type LogoutAccountResponse interface{}
 type MarketplaceAddResponse interface{}
 type MarketplaceRemoveResponse interface{}
 type MarketplaceUpgradeResponse interface{}
-type McpResourceReadParams interface{}
-type McpResourceReadResponse interface{}
 type McpServerElicitationRequestParams interface{}
-type McpServerElicitationRequestResponse interface{}
-type McpServerOauthLoginCompletedNotification interface{}
-type McpServerOauthLoginParams interface{}
-type McpServerOauthLoginResponse interface{}
 type McpServerRefreshResponse interface{}
-type McpServerStatusUpdatedNotification interface{}
-type McpServerToolCallParams interface{}
-type McpServerToolCallResponse interface{}
-type McpToolCallProgressNotification interface{}
 type PluginListResponse interface{}
 type PluginReadResponse interface{}
 type PluginUninstallResponse interface{}
-type ReviewStartResponse interface{}
 type ServerNotification interface{}
 type ServerRequest interface{}
-type SkillsChangedNotification interface{}
 type SkillsListResponse interface{}
 type ThreadApproveGuardianDeniedActionResponse interface{}
-type ThreadArchiveResponse interface{}
 type ThreadCompactStartResponse interface{}
 type ThreadForkResponse interface{}
 type ThreadInjectItemsResponse interface{}
-type ThreadListResponse interface{}
 type ThreadMetadataUpdateResponse interface{}
 type ThreadReadResponse interface{}
 type ThreadRollbackResponse interface{}
 type ThreadSetNameResponse interface{}
 type ThreadShellCommandResponse interface{}
-type ThreadStartedNotification interface{}
 type ThreadTurnsListResponse interface{}
-type ThreadUnarchiveResponse interface{}
-type TurnInterruptResponse interface{}
 type TurnStartResponse interface{}
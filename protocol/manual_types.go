@@ -11,6 +11,8 @@ type Thread struct {
 type ThreadResponse struct {
 	ThreadID string  `json:"threadId,omitempty"`
 	Thread   *Thread `json:"thread,omitempty"`
+	// Cwd is the working directory the thread runs commands in.
+	Cwd string `json:"cwd,omitempty"`
 }
 
 // ThreadStartResponse is the response payload for thread/start.
@@ -19,6 +21,48 @@ type ThreadStartResponse = ThreadResponse
 // ThreadResumeResponse is the response payload for thread/resume.
 type ThreadResumeResponse = ThreadResponse
 
+// ThreadArchiveResponse is the response payload for thread/archive.
+type ThreadArchiveResponse struct{}
+
+// ThreadUnarchiveResponse is the response payload for thread/unarchive.
+type ThreadUnarchiveResponse struct {
+	// Thread describes the restored thread.
+	Thread *ThreadListEntry `json:"thread,omitempty"`
+}
+
+// ThreadListResponse is the response payload for thread/list.
+type ThreadListResponse struct {
+	Data []ThreadListEntry `json:"data"`
+	// NextCursor is passed as ThreadListParams.Cursor to fetch the next page;
+	// it is nil on the last page.
+	NextCursor *string `json:"nextCursor,omitempty"`
+}
+
+// ThreadListEntry describes a persisted thread in a thread/list response.
+type ThreadListEntry struct {
+	ID string `json:"id"`
+	// Name is the user-assigned title, if any.
+	Name *string `json:"name,omitempty"`
+	// Preview is the start of the first user message.
+	Preview       string `json:"preview,omitempty"`
+	ModelProvider string `json:"modelProvider,omitempty"`
+	// CreatedAt and UpdatedAt are Unix timestamps in seconds.
+	CreatedAt int64 `json:"createdAt,omitempty"`
+	UpdatedAt int64 `json:"updatedAt,omitempty"`
+	// Path is the rollout file on disk, when the thread is persisted.
+	Path       *string        `json:"path,omitempty"`
+	Cwd        string         `json:"cwd,omitempty"`
+	CliVersion string         `json:"cliVersion,omitempty"`
+	GitInfo    *ThreadGitInfo `json:"gitInfo,omitempty"`
+}
+
+// ThreadGitInfo is the git metadata recorded for a thread.
+type ThreadGitInfo struct {
+	Sha       *string `json:"sha,omitempty"`
+	Branch    *string `json:"branch,omitempty"`
+	OriginURL *string `json:"originUrl,omitempty"`
+}
+
 // TurnNotification describes turn/started and turn/completed notifications.
 type TurnNotification struct {
 	ThreadID string                `json:"threadId,omitempty"`
@@ -40,7 +84,57 @@ type TurnNotificationTurn struct {
 
 // TurnNotificationError describes a turn error payload.
 type TurnNotificationError struct {
-	Message string `json:"message,omitempty"`
+	Message           string  `json:"message,omitempty"`
+	AdditionalDetails *string `json:"additionalDetails,omitempty"`
+	// CodexErrorInfo is either a bare variant name such as
+	// "usageLimitExceeded" or an object keyed by the variant name, for
+	// example {"httpConnectionFailed": {"httpStatusCode": 502}}.
+	CodexErrorInfo any `json:"codexErrorInfo,omitempty"`
+}
+
+// TurnInterruptResponse is the response payload for turn/interrupt. The
+// interrupted turn reports its outcome in turn/completed.
+type TurnInterruptResponse struct{}
+
+// ReviewStartResponse is the response payload for review/start.
+type ReviewStartResponse struct {
+	Turn *TurnNotificationTurn `json:"turn,omitempty"`
+	// ReviewThreadID is the thread the review runs on: the reviewed thread
+	// for inline delivery, or a new thread for detached delivery.
+	ReviewThreadID string `json:"reviewThreadId,omitempty"`
+}
+
+// ReviewOutput is the structured result of a review, as the reviewer
+// model reports it.
+type ReviewOutput struct {
+	Findings           []ReviewFinding `json:"findings"`
+	OverallCorrectness string          `json:"overall_correctness,omitempty"`
+	OverallExplanation string          `json:"overall_explanation,omitempty"`
+	// OverallConfidenceScore is between 0 and 1.
+	OverallConfidenceScore float64 `json:"overall_confidence_score,omitempty"`
+}
+
+// ReviewFinding is one issue found by a review.
+type ReviewFinding struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// ConfidenceScore is between 0 and 1.
+	ConfidenceScore float64 `json:"confidence_score,omitempty"`
+	// Priority ranks the finding from 0 (most urgent) to 3.
+	Priority     *int               `json:"priority,omitempty"`
+	CodeLocation ReviewCodeLocation `json:"code_location"`
+}
+
+// ReviewCodeLocation locates a review finding in a file.
+type ReviewCodeLocation struct {
+	AbsoluteFilePath string          `json:"absolute_file_path"`
+	LineRange        ReviewLineRange `json:"line_range"`
+}
+
+// ReviewLineRange is an inclusive, 1-based range of lines.
+type ReviewLineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
 }
 
 // ItemCompletedNotification is the payload for item/completed.
@@ -49,6 +143,74 @@ type ItemCompletedNotification struct {
 	Item     json.RawMessage `json:"item,omitempty"`
 }
 
+// ItemStartedNotification is the payload for item/started.
+type ItemStartedNotification struct {
+	ThreadID string          `json:"threadId,omitempty"`
+	TurnID   string          `json:"turnId,omitempty"`
+	Item     json.RawMessage `json:"item,omitempty"`
+}
+
+// ThreadStartedNotification is the payload for thread/started.
+type ThreadStartedNotification struct {
+	Thread *ThreadListEntry `json:"thread,omitempty"`
+}
+
+// CommandExecOutputDeltaNotification is the payload for
+// command/exec/outputDelta, a chunk of output from a command started with
+// command/exec.
+type CommandExecOutputDeltaNotification struct {
+	ProcessID string `json:"processId,omitempty"`
+	// Stream is "stdout" or "stderr".
+	Stream string `json:"stream,omitempty"`
+	// DeltaBase64 is the chunk, base64-encoded because output may not be
+	// valid UTF-8.
+	DeltaBase64 string `json:"deltaBase64,omitempty"`
+	// CapReached reports that the output limit was hit and later output is
+	// dropped.
+	CapReached bool `json:"capReached,omitempty"`
+}
+
+// FsChangedNotification is the payload for fs/changed.
+type FsChangedNotification struct {
+	WatchID      string   `json:"watchId,omitempty"`
+	ChangedPaths []string `json:"changedPaths,omitempty"`
+}
+
+// HookStartedNotification is the payload for hook/started.
+type HookStartedNotification struct {
+	ThreadID string  `json:"threadId,omitempty"`
+	TurnID   *string `json:"turnId,omitempty"`
+	// Run describes the hook run.
+	Run json.RawMessage `json:"run,omitempty"`
+}
+
+// HookCompletedNotification is the payload for hook/completed.
+type HookCompletedNotification = HookStartedNotification
+
+// ItemGuardianApprovalReviewStartedNotification is the payload for
+// item/autoApprovalReview/started.
+type ItemGuardianApprovalReviewStartedNotification struct {
+	ThreadID string `json:"threadId,omitempty"`
+	TurnID   string `json:"turnId,omitempty"`
+	// TargetItemID is the item whose approval is being reviewed.
+	TargetItemID string `json:"targetItemId,omitempty"`
+	// Review is the state of the automatic review.
+	Review json.RawMessage `json:"review,omitempty"`
+}
+
+// ItemGuardianApprovalReviewCompletedNotification is the payload for
+// item/autoApprovalReview/completed.
+type ItemGuardianApprovalReviewCompletedNotification = ItemGuardianApprovalReviewStartedNotification
+
+// SkillsChangedNotification is the payload for skills/changed. It carries
+// no fields; clients list skills again.
+type SkillsChangedNotification struct{}
+
+// ExternalAgentConfigImportCompletedNotification is the payload for
+// externalAgentConfig/import/completed. It carries no fields the SDK
+// interprets; the raw params stay available on the notification.
+type ExternalAgentConfigImportCompletedNotification struct{}
+
 // ErrorNotification is the payload for error notifications.
 type ErrorNotification struct {
 	ThreadID  string                 `json:"threadId,omitempty"`
@@ -0,0 +1,208 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ThreadItem is one item of a thread, such as a message or a command run.
+// DecodeThreadItem picks the concrete type from the item's "type" field;
+// types the SDK does not know decode to *UnknownItem.
+type ThreadItem interface {
+	// ItemType returns the "type" discriminator, such as "agentMessage".
+	ItemType() string
+	// ItemID returns the item's id.
+	ItemID() string
+}
+
+// Thread item types.
+const (
+	ThreadItemTypeUserMessage       = "userMessage"
+	ThreadItemTypeAgentMessage      = "agentMessage"
+	ThreadItemTypeReasoning         = "reasoning"
+	ThreadItemTypeCommandExecution  = "commandExecution"
+	ThreadItemTypeFileChange        = "fileChange"
+	ThreadItemTypeMCPToolCall       = "mcpToolCall"
+	ThreadItemTypeWebSearch         = "webSearch"
+	ThreadItemTypeError             = "error"
+	ThreadItemTypeEnteredReviewMode = "enteredReviewMode"
+	ThreadItemTypeExitedReviewMode  = "exitedReviewMode"
+)
+
+// UserMessageItem is a message sent by the user.
+type UserMessageItem struct {
+	ID      string             `json:"id"`
+	Content []UserMessageInput `json:"content"`
+}
+
+// UserMessageInput is one part of a user message. Type is "text", "image",
+// "localImage", "skill" or "mention", and selects which other fields are
+// set.
+type UserMessageInput struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+	URL  string `json:"url,omitempty"`
+	Path string `json:"path,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// AgentMessageItem is a message from the agent.
+type AgentMessageItem struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// ReasoningItem holds the model's reasoning. Summary is what the model chose
+// to show; Content is the raw reasoning, when the provider returns it.
+type ReasoningItem struct {
+	ID      string   `json:"id"`
+	Summary []string `json:"summary,omitempty"`
+	Content []string `json:"content,omitempty"`
+}
+
+// CommandExecutionItem is a command run by the agent. Status is
+// "inProgress", "completed", "failed" or "declined".
+type CommandExecutionItem struct {
+	ID               string  `json:"id"`
+	Command          string  `json:"command"`
+	Cwd              string  `json:"cwd,omitempty"`
+	Status           string  `json:"status"`
+	AggregatedOutput *string `json:"aggregatedOutput,omitempty"`
+	ExitCode         *int    `json:"exitCode,omitempty"`
+	DurationMs       *int64  `json:"durationMs,omitempty"`
+}
+
+// FileChangeItem is a patch applied by the agent. Status is "inProgress",
+// "completed", "failed" or "declined".
+type FileChangeItem struct {
+	ID      string             `json:"id"`
+	Changes []FileUpdateChange `json:"changes"`
+	Status  string             `json:"status"`
+}
+
+// MCPToolCallItem is a call of an MCP server's tool. Status is
+// "inProgress", "completed" or "failed".
+type MCPToolCallItem struct {
+	ID        string          `json:"id"`
+	Server    string          `json:"server"`
+	Tool      string          `json:"tool"`
+	Status    string          `json:"status"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	// Result is the tool's result, with "content" and "structuredContent",
+	// once the call succeeded.
+	Result     json.RawMessage   `json:"result,omitempty"`
+	Error      *MCPToolCallError `json:"error,omitempty"`
+	DurationMs *int64            `json:"durationMs,omitempty"`
+}
+
+// MCPToolCallError describes a failed MCP tool call.
+type MCPToolCallError struct {
+	Message string `json:"message"`
+}
+
+// WebSearchItem is a web search made by the agent.
+type WebSearchItem struct {
+	ID    string `json:"id"`
+	Query string `json:"query"`
+}
+
+// ErrorItem is an error recorded in the thread.
+type ErrorItem struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+// EnteredReviewModeItem marks the start of a review; Review describes what
+// is reviewed.
+type EnteredReviewModeItem struct {
+	ID     string `json:"id"`
+	Review string `json:"review"`
+}
+
+// ExitedReviewModeItem marks the end of a review; Review is its result.
+type ExitedReviewModeItem struct {
+	ID     string `json:"id"`
+	Review string `json:"review"`
+}
+
+// UnknownItem is an item of a type the SDK does not decode. Raw holds its
+// JSON.
+type UnknownItem struct {
+	Type string
+	ID   string
+	Raw  json.RawMessage
+}
+
+func (i *UserMessageItem) ItemType() string       { return ThreadItemTypeUserMessage }
+func (i *AgentMessageItem) ItemType() string      { return ThreadItemTypeAgentMessage }
+func (i *ReasoningItem) ItemType() string         { return ThreadItemTypeReasoning }
+func (i *CommandExecutionItem) ItemType() string  { return ThreadItemTypeCommandExecution }
+func (i *FileChangeItem) ItemType() string        { return ThreadItemTypeFileChange }
+func (i *MCPToolCallItem) ItemType() string       { return ThreadItemTypeMCPToolCall }
+func (i *WebSearchItem) ItemType() string         { return ThreadItemTypeWebSearch }
+func (i *ErrorItem) ItemType() string             { return ThreadItemTypeError }
+func (i *EnteredReviewModeItem) ItemType() string { return ThreadItemTypeEnteredReviewMode }
+func (i *ExitedReviewModeItem) ItemType() string  { return ThreadItemTypeExitedReviewMode }
+func (i *UnknownItem) ItemType() string           { return i.Type }
+
+func (i *UserMessageItem) ItemID() string       { return i.ID }
+func (i *AgentMessageItem) ItemID() string      { return i.ID }
+func (i *ReasoningItem) ItemID() string         { return i.ID }
+func (i *CommandExecutionItem) ItemID() string  { return i.ID }
+func (i *FileChangeItem) ItemID() string        { return i.ID }
+func (i *MCPToolCallItem) ItemID() string       { return i.ID }
+func (i *WebSearchItem) ItemID() string         { return i.ID }
+func (i *ErrorItem) ItemID() string             { return i.ID }
+func (i *EnteredReviewModeItem) ItemID() string { return i.ID }
+func (i *ExitedReviewModeItem) ItemID() string  { return i.ID }
+func (i *UnknownItem) ItemID() string           { return i.ID }
+
+// DecodeThreadItem decodes a thread item by its "type" field.
+func DecodeThreadItem(data json.RawMessage) (ThreadItem, error) {
+	var header struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("decode thread item: %w", err)
+	}
+	var item ThreadItem
+	switch header.Type {
+	case ThreadItemTypeUserMessage:
+		item = &UserMessageItem{}
+	case ThreadItemTypeAgentMessage:
+		item = &AgentMessageItem{}
+	case ThreadItemTypeReasoning:
+		item = &ReasoningItem{}
+	case ThreadItemTypeCommandExecution:
+		item = &CommandExecutionItem{}
+	case ThreadItemTypeFileChange:
+		item = &FileChangeItem{}
+	case ThreadItemTypeMCPToolCall:
+		item = &MCPToolCallItem{}
+	case ThreadItemTypeWebSearch:
+		item = &WebSearchItem{}
+	case ThreadItemTypeError:
+		item = &ErrorItem{}
+	case ThreadItemTypeEnteredReviewMode:
+		item = &EnteredReviewModeItem{}
+	case ThreadItemTypeExitedReviewMode:
+		item = &ExitedReviewModeItem{}
+	default:
+		return &UnknownItem{Type: header.Type, ID: header.ID, Raw: data}, nil
+	}
+	if err := json.Unmarshal(data, item); err != nil {
+		return nil, fmt.Errorf("decode %s item: %w", header.Type, err)
+	}
+	return item, nil
+}
+
+// DecodeItem decodes the notification's item.
+func (n ItemStartedNotification) DecodeItem() (ThreadItem, error) {
+	return DecodeThreadItem(n.Item)
+}
+
+// DecodeItem decodes the notification's item.
+func (n ItemCompletedNotification) DecodeItem() (ThreadItem, error) {
+	return DecodeThreadItem(n.Item)
+}
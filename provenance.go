@@ -0,0 +1,136 @@
+package codex
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Provenance identifies the codex binary that served a turn, so pipelines
+// can verify which agent build produced a change.
+type Provenance struct {
+	// Path is the absolute path of the binary with symlinks resolved.
+	Path string `json:"path"`
+	// Version is the trimmed output of "codex --version".
+	Version string `json:"version"`
+	// SHA256 is the hex-encoded digest of the binary's contents.
+	SHA256 string `json:"sha256"`
+}
+
+// ResolveProvenance locates the codex binary (searching PATH when codexPath
+// has no path separator), hashes its contents and asks it for its version.
+func ResolveProvenance(ctx context.Context, codexPath string) (*Provenance, error) {
+	return resolveProvenance(ctx, SpawnOptions{CodexPath: codexPath})
+}
+
+// resolveProvenance is ResolveProvenance for the binary spawn runs. A
+// relative CodexPath resolves against spawn.Dir, as it does for the spawned
+// process, a bare name is looked up in the PATH of the spawn environment,
+// and --version runs with that environment and directory.
+func resolveProvenance(ctx context.Context, spawn SpawnOptions) (*Provenance, error) {
+	path, err := spawn.lookBinary()
+	if err != nil {
+		return nil, err
+	}
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return nil, err
+	}
+
+	digest, err := hashFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("hash %s: %w", path, err)
+	}
+	cmd := exec.CommandContext(ctx, path, "--version")
+	cmd.Env = spawn.environ()
+	cmd.Dir = spawn.Dir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s --version: %w", path, err)
+	}
+	return &Provenance{Path: path, Version: strings.TrimSpace(string(output)), SHA256: digest}, nil
+}
+
+// lookBinary returns the absolute path of CodexPath as the spawned process
+// sees it.
+func (s SpawnOptions) lookBinary() (string, error) {
+	name := s.CodexPath
+	if strings.ContainsRune(name, '/') || strings.ContainsRune(name, filepath.Separator) {
+		return exec.LookPath(s.absPath(name))
+	}
+	for _, dir := range filepath.SplitList(s.getenv("PATH")) {
+		if dir == "" {
+			dir = "."
+		}
+		if path, err := exec.LookPath(s.absPath(filepath.Join(dir, name))); err == nil {
+			return path, nil
+		}
+	}
+	return "", &exec.Error{Name: name, Err: exec.ErrNotFound}
+}
+
+func (s SpawnOptions) absPath(path string) string {
+	if !filepath.IsAbs(path) && s.Dir != "" {
+		path = filepath.Join(s.Dir, path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// getenv returns key from the spawn environment.
+func (s SpawnOptions) getenv(key string) string {
+	env := s.environ()
+	if env == nil {
+		return os.Getenv(key)
+	}
+	value := ""
+	for _, entry := range env {
+		name, v, ok := strings.Cut(entry, "=")
+		if ok && (name == key || runtime.GOOS == "windows" && strings.EqualFold(name, key)) {
+			value = v
+		}
+	}
+	return value
+}
+
+// withProvenance points CodexPath at the binary provenance recorded, so the
+// process that starts is the one that was hashed.
+func (s SpawnOptions) withProvenance(provenance *Provenance) SpawnOptions {
+	if provenance != nil {
+		s.CodexPath = provenance.Path
+	}
+	return s
+}
+
+func (c *Codex) setProvenance(provenance *Provenance) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.provenance = provenance
+}
+
+func (c *Codex) currentProvenance() *Provenance {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.provenance
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
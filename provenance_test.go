@@ -0,0 +1,116 @@
+package codex
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestResolveProvenance(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("spawn script test is unix-only")
+	}
+	path := writeFakeCodexBinary(t)
+	link := filepath.Join(t.TempDir(), "codex")
+	if err := os.Symlink(path, link); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	provenance, err := ResolveProvenance(context.Background(), link)
+	if err != nil {
+		t.Fatalf("resolve provenance: %v", err)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		t.Fatalf("eval symlinks: %v", err)
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read binary: %v", err)
+	}
+	sum := sha256.Sum256(contents)
+	want := Provenance{Path: resolved, Version: "codex-cli 0.0.0-test", SHA256: hex.EncodeToString(sum[:])}
+	if *provenance != want {
+		t.Fatalf("unexpected provenance: %+v, want %+v", *provenance, want)
+	}
+}
+
+func TestResolveProvenanceMissingBinary(t *testing.T) {
+	if _, err := ResolveProvenance(context.Background(), filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatalf("expected error for missing binary")
+	}
+}
+
+func TestNewResolvesProvenanceLikeTheSpawn(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("spawn script test is unix-only")
+	}
+	binary := writeFakeCodexBinary(t)
+	dir := filepath.Dir(binary)
+	resolved, err := filepath.EvalSymlinks(binary)
+	if err != nil {
+		t.Fatalf("eval symlinks: %v", err)
+	}
+	tests := []struct {
+		name  string
+		spawn SpawnOptions
+	}{
+		{name: "relative path", spawn: SpawnOptions{CodexPath: "./" + filepath.Base(binary), Dir: dir}},
+		{name: "bare name", spawn: SpawnOptions{CodexPath: filepath.Base(binary), Env: []string{"PATH=" + dir + string(filepath.ListSeparator) + os.Getenv("PATH")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spawn := tt.spawn
+			spawn.RecordProvenance = true
+			spawn.Env = append(spawn.Env, "FAKE_CODEX_VERSION=1.2.3")
+			client, err := New(context.Background(), Options{Spawn: spawn})
+			if err != nil {
+				t.Fatalf("new client error: %v", err)
+			}
+			defer client.Close()
+
+			provenance := client.currentProvenance()
+			if provenance == nil || provenance.Path != resolved || provenance.Version != "codex-cli 1.2.3" {
+				t.Fatalf("unexpected provenance: %+v", provenance)
+			}
+		})
+	}
+}
+
+func TestRunAttachesProvenance(t *testing.T) {
+	client, _ := newRepairThread(t, []repairTurn{{prompt: "hi", response: "done"}})
+	defer client.Close()
+	provenance := &Provenance{Path: "/bin/codex", Version: "codex-cli 1.0.0", SHA256: "abc"}
+	client.setProvenance(provenance)
+	thread := &Thread{client: client.Client(), owner: client, id: "thr_123"}
+
+	result, err := thread.Run(context.Background(), "hi", nil)
+	if err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	if result.Provenance != provenance {
+		t.Fatalf("expected provenance on result, got %+v", result.Provenance)
+	}
+}
+
+func TestNewRecordsSpawnProvenance(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("spawn script test is unix-only")
+	}
+	client, err := New(context.Background(), Options{
+		Spawn: SpawnOptions{CodexPath: writeFakeCodexBinary(t), RecordProvenance: true},
+	})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	defer client.Close()
+
+	provenance := client.currentProvenance()
+	if provenance == nil || provenance.Version != "codex-cli 0.0.0-test" || provenance.SHA256 == "" {
+		t.Fatalf("unexpected provenance: %+v", provenance)
+	}
+}
@@ -0,0 +1,315 @@
+package codex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+const (
+	defaultReconnectMaxAttempts    = 5
+	defaultReconnectInitialBackoff = 200 * time.Millisecond
+	defaultReconnectMaxBackoff     = 10 * time.Second
+)
+
+var errClosed = errors.New("codex client is closed")
+
+// ReconnectPolicy configures automatic reconnection. After the connection
+// is lost, Codex opens a new transport, repeats the initialize handshake and
+// resumes every thread it started or resumed. Running TurnStreams re-attach
+// to their turns.
+type ReconnectPolicy struct {
+	// MaxAttempts bounds the consecutive attempts after one loss (defaults
+	// to 5). When they are exhausted, Codex.Done is closed.
+	MaxAttempts int
+	// InitialBackoff is the delay after the first failed attempt (defaults to
+	// 200ms). Each later attempt doubles the delay.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts (defaults to 10s).
+	MaxBackoff time.Duration
+	// RetryCalls re-sends facade requests (thread/start, thread/resume and
+	// turn/start) that failed because the connection dropped, once it is
+	// re-established. When false they fail with the transport error. A
+	// request may have reached the server before the loss, so a retried
+	// turn/start can start a second turn.
+	RetryCalls bool
+	// SkipResume leaves the threads the client had open detached on the new
+	// connection instead of sending thread/resume for each.
+	SkipResume bool
+	// OnStateChange, when set, is called when the connection is lost, after
+	// each failed attempt, when a replacement is connected and when
+	// reconnecting gives up. It runs on the reconnecting goroutine and
+	// should return quickly.
+	OnStateChange func(ReconnectEvent)
+}
+
+// ReconnectEvent reports progress of automatic reconnection.
+type ReconnectEvent struct {
+	// State is rpc.StateDisconnected when the connection is lost or an
+	// attempt fails, rpc.StateConnected once a replacement is initialized
+	// and rpc.StateFailed when reconnecting gives up.
+	State rpc.ConnectionState
+	// Attempt counts attempts since the loss; it is zero for the loss
+	// itself.
+	Attempt int
+	// Err is the error that ended the connection or failed the attempt.
+	Err error
+	// ResumedThreads lists the threads resumed on the new connection, for
+	// rpc.StateConnected.
+	ResumedThreads []string
+}
+
+func (p *ReconnectPolicy) report(event ReconnectEvent) {
+	if p != nil && p.OnStateChange != nil {
+		p.OnStateChange(event)
+	}
+}
+
+func (p *ReconnectPolicy) normalized() *ReconnectPolicy {
+	if p == nil {
+		return nil
+	}
+	out := *p
+	if out.MaxAttempts <= 0 {
+		out.MaxAttempts = defaultReconnectMaxAttempts
+	}
+	if out.InitialBackoff <= 0 {
+		out.InitialBackoff = defaultReconnectInitialBackoff
+	}
+	if out.MaxBackoff <= 0 {
+		out.MaxBackoff = defaultReconnectMaxBackoff
+	}
+	return &out
+}
+
+// backoff returns the delay after the given failed attempt (1-based).
+func (p *ReconnectPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxBackoff)
+}
+
+// supervise waits for each connection to end and replaces it, until the
+// client is closed or reconnecting gives up. A connection stopped by
+// Options.IdleShutdown is left alone until the next operation replaces it.
+// Without a reconnect policy, any other loss ends supervision.
+func (c *Codex) supervise() {
+	defer close(c.done)
+	for {
+		client := c.currentClient()
+		select {
+		case <-client.Done():
+		case <-c.lifecycle.Done():
+			return
+		}
+		if c.isClosed() {
+			return
+		}
+		if woken, ok := c.stoppedForIdle(client); ok {
+			select {
+			case <-woken:
+			case <-c.lifecycle.Done():
+				return
+			}
+			continue
+		}
+		if c.reconnectPolicy == nil {
+			return
+		}
+		c.reconnectPolicy.report(ReconnectEvent{State: rpc.StateDisconnected, Err: client.Err()})
+		if _, err := c.reconnectWithBackoff(c.lifecycle, client); err != nil {
+			if !c.isClosed() {
+				c.logger.Error("codex reconnect failed", "error", err)
+			}
+			return
+		}
+	}
+}
+
+// awaitReconnect returns a live replacement for stale, retrying with the
+// reconnect policy's backoff when one is configured.
+func (c *Codex) awaitReconnect(ctx context.Context, stale *rpc.Client) (*rpc.Client, error) {
+	if c.reconnectPolicy == nil {
+		return c.reconnect(ctx, stale, 1)
+	}
+	return c.reconnectWithBackoff(ctx, stale)
+}
+
+func (c *Codex) reconnectWithBackoff(ctx context.Context, stale *rpc.Client) (*rpc.Client, error) {
+	policy := c.reconnectPolicy
+	for attempt := 1; ; attempt++ {
+		client, err := c.reconnect(ctx, stale, attempt)
+		if err == nil {
+			return client, nil
+		}
+		if errors.Is(err, errClosed) {
+			return nil, fmt.Errorf("reconnect failed after %d attempts: %w", attempt, err)
+		}
+		if attempt >= policy.MaxAttempts {
+			policy.report(ReconnectEvent{State: rpc.StateFailed, Attempt: attempt, Err: err})
+			return nil, fmt.Errorf("reconnect failed after %d attempts: %w", attempt, err)
+		}
+		policy.report(ReconnectEvent{State: rpc.StateDisconnected, Attempt: attempt, Err: err})
+		delay := policy.backoff(attempt)
+		c.logger.Warn("codex reconnect attempt failed", "attempt", attempt, "delay", delay, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reconnect replaces stale with a freshly dialed and initialized client and
+// resumes the known threads on it. Callers that lost the same connection
+// share one redial: if another caller already replaced stale, its
+// replacement is returned. attempt is reported to OnStateChange.
+func (c *Codex) reconnect(ctx context.Context, stale *rpc.Client, attempt int) (*rpc.Client, error) {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+
+	c.mu.Lock()
+	current, closed := c.client, c.closed
+	c.mu.Unlock()
+	if closed {
+		return nil, errClosed
+	}
+	if current != stale {
+		return current, nil
+	}
+	if c.redial == nil {
+		return nil, errors.New("reconnect is not configured")
+	}
+
+	c.logger.Info("codex reconnecting", "error", stale.Err())
+	transport, err := c.redial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("redial: %w", err)
+	}
+	options := c.clientOptions
+	if options.RequestIDPrefix != "" {
+		c.reconnects++
+		options.RequestIDPrefix = fmt.Sprintf("%s.%d", options.RequestIDPrefix, c.reconnects+1)
+	}
+	client, err := connect(ctx, transport, options, c.initParams, c.checkCompat)
+	if err != nil {
+		return nil, fmt.Errorf("reinitialize: %w", err)
+	}
+	if err := c.checkServerVersion(ctx, client); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("reinitialize: %w", err)
+	}
+	var resumed []string
+	if c.reconnectPolicy == nil || !c.reconnectPolicy.SkipResume {
+		for _, threadID := range c.knownThreads() {
+			if _, err := client.ThreadResume(ctx, protocol.ThreadResumeParams{ThreadID: threadID}); err != nil {
+				c.logger.Warn("codex thread resume failed after reconnect", "thread_id", threadID, "error", err)
+				continue
+			}
+			resumed = append(resumed, threadID)
+		}
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		_ = client.Close()
+		return nil, errClosed
+	}
+	c.client = client
+	c.mu.Unlock()
+	_ = stale.Close()
+	c.logger.Info("codex reconnected")
+	c.reconnectPolicy.report(ReconnectEvent{State: rpc.StateConnected, Attempt: attempt, ResumedThreads: resumed})
+	return client, nil
+}
+
+// call sends a facade request on the current client. When the connection
+// drops and the reconnect policy retries calls, it is re-sent once on the
+// replacement client. The client that answered is returned.
+func (c *Codex) call(ctx context.Context, method string, params any, result any) (*rpc.Client, error) {
+	client, release, err := c.acquireClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	err = client.Call(ctx, method, params, result)
+	if !c.retriesCall(ctx, client, err) {
+		return client, classifyError(client, method, err)
+	}
+	next, reconnectErr := c.awaitReconnect(ctx, client)
+	if reconnectErr != nil {
+		return client, errors.Join(err, reconnectErr)
+	}
+	c.logger.Info("codex retrying call after reconnect", "method", method)
+	return next, classifyError(next, method, next.Call(ctx, method, params, result))
+}
+
+// retriesCall reports whether a call that failed with err on client should be
+// re-sent after reconnecting.
+func (c *Codex) retriesCall(ctx context.Context, client *rpc.Client, err error) bool {
+	if err == nil || c.reconnectPolicy == nil || !c.reconnectPolicy.RetryCalls {
+		return false
+	}
+	return ctx.Err() == nil && client.Err() != nil
+}
+
+// markClosed records that the caller is closing the client so neither the
+// supervisor nor a TurnStream reconnects, and returns the client to close.
+func (c *Codex) markClosed() *rpc.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.cancel != nil {
+		c.cancel()
+	}
+	return c.client
+}
+
+func (c *Codex) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func (c *Codex) trackThread(threadID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.threads == nil {
+		c.threads = make(map[string]struct{})
+	}
+	c.threads[threadID] = struct{}{}
+}
+
+// untrackThread stops resuming threadID on reconnect, once the thread is
+// archived or released.
+func (c *Codex) untrackThread(threadID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.threads, threadID)
+}
+
+func (c *Codex) tracksThread(threadID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.threads[threadID]
+	return ok
+}
+
+func (c *Codex) knownThreads() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	threads := make([]string, 0, len(c.threads))
+	for threadID := range c.threads {
+		threads = append(threads, threadID)
+	}
+	return threads
+}
@@ -0,0 +1,310 @@
+package codex
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestReconnectResumesKnownThreads(t *testing.T) {
+	first := rpc.NewReplayTransport(append(initializeTranscript(),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(2), Method: "thread/start", Params: mustRaw(map[string]any{})}),
+		readLine(rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(2), Result: mustRaw(map[string]any{"thread": map[string]any{"id": "thr_123"}})}),
+	))
+	second := rpc.NewReplayTransport(append(initializeTranscript(),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(2), Method: "thread/resume", Params: mustRaw(map[string]any{"threadId": "thr_123"})}),
+		readLine(rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(2), Result: mustRaw(map[string]any{"thread": map[string]any{"id": "thr_123"}})}),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(3), Method: "thread/start", Params: mustRaw(map[string]any{})}),
+		readLine(rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(3), Result: mustRaw(map[string]any{"thread": map[string]any{"id": "thr_456"}})}),
+	))
+	client := newReconnectTestClient(t, first, &ReconnectPolicy{InitialBackoff: time.Millisecond}, second)
+
+	ctx := context.Background()
+	if _, err := client.StartThread(ctx, ThreadStartOptions{}); err != nil {
+		t.Fatalf("start thread error: %v", err)
+	}
+	stale := client.Client()
+	_ = first.Close()
+
+	waitForCondition(t, func() bool { return client.Client() != stale })
+	select {
+	case <-client.Done():
+		t.Fatalf("done closed after successful reconnect: %v", client.Err())
+	default:
+	}
+	thread, err := client.StartThread(ctx, ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("start thread after reconnect: %v", err)
+	}
+	if thread.ID() != "thr_456" {
+		t.Fatalf("unexpected thread id %q", thread.ID())
+	}
+}
+
+func TestReconnectReportsStateChanges(t *testing.T) {
+	first := rpc.NewReplayTransport(append(initializeTranscript(),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(2), Method: "thread/start", Params: mustRaw(map[string]any{})}),
+		readLine(rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(2), Result: mustRaw(map[string]any{"thread": map[string]any{"id": "thr_123"}})}),
+	))
+	second := rpc.NewReplayTransport(append(initializeTranscript(),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(2), Method: "thread/resume", Params: mustRaw(map[string]any{"threadId": "thr_123"})}),
+		readLine(rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(2), Result: mustRaw(map[string]any{"thread": map[string]any{"id": "thr_123"}})}),
+	))
+	events := make(chan ReconnectEvent, 8)
+	dials := 0
+	client, err := New(context.Background(), Options{
+		Transport: first,
+		Redial: func(context.Context) (rpc.Transport, error) {
+			dials++
+			if dials == 1 {
+				return nil, errors.New("app-server still starting")
+			}
+			return second, nil
+		},
+		Reconnect: &ReconnectPolicy{
+			InitialBackoff: time.Millisecond,
+			OnStateChange:  func(event ReconnectEvent) { events <- event },
+		},
+	})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	defer client.Close()
+	if _, err := client.StartThread(context.Background(), ThreadStartOptions{}); err != nil {
+		t.Fatalf("start thread error: %v", err)
+	}
+	_ = first.Close()
+
+	want := []ReconnectEvent{
+		{State: rpc.StateDisconnected},
+		{State: rpc.StateDisconnected, Attempt: 1},
+		{State: rpc.StateConnected, Attempt: 2, ResumedThreads: []string{"thr_123"}},
+	}
+	for i, w := range want {
+		var got ReconnectEvent
+		select {
+		case got = <-events:
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+		if got.State != w.State || got.Attempt != w.Attempt || !slices.Equal(got.ResumedThreads, w.ResumedThreads) {
+			t.Fatalf("event %d = %+v, want %+v", i, got, w)
+		}
+		if w.State == rpc.StateDisconnected && got.Err == nil {
+			t.Fatalf("event %d has no error", i)
+		}
+	}
+}
+
+func TestReconnectSkipResume(t *testing.T) {
+	first := rpc.NewReplayTransport(append(initializeTranscript(),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(2), Method: "thread/start", Params: mustRaw(map[string]any{})}),
+		readLine(rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(2), Result: mustRaw(map[string]any{"thread": map[string]any{"id": "thr_123"}})}),
+	))
+	second := rpc.NewReplayTransport(append(initializeTranscript(),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(2), Method: "thread/start", Params: mustRaw(map[string]any{})}),
+		readLine(rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(2), Result: mustRaw(map[string]any{"thread": map[string]any{"id": "thr_456"}})}),
+	))
+	client := newReconnectTestClient(t, first, &ReconnectPolicy{InitialBackoff: time.Millisecond, SkipResume: true}, second)
+
+	ctx := context.Background()
+	if _, err := client.StartThread(ctx, ThreadStartOptions{}); err != nil {
+		t.Fatalf("start thread error: %v", err)
+	}
+	stale := client.Client()
+	_ = first.Close()
+	waitForCondition(t, func() bool { return client.Client() != stale })
+
+	thread, err := client.StartThread(ctx, ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("start thread after reconnect: %v", err)
+	}
+	if thread.ID() != "thr_456" {
+		t.Fatalf("unexpected thread id %q", thread.ID())
+	}
+}
+
+func TestReconnectRequestIDPrefixChangesPerConnection(t *testing.T) {
+	prefixedInitialize := func(prefix string) []rpc.TranscriptEntry {
+		entries := initializeTranscript()
+		entries[0] = writeLine(rpc.JSONRPCRequest{
+			ID:     rpc.NewStringRequestID(prefix + ":1"),
+			Method: "initialize",
+			Params: mustRaw(protocol.InitializeParams{ClientInfo: defaultClientInfo()}),
+		})
+		entries[1] = readLine(rpc.JSONRPCResponse{ID: rpc.NewStringRequestID(prefix + ":1"), Result: mustRaw(map[string]any{})})
+		return entries
+	}
+	first := rpc.NewReplayTransport(prefixedInitialize("app"))
+	second := rpc.NewReplayTransport(append(prefixedInitialize("app.2"),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewStringRequestID("app.2:2"), Method: "thread/start", Params: mustRaw(map[string]any{})}),
+		readLine(rpc.JSONRPCResponse{ID: rpc.NewStringRequestID("app.2:2"), Result: mustRaw(map[string]any{"thread": map[string]any{"id": "thr_123"}})}),
+	))
+	client, err := New(context.Background(), Options{
+		Transport:       first,
+		Redial:          func(context.Context) (rpc.Transport, error) { return second, nil },
+		Reconnect:       &ReconnectPolicy{InitialBackoff: time.Millisecond},
+		RequestIDPrefix: "app",
+	})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	defer client.Close()
+
+	stale := client.Client()
+	_ = first.Close()
+	waitForCondition(t, func() bool { return client.Client() != stale })
+	if _, err := client.StartThread(context.Background(), ThreadStartOptions{}); err != nil {
+		t.Fatalf("start thread after reconnect: %v", err)
+	}
+}
+
+func TestReconnectRetriesCallsWhenPolicyAllows(t *testing.T) {
+	first := &dropAfterWriteTransport{
+		ReplayTransport: rpc.NewReplayTransport(append(initializeTranscript(),
+			writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(2), Method: "thread/start", Params: mustRaw(map[string]any{})}),
+		)),
+		method: "thread/start",
+	}
+	second := rpc.NewReplayTransport(append(initializeTranscript(),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(2), Method: "thread/start", Params: mustRaw(map[string]any{})}),
+		readLine(rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(2), Result: mustRaw(map[string]any{"thread": map[string]any{"id": "thr_123"}})}),
+	))
+	client := newReconnectTestClient(t, first, &ReconnectPolicy{InitialBackoff: time.Millisecond, RetryCalls: true}, second)
+
+	thread, err := client.StartThread(context.Background(), ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("expected retried start to succeed, got %v", err)
+	}
+	if thread.ID() != "thr_123" || thread.rpcClient() == first.client {
+		t.Fatalf("unexpected thread after retry: %q", thread.ID())
+	}
+}
+
+func TestReconnectFailsCallsByDefault(t *testing.T) {
+	first := &dropAfterWriteTransport{
+		ReplayTransport: rpc.NewReplayTransport(append(initializeTranscript(),
+			writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(2), Method: "thread/start", Params: mustRaw(map[string]any{})}),
+		)),
+		method: "thread/start",
+	}
+	second := rpc.NewReplayTransport(initializeTranscript())
+	client := newReconnectTestClient(t, first, &ReconnectPolicy{InitialBackoff: time.Millisecond}, second)
+
+	if _, err := client.StartThread(context.Background(), ThreadStartOptions{}); err == nil {
+		t.Fatalf("expected start to fail when the connection drops")
+	}
+}
+
+func TestReconnectGivesUpAfterMaxAttempts(t *testing.T) {
+	first := rpc.NewReplayTransport(initializeTranscript())
+	dials := 0
+	client, err := New(context.Background(), Options{
+		Transport: first,
+		Redial: func(context.Context) (rpc.Transport, error) {
+			dials++
+			return nil, errors.New("dial refused")
+		},
+		Reconnect: &ReconnectPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	defer client.Close()
+
+	_ = first.Close()
+	select {
+	case <-client.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for Done")
+	}
+	if client.Err() == nil {
+		t.Fatalf("expected error after giving up")
+	}
+	if dials != 2 {
+		t.Fatalf("expected 2 dial attempts, got %d", dials)
+	}
+}
+
+func TestReconnectRequiresRedialForCustomTransport(t *testing.T) {
+	_, err := New(context.Background(), Options{
+		Transport: rpc.NewReplayTransport(nil),
+		Reconnect: &ReconnectPolicy{},
+	})
+	if err == nil || !strings.Contains(err.Error(), "Redial") {
+		t.Fatalf("expected redial error, got %v", err)
+	}
+}
+
+func TestReconnectPolicyBackoff(t *testing.T) {
+	policy := (&ReconnectPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 30 * time.Millisecond}).normalized()
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: 10 * time.Millisecond},
+		{attempt: 2, want: 20 * time.Millisecond},
+		{attempt: 3, want: 30 * time.Millisecond},
+		{attempt: 8, want: 30 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := policy.backoff(tt.attempt); got != tt.want {
+			t.Fatalf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+	if policy.MaxAttempts != defaultReconnectMaxAttempts {
+		t.Fatalf("unexpected default max attempts %d", policy.MaxAttempts)
+	}
+}
+
+func newReconnectTestClient(t *testing.T, transport rpc.Transport, policy *ReconnectPolicy, next rpc.Transport) *Codex {
+	t.Helper()
+	client, err := New(context.Background(), Options{
+		Transport: transport,
+		Redial:    func(context.Context) (rpc.Transport, error) { return next, nil },
+		Reconnect: policy,
+	})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	if drop, ok := transport.(*dropAfterWriteTransport); ok {
+		drop.client = client.Client()
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// dropAfterWriteTransport closes the connection right after a request for
+// method is written, leaving the request unanswered.
+type dropAfterWriteTransport struct {
+	*rpc.ReplayTransport
+	method string
+	client *rpc.Client
+}
+
+func (t *dropAfterWriteTransport) WriteLine(line string) error {
+	if err := t.ReplayTransport.WriteLine(line); err != nil {
+		return err
+	}
+	if strings.Contains(line, `"method":"`+t.method+`"`) {
+		return t.ReplayTransport.Close()
+	}
+	return nil
+}
+
+func waitForCondition(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,52 @@
+package codex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrRepairExhausted is returned by RunUntil when the check never passes
+// within the allowed number of iterations.
+var ErrRepairExhausted = errors.New("repair loop exhausted")
+
+// RepairCheck inspects a completed turn. It reports whether the result is
+// acceptable and, if not, the feedback prompt to send on the next turn.
+type RepairCheck func(result *TurnResult) (ok bool, feedback string)
+
+// RunUntil runs prompt on thread and re-prompts with the check's feedback until
+// the check passes or maxIters turns have run. The last turn result is always
+// returned alongside ErrRepairExhausted so callers can inspect the final attempt.
+func RunUntil(ctx context.Context, thread *Thread, prompt string, check RepairCheck, maxIters int) (*TurnResult, error) {
+	if err := thread.ensureReady(); err != nil {
+		return nil, err
+	}
+	if check == nil {
+		return nil, errors.New("repair check is nil")
+	}
+	if maxIters <= 0 {
+		return nil, errors.New("max iterations must be positive")
+	}
+
+	logger := resolveLogger(thread.logger)
+	next := prompt
+	var result *TurnResult
+	for iter := 1; iter <= maxIters; iter++ {
+		var err error
+		result, err = thread.Run(ctx, next, nil)
+		if err != nil {
+			return result, err
+		}
+		ok, feedback := check(result)
+		if ok {
+			logger.Info("codex repair loop passed", "thread_id", thread.id, "iterations", iter)
+			return result, nil
+		}
+		if feedback == "" {
+			return result, fmt.Errorf("repair check failed without feedback on iteration %d", iter)
+		}
+		logger.Info("codex repair loop retrying", "thread_id", thread.id, "iteration", iter)
+		next = feedback
+	}
+	return result, fmt.Errorf("%w after %d iterations", ErrRepairExhausted, maxIters)
+}
@@ -0,0 +1,113 @@
+package codex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestRunUntilPassesAfterFeedback(t *testing.T) {
+	ctx := context.Background()
+	client, thread := newRepairThread(t, []repairTurn{
+		{prompt: "fix it", response: "still broken"},
+		{prompt: "tests fail", response: "fixed"},
+	})
+	defer client.Close()
+
+	var checks int
+	result, err := RunUntil(ctx, thread, "fix it", func(result *TurnResult) (bool, string) {
+		checks++
+		if result.FinalResponse == "fixed" {
+			return true, ""
+		}
+		return false, "tests fail"
+	}, 3)
+	if err != nil {
+		t.Fatalf("run until error: %v", err)
+	}
+	if checks != 2 {
+		t.Fatalf("expected 2 checks, got %d", checks)
+	}
+	if result.FinalResponse != "fixed" || result.TurnID != "turn_2" {
+		t.Fatalf("unexpected result: %#v", result)
+	}
+}
+
+func TestRunUntilExhausted(t *testing.T) {
+	ctx := context.Background()
+	client, thread := newRepairThread(t, []repairTurn{
+		{prompt: "fix it", response: "nope"},
+		{prompt: "try again", response: "still nope"},
+	})
+	defer client.Close()
+
+	result, err := RunUntil(ctx, thread, "fix it", func(*TurnResult) (bool, string) {
+		return false, "try again"
+	}, 2)
+	if !errors.Is(err, ErrRepairExhausted) {
+		t.Fatalf("expected exhausted error, got %v", err)
+	}
+	if result == nil || result.FinalResponse != "still nope" {
+		t.Fatalf("expected last result, got %#v", result)
+	}
+}
+
+func TestRunUntilRejectsInvalidArguments(t *testing.T) {
+	thread := &Thread{client: &rpc.Client{}, id: "thr_123"}
+	check := func(*TurnResult) (bool, string) { return true, "" }
+	if _, err := RunUntil(context.Background(), nil, "hi", check, 1); err == nil {
+		t.Fatalf("expected nil thread error")
+	}
+	if _, err := RunUntil(context.Background(), thread, "hi", nil, 1); err == nil {
+		t.Fatalf("expected nil check error")
+	}
+	if _, err := RunUntil(context.Background(), thread, "hi", check, 0); err == nil {
+		t.Fatalf("expected max iterations error")
+	}
+}
+
+type repairTurn struct {
+	prompt   string
+	response string
+}
+
+func newRepairThread(t *testing.T, turns []repairTurn) (*Codex, *Thread) {
+	t.Helper()
+	client, err := New(context.Background(), Options{Transport: rpc.NewReplayTransport(repairTranscript(turns))})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	return client, &Thread{client: client.Client(), id: "thr_123", logger: client.logger}
+}
+
+func repairTranscript(turns []repairTurn) []rpc.TranscriptEntry {
+	entries := initializeTranscript()
+	for i, turn := range turns {
+		id := int64(i + 2)
+		turnID := fmt.Sprintf("turn_%d", i+1)
+		entries = append(entries,
+			writeLine(rpc.JSONRPCRequest{
+				ID:     rpc.NewIntRequestID(id),
+				Method: "turn/start",
+				Params: mustRaw(turnStartParams(turn.prompt)),
+			}),
+			readLine(rpc.JSONRPCResponse{
+				ID:     rpc.NewIntRequestID(id),
+				Result: mustRaw(map[string]any{"turn": turnPayload(turnID, "inProgress")}),
+			}),
+			readLine(rpc.JSONRPCNotification{
+				Method: "item/completed",
+				Params: mustRaw(protocol.ItemCompletedNotification{ThreadID: "thr_123", Item: mustRaw(map[string]any{"text": turn.response})}),
+			}),
+			readLine(rpc.JSONRPCNotification{
+				Method: "turn/completed",
+				Params: mustRaw(map[string]any{"threadId": "thr_123", "turn": turnPayload(turnID, "completed")}),
+			}),
+		)
+	}
+	return entries
+}
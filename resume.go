@@ -0,0 +1,181 @@
+package codex
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+// canResume reports whether a failed Next should re-attach to the turn: the
+// turn must still be open, ctx still live, the connection actually lost,
+// and a redial configured.
+func (s *TurnStream) canResume(ctx context.Context) bool {
+	if s.finished || s.thread == nil || s.thread.owner == nil || s.thread.owner.redial == nil {
+		return false
+	}
+	return ctx.Err() == nil && s.client != nil && s.client.Err() != nil
+}
+
+// resume reconnects, resumes the thread and reads the turn's current state.
+// Items completed while disconnected are queued as item/completed
+// notifications; if the turn finished in the meantime a turn/completed
+// notification carrying its final status is queued as well. Otherwise the
+// stream keeps delivering live notifications from the new connection.
+func (s *TurnStream) resume(ctx context.Context) error {
+	owner := s.thread.owner
+	client, err := owner.awaitReconnect(ctx, s.client)
+	if err != nil {
+		return err
+	}
+	iter := client.SubscribeNotifications(0)
+	// Threads known to the owner were already resumed by reconnect.
+	if !owner.tracksThread(s.threadID) {
+		if _, err := client.ThreadResume(ctx, protocol.ThreadResumeParams{ThreadID: s.threadID}); err != nil {
+			iter.Close()
+			return fmt.Errorf("resume thread: %w", classifyError(client, "thread/resume", err))
+		}
+	}
+	var response json.RawMessage
+	if err := client.Call(ctx, "thread/read", protocol.ThreadReadParams{ThreadID: s.threadID, IncludeTurns: true}, &response); err != nil {
+		iter.Close()
+		return fmt.Errorf("read thread: %w", classifyError(client, "thread/read", err))
+	}
+	turn, err := findResumedTurn(response, s.turnID)
+	if err != nil {
+		iter.Close()
+		return err
+	}
+
+	var missed []rpc.Notification
+	for _, item := range turn.Items {
+		if id := itemID(item); id != "" && s.seenItems[id] {
+			continue
+		}
+		note, err := replayedNotification("item/completed", map[string]any{"threadId": s.threadID, "turnId": turn.ID, "item": item})
+		if err != nil {
+			iter.Close()
+			return err
+		}
+		missed = append(missed, note)
+	}
+	if turn.Status != "" && turn.Status != "inProgress" {
+		note, err := replayedNotification("turn/completed", map[string]any{
+			"threadId": s.threadID,
+			"turn":     protocol.TurnNotificationTurn{ID: turn.ID, Status: turn.Status, Error: turn.Error},
+		})
+		if err != nil {
+			iter.Close()
+			return err
+		}
+		missed = append(missed, note)
+	}
+	s.pending = append(s.pending, missed...)
+
+	s.replaceIter(iter)
+	s.client = client
+	s.reattached = true
+	s.setTurnID(turn.ID)
+	resolveLogger(s.thread.logger).Info("codex turn stream resumed", "thread_id", s.threadID, "turn_id", turn.ID, "status", turn.Status, "replayed", len(s.pending))
+	return nil
+}
+
+// trackResumeState records what the stream has delivered so resume only
+// replays what was missed.
+func (s *TurnStream) trackResumeState(note rpc.Notification) {
+	switch note.Method {
+	case "turn/started":
+		if payload, err := parseTurnNotification(note); err == nil && payload.Turn != nil && payload.Turn.ID != "" {
+			s.setTurnID(payload.Turn.ID)
+		}
+	case "item/completed":
+		if payload, err := parseTurnNotification(note); err == nil {
+			if id := itemID(payload.Item); id != "" {
+				if s.seenItems == nil {
+					s.seenItems = make(map[string]bool)
+				}
+				s.seenItems[id] = true
+			}
+		}
+	case "turn/completed", "turn/failed":
+		s.finished = true
+	}
+}
+
+// redelivered reports whether note is an item notification for an item
+// the stream already delivered as completed. The subscription opened by
+// resume can repeat items that thread/read already returned.
+func (s *TurnStream) redelivered(note rpc.Notification) bool {
+	if !s.reattached || len(s.seenItems) == 0 || !strings.HasPrefix(note.Method, "item/") {
+		return false
+	}
+	var payload struct {
+		Item struct {
+			ID string `json:"id"`
+		} `json:"item"`
+		ItemID string `json:"itemId"`
+	}
+	if note.UnmarshalParams(&payload) != nil {
+		return false
+	}
+	id := cmp.Or(payload.Item.ID, payload.ItemID)
+	return id != "" && s.seenItems[id]
+}
+
+type resumedTurn struct {
+	ID     string                          `json:"id"`
+	Status string                          `json:"status"`
+	Error  *protocol.TurnNotificationError `json:"error"`
+	Items  []json.RawMessage               `json:"items"`
+}
+
+// findResumedTurn locates turnID in a thread/read response. When turnID is
+// unknown the thread's latest turn is used.
+func findResumedTurn(response json.RawMessage, turnID string) (resumedTurn, error) {
+	var payload struct {
+		Thread struct {
+			Turns []resumedTurn `json:"turns"`
+		} `json:"thread"`
+	}
+	if err := json.Unmarshal(response, &payload); err != nil {
+		return resumedTurn{}, fmt.Errorf("decode thread: %w", err)
+	}
+	turns := payload.Thread.Turns
+	if turnID == "" && len(turns) > 0 {
+		return turns[len(turns)-1], nil
+	}
+	for _, turn := range turns {
+		if turn.ID == turnID {
+			return turn, nil
+		}
+	}
+	return resumedTurn{}, fmt.Errorf("turn %q not found after reconnect", turnID)
+}
+
+func itemID(item json.RawMessage) string {
+	var payload struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(item, &payload); err != nil {
+		return ""
+	}
+	return payload.ID
+}
+
+// replayedNotification builds a notification as if it had arrived on the
+// wire, so its Params carry the same typed payload.
+func replayedNotification(method string, params any) (rpc.Notification, error) {
+	raw, err := json.Marshal(map[string]any{"method": method, "params": params})
+	if err != nil {
+		return rpc.Notification{}, err
+	}
+	var note rpc.Notification
+	if err := json.Unmarshal(raw, &note); err != nil {
+		return rpc.Notification{}, err
+	}
+	return note, nil
+}
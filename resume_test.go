@@ -0,0 +1,193 @@
+package codex
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestTurnStreamResumesAfterTransportLoss(t *testing.T) {
+	first := rpc.NewReplayTransport(append(initializeTranscript(),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(2), Method: "turn/start", Params: mustRaw(turnStartParams("hi"))}),
+		readLine(rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(2), Result: mustRaw(map[string]any{"turn": turnPayload("turn_1", "inProgress")})}),
+		readLine(rpc.JSONRPCNotification{Method: "item/completed", Params: mustRaw(map[string]any{"threadId": "thr_123", "item": map[string]any{"id": "item_1", "text": "one"}})}),
+	))
+	second := rpc.NewReplayTransport(append(reconnectTranscript(map[string]any{
+		"id":     "turn_1",
+		"status": "inProgress",
+		"items":  []any{map[string]any{"id": "item_1", "text": "one"}, map[string]any{"id": "item_2", "text": "two"}},
+	}),
+		readLine(rpc.JSONRPCNotification{Method: "item/completed", Params: mustRaw(map[string]any{"threadId": "thr_123", "item": map[string]any{"id": "item_3", "text": "three"}})}),
+		readLine(rpc.JSONRPCNotification{Method: "turn/completed", Params: mustRaw(map[string]any{"threadId": "thr_123", "turn": turnPayload("turn_1", "completed")})}),
+	))
+	thread := newResumeTestThread(t, first, func(context.Context) (rpc.Transport, error) { return second, nil })
+
+	ctx := context.Background()
+	stream, err := thread.RunStreamed(ctx, []Input{TextInput("hi")}, nil)
+	if err != nil {
+		t.Fatalf("run streamed error: %v", err)
+	}
+	defer stream.Close()
+
+	note, err := stream.Next(ctx)
+	if err != nil || note.Method != "item/completed" {
+		t.Fatalf("unexpected first notification %q: %v", note.Method, err)
+	}
+	_ = first.Close()
+
+	result := &TurnResult{}
+	for {
+		note, err := stream.Next(ctx)
+		if err != nil {
+			t.Fatalf("next after reconnect: %v", err)
+		}
+		updateTurnResult(result, note)
+		if note.Method == "turn/completed" {
+			break
+		}
+	}
+	if len(result.Items) != 2 || result.FinalResponse != "three" {
+		t.Fatalf("expected missed and live items after resume, got %+v", result)
+	}
+	if thread.owner.Client() == nil || thread.owner.Err() != nil {
+		t.Fatalf("expected reconnected client to be open: %v", thread.owner.Err())
+	}
+}
+
+func TestTurnStreamResumeSkipsRedeliveredItems(t *testing.T) {
+	first := rpc.NewReplayTransport(append(initializeTranscript(),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(2), Method: "turn/start", Params: mustRaw(turnStartParams("hi"))}),
+		readLine(rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(2), Result: mustRaw(map[string]any{"turn": turnPayload("turn_1", "inProgress")})}),
+		readLine(rpc.JSONRPCNotification{Method: "item/completed", Params: mustRaw(map[string]any{"threadId": "thr_123", "item": map[string]any{"id": "item_1", "text": "one"}})}),
+	))
+	second := rpc.NewReplayTransport(append(reconnectTranscript(map[string]any{
+		"id":     "turn_1",
+		"status": "inProgress",
+		"items":  []any{map[string]any{"id": "item_1", "text": "one"}, map[string]any{"id": "item_2", "text": "two"}},
+	}),
+		// The live subscription repeats items thread/read already returned.
+		readLine(rpc.JSONRPCNotification{Method: "item/completed", Params: mustRaw(map[string]any{"threadId": "thr_123", "item": map[string]any{"id": "item_1", "text": "one"}})}),
+		readLine(rpc.JSONRPCNotification{Method: "item/agentMessage/delta", Params: mustRaw(map[string]any{"threadId": "thr_123", "itemId": "item_2", "delta": "two"})}),
+		readLine(rpc.JSONRPCNotification{Method: "item/completed", Params: mustRaw(map[string]any{"threadId": "thr_123", "item": map[string]any{"id": "item_2", "text": "two"}})}),
+		readLine(rpc.JSONRPCNotification{Method: "item/completed", Params: mustRaw(map[string]any{"threadId": "thr_123", "item": map[string]any{"id": "item_3", "text": "three"}})}),
+		readLine(rpc.JSONRPCNotification{Method: "turn/completed", Params: mustRaw(map[string]any{"threadId": "thr_123", "turn": turnPayload("turn_1", "completed")})}),
+	))
+	thread := newResumeTestThread(t, first, func(context.Context) (rpc.Transport, error) { return second, nil })
+
+	ctx := context.Background()
+	stream, err := thread.RunStreamed(ctx, []Input{TextInput("hi")}, nil)
+	if err != nil {
+		t.Fatalf("run streamed error: %v", err)
+	}
+	defer stream.Close()
+
+	if note, err := stream.Next(ctx); err != nil || note.Method != "item/completed" {
+		t.Fatalf("unexpected first notification %q: %v", note.Method, err)
+	}
+	_ = first.Close()
+
+	var got []string
+	for {
+		note, err := stream.Next(ctx)
+		if err != nil {
+			t.Fatalf("next after reconnect: %v", err)
+		}
+		if note.Method == "turn/completed" {
+			break
+		}
+		payload, err := parseTurnNotification(note)
+		if err != nil {
+			t.Fatalf("parse %s: %v", note.Method, err)
+		}
+		got = append(got, note.Method+" "+itemID(payload.Item))
+	}
+	want := []string{"item/completed item_2", "item/completed item_3"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("delivered %v, want %v", got, want)
+	}
+}
+
+func TestTurnStreamResumeReportsTurnFinishedWhileDisconnected(t *testing.T) {
+	first := rpc.NewReplayTransport(append(initializeTranscript(),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(2), Method: "turn/start", Params: mustRaw(turnStartParams("hi"))}),
+		readLine(rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(2), Result: mustRaw(map[string]any{"turn": turnPayload("turn_1", "inProgress")})}),
+	))
+	second := rpc.NewReplayTransport(reconnectTranscript(map[string]any{
+		"id":     "turn_1",
+		"status": "failed",
+		"error":  map[string]any{"message": "boom"},
+	}))
+	thread := newResumeTestThread(t, first, func(context.Context) (rpc.Transport, error) { return second, nil })
+
+	ctx := context.Background()
+	stream, err := thread.RunStreamed(ctx, []Input{TextInput("hi")}, nil)
+	if err != nil {
+		t.Fatalf("run streamed error: %v", err)
+	}
+	defer stream.Close()
+	_ = first.Close()
+
+	note, err := stream.Next(ctx)
+	if err != nil || note.Method != "turn/completed" {
+		t.Fatalf("expected replayed turn/completed, got %q: %v", note.Method, err)
+	}
+	if err := notificationError(note); err == nil || err.Error() != "boom" {
+		t.Fatalf("expected turn failure from resumed status, got %v", err)
+	}
+}
+
+func TestTurnStreamWithoutRedialReturnsTransportError(t *testing.T) {
+	first := rpc.NewReplayTransport(append(initializeTranscript(),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(2), Method: "turn/start", Params: mustRaw(turnStartParams("hi"))}),
+		readLine(rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(2), Result: mustRaw(map[string]any{"turn": turnPayload("turn_1", "inProgress")})}),
+	))
+	thread := newResumeTestThread(t, first, nil)
+
+	ctx := context.Background()
+	stream, err := thread.RunStreamed(ctx, []Input{TextInput("hi")}, nil)
+	if err != nil {
+		t.Fatalf("run streamed error: %v", err)
+	}
+	defer stream.Close()
+	_ = first.Close()
+	if _, err := stream.Next(ctx); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+func TestReconnectRefusedAfterClose(t *testing.T) {
+	dialed := false
+	thread := newResumeTestThread(t, rpc.NewReplayTransport(initializeTranscript()), func(context.Context) (rpc.Transport, error) {
+		dialed = true
+		return nil, io.EOF
+	})
+	stale := thread.owner.Client()
+	_ = thread.owner.Close()
+	if _, err := thread.owner.reconnect(context.Background(), stale, 1); err == nil {
+		t.Fatalf("expected reconnect to fail after close")
+	}
+	if dialed {
+		t.Fatalf("expected no redial after close")
+	}
+}
+
+func newResumeTestThread(t *testing.T, transport rpc.Transport, redial func(context.Context) (rpc.Transport, error)) *Thread {
+	t.Helper()
+	client, err := New(context.Background(), Options{Transport: transport, Redial: redial})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return &Thread{client: client.Client(), owner: client, id: "thr_123", turns: client.turns}
+}
+
+func reconnectTranscript(turn map[string]any) []rpc.TranscriptEntry {
+	return append(initializeTranscript(),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(2), Method: "thread/resume", Params: mustRaw(map[string]any{"threadId": "thr_123"})}),
+		readLine(rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(2), Result: mustRaw(map[string]any{"thread": map[string]any{"id": "thr_123"}})}),
+		writeLine(rpc.JSONRPCRequest{ID: rpc.NewIntRequestID(3), Method: "thread/read", Params: mustRaw(map[string]any{"threadId": "thr_123", "includeTurns": true})}),
+		readLine(rpc.JSONRPCResponse{ID: rpc.NewIntRequestID(3), Result: mustRaw(map[string]any{"thread": map[string]any{"id": "thr_123", "turns": []any{turn}}})}),
+	)
+}
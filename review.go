@@ -0,0 +1,245 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+const (
+	// ReviewTargetUncommittedChanges reviews staged, unstaged and untracked
+	// changes.
+	ReviewTargetUncommittedChanges = "uncommittedChanges"
+	// ReviewTargetBaseBranch reviews the changes against a base branch.
+	ReviewTargetBaseBranch = "baseBranch"
+	// ReviewTargetCommit reviews a single commit.
+	ReviewTargetCommit = "commit"
+	// ReviewTargetCustom reviews whatever the instructions describe.
+	ReviewTargetCustom = "custom"
+)
+
+// ReviewTarget selects what a review looks at.
+type ReviewTarget struct {
+	// Type must be one of the ReviewTarget* constants.
+	Type   string `json:"type"`
+	Branch string `json:"branch,omitempty"`
+	Sha    string `json:"sha,omitempty"`
+	// Title is the commit title shown to the reviewer.
+	Title        string `json:"title,omitempty"`
+	Instructions string `json:"instructions,omitempty"`
+}
+
+// UncommittedChangesReview reviews the working tree's uncommitted changes.
+func UncommittedChangesReview() ReviewTarget {
+	return ReviewTarget{Type: ReviewTargetUncommittedChanges}
+}
+
+// BaseBranchReview reviews the changes of the current branch against branch.
+func BaseBranchReview(branch string) ReviewTarget {
+	return ReviewTarget{Type: ReviewTargetBaseBranch, Branch: branch}
+}
+
+// CommitReview reviews the commit sha; title may be empty.
+func CommitReview(sha, title string) ReviewTarget {
+	return ReviewTarget{Type: ReviewTargetCommit, Sha: sha, Title: title}
+}
+
+// CustomReview runs a review with free-form instructions.
+func CustomReview(instructions string) ReviewTarget {
+	return ReviewTarget{Type: ReviewTargetCustom, Instructions: instructions}
+}
+
+func (r ReviewTarget) validate() error {
+	switch r.Type {
+	case ReviewTargetUncommittedChanges:
+	case ReviewTargetBaseBranch:
+		if r.Branch == "" {
+			return errors.New("base branch review branch is empty")
+		}
+	case ReviewTargetCommit:
+		if r.Sha == "" {
+			return errors.New("commit review sha is empty")
+		}
+	case ReviewTargetCustom:
+		if r.Instructions == "" {
+			return errors.New("custom review instructions are empty")
+		}
+	default:
+		return fmt.Errorf("unknown review target type %q", r.Type)
+	}
+	return nil
+}
+
+// ReviewOptions configures Thread.Review.
+type ReviewOptions struct {
+	// Detached runs the review on a new thread instead of adding it to the
+	// reviewed thread's history.
+	Detached bool
+}
+
+// ReviewFinding is one issue reported by a review.
+type ReviewFinding struct {
+	Title string
+	Body  string
+	// File is the absolute path of the file the finding is about, and
+	// StartLine and EndLine the 1-based, inclusive lines.
+	File      string
+	StartLine int
+	EndLine   int
+	// Priority ranks the finding from 0 (most urgent) to 3, or is -1 when
+	// the reviewer gave none.
+	Priority int
+	// Confidence is between 0 and 1, or 0 when not reported.
+	Confidence float64
+}
+
+// ReviewResult is the outcome of Thread.Review.
+type ReviewResult struct {
+	// ThreadID is the thread the review ran on.
+	ThreadID string
+	// Text is the review as the app-server reported it.
+	Text string
+	// Findings are the issues the review reported, in report order.
+	Findings []ReviewFinding
+	// OverallCorrectness and OverallExplanation summarize the review when
+	// the reviewer reported them.
+	OverallCorrectness string
+	OverallExplanation string
+	// Turn holds the review turn's notifications and items.
+	Turn *TurnResult
+}
+
+// Review runs a code review of target and waits for it to finish. Like
+// Run, when the review fails after it started the partial result is
+// returned with a *PartialResultError.
+func (t *Thread) Review(ctx context.Context, target ReviewTarget, opts *ReviewOptions) (*ReviewResult, error) {
+	if err := t.ensureReady(); err != nil {
+		return nil, err
+	}
+	if err := target.validate(); err != nil {
+		return nil, fmt.Errorf("review target: %w", err)
+	}
+	params := protocol.ReviewStartParams{ThreadID: t.id, Target: target}
+	if opts != nil && opts.Detached {
+		params.Delivery = protocol.ReviewDeliveryDetached
+	}
+	stream, err := t.startTurn(ctx, turnRequest{
+		method: "review/start",
+		params: func() (any, error) { return params, nil },
+		threadID: func(response json.RawMessage) string {
+			var payload protocol.ReviewStartResponse
+			if err := json.Unmarshal(response, &payload); err != nil {
+				return ""
+			}
+			return payload.ReviewThreadID
+		},
+		logAttrs: []any{"review_target", target.Type},
+	})
+	if err != nil {
+		return nil, err
+	}
+	threadID := stream.threadID
+	turn, err := t.collectTurn(ctx, stream)
+	return newReviewResult(threadID, turn), err
+}
+
+func newReviewResult(threadID string, turn *TurnResult) *ReviewResult {
+	result := &ReviewResult{ThreadID: threadID, Turn: turn}
+	if turn == nil {
+		return result
+	}
+	for _, raw := range turn.Items {
+		if item, err := protocol.DecodeThreadItem(raw); err == nil {
+			if exited, ok := item.(*protocol.ExitedReviewModeItem); ok {
+				result.Text = exited.Review
+			}
+		}
+	}
+	var output protocol.ReviewOutput
+	if err := json.Unmarshal([]byte(result.Text), &output); err == nil {
+		result.OverallCorrectness = output.OverallCorrectness
+		result.OverallExplanation = output.OverallExplanation
+		for _, finding := range output.Findings {
+			result.Findings = append(result.Findings, reviewFinding(finding))
+		}
+		return result
+	}
+	result.Findings = parseReviewFindings(result.Text)
+	return result
+}
+
+func reviewFinding(finding protocol.ReviewFinding) ReviewFinding {
+	out := ReviewFinding{
+		Title:      finding.Title,
+		Body:       finding.Body,
+		File:       finding.CodeLocation.AbsoluteFilePath,
+		StartLine:  finding.CodeLocation.LineRange.Start,
+		EndLine:    finding.CodeLocation.LineRange.End,
+		Priority:   titlePriority(finding.Title),
+		Confidence: finding.ConfidenceScore,
+	}
+	if finding.Priority != nil {
+		out.Priority = *finding.Priority
+	}
+	return out
+}
+
+// reviewFindingLine matches the finding headers in reviews the app-server
+// renders as text: "- <title> — <path>:<start>-<end>".
+var reviewFindingLine = regexp.MustCompile(`^- (.+) — (.+):(\d+)-(\d+)$`)
+
+// parseReviewFindings extracts findings from a review rendered as text,
+// where each header line is followed by the body indented two spaces.
+func parseReviewFindings(text string) []ReviewFinding {
+	var (
+		findings []ReviewFinding
+		body     []string
+	)
+	flush := func() {
+		if len(findings) > 0 {
+			findings[len(findings)-1].Body = strings.Join(body, "\n")
+		}
+		body = nil
+	}
+	for line := range strings.Lines(text) {
+		line = strings.TrimRight(line, "\r\n")
+		if match := reviewFindingLine.FindStringSubmatch(line); match != nil {
+			flush()
+			start, _ := strconv.Atoi(match[3])
+			end, _ := strconv.Atoi(match[4])
+			findings = append(findings, ReviewFinding{
+				Title:     match[1],
+				File:      match[2],
+				StartLine: start,
+				EndLine:   end,
+				Priority:  titlePriority(match[1]),
+			})
+			continue
+		}
+		if len(findings) > 0 {
+			if rest, ok := strings.CutPrefix(line, "  "); ok {
+				body = append(body, rest)
+			}
+		}
+	}
+	flush()
+	return findings
+}
+
+// titlePriority reads the "[P1]" tag reviewers put at the start of finding
+// titles, or returns -1.
+func titlePriority(title string) int {
+	if len(title) < 4 || title[0] != '[' || title[1] != 'P' || title[3] != ']' {
+		return -1
+	}
+	if p := int(title[2] - '0'); p >= 0 && p <= 3 {
+		return p
+	}
+	return -1
+}
@@ -0,0 +1,121 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+// reviewServer answers review/start on reviewThreadID and reports review as
+// the exitedReviewMode item.
+func reviewServer(t *testing.T, reviewThreadID, review string) (*fakeAppServer, *Codex) {
+	t.Helper()
+	var server *fakeAppServer
+	server, codex := newFakeAppServer(t, map[string]fakeHandler{
+		"thread/start": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			return map[string]any{"thread": map[string]any{"id": "thr_1"}}, nil
+		},
+		"review/start": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			server.notify("turn/started", map[string]any{"threadId": reviewThreadID, "turn": map[string]any{"id": "turn_r"}})
+			server.notify("item/completed", map[string]any{"threadId": reviewThreadID, "item": map[string]any{"type": "enteredReviewMode", "id": "i1", "review": "current changes"}})
+			server.notify("item/completed", map[string]any{"threadId": reviewThreadID, "item": map[string]any{"type": "exitedReviewMode", "id": "i2", "review": review}})
+			server.notify("turn/completed", map[string]any{"threadId": reviewThreadID, "turn": map[string]any{"id": "turn_r", "status": "completed"}})
+			return map[string]any{"turn": map[string]any{"id": "turn_r"}, "reviewThreadId": reviewThreadID}, nil
+		},
+	})
+	return server, codex
+}
+
+func TestThreadReviewStructuredOutput(t *testing.T) {
+	review := string(mustRaw(map[string]any{
+		"findings": []any{map[string]any{
+			"title": "[P1] Nil map write", "body": "counts is never initialized.", "confidence_score": 0.8, "priority": 1,
+			"code_location": map[string]any{"absolute_file_path": "/repo/stats.go", "line_range": map[string]any{"start": 12, "end": 14}},
+		}},
+		"overall_correctness":      "patch is incorrect",
+		"overall_explanation":      "The new counter panics.",
+		"overall_confidence_score": 0.7,
+	}))
+	server, codex := reviewServer(t, "thr_1", review)
+	ctx := context.Background()
+	thread, err := codex.StartThread(ctx, ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("StartThread: %v", err)
+	}
+	result, err := thread.Review(ctx, UncommittedChangesReview(), nil)
+	if err != nil {
+		t.Fatalf("Review: %v", err)
+	}
+	want := []ReviewFinding{{
+		Title: "[P1] Nil map write", Body: "counts is never initialized.",
+		File: "/repo/stats.go", StartLine: 12, EndLine: 14, Priority: 1, Confidence: 0.8,
+	}}
+	if !reflect.DeepEqual(result.Findings, want) {
+		t.Fatalf("Findings = %+v, want %+v", result.Findings, want)
+	}
+	if result.ThreadID != "thr_1" || result.OverallCorrectness != "patch is incorrect" || result.OverallExplanation != "The new counter panics." {
+		t.Fatalf("result = %+v", result)
+	}
+	if result.Turn == nil || result.Turn.TurnID != "turn_r" {
+		t.Fatalf("Turn = %+v, want turn_r", result.Turn)
+	}
+	if got := string(server.request(t, "review/start").Params); got != `{"target":{"type":"uncommittedChanges"},"threadId":"thr_1"}` {
+		t.Fatalf("review/start params = %s", got)
+	}
+}
+
+func TestThreadReviewDetachedTextOutput(t *testing.T) {
+	const review = "The change mostly works.\n\nFull review comments:\n\n" +
+		"- [P2] Leaked file handle — /repo/io.go:5-7\n  Close is never called.\n  Use defer.\n\n" +
+		"- Typo in message — /repo/main.go:20-20\n  \"recieve\" is misspelled.\n"
+	server, codex := reviewServer(t, "thr_review", review)
+	ctx := context.Background()
+	thread, err := codex.StartThread(ctx, ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("StartThread: %v", err)
+	}
+	result, err := thread.Review(ctx, BaseBranchReview("main"), &ReviewOptions{Detached: true})
+	if err != nil {
+		t.Fatalf("Review: %v", err)
+	}
+	want := []ReviewFinding{
+		{Title: "[P2] Leaked file handle", Body: "Close is never called.\nUse defer.", File: "/repo/io.go", StartLine: 5, EndLine: 7, Priority: 2},
+		{Title: "Typo in message", Body: "\"recieve\" is misspelled.", File: "/repo/main.go", StartLine: 20, EndLine: 20, Priority: -1},
+	}
+	if !reflect.DeepEqual(result.Findings, want) {
+		t.Fatalf("Findings = %+v, want %+v", result.Findings, want)
+	}
+	if result.ThreadID != "thr_review" || result.Text != review {
+		t.Fatalf("result = %+v", result)
+	}
+	if got := string(server.request(t, "review/start").Params); got != `{"delivery":"detached","target":{"type":"baseBranch","branch":"main"},"threadId":"thr_1"}` {
+		t.Fatalf("review/start params = %s", got)
+	}
+}
+
+func TestReviewTargetValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  ReviewTarget
+		wantErr bool
+	}{
+		{name: "uncommitted", target: UncommittedChangesReview()},
+		{name: "base branch", target: BaseBranchReview("main")},
+		{name: "commit", target: CommitReview("abc123", "")},
+		{name: "custom", target: CustomReview("check the error handling")},
+		{name: "missing branch", target: BaseBranchReview(""), wantErr: true},
+		{name: "missing sha", target: CommitReview("", "title"), wantErr: true},
+		{name: "missing instructions", target: CustomReview(""), wantErr: true},
+		{name: "unknown type", target: ReviewTarget{Type: "everything"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.target.validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
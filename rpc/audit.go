@@ -0,0 +1,172 @@
+package rpc
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// QueueAudit counts notifications waiting to be archived by the AuditSink.
+const QueueAudit = "audit"
+
+const defaultAuditQueueSize = 4096
+
+// AuditQueuePolicy bounds the queue between the read loop and the
+// AuditSink.
+type AuditQueuePolicy struct {
+	// Size bounds the number of notifications waiting to be archived
+	// (defaults to 4096).
+	Size int
+	// Overflow applies when the queue is full. OverflowBlock stops reading
+	// from the server until the sink catches up, so no record is lost;
+	// OverflowError drops the notification from the audit record and
+	// counts it in ClientStats.AuditDropped.
+	Overflow OverflowPolicy
+}
+
+// normalized fills in defaults. Without a policy the queue blocks, so the
+// audit record has no gaps.
+func (p *AuditQueuePolicy) normalized() AuditQueuePolicy {
+	if p == nil {
+		return AuditQueuePolicy{Size: defaultAuditQueueSize, Overflow: OverflowBlock}
+	}
+	out := *p
+	if out.Size <= 0 {
+		out.Size = defaultAuditQueueSize
+	}
+	return out
+}
+
+// AuditSink archives every server notification for compliance recording.
+// Archive is called from a single goroutine, in arrival order.
+type AuditSink interface {
+	Archive(Notification) error
+}
+
+// AuditSinkFunc adapts a function to an AuditSink.
+type AuditSinkFunc func(Notification) error
+
+// Archive calls f(note).
+func (f AuditSinkFunc) Archive(note Notification) error {
+	return f(note)
+}
+
+// auditMirror is the client's internal, read-only subscription for the
+// AuditSink. Unlike consumer subscriptions it cannot be cancelled, it sees
+// notifications before interceptors run, and its queue is flushed rather
+// than discarded when the client stops. The queue is bounded by an
+// AuditQueuePolicy: when it is full, the read loop either waits for the
+// sink or drops the record.
+type auditMirror struct {
+	sink    AuditSink
+	logger  *slog.Logger
+	onDepth func(depth int)
+	policy  AuditQueuePolicy
+
+	mu      sync.Mutex
+	wake    *sync.Cond
+	room    *sync.Cond
+	queue   []Notification
+	dropped int64
+	closed  bool
+	drained chan struct{}
+}
+
+func newAuditMirror(sink AuditSink, policy *AuditQueuePolicy, logger *slog.Logger, onDepth func(depth int)) *auditMirror {
+	m := &auditMirror{
+		sink:    sink,
+		logger:  logger,
+		onDepth: onDepth,
+		policy:  policy.normalized(),
+		drained: make(chan struct{}),
+	}
+	m.wake = sync.NewCond(&m.mu)
+	m.room = sync.NewCond(&m.mu)
+	go m.run()
+	return m
+}
+
+func (m *auditMirror) record(note Notification) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	for len(m.queue) >= m.policy.Size && m.policy.Overflow == OverflowBlock && !m.closed {
+		m.room.Wait()
+	}
+	if m.closed {
+		m.mu.Unlock()
+		return
+	}
+	if len(m.queue) >= m.policy.Size {
+		m.dropped++
+		m.mu.Unlock()
+		m.logger.Warn("audit queue is full; notification not archived", slog.String("method", note.Method))
+		return
+	}
+	m.queue = append(m.queue, note)
+	depth := len(m.queue)
+	m.wake.Signal()
+	m.mu.Unlock()
+	m.reportDepth(depth)
+}
+
+// droppedCount returns the number of notifications dropped on overflow.
+func (m *auditMirror) droppedCount() int64 {
+	if m == nil {
+		return 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.dropped
+}
+
+func (m *auditMirror) run() {
+	defer close(m.drained)
+	for {
+		m.mu.Lock()
+		for len(m.queue) == 0 && !m.closed {
+			m.wake.Wait()
+		}
+		if len(m.queue) == 0 {
+			m.mu.Unlock()
+			return
+		}
+		note := m.queue[0]
+		m.queue[0] = Notification{}
+		m.queue = m.queue[1:]
+		depth := len(m.queue)
+		m.room.Signal()
+		m.mu.Unlock()
+
+		m.reportDepth(depth)
+		if err := m.sink.Archive(note); err != nil {
+			m.logger.Warn("audit sink failed to archive notification", slog.String("method", note.Method), slog.Any("error", err))
+		}
+	}
+}
+
+// close stops accepting notifications. Queued ones are still archived.
+func (m *auditMirror) close() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.closed = true
+	m.wake.Broadcast()
+	m.room.Broadcast()
+	m.mu.Unlock()
+}
+
+// wait blocks until every queued notification has been archived.
+func (m *auditMirror) wait() {
+	if m == nil {
+		return
+	}
+	<-m.drained
+}
+
+func (m *auditMirror) reportDepth(depth int) {
+	if m.onDepth != nil {
+		m.onDepth(depth)
+	}
+}
@@ -0,0 +1,179 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingSink struct {
+	mu      sync.Mutex
+	methods []string
+	release chan struct{}
+}
+
+func (s *recordingSink) Archive(note Notification) error {
+	if s.release != nil {
+		<-s.release
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.methods = append(s.methods, note.Method)
+	return nil
+}
+
+func (s *recordingSink) recorded() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.methods...)
+}
+
+func TestAuditSinkArchivesNotificationsConsumersDrop(t *testing.T) {
+	transport := newChannelTransport()
+	sink := &recordingSink{}
+	dropAll := func(Notification, NotificationInvoker) {}
+	client := NewClient(transport, ClientOptions{
+		AuditSink:    sink,
+		Interceptors: []Interceptor{{Notification: dropAll}},
+	})
+	defer client.Close()
+
+	// A subscriber that never reads and one that cancels immediately must not
+	// affect the audit record.
+	stalled := client.SubscribeNotifications(1)
+	defer stalled.Close()
+	client.SubscribeNotifications(1).Close()
+
+	for i := range 10 {
+		transport.pushReadLine(fmt.Sprintf(`{"jsonrpc":"2.0","method":"test/%d","params":{}}`, i))
+	}
+	transport.waitForReads(t, 10)
+
+	waitFor(t, func() bool { return len(sink.recorded()) == 10 })
+	for i, method := range sink.recorded() {
+		if want := fmt.Sprintf("test/%d", i); method != want {
+			t.Fatalf("record %d = %q, want %q", i, method, want)
+		}
+	}
+}
+
+func TestAuditSinkFlushedOnClose(t *testing.T) {
+	transport := newChannelTransport()
+	sink := &recordingSink{release: make(chan struct{})}
+	client := NewClient(transport, ClientOptions{AuditSink: sink})
+
+	for i := range 3 {
+		transport.pushReadLine(fmt.Sprintf(`{"jsonrpc":"2.0","method":"test/%d","params":{}}`, i))
+	}
+	transport.waitForReads(t, 3)
+
+	closed := make(chan error, 1)
+	go func() { closed <- client.Close() }()
+	select {
+	case <-closed:
+		t.Fatalf("Close returned before the audit sink drained")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(sink.release)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for Close")
+	}
+	if got := sink.recorded(); len(got) != 3 {
+		t.Fatalf("expected 3 archived notifications, got %v", got)
+	}
+}
+
+func TestAuditSinkShutdownHonorsContext(t *testing.T) {
+	transport := newChannelTransport()
+	sink := &recordingSink{release: make(chan struct{})}
+	defer close(sink.release)
+	client := NewClient(transport, ClientOptions{AuditSink: sink})
+
+	transport.pushReadLine(`{"jsonrpc":"2.0","method":"test/stuck","params":{}}`)
+	transport.waitForReads(t, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestAuditSinkErrorsDoNotStopArchiving(t *testing.T) {
+	transport := newChannelTransport()
+	var mu sync.Mutex
+	var attempts int
+	sink := AuditSinkFunc(func(Notification) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		return errors.New("disk full")
+	})
+	client := NewClient(transport, ClientOptions{AuditSink: sink})
+	defer client.Close()
+
+	transport.pushReadLine(`{"jsonrpc":"2.0","method":"test/a","params":{}}`)
+	transport.pushReadLine(`{"jsonrpc":"2.0","method":"test/b","params":{}}`)
+	transport.waitForReads(t, 2)
+
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return attempts == 2
+	})
+}
+
+func TestAuditQueueDropsWhenFull(t *testing.T) {
+	transport := newChannelTransport()
+	sink := &recordingSink{release: make(chan struct{})}
+	client := NewClient(transport, ClientOptions{
+		AuditSink:  sink,
+		AuditQueue: &AuditQueuePolicy{Size: 1, Overflow: OverflowError},
+	})
+
+	for i := range 4 {
+		transport.pushReadLine(fmt.Sprintf(`{"jsonrpc":"2.0","method":"test/%d","params":{}}`, i))
+	}
+	transport.waitForReads(t, 4)
+	waitFor(t, func() bool { return client.Stats().AuditDropped >= 2 })
+
+	close(sink.release)
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	dropped := client.Stats().AuditDropped
+	if got := int64(len(sink.recorded())); got+dropped != 4 {
+		t.Fatalf("archived %d and dropped %d, want 4 in total", got, dropped)
+	}
+}
+
+func TestAuditQueueBlocksWhenFull(t *testing.T) {
+	transport := newChannelTransport()
+	sink := &recordingSink{release: make(chan struct{})}
+	client := NewClient(transport, ClientOptions{
+		AuditSink:  sink,
+		AuditQueue: &AuditQueuePolicy{Size: 1, Overflow: OverflowBlock},
+	})
+
+	for i := range 4 {
+		transport.pushReadLine(fmt.Sprintf(`{"jsonrpc":"2.0","method":"test/%d","params":{}}`, i))
+	}
+	// The read loop waits for room instead of dropping.
+	time.Sleep(20 * time.Millisecond)
+	close(sink.release)
+	waitFor(t, func() bool { return len(sink.recorded()) == 4 })
+	client.Close()
+	for i, method := range sink.recorded() {
+		if want := fmt.Sprintf("test/%d", i); method != want {
+			t.Fatalf("record %d = %q, want %q", i, method, want)
+		}
+	}
+	if dropped := client.Stats().AuditDropped; dropped != 0 {
+		t.Fatalf("AuditDropped = %d, want 0", dropped)
+	}
+}
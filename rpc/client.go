@@ -7,28 +7,175 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"runtime/debug"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
+// ErrHandlerTimeout is the cause of a server request handler's context
+// being canceled once ClientOptions.HandlerTimeout elapses.
+var ErrHandlerTimeout = errors.New("server request handler timed out")
+
+// ErrHandlerPanic is returned to the app-server, as an internal error, when
+// a server request handler or interceptor panics.
+var ErrHandlerPanic = errors.New("server request handler panicked")
+
 type ClientOptions struct {
 	Logger         *slog.Logger
 	RequestHandler ServerRequestHandler
+	// MaxConcurrentCalls caps the number of in-flight Call requests. Calls
+	// beyond the limit wait for a free slot or for their context to end.
+	// Zero or negative means unlimited.
+	MaxConcurrentCalls int
+	// Retry enables automatic retries for idempotent methods. Nil disables retries.
+	Retry *RetryPolicy
+	// Interceptors wrap outgoing calls, notifications, and server requests.
+	// The first interceptor is outermost.
+	Interceptors []Interceptor
+	// Metrics receives request, notification, and queue depth callbacks.
+	// If it also implements TransportMetrics, the transport is wrapped in a
+	// MeteredTransport that reports traffic to it. Nil disables
+	// instrumentation.
+	Metrics Metrics
+	// WireLog logs every JSON-RPC line sent and received at debug level
+	// through Logger, after applying WireRedactors.
+	WireLog bool
+	// WireRedactors rewrite logged lines. Nil uses DefaultWireRedactors; an
+	// empty slice logs lines unchanged.
+	WireRedactors []Redactor
+	// ClockSkewThreshold logs a warning when the estimated server clock skew
+	// exceeds it (defaults to 5s). Negative disables the warning.
+	ClockSkewThreshold time.Duration
+	// Keepalive enables periodic liveness probes. Nil disables them.
+	Keepalive *KeepalivePolicy
+	// HandlerTimeout bounds how long a server request handler may run. When
+	// it is exceeded the handler's context is canceled and the server gets an
+	// error reply. Zero means no limit.
+	HandlerTimeout time.Duration
+	// MaxConcurrentHandlers caps the number of server request handlers
+	// running at once. Requests beyond the limit wait for a free slot.
+	// Requests for the same thread are always handled one at a time, in the
+	// order they arrived. Zero or negative means unlimited.
+	MaxConcurrentHandlers int
+	// WriteQueue sends all outgoing lines through a bounded queue drained by
+	// a writer goroutine, so Notify and replies to server requests do not
+	// block on the transport. Nil writes synchronously on the caller's
+	// goroutine.
+	WriteQueue *WriteQueuePolicy
+	// OnNotificationError enables strict mode: it is called for every
+	// notification whose method is unknown to the SDK or whose params do not
+	// decode into the generated type, so protocol drift against a newer
+	// app-server is noticed. The notification is still delivered with its
+	// Raw params. It runs on the read loop and must return quickly.
+	OnNotificationError func(*NotificationDecodeError)
+	// RequestIDPrefix switches request IDs from integers to strings of the
+	// form "<prefix>:<n>", for example "go-sdk-1:42". Giving each client a
+	// distinct prefix keeps IDs unique in logs of a server shared by several
+	// clients. Empty keeps integer IDs.
+	RequestIDPrefix string
+	// OnUnmatchedResponse is called for every response that matches no
+	// pending request: orphans, duplicates, and responses that arrive after
+	// the caller gave up. Such responses are dropped; ClientStats counts
+	// them. It runs on the read loop and must return quickly.
+	OnUnmatchedResponse func(UnmatchedResponse)
+	// AuditSink receives every notification the server sends, before
+	// interceptors and subscribers see it, regardless of how fast consumers
+	// read. Close and Shutdown wait until it has archived everything queued.
+	AuditSink AuditSink
+	// AuditQueue bounds the queue in front of AuditSink. Without it the
+	// queue holds 4096 notifications and reading from the server waits
+	// while it is full.
+	AuditQueue *AuditQueuePolicy
+	// ReplayBuffer retains the last ReplayBuffer notifications so that
+	// SubscribeNotificationsReplay can deliver them to late subscribers.
+	// Zero disables retention.
+	ReplayBuffer int
+	// RequestMutators edit the params of every outgoing request and
+	// notification, in order, just before they are marshaled.
+	RequestMutators []RequestMutator
+	// SizeLimits bounds incoming lines and response results. Nil means no
+	// limits.
+	SizeLimits *SizeLimits
+	// StrictEnvelope rejects incoming lines without "jsonrpc":"2.0", responses
+	// carrying both result and error, and requests or notifications carrying
+	// either. The app-server omits the jsonrpc field, so enable it only
+	// against servers known to send it.
+	StrictEnvelope bool
+	// OnMalformedMessage is called for every incoming line dropped because it
+	// is not valid JSON or, in strict mode, not a valid envelope. It runs on
+	// the read loop and must return quickly.
+	OnMalformedMessage func(*MalformedMessageError)
+	// ShutdownMethod, when set, names a request that asks the server to
+	// persist its state and exit. Shutdown sends it once in-flight work has
+	// drained, and RequestShutdown sends it on demand, before the transport
+	// is closed. It is only sent to servers that list it in their initialize
+	// capabilities.
+	ShutdownMethod string
 }
 
 // Client manages JSON-RPC requests over a Transport.
 type Client struct {
-	transport Transport
-	logger    *slog.Logger
-
-	nextID int64
+	transportMu      sync.Mutex
+	transport        Transport
+	transportGen     uint64
+	transportMetrics TransportMetrics
+	logger           *slog.Logger
+
+	nextID   int64
+	idPrefix string
+
+	callSlots    chan struct{}
+	retry        *RetryPolicy
+	interceptors []Interceptor
+	mutators     []RequestMutator
+	limits       *SizeLimits
+	metrics      Metrics
+	queuedNotes  atomic.Int64
+	wireLog      bool
+	redactors    []Redactor
+	audit        *auditMirror
+	replay       *replayBuffer
+	writes       *writeQueue
+
+	onNotificationError func(*NotificationDecodeError)
+	onMalformed         func(*MalformedMessageError)
+	strictEnvelope      bool
+
+	handlerTimeout time.Duration
+	handlerSlots   chan struct{}
+	pingMethod     string
+	shutdownMethod string
+	threadQueues   threadQueues
+	inbound        inboundRequests
+
+	skewMu        sync.Mutex
+	skew          skewEstimator
+	skewThreshold time.Duration
+	skewWarned    bool
+
+	infoMu      sync.Mutex
+	serverInfo  *ServerInfo
+	unsupported map[string]bool
+
+	activeMu sync.Mutex
+	active   int
+	draining bool
+	idle     chan struct{}
 
 	pendingMu sync.Mutex
-	pending   map[string]chan response
+	pending   map[string]pendingCall
+	// settled remembers recently completed requests so unmatched responses
+	// can be classified.
+	settled     settledRequests
+	unmatched   atomic.Int64
+	onUnmatched func(UnmatchedResponse)
 
 	subsMu  sync.Mutex
 	subs    map[int]*notificationSubscription
+	rawSubs map[int]*subscription[json.RawMessage]
 	nextSub int
 
 	handlerMu sync.RWMutex
@@ -51,17 +198,73 @@ func NewClient(transport Transport, options ClientOptions) *Client {
 	lifecycle, cancel := context.WithCancel(context.Background())
 
 	client := &Client{
-		transport: transport,
 		logger:    logger,
-		pending:   make(map[string]chan response),
+		pending:   make(map[string]pendingCall),
 		subs:      make(map[int]*notificationSubscription),
 		handler:   options.RequestHandler,
 		lifecycle: lifecycle,
 		cancel:    cancel,
 		done:      make(chan struct{}),
+		retry:     options.Retry.normalized(),
+		metrics:   options.Metrics,
+
+		handlerTimeout:      options.HandlerTimeout,
+		idPrefix:            options.RequestIDPrefix,
+		onUnmatched:         options.OnUnmatchedResponse,
+		onNotificationError: options.OnNotificationError,
+		onMalformed:         options.OnMalformedMessage,
+		strictEnvelope:      options.StrictEnvelope,
+		shutdownMethod:      options.ShutdownMethod,
+	}
+	client.skewThreshold = options.ClockSkewThreshold
+	if client.skewThreshold == 0 {
+		client.skewThreshold = defaultClockSkewThreshold
+	}
+	client.interceptors = append([]Interceptor(nil), options.Interceptors...)
+	client.mutators = append([]RequestMutator(nil), options.RequestMutators...)
+	if options.SizeLimits != nil {
+		limits := *options.SizeLimits
+		client.limits = &limits
+	}
+	client.transportMetrics, _ = options.Metrics.(TransportMetrics)
+	client.transport = client.prepareTransport(transport)
+	if options.WireLog {
+		client.wireLog = true
+		client.redactors = options.WireRedactors
+		if client.redactors == nil {
+			client.redactors = DefaultWireRedactors()
+		}
+	}
+	if options.MaxConcurrentCalls > 0 {
+		client.callSlots = make(chan struct{}, options.MaxConcurrentCalls)
+	}
+	client.replay = newReplayBuffer(options.ReplayBuffer)
+	if options.MaxConcurrentHandlers > 0 {
+		client.handlerSlots = make(chan struct{}, options.MaxConcurrentHandlers)
+	}
+	if options.AuditSink != nil {
+		var onDepth func(int)
+		if client.metrics != nil {
+			onDepth = func(depth int) { client.metrics.QueueDepth(QueueAudit, depth) }
+		}
+		client.audit = newAuditMirror(options.AuditSink, options.AuditQueue, logger, onDepth)
+	}
+
+	if policy := options.WriteQueue.normalized(); policy != nil {
+		var onDepth func(int)
+		if client.metrics != nil {
+			onDepth = func(depth int) { client.metrics.QueueDepth(QueueWrites, depth) }
+		}
+		client.writes = newWriteQueue(policy, onDepth)
+		go client.writeLoop()
 	}
 
 	go client.readLoop()
+	client.pingMethod = defaultKeepaliveMethod
+	if keepalive := options.Keepalive.normalized(); keepalive != nil {
+		client.pingMethod = keepalive.Method
+		go client.keepalive(keepalive)
+	}
 
 	return client
 }
@@ -69,7 +272,22 @@ func NewClient(transport Transport, options ClientOptions) *Client {
 // Close shuts down the client and transport.
 func (c *Client) Close() error {
 	c.finish(errors.New("client closed"))
-	return c.transport.Close()
+	transport, _ := c.currentTransport()
+	err := transport.Close()
+	c.audit.wait()
+	return err
+}
+
+// Done returns a channel that is closed once the client stops, either
+// because Close was called or because the transport failed.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns nil while the client is open. After Done is closed it
+// returns the reason the client stopped, such as the transport read error.
+func (c *Client) Err() error {
+	return c.ensureOpen()
 }
 
 // SetRequestHandler replaces the server request handler.
@@ -87,44 +305,95 @@ func (c *Client) Call(ctx context.Context, method string, params any, result any
 	if err := c.ensureOpen(); err != nil {
 		return err
 	}
+	if err := c.beginWork(true); err != nil {
+		return err
+	}
+	defer c.endWork()
+	ctx = ensureCorrelationID(ctx)
+	return c.observeCall(method, func() error {
+		return chainCall(c.interceptors, c.invoke)(ctx, method, params, result)
+	})
+}
+
+// CallRaw sends a JSON-RPC request like Call and returns the result bytes
+// without decoding them, so callers can defer decoding, pass results through
+// unchanged, or try several candidate types.
+func (c *Client) CallRaw(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	var result json.RawMessage
+	if err := c.Call(ctx, method, params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) invoke(ctx context.Context, method string, params any, result any) error {
+	release, err := c.acquireCallSlot(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if c.retry == nil || !c.retry.allows(method) {
+		_, err := c.callOnce(ctx, method, params, result)
+		return err
+	}
+	return c.callWithRetry(ctx, method, params, result)
+}
 
+// callOnce performs a single request/response exchange. The boolean reports
+// whether the request failed while writing it to the transport.
+func (c *Client) callOnce(ctx context.Context, method string, params any, result any) (bool, error) {
 	id := c.nextRequestID()
 	respCh := make(chan response, 1)
 
 	c.pendingMu.Lock()
-	c.pending[id.Key()] = respCh
+	c.pending[id.Key()] = pendingCall{ch: respCh, id: id, method: method, started: time.Now()}
+	depth := len(c.pending)
 	c.pendingMu.Unlock()
+	c.reportPending(depth)
 
+	params, err := c.mutateParams(ctx, method, params)
+	if err != nil {
+		c.deletePending(id)
+		return false, err
+	}
 	payload, err := BuildClientRequest(method, params, id)
 	if err != nil {
 		c.deletePending(id)
-		return err
+		return false, err
 	}
 
 	if err := ctx.Err(); err != nil {
 		c.deletePending(id)
-		return err
+		return false, err
 	}
-	if err := c.send(payload); err != nil {
+	if err := c.send(ctx, payload, true); err != nil {
 		c.deletePending(id)
-		return err
+		return true, err
 	}
+	correlation := slog.String("correlation_id", CorrelationID(ctx))
+	c.logger.Debug("json-rpc request sent", slog.String("method", method), slog.String("id", id.String()), correlation)
 
 	select {
 	case <-c.done:
 		c.deletePending(id)
-		return c.errOrClosed()
+		return false, c.errOrClosed()
 	case <-ctx.Done():
 		c.deletePending(id)
-		return ctx.Err()
+		return false, ctx.Err()
 	case resp := <-respCh:
+		c.logger.Debug("json-rpc response received", slog.String("method", method), slog.String("id", id.String()), correlation, slog.Any("error", resp.err))
+		c.observeResponse(method, resp.result, resp.err)
 		if resp.err != nil {
-			return resp.err
+			return false, resp.err
+		}
+		if err := c.limits.checkResult(method, resp.result); err != nil {
+			return false, err
 		}
 		if result == nil {
-			return nil
+			return false, nil
 		}
-		return json.Unmarshal(resp.result, result)
+		return false, json.Unmarshal(resp.result, result)
 	}
 }
 
@@ -133,7 +402,15 @@ func (c *Client) Notify(ctx context.Context, method string, params any) error {
 	if err := c.ensureOpen(); err != nil {
 		return err
 	}
+	if err := c.beginWork(true); err != nil {
+		return err
+	}
+	defer c.endWork()
 
+	params, err := c.mutateParams(ctx, method, params)
+	if err != nil {
+		return err
+	}
 	payload := JSONRPCNotification{Method: method}
 	if params != nil {
 		data, err := json.Marshal(params)
@@ -154,21 +431,42 @@ func (c *Client) Notify(ctx context.Context, method string, params any) error {
 	case <-c.done:
 		return c.errOrClosed()
 	default:
-		return c.transport.WriteLine(string(data))
+		return c.write(ctx, string(data), false)
 	}
 }
 
 // SubscribeNotifications creates an iterator over server notifications.
 func (c *Client) SubscribeNotifications(buffer int) *NotificationIterator {
+	return c.SubscribeNotificationsReplay(buffer, Replay{})
+}
+
+// SubscribeNotificationsReplay subscribes like SubscribeNotifications, but
+// first delivers the retained notifications selected by replay, so a
+// consumer that subscribes slightly late does not miss events. Retention is
+// enabled by ClientOptions.ReplayBuffer; without it this is the same as
+// SubscribeNotifications.
+func (c *Client) SubscribeNotificationsReplay(buffer int, replay Replay) *NotificationIterator {
 	sub := newNotificationSubscription(buffer)
+	if c.metrics != nil {
+		sub.onDepth = c.adjustQueuedNotifications
+	}
 
 	c.subsMu.Lock()
+	if c.stopped() {
+		c.subsMu.Unlock()
+		sub.close()
+		return &NotificationIterator{sub: sub, ch: sub.out, done: c.done, err: c.errOrClosed}
+	}
+	sub.queue = c.replay.snapshot(replay)
 	id := c.nextSub
 	c.nextSub++
 	c.subs[id] = sub
 	c.subsMu.Unlock()
 
+	go sub.run()
+
 	return &NotificationIterator{
+		sub:  sub,
 		ch:   sub.out,
 		done: c.done,
 		err:  c.errOrClosed,
@@ -186,18 +484,28 @@ func (c *Client) SubscribeNotifications(buffer int) *NotificationIterator {
 
 func (c *Client) readLoop() {
 	for {
-		line, err := c.transport.ReadLine()
+		transport, gen := c.currentTransport()
+		line, err := transportRead(c.lifecycle, transport)
+		if err == nil {
+			err = c.limits.checkLine(line)
+		}
 		if err != nil {
+			if _, current := c.currentTransport(); current != gen {
+				// SwapTransport closed the transport under this read.
+				continue
+			}
 			c.finish(err)
 			return
 		}
 		if strings.TrimSpace(line) == "" {
 			continue
 		}
+		c.logWire(TranscriptRead, line)
+		c.publishRaw(line)
 
-		msg, err := parseMessage([]byte(line))
+		msg, err := parseMessage([]byte(line), c.strictEnvelope)
 		if err != nil {
-			c.logger.Warn("failed to parse json-rpc message", slog.Any("error", err))
+			c.reportMalformed(line, err)
 			continue
 		}
 
@@ -207,7 +515,7 @@ func (c *Client) readLoop() {
 		case messageError:
 			c.handleError(msg.error)
 		case messageRequest:
-			go c.handleServerRequest(msg.request)
+			c.scheduleServerRequest(msg.request)
 		case messageNotification:
 			c.handleNotification(msg.notification)
 		}
@@ -215,12 +523,9 @@ func (c *Client) readLoop() {
 }
 
 func (c *Client) handleResponse(resp JSONRPCResponse) {
-	c.pendingMu.Lock()
-	ch := c.pending[resp.ID.Key()]
-	delete(c.pending, resp.ID.Key())
-	c.pendingMu.Unlock()
-
+	ch, reason := c.takePending(resp.ID)
 	if ch == nil {
+		c.reportUnmatched(UnmatchedResponse{ID: resp.ID, Reason: reason, Result: resp.Result})
 		return
 	}
 
@@ -228,12 +533,10 @@ func (c *Client) handleResponse(resp JSONRPCResponse) {
 }
 
 func (c *Client) handleError(resp JSONRPCError) {
-	c.pendingMu.Lock()
-	ch := c.pending[resp.ID.Key()]
-	delete(c.pending, resp.ID.Key())
-	c.pendingMu.Unlock()
-
+	ch, reason := c.takePending(resp.ID)
 	if ch == nil {
+		detail := resp.Error
+		c.reportUnmatched(UnmatchedResponse{ID: resp.ID, Reason: reason, Error: &detail})
 		return
 	}
 
@@ -241,12 +544,23 @@ func (c *Client) handleError(resp JSONRPCError) {
 }
 
 func (c *Client) handleNotification(note JSONRPCNotification) {
+	if c.metrics != nil {
+		c.metrics.NotificationReceived(note.Method)
+	}
+	c.observeClockSkew(note.Method, note.Params, time.Now())
 	notification, err := parseServerNotification(note.Method, note.Params)
 	if err != nil {
 		c.logger.Warn("failed to decode notification", slog.String("method", note.Method), slog.Any("error", err))
 	}
+	c.reportNotificationError(note, err)
+	c.audit.record(notification)
+
+	chainNotification(c.interceptors, c.publishNotification)(notification)
+}
 
+func (c *Client) publishNotification(notification Notification) {
 	c.subsMu.Lock()
+	c.replay.add(notification)
 	subs := make([]*notificationSubscription, 0, len(c.subs))
 	for _, sub := range c.subs {
 		subs = append(subs, sub)
@@ -261,29 +575,92 @@ func (c *Client) handleNotification(note JSONRPCNotification) {
 func (c *Client) handleServerRequest(req JSONRPCRequest) {
 	handler := c.currentHandler()
 	if handler == nil {
-		_ = c.replyError(req.ID, -32601, "no handler configured", nil)
+		_ = c.replyError(req.ID, CodeMethodNotFound, "no handler configured", nil)
 		return
 	}
 
-	result, err := dispatchServerRequest(c.requestContext(), handler, req)
+	dispatch := func(ctx context.Context, req JSONRPCRequest) (any, error) {
+		return dispatchServerRequest(ctx, handler, req)
+	}
+	result, err := c.runServerRequest(c.recoverServerRequest(chainServerRequest(c.interceptors, dispatch)), req)
+	if errors.Is(err, ErrHandlerTimeout) {
+		c.logger.Warn("server request handler timed out", slog.String("method", req.Method), slog.Duration("timeout", c.handlerTimeout))
+		_ = c.replyError(req.ID, CodeInternalError, err.Error(), nil)
+		return
+	}
+	if errors.Is(err, ErrHandlerPanic) {
+		_ = c.replyError(req.ID, CodeInternalError, err.Error(), nil)
+		return
+	}
 	if err != nil {
-		_ = c.replyError(req.ID, -32602, err.Error(), nil)
+		_ = c.replyError(req.ID, CodeInvalidParams, err.Error(), nil)
 		return
 	}
 
 	_ = c.replyResult(req.ID, result)
 }
 
+// recoverServerRequest wraps invoke so that a panic in a handler or
+// interceptor is logged and turned into ErrHandlerPanic, rather than
+// crashing the process from the goroutine serving the request.
+func (c *Client) recoverServerRequest(invoke ServerRequestInvoker) ServerRequestInvoker {
+	return func(ctx context.Context, req JSONRPCRequest) (result any, err error) {
+		defer func() {
+			if value := recover(); value != nil {
+				c.logger.Error("server request handler panicked", slog.String("method", req.Method), slog.Any("panic", value), slog.String("stack", string(debug.Stack())))
+				result, err = nil, fmt.Errorf("%w: %v", ErrHandlerPanic, value)
+			}
+		}()
+		return invoke(ctx, req)
+	}
+}
+
+// runServerRequest invokes dispatch, bounded by the handler timeout. A
+// handler that ignores its canceled context is abandoned; its eventual
+// result is discarded.
+func (c *Client) runServerRequest(dispatch ServerRequestInvoker, req JSONRPCRequest) (any, error) {
+	ctx := c.requestContext()
+	if c.handlerTimeout <= 0 {
+		return dispatch(ctx, req)
+	}
+	ctx, cancel := context.WithTimeoutCause(ctx, c.handlerTimeout, ErrHandlerTimeout)
+	defer cancel()
+
+	type outcome struct {
+		result any
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := dispatch(ctx, req)
+		done <- outcome{result: result, err: err}
+	}()
+	var out outcome
+	select {
+	case out = <-done:
+	case <-ctx.Done():
+		out.err = ctx.Err()
+	}
+	if out.err != nil && errors.Is(context.Cause(ctx), ErrHandlerTimeout) {
+		return nil, fmt.Errorf("%w after %s", ErrHandlerTimeout, c.handlerTimeout)
+	}
+	return out.result, out.err
+}
+
+// replyResult and replyError answer a server request. The request's ID is
+// released first, since the server may reuse it once it has the reply.
 func (c *Client) replyResult(id RequestID, result any) error {
+	c.inbound.remove(id)
 	data, err := json.Marshal(result)
 	if err != nil {
 		return err
 	}
 	resp := JSONRPCResponse{ID: id, Result: data}
-	return c.send(resp)
+	return c.send(c.requestContext(), resp, false)
 }
 
 func (c *Client) replyError(id RequestID, code int64, message string, data json.RawMessage) error {
+	c.inbound.remove(id)
 	resp := JSONRPCError{
 		ID: id,
 		Error: JSONRPCErrorError{
@@ -292,26 +669,57 @@ func (c *Client) replyError(id RequestID, code int64, message string, data json.
 			Data:    data,
 		},
 	}
-	return c.send(resp)
+	return c.send(c.requestContext(), resp, false)
 }
 
-func (c *Client) send(payload any) error {
+func (c *Client) send(ctx context.Context, payload any, wait bool) error {
 	data, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
-	return c.transport.WriteLine(string(data))
+	return c.write(ctx, string(data), wait)
+}
+
+func (c *Client) writeLine(ctx context.Context, line string) error {
+	c.logWire(TranscriptWrite, line)
+	transport, _ := c.currentTransport()
+	return transportWrite(ctx, transport, line)
+}
+
+func (c *Client) acquireCallSlot(ctx context.Context) (func(), error) {
+	if c.callSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.callSlots <- struct{}{}:
+		return func() { <-c.callSlots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.done:
+		return nil, c.errOrClosed()
+	}
 }
 
 func (c *Client) nextRequestID() RequestID {
 	next := atomic.AddInt64(&c.nextID, 1)
+	if c.idPrefix != "" {
+		return NewStringRequestID(c.idPrefix + ":" + strconv.FormatInt(next, 10))
+	}
 	return NewIntRequestID(next)
 }
 
 func (c *Client) deletePending(id RequestID) {
 	c.pendingMu.Lock()
+	_, ok := c.pending[id.Key()]
 	delete(c.pending, id.Key())
+	if ok {
+		c.settled.add(id.Key(), settledAbandoned)
+	}
+	depth := len(c.pending)
 	c.pendingMu.Unlock()
+	if ok {
+		c.reportPending(depth)
+	}
 }
 
 func (c *Client) currentHandler() ServerRequestHandler {
@@ -336,9 +744,16 @@ func (c *Client) ensureOpen() error {
 	}
 }
 
+// errOrClosed returns the reason the client stopped. c.err is only read
+// once done is closed, since finish sets it just before closing done; an
+// iterator closed while the client is still open gets the generic error.
 func (c *Client) errOrClosed() error {
-	if c.err != nil {
-		return c.err
+	select {
+	case <-c.done:
+		if c.err != nil {
+			return c.err
+		}
+	default:
 	}
 	return errors.New("connection closed")
 }
@@ -349,13 +764,10 @@ func (c *Client) finish(err error) {
 		if c.cancel != nil {
 			c.cancel()
 		}
+		c.transportMu.Lock()
 		close(c.done)
-		c.pendingMu.Lock()
-		for _, ch := range c.pending {
-			ch <- response{err: err}
-		}
-		c.pending = map[string]chan response{}
-		c.pendingMu.Unlock()
+		c.transportMu.Unlock()
+		c.failPending(err)
 
 		c.subsMu.Lock()
 		subs := make([]*notificationSubscription, 0, len(c.subs))
@@ -363,59 +775,105 @@ func (c *Client) finish(err error) {
 			subs = append(subs, sub)
 		}
 		c.subs = map[int]*notificationSubscription{}
+		rawSubs := c.rawSubs
+		c.rawSubs = nil
 		c.subsMu.Unlock()
 
 		for _, sub := range subs {
 			sub.close()
 		}
+		for _, sub := range rawSubs {
+			sub.close()
+		}
+		c.audit.close()
 	})
 }
 
+// stopped reports whether the client has finished. Checked under subsMu it
+// orders a new subscription with finish, which closes done before it
+// closes the subscriptions.
+func (c *Client) stopped() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
 type response struct {
 	result json.RawMessage
 	err    error
 }
 
-type notificationSubscription struct {
-	out      chan Notification
-	inbox    chan Notification
+// subscription delivers published values to one consumer through an
+// unbounded queue, so a slow consumer never blocks the publisher.
+type subscription[T any] struct {
+	out      chan T
+	inbox    chan T
 	done     chan struct{}
 	doneOnce sync.Once
+	// onDepth, when set, receives changes in the number of queued values.
+	onDepth func(delta int)
+	// queue holds values to deliver before any published ones. It belongs
+	// to run once run starts.
+	queue []T
+	// paused and pauseLimit bound the backlog while a NotificationIterator
+	// is paused; overflow records that the bound was exceeded.
+	paused     atomic.Bool
+	pauseLimit atomic.Int64
+	overflow   atomic.Bool
 }
 
-func newNotificationSubscription(buffer int) *notificationSubscription {
+type notificationSubscription = subscription[Notification]
+
+func newSubscription[T any](buffer int) *subscription[T] {
 	if buffer <= 0 {
 		buffer = 64
 	}
-	sub := &notificationSubscription{
-		out:   make(chan Notification, buffer),
-		inbox: make(chan Notification),
+	sub := &subscription[T]{
+		out:   make(chan T, buffer),
+		inbox: make(chan T),
 		done:  make(chan struct{}),
 	}
-	go sub.run()
 	return sub
 }
 
-func (s *notificationSubscription) publish(note Notification) {
+func newNotificationSubscription(buffer int) *notificationSubscription {
+	return newSubscription[Notification](buffer)
+}
+
+func (s *subscription[T]) publish(value T) {
 	select {
 	case <-s.done:
-	case s.inbox <- note:
+	case s.inbox <- value:
 	}
 }
 
-func (s *notificationSubscription) close() {
+func (s *subscription[T]) close() {
 	s.doneOnce.Do(func() {
 		close(s.done)
 	})
 }
 
-func (s *notificationSubscription) run() {
+func (s *subscription[T]) run() {
 	defer close(s.out)
 
-	queue := make([]Notification, 0, 8)
+	queue := s.queue
+	s.queue = nil
+	if s.onDepth != nil {
+		if len(queue) > 0 {
+			s.onDepth(len(queue))
+		}
+		defer func() {
+			if len(queue) > 0 {
+				s.onDepth(-len(queue))
+			}
+		}()
+	}
 	for {
-		var out chan Notification
-		var next Notification
+		var out chan T
+		var next T
 		if len(queue) > 0 {
 			out = s.out
 			next = queue[0]
@@ -424,24 +882,47 @@ func (s *notificationSubscription) run() {
 		select {
 		case <-s.done:
 			return
-		case note := <-s.inbox:
-			queue = append(queue, note)
+		case value := <-s.inbox:
+			queue = append(queue, value)
+			if s.onDepth != nil {
+				s.onDepth(1)
+			}
+			if s.overPauseLimit(len(queue) + len(s.out)) {
+				s.overflow.Store(true)
+				s.close()
+				return
+			}
 		case out <- next:
 			queue = queue[1:]
+			if s.onDepth != nil {
+				s.onDepth(-1)
+			}
 		}
 	}
 }
 
 // NotificationIterator iterates notifications from the server.
 type NotificationIterator struct {
+	sub    *notificationSubscription
 	ch     <-chan Notification
 	done   <-chan struct{}
 	err    func() error
 	cancel func()
+
+	// resumed is closed by Resume; nil while not paused.
+	pauseMu sync.Mutex
+	resumed chan struct{}
 }
 
-// Next returns the next notification or an error.
+// Next returns the next notification or an error. While the iterator is
+// paused it waits for Resume.
 func (it *NotificationIterator) Next(ctx context.Context) (Notification, error) {
+	if err := it.waitResumed(ctx); err != nil {
+		return Notification{}, err
+	}
+	if it.sub != nil && it.sub.overflow.Load() {
+		return Notification{}, ErrPauseOverflow
+	}
 	select {
 	case <-ctx.Done():
 		return Notification{}, ctx.Err()
@@ -449,6 +930,9 @@ func (it *NotificationIterator) Next(ctx context.Context) (Notification, error)
 		return Notification{}, it.err()
 	case note, ok := <-it.ch:
 		if !ok {
+			if it.sub != nil && it.sub.overflow.Load() {
+				return Notification{}, ErrPauseOverflow
+			}
 			return Notification{}, it.err()
 		}
 		return note, nil
@@ -462,19 +946,26 @@ func (it *NotificationIterator) Close() {
 	}
 }
 
-// parseMessage decodes a JSON-RPC line into a typed message.
-func parseMessage(data []byte) (message, error) {
+// parseMessage decodes a JSON-RPC line into a typed message. Strict mode
+// also validates the envelope; see ClientOptions.StrictEnvelope.
+func parseMessage(data []byte, strict bool) (message, error) {
 	var envelope struct {
-		ID     json.RawMessage    `json:"id"`
-		Method string             `json:"method"`
-		Params json.RawMessage    `json:"params"`
-		Result json.RawMessage    `json:"result"`
-		Error  *JSONRPCErrorError `json:"error"`
+		JSONRPC *string            `json:"jsonrpc"`
+		ID      json.RawMessage    `json:"id"`
+		Method  string             `json:"method"`
+		Params  json.RawMessage    `json:"params"`
+		Result  json.RawMessage    `json:"result"`
+		Error   *JSONRPCErrorError `json:"error"`
 	}
 
 	if err := json.Unmarshal(data, &envelope); err != nil {
 		return message{}, err
 	}
+	if strict {
+		if err := validateEnvelope(envelope.JSONRPC, envelope.Method, len(envelope.Result) > 0, envelope.Error != nil); err != nil {
+			return message{}, err
+		}
+	}
 
 	if envelope.Method != "" {
 		if len(envelope.ID) > 0 {
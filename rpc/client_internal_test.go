@@ -16,7 +16,7 @@ func TestClientInternals(t *testing.T) {
 	client := &Client{
 		transport: transport,
 		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
-		pending:   make(map[string]chan response),
+		pending:   make(map[string]pendingCall),
 		subs:      make(map[int]*notificationSubscription),
 		done:      make(chan struct{}),
 	}
@@ -29,7 +29,7 @@ func TestClientInternals(t *testing.T) {
 
 	id := NewIntRequestID(1)
 	ch := make(chan response, 1)
-	client.pending[id.Key()] = ch
+	client.pending[id.Key()] = pendingCall{ch: ch, id: id}
 	client.deletePending(id)
 	if _, ok := client.pending[id.Key()]; ok {
 		t.Fatalf("expected pending to be deleted")
@@ -45,7 +45,7 @@ func TestClientInternals(t *testing.T) {
 	if err := client.replyResult(NewIntRequestID(3), map[string]any{"bad": func() {}}); err == nil {
 		t.Fatalf("expected replyResult error")
 	}
-	if err := client.send(map[string]any{"bad": func() {}}); err == nil {
+	if err := client.send(context.Background(), map[string]any{"bad": func() {}}, false); err == nil {
 		t.Fatalf("expected send error")
 	}
 
@@ -67,7 +67,7 @@ func TestHandleServerRequestErrors(t *testing.T) {
 	client := &Client{
 		transport: transport,
 		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
-		pending:   make(map[string]chan response),
+		pending:   make(map[string]pendingCall),
 		subs:      make(map[int]*notificationSubscription),
 		done:      make(chan struct{}),
 	}
@@ -130,6 +130,48 @@ func TestNotificationDeliveryDoesNotDropWhenBufferFills(t *testing.T) {
 	}
 }
 
+func TestClientCallRaw(t *testing.T) {
+	transcript := []TranscriptEntry{
+		writeLine(JSONRPCRequest{ID: NewIntRequestID(1), Method: "config/read", Params: mustRaw(map[string]any{})}),
+		readLine(JSONRPCResponse{ID: NewIntRequestID(1), Result: json.RawMessage(`{"config":{"model":"gpt-5"},"extra":[1,2]}`)}),
+		writeLine(JSONRPCRequest{ID: NewIntRequestID(2), Method: "config/read", Params: mustRaw(map[string]any{})}),
+		readLine(JSONRPCError{ID: NewIntRequestID(2), Error: JSONRPCErrorError{Code: -1, Message: "boom"}}),
+	}
+	client := NewClient(NewReplayTransport(transcript), ClientOptions{})
+	defer client.Close()
+
+	raw, err := client.CallRaw(context.Background(), "config/read", map[string]any{})
+	if err != nil {
+		t.Fatalf("call raw: %v", err)
+	}
+	if string(raw) != `{"config":{"model":"gpt-5"},"extra":[1,2]}` {
+		t.Fatalf("unexpected raw result: %s", raw)
+	}
+	if raw, err := client.CallRaw(context.Background(), "config/read", map[string]any{}); err == nil || raw != nil {
+		t.Fatalf("expected error and nil result, got %s, %v", raw, err)
+	}
+}
+
+func TestClientRequestIDPrefix(t *testing.T) {
+	transcript := []TranscriptEntry{
+		writeLine(JSONRPCRequest{ID: NewStringRequestID("go-sdk-1:1"), Method: "model/list", Params: mustRaw(map[string]any{})}),
+		readLine(JSONRPCResponse{ID: NewStringRequestID("go-sdk-1:1"), Result: mustRaw(map[string]any{"data": []any{}})}),
+		writeLine(JSONRPCRequest{ID: NewStringRequestID("go-sdk-1:2"), Method: "model/list", Params: mustRaw(map[string]any{})}),
+		readLine(JSONRPCError{ID: NewStringRequestID("go-sdk-1:2"), Error: JSONRPCErrorError{Code: -1, Message: "boom"}}),
+	}
+	client := NewClient(NewReplayTransport(transcript), ClientOptions{RequestIDPrefix: "go-sdk-1"})
+	defer client.Close()
+
+	var result map[string]any
+	if err := client.Call(context.Background(), "model/list", map[string]any{}, &result); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	var respErr *ResponseError
+	if err := client.Call(context.Background(), "model/list", map[string]any{}, &result); !errors.As(err, &respErr) || respErr.ID.String() != "go-sdk-1:2" {
+		t.Fatalf("expected error response for go-sdk-1:2, got %v", err)
+	}
+}
+
 func TestServerRequestDispatch(t *testing.T) {
 	resp := protocol.ApplyPatchApprovalResponse{Decision: "approved"}
 	handler := &testHandler{
@@ -215,6 +257,86 @@ func TestServerRequestHandlerDoesNotBlockReaderAndReceivesCloseContext(t *testin
 	}
 }
 
+func TestServerRequestHandlerPanicRepliesAndKeepsClient(t *testing.T) {
+	for _, timeout := range []time.Duration{0, time.Minute} {
+		t.Run("timeout "+timeout.String(), func(t *testing.T) {
+			handler := &testHandler{called: make(chan struct{}, 2), applyPatch: func(protocol.ApplyPatchApprovalParams) (*protocol.ApplyPatchApprovalResponse, error) {
+				panic("boom")
+			}}
+			transport := newChannelTransport()
+			client := NewClient(transport, ClientOptions{RequestHandler: handler, HandlerTimeout: timeout})
+			defer client.Close()
+
+			params := mustRaw(map[string]any{"callId": "call", "conversationId": "thr", "fileChanges": map[string]any{}})
+			transport.pushReadLine(mustJSON(JSONRPCRequest{ID: NewIntRequestID(9), Method: "applyPatchApproval", Params: params}))
+			writes := transport.waitForWrites(t, 1)
+			var reply JSONRPCError
+			if err := json.Unmarshal([]byte(writes[0]), &reply); err != nil {
+				t.Fatalf("decode reply: %v", err)
+			}
+			if reply.ID.Key() != NewIntRequestID(9).Key() || reply.Error.Code != CodeInternalError ||
+				!strings.Contains(reply.Error.Message, ErrHandlerPanic.Error()+": boom") {
+				t.Fatalf("unexpected reply: %s", writes[0])
+			}
+
+			// The client keeps serving requests after the panic.
+			transport.pushReadLine(mustJSON(JSONRPCRequest{ID: NewIntRequestID(10), Method: "applyPatchApproval", Params: params}))
+			transport.waitForWrites(t, 2)
+			select {
+			case <-client.Done():
+				t.Fatalf("client stopped after handler panic: %v", client.Err())
+			default:
+			}
+		})
+	}
+}
+
+func TestServerRequestHandlerTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler ServerRequestHandler
+	}{
+		{
+			name:    "handler honors context",
+			handler: &blockingServerRequestHandler{entered: make(chan struct{}), done: make(chan error, 1)},
+		},
+		{
+			name: "handler ignores context",
+			handler: &testHandler{applyPatch: func(protocol.ApplyPatchApprovalParams) (*protocol.ApplyPatchApprovalResponse, error) {
+				select {}
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := newChannelTransport()
+			client := NewClient(transport, ClientOptions{RequestHandler: tt.handler, HandlerTimeout: 10 * time.Millisecond})
+			defer client.Close()
+
+			transport.pushReadLine(mustJSON(JSONRPCRequest{
+				ID:     NewIntRequestID(9),
+				Method: "applyPatchApproval",
+				Params: mustRaw(map[string]any{"callId": "call", "conversationId": "thr", "fileChanges": map[string]any{}}),
+			}))
+
+			writes := transport.waitForWrites(t, 1)
+			var reply JSONRPCError
+			if err := json.Unmarshal([]byte(writes[0]), &reply); err != nil {
+				t.Fatalf("decode reply: %v", err)
+			}
+			if reply.ID.Key() != NewIntRequestID(9).Key() || reply.Error.Code != CodeInternalError ||
+				!strings.Contains(reply.Error.Message, ErrHandlerTimeout.Error()) {
+				t.Fatalf("unexpected reply: %s", writes[0])
+			}
+			if blocking, ok := tt.handler.(*blockingServerRequestHandler); ok {
+				if err := <-blocking.done; !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("expected handler deadline, got %v", err)
+				}
+			}
+		})
+	}
+}
+
 func TestRecordTransport(t *testing.T) {
 	base := &stubTransport{reads: []string{"hello"}}
 	recorder := NewRecordTransport(base)
@@ -362,6 +484,45 @@ func TestCallContextCancelAfterSend(t *testing.T) {
 	}
 }
 
+func TestCallMaxConcurrentCalls(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{MaxConcurrentCalls: 1})
+	defer client.Close()
+
+	first := make(chan error, 1)
+	go func() {
+		first <- client.Call(context.Background(), "ping", nil, nil)
+	}()
+	transport.waitForWrites(t, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.Call(ctx, "ping", nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected blocked call to time out, got %v", err)
+	}
+	transport.mu.Lock()
+	writes := len(transport.writes)
+	transport.mu.Unlock()
+	if writes != 1 {
+		t.Fatalf("expected blocked call not to write, got %d writes", writes)
+	}
+
+	transport.pushReadLine(mustJSON(JSONRPCResponse{ID: NewIntRequestID(1), Result: mustRaw(map[string]any{})}))
+	if err := <-first; err != nil {
+		t.Fatalf("first call failed: %v", err)
+	}
+
+	second := make(chan error, 1)
+	go func() {
+		second <- client.Call(context.Background(), "ping", nil, nil)
+	}()
+	transport.waitForWrites(t, 2)
+	transport.pushReadLine(mustJSON(JSONRPCResponse{ID: NewIntRequestID(2), Result: mustRaw(map[string]any{})}))
+	if err := <-second; err != nil {
+		t.Fatalf("second call failed: %v", err)
+	}
+}
+
 func TestCallInvalidResultJSON(t *testing.T) {
 	transcript := []TranscriptEntry{
 		writeLine(JSONRPCRequest{
@@ -393,6 +554,37 @@ func TestCallAfterClose(t *testing.T) {
 	}
 }
 
+func TestClientDoneReportsTransportFailure(t *testing.T) {
+	client := NewClient(&errorTransport{}, ClientOptions{})
+	defer client.Close()
+
+	select {
+	case <-client.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for Done")
+	}
+	if err := client.Err(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+func TestClientErrNilWhileOpen(t *testing.T) {
+	client := NewClient(newChannelTransport(), ClientOptions{})
+	if err := client.Err(); err != nil {
+		t.Fatalf("expected nil error while open, got %v", err)
+	}
+	select {
+	case <-client.Done():
+		t.Fatalf("done closed while open")
+	default:
+	}
+	_ = client.Close()
+	<-client.Done()
+	if err := client.Err(); err == nil {
+		t.Fatalf("expected error after close")
+	}
+}
+
 func TestNotifyContextCancel(t *testing.T) {
 	client := NewClient(NewReplayTransport(nil), ClientOptions{})
 	defer client.Close()
@@ -0,0 +1,186 @@
+package rpc
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Compression names a stream compression algorithm.
+type Compression string
+
+const (
+	// CompressionNone leaves the stream uncompressed.
+	CompressionNone Compression = "none"
+	// CompressionGzip compresses the stream with gzip.
+	CompressionGzip Compression = "gzip"
+	// CompressionDeflate compresses the stream with raw DEFLATE, which has
+	// slightly less framing overhead than gzip.
+	CompressionDeflate Compression = "deflate"
+)
+
+// compressionPreamble starts the uncompressed line each side sends before
+// the compressed stream begins.
+const compressionPreamble = "compress "
+
+// maxPreamble bounds the negotiation line.
+const maxPreamble = 256
+
+// compressTrailerTimeout bounds the write of the compressed stream's
+// trailer on Close.
+const compressTrailerTimeout = 200 * time.Millisecond
+
+// ErrCompressionNegotiation is returned when the peer does not follow the
+// compression handshake.
+var ErrCompressionNegotiation = errors.New("compression negotiation failed")
+
+// NegotiateCompression runs the client side of the compression handshake on
+// a freshly opened connection. It offers the algorithms in order of
+// preference as one uncompressed line ("compress gzip,deflate"), reads the
+// algorithm the peer picked, and returns conn wrapped to compress in both
+// directions. Both ends must use this package (the peer calls
+// AcceptCompression), since the app-server itself does not compress; put a
+// proxy in front of it for remote connections. DialOptions.Compression runs
+// this for DialTCP.
+func NegotiateCompression(conn io.ReadWriteCloser, offer ...Compression) (io.ReadWriteCloser, Compression, error) {
+	names := make([]string, 0, len(offer))
+	for _, algorithm := range offer {
+		names = append(names, string(algorithm))
+	}
+	if _, err := io.WriteString(conn, compressionPreamble+strings.Join(names, ",")+"\n"); err != nil {
+		return nil, "", fmt.Errorf("send compression offer: %w", err)
+	}
+	reply, err := readPreamble(conn)
+	if err != nil {
+		return nil, "", err
+	}
+	chosen := Compression(reply)
+	if chosen != CompressionNone && !slices.Contains(offer, chosen) {
+		return nil, "", fmt.Errorf("%w: peer chose %q, which was not offered", ErrCompressionNegotiation, reply)
+	}
+	return compressConn(conn, chosen), chosen, nil
+}
+
+// AcceptCompression runs the server side of the compression handshake. It
+// picks the first offered algorithm found in accept, or CompressionNone,
+// and returns conn wrapped accordingly.
+func AcceptCompression(conn io.ReadWriteCloser, accept ...Compression) (io.ReadWriteCloser, Compression, error) {
+	offer, err := readPreamble(conn)
+	if err != nil {
+		return nil, "", err
+	}
+	chosen := CompressionNone
+	for _, name := range strings.Split(offer, ",") {
+		if algorithm := Compression(strings.TrimSpace(name)); algorithm != CompressionNone && slices.Contains(accept, algorithm) {
+			chosen = algorithm
+			break
+		}
+	}
+	if _, err := io.WriteString(conn, compressionPreamble+string(chosen)+"\n"); err != nil {
+		return nil, "", fmt.Errorf("send compression choice: %w", err)
+	}
+	return compressConn(conn, chosen), chosen, nil
+}
+
+// readPreamble reads one handshake line a byte at a time, so none of the
+// compressed stream that follows it is consumed.
+func readPreamble(r io.Reader) (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	for len(line) < maxPreamble {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return "", fmt.Errorf("read compression handshake: %w", err)
+		}
+		if buf[0] == '\n' {
+			value, ok := strings.CutPrefix(string(line), compressionPreamble)
+			if !ok {
+				return "", fmt.Errorf("%w: unexpected line %q", ErrCompressionNegotiation, line)
+			}
+			return value, nil
+		}
+		line = append(line, buf[0])
+	}
+	return "", fmt.Errorf("%w: handshake line too long", ErrCompressionNegotiation)
+}
+
+func compressConn(conn io.ReadWriteCloser, algorithm Compression) io.ReadWriteCloser {
+	if algorithm == CompressionNone {
+		return conn
+	}
+	c := &compressedConn{conn: conn, algorithm: algorithm}
+	if algorithm == CompressionGzip {
+		c.writer = gzip.NewWriter(conn)
+	} else {
+		// NewWriter only fails for an invalid level.
+		c.writer, _ = flate.NewWriter(conn, flate.DefaultCompression)
+	}
+	return c
+}
+
+// flushWriter is the subset of gzip.Writer and flate.Writer in use.
+type flushWriter interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// compressedConn compresses writes and decompresses reads. Each Write is
+// flushed, so a message reaches the peer as soon as it is written.
+type compressedConn struct {
+	conn      io.ReadWriteCloser
+	algorithm Compression
+
+	writeMu sync.Mutex
+	writer  flushWriter
+
+	// reader is created on first Read, since gzip.NewReader blocks until
+	// the peer's header arrives.
+	reader    io.Reader
+	readerErr error
+}
+
+func (c *compressedConn) Read(p []byte) (int, error) {
+	if c.reader == nil && c.readerErr == nil {
+		if c.algorithm == CompressionGzip {
+			c.reader, c.readerErr = gzip.NewReader(c.conn)
+		} else {
+			c.reader = flate.NewReader(c.conn)
+		}
+	}
+	if c.readerErr != nil {
+		return 0, c.readerErr
+	}
+	return c.reader.Read(p)
+}
+
+func (c *compressedConn) Write(p []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	n, err := c.writer.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, c.writer.Flush()
+}
+
+// Close finishes the compressed stream and closes the connection, so the
+// peer reads a clean end of stream. The trailer is only written when conn
+// supports write deadlines: a deadline of compressTrailerTimeout bounds
+// both a write in progress and the trailer, so a peer that stopped reading
+// cannot block Close. Without it the peer's reads fail with
+// io.ErrUnexpectedEOF, as they do when the connection drops mid-stream.
+func (c *compressedConn) Close() error {
+	if deadliner, ok := c.conn.(interface{ SetWriteDeadline(time.Time) error }); ok {
+		if deadliner.SetWriteDeadline(time.Now().Add(compressTrailerTimeout)) == nil {
+			c.writeMu.Lock()
+			_ = c.writer.Close()
+			c.writeMu.Unlock()
+		}
+	}
+	return c.conn.Close()
+}
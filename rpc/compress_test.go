@@ -0,0 +1,153 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestCompressionNegotiation(t *testing.T) {
+	tests := []struct {
+		name   string
+		offer  []Compression
+		accept []Compression
+		want   Compression
+	}{
+		{name: "gzip", offer: []Compression{CompressionGzip, CompressionDeflate}, accept: []Compression{CompressionDeflate, CompressionGzip}, want: CompressionGzip},
+		{name: "deflate", offer: []Compression{CompressionDeflate}, accept: []Compression{CompressionGzip, CompressionDeflate}, want: CompressionDeflate},
+		{name: "no overlap", offer: []Compression{"zstd"}, accept: []Compression{CompressionGzip}, want: CompressionNone},
+		{name: "nothing offered", accept: []Compression{CompressionGzip}, want: CompressionNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientConn, serverConn := net.Pipe()
+			type result struct {
+				conn   io.ReadWriteCloser
+				chosen Compression
+				err    error
+			}
+			accepted := make(chan result, 1)
+			go func() {
+				conn, chosen, err := AcceptCompression(serverConn, tt.accept...)
+				accepted <- result{conn, chosen, err}
+			}()
+			client, chosen, err := NegotiateCompression(clientConn, tt.offer...)
+			if err != nil {
+				t.Fatalf("NegotiateCompression: %v", err)
+			}
+			server := <-accepted
+			if server.err != nil {
+				t.Fatalf("AcceptCompression: %v", server.err)
+			}
+			if chosen != tt.want || server.chosen != tt.want {
+				t.Fatalf("chosen = %s/%s, want %s", chosen, server.chosen, tt.want)
+			}
+
+			clientEnd, serverEnd := NewConnTransport(client), NewConnTransport(server.conn)
+			payload := `{"method":"item/agentMessage/delta","params":{"delta":"` + strings.Repeat("diff ", 2000) + `"}}`
+			go func() {
+				_ = clientEnd.WriteLine(payload)
+				_ = clientEnd.WriteLine("second")
+			}()
+			for _, want := range []string{payload, "second"} {
+				if line, err := serverEnd.ReadLine(); err != nil || line != want {
+					t.Fatalf("ReadLine = %.40q, %v", line, err)
+				}
+			}
+
+			// The pipe is synchronous, so the reader must be waiting for
+			// the trailer Close writes.
+			read := make(chan error, 1)
+			go func() {
+				_, err := serverEnd.ReadLine()
+				read <- err
+			}()
+			_ = clientEnd.Close()
+			if err := <-read; err != io.EOF {
+				t.Fatalf("ReadLine after close = %v, want EOF", err)
+			}
+			_ = serverEnd.Close()
+		})
+	}
+}
+
+func TestCompressedConnReportsTruncation(t *testing.T) {
+	for _, algorithm := range []Compression{CompressionGzip, CompressionDeflate} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			clientConn, serverConn := net.Pipe()
+			client, server := compressConn(clientConn, algorithm), NewConnTransport(compressConn(serverConn, algorithm))
+			defer server.Close()
+			go func() {
+				_, _ = client.Write([]byte("first\n"))
+				// Drop the connection without the compressed trailer.
+				_ = clientConn.Close()
+			}()
+			if line, err := server.ReadLine(); err != nil || line != "first" {
+				t.Fatalf("ReadLine = %q, %v", line, err)
+			}
+			if _, err := server.ReadLine(); !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Fatalf("ReadLine after a dropped connection = %v, want ErrUnexpectedEOF", err)
+			}
+		})
+	}
+}
+
+func TestCompressionNegotiationErrors(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	go func() {
+		defer serverConn.Close()
+		buf := make([]byte, 64)
+		_, _ = serverConn.Read(buf)
+		_, _ = serverConn.Write([]byte("compress deflate\n"))
+	}()
+	if _, _, err := NegotiateCompression(clientConn, CompressionGzip); !errors.Is(err, ErrCompressionNegotiation) {
+		t.Fatalf("expected negotiation error for an unoffered choice, got %v", err)
+	}
+
+	clientConn, serverConn = net.Pipe()
+	defer clientConn.Close()
+	go func() {
+		defer serverConn.Close()
+		_, _ = serverConn.Write([]byte(`{"jsonrpc":"2.0"}` + "\n"))
+	}()
+	if _, _, err := AcceptCompression(clientConn, CompressionGzip); !errors.Is(err, ErrCompressionNegotiation) {
+		t.Fatalf("expected negotiation error for a JSON line, got %v", err)
+	}
+}
+
+func TestDialTCPWithCompression(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		stream, _, err := AcceptCompression(conn, CompressionGzip)
+		if err != nil {
+			_ = conn.Close()
+			return
+		}
+		server := NewConnTransport(stream)
+		defer server.Close()
+		line, err := server.ReadLine()
+		if err != nil {
+			return
+		}
+		_ = server.WriteLine("echo " + line)
+	}()
+
+	transport, err := DialTCP(context.Background(), listener.Addr().String(), DialOptions{Compression: []Compression{CompressionGzip}})
+	if err != nil {
+		t.Fatalf("DialTCP: %v", err)
+	}
+	defer transport.Close()
+	expectEcho(t, transport)
+}
@@ -0,0 +1,39 @@
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+type correlationKey struct{}
+
+// WithCorrelationID returns a context carrying a correlation ID. Call logs
+// its request and response with the ID, so the log records of one
+// operation can be picked out of interleaved output. The facade sets one per
+// turn.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID returns the correlation ID carried by ctx, or "" if none.
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// NewCorrelationID returns a random 16-character hex ID.
+func NewCorrelationID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// ensureCorrelationID returns ctx with a correlation ID, adding a new one if
+// it has none.
+func ensureCorrelationID(ctx context.Context) context.Context {
+	if CorrelationID(ctx) != "" {
+		return ctx
+	}
+	return WithCorrelationID(ctx, NewCorrelationID())
+}
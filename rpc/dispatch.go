@@ -0,0 +1,133 @@
+package rpc
+
+import (
+	"encoding/json"
+	"log/slog"
+	"sync"
+)
+
+// inboundRequests tracks the IDs of server requests that have not been
+// answered yet, so a reused ID is caught before replies are mis-correlated.
+// IDs are released by replyResult and replyError.
+type inboundRequests struct {
+	mu   sync.Mutex
+	open map[string]bool
+}
+
+// add records id as open. It reports false if id is already open.
+func (r *inboundRequests) add(id RequestID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.open[id.Key()] {
+		return false
+	}
+	if r.open == nil {
+		r.open = make(map[string]bool)
+	}
+	r.open[id.Key()] = true
+	return true
+}
+
+func (r *inboundRequests) remove(id RequestID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.open, id.Key())
+}
+
+// threadQueues orders server requests per thread. Requests naming the same
+// thread are handled one at a time in arrival order, so a slow approval
+// delays only later approvals for that thread. Requests for other threads,
+// or naming none, run concurrently.
+type threadQueues struct {
+	mu sync.Mutex
+	// queues holds the requests waiting behind the one being handled for
+	// each busy thread. A thread has an entry while a worker is draining it.
+	queues map[string][]JSONRPCRequest
+}
+
+// scheduleServerRequest hands req to a worker without blocking the read
+// loop.
+func (c *Client) scheduleServerRequest(req JSONRPCRequest) {
+	_ = c.beginWork(false)
+	if !c.inbound.add(req.ID) {
+		c.logger.Warn("server reused an open request id", slog.String("id", req.ID.String()), slog.String("method", req.Method))
+		// Not replyError, which would release the ID of the request that
+		// is still open.
+		resp := JSONRPCError{ID: req.ID, Error: JSONRPCErrorError{Code: CodeInvalidRequest, Message: "duplicate request id " + req.ID.String()}}
+		go func() {
+			defer c.endWork()
+			_ = c.send(c.requestContext(), resp, false)
+		}()
+		return
+	}
+	thread := serverRequestThread(req.Params)
+	if thread == "" {
+		go c.runServerRequestSlot(req)
+		return
+	}
+
+	q := &c.threadQueues
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if waiting, busy := q.queues[thread]; busy {
+		q.queues[thread] = append(waiting, req)
+		return
+	}
+	if q.queues == nil {
+		q.queues = make(map[string][]JSONRPCRequest)
+	}
+	q.queues[thread] = nil
+	go c.drainThread(thread, req)
+}
+
+// drainThread handles req and then every request queued behind it for the
+// same thread.
+func (c *Client) drainThread(thread string, req JSONRPCRequest) {
+	q := &c.threadQueues
+	for {
+		c.runServerRequestSlot(req)
+
+		q.mu.Lock()
+		waiting := q.queues[thread]
+		if len(waiting) == 0 {
+			delete(q.queues, thread)
+			q.mu.Unlock()
+			return
+		}
+		req = waiting[0]
+		q.queues[thread] = waiting[1:]
+		q.mu.Unlock()
+	}
+}
+
+// runServerRequestSlot handles req once a handler slot is free. Requests
+// still waiting for a slot when the client stops are dropped, since their
+// replies could not be sent.
+func (c *Client) runServerRequestSlot(req JSONRPCRequest) {
+	defer c.endWork()
+	if c.handlerSlots != nil {
+		select {
+		case c.handlerSlots <- struct{}{}:
+			defer func() { <-c.handlerSlots }()
+		case <-c.done:
+			return
+		}
+	}
+	c.handleServerRequest(req)
+}
+
+// serverRequestThread returns the thread a server request belongs to. Legacy
+// approval requests name it conversationId.
+func serverRequestThread(params json.RawMessage) string {
+	var scope struct {
+		ThreadID       string `json:"threadId"`
+		ConversationID string `json:"conversationId"`
+	}
+	if len(params) == 0 || json.Unmarshal(params, &scope) != nil {
+		return ""
+	}
+	if scope.ThreadID != "" {
+		return scope.ThreadID
+	}
+	return scope.ConversationID
+}
@@ -0,0 +1,161 @@
+package rpc
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+func TestServerRequestsOrderedPerThread(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxHandlers   int
+		otherThreadOK bool
+	}{
+		{name: "unlimited", otherThreadOK: true},
+		{name: "one handler at a time", maxHandlers: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, started, release := newGatedHandler("a1", "a2", "b1")
+			transport := newChannelTransport()
+			client := NewClient(transport, ClientOptions{RequestHandler: handler, MaxConcurrentHandlers: tt.maxHandlers})
+			defer client.Close()
+
+			transport.pushReadLine(approvalRequest(1, "a1", "thr_a"))
+			expectStarted(t, started, "a1")
+			transport.pushReadLine(approvalRequest(2, "a2", "thr_a"))
+			transport.pushReadLine(approvalRequest(3, "b1", "thr_b"))
+
+			if tt.otherThreadOK {
+				expectStarted(t, started, "b1")
+			}
+			expectNotStarted(t, started)
+
+			close(release["a1"])
+			if tt.otherThreadOK {
+				expectStarted(t, started, "a2")
+			} else {
+				// b1 and a2 compete for the single slot; either may go first.
+				first := expectStarted(t, started, "")
+				expectNotStarted(t, started)
+				close(release[first])
+				expectStarted(t, started, map[string]string{"a2": "b1", "b1": "a2"}[first])
+			}
+			for _, ch := range release {
+				select {
+				case <-ch:
+				default:
+					close(ch)
+				}
+			}
+			transport.waitForWrites(t, 3)
+		})
+	}
+}
+
+func TestServerRequestThread(t *testing.T) {
+	tests := []struct {
+		params string
+		want   string
+	}{
+		{params: `{"threadId":"thr_1","turnId":"t"}`, want: "thr_1"},
+		{params: `{"conversationId":"thr_2"}`, want: "thr_2"},
+		{params: `{"callId":"c"}`},
+		{params: `[1]`},
+		{},
+	}
+	for _, tt := range tests {
+		if got := serverRequestThread([]byte(tt.params)); got != tt.want {
+			t.Fatalf("serverRequestThread(%s) = %q, want %q", tt.params, got, tt.want)
+		}
+	}
+}
+
+// newGatedHandler returns a handler whose applyPatchApproval calls report
+// their callId on started and block until the call's release channel closes.
+func newGatedHandler(callIDs ...string) (*testHandler, chan string, map[string]chan struct{}) {
+	started := make(chan string, len(callIDs))
+	release := make(map[string]chan struct{}, len(callIDs))
+	for _, id := range callIDs {
+		release[id] = make(chan struct{})
+	}
+	handler := &testHandler{
+		called: make(chan struct{}, len(callIDs)),
+		applyPatch: func(params protocol.ApplyPatchApprovalParams) (*protocol.ApplyPatchApprovalResponse, error) {
+			started <- params.CallID
+			<-release[params.CallID]
+			return &protocol.ApplyPatchApprovalResponse{Decision: "approved"}, nil
+		},
+	}
+	return handler, started, release
+}
+
+func approvalRequest(id int64, callID, threadID string) string {
+	return mustJSON(JSONRPCRequest{
+		ID:     NewIntRequestID(id),
+		Method: "applyPatchApproval",
+		Params: mustRaw(map[string]any{"callId": callID, "conversationId": threadID, "fileChanges": map[string]any{}}),
+	})
+}
+
+// expectStarted waits for the next handler call and checks its callId,
+// unless want is empty.
+func expectStarted(t *testing.T, started chan string, want string) string {
+	t.Helper()
+	select {
+	case got := <-started:
+		if want != "" && got != want {
+			t.Fatalf("started %s, want %s", got, want)
+		}
+		return got
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for handler %s", want)
+		return ""
+	}
+}
+
+func expectNotStarted(t *testing.T, started chan string) {
+	t.Helper()
+	select {
+	case got := <-started:
+		t.Fatalf("handler %s started out of turn", got)
+	case <-time.After(30 * time.Millisecond):
+	}
+}
+
+func TestDuplicateInboundRequestID(t *testing.T) {
+	handler, started, release := newGatedHandler("a1", "b1", "c1")
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{RequestHandler: handler})
+	defer client.Close()
+
+	transport.pushReadLine(approvalRequest(1, "a1", "thr_a"))
+	expectStarted(t, started, "a1")
+	transport.pushReadLine(approvalRequest(1, "b1", "thr_b"))
+
+	writes := transport.waitForWrites(t, 1)
+	var reply JSONRPCError
+	if err := json.Unmarshal([]byte(writes[0]), &reply); err != nil {
+		t.Fatalf("decode reply: %v", err)
+	}
+	if reply.ID.Key() != NewIntRequestID(1).Key() || reply.Error.Code != CodeInvalidRequest || reply.Error.Message != "duplicate request id 1" {
+		t.Fatalf("unexpected reply: %s", writes[0])
+	}
+	expectNotStarted(t, started)
+
+	close(release["a1"])
+	writes = transport.waitForWrites(t, 2)
+	if !strings.Contains(writes[1], `"result":{"decision":"approved"}`) {
+		t.Fatalf("expected original request to be answered, got %s", writes[1])
+	}
+
+	// Once answered, the ID may be used again.
+	close(release["c1"])
+	transport.pushReadLine(approvalRequest(1, "c1", "thr_c"))
+	expectStarted(t, started, "c1")
+	transport.waitForWrites(t, 3)
+}
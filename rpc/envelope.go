@@ -0,0 +1,51 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ErrInvalidEnvelope is the cause reported for lines that strict envelope
+// validation rejects; see ClientOptions.StrictEnvelope.
+var ErrInvalidEnvelope = errors.New("invalid json-rpc envelope")
+
+// MalformedMessageError describes an incoming line the client dropped
+// because it is not a valid JSON-RPC message.
+type MalformedMessageError struct {
+	Line string
+	// Err is the JSON decoding error, or wraps ErrInvalidEnvelope.
+	Err error
+}
+
+func (e *MalformedMessageError) Error() string {
+	return fmt.Sprintf("malformed json-rpc message: %v", e.Err)
+}
+
+func (e *MalformedMessageError) Unwrap() error {
+	return e.Err
+}
+
+// validateEnvelope applies the strict-mode checks to a decoded envelope.
+func validateEnvelope(version *string, method string, hasResult, hasError bool) error {
+	switch {
+	case version == nil:
+		return fmt.Errorf("%w: missing jsonrpc version", ErrInvalidEnvelope)
+	case *version != "2.0":
+		return fmt.Errorf("%w: jsonrpc version %q, want \"2.0\"", ErrInvalidEnvelope, *version)
+	case method != "" && (hasResult || hasError):
+		return fmt.Errorf("%w: %s carries a result or error", ErrInvalidEnvelope, method)
+	case hasResult && hasError:
+		return fmt.Errorf("%w: response carries both result and error", ErrInvalidEnvelope)
+	}
+	return nil
+}
+
+// reportMalformed logs a dropped line and passes it to
+// ClientOptions.OnMalformedMessage.
+func (c *Client) reportMalformed(line string, err error) {
+	c.logger.Warn("failed to parse json-rpc message", slog.Any("error", err))
+	if c.onMalformed != nil {
+		c.onMalformed(&MalformedMessageError{Line: line, Err: err})
+	}
+}
@@ -0,0 +1,102 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Standard JSON-RPC error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Sentinel errors matched by ResponseError through errors.Is.
+var (
+	// ErrParseError matches code -32700.
+	ErrParseError = errors.New("json-rpc parse error")
+	// ErrInvalidRequest matches code -32600.
+	ErrInvalidRequest = errors.New("json-rpc invalid request")
+	// ErrMethodNotFound matches code -32601.
+	ErrMethodNotFound = errors.New("json-rpc method not found")
+	// ErrInvalidParams matches code -32602.
+	ErrInvalidParams = errors.New("json-rpc invalid params")
+	// ErrInternalError matches code -32603.
+	ErrInternalError = errors.New("json-rpc internal error")
+	// ErrRateLimited matches errors caused by a usage limit or an upstream
+	// HTTP 429, as reported in the error data's codexErrorInfo or, failing
+	// that, in the message.
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// Is reports whether the error matches one of the sentinel errors above,
+// so callers can write errors.Is(err, rpc.ErrMethodNotFound).
+func (err *ResponseError) Is(target error) bool {
+	switch target {
+	case ErrParseError:
+		return err.Detail.Code == CodeParseError
+	case ErrInvalidRequest:
+		return err.Detail.Code == CodeInvalidRequest
+	case ErrMethodNotFound:
+		return err.Detail.Code == CodeMethodNotFound
+	case ErrInvalidParams:
+		return err.Detail.Code == CodeInvalidParams
+	case ErrInternalError:
+		return err.Detail.Code == CodeInternalError
+	case ErrRateLimited:
+		return err.rateLimited()
+	}
+	return false
+}
+
+// Code returns the JSON-RPC error code.
+func (err *ResponseError) Code() int64 {
+	return err.Detail.Code
+}
+
+// Data returns the error's structured data payload, or nil if the server
+// sent none.
+func (err *ResponseError) Data() json.RawMessage {
+	return err.Detail.Data
+}
+
+// DecodeData unmarshals the error's data payload into v. It returns an
+// error if the server sent no data.
+func (err *ResponseError) DecodeData(v any) error {
+	if len(err.Detail.Data) == 0 {
+		return errors.New("json-rpc error has no data")
+	}
+	return json.Unmarshal(err.Detail.Data, v)
+}
+
+func (err *ResponseError) rateLimited() bool {
+	var data struct {
+		CodexErrorInfo json.RawMessage `json:"codexErrorInfo"`
+		HTTPStatusCode int             `json:"httpStatusCode"`
+	}
+	if len(err.Detail.Data) > 0 && json.Unmarshal(err.Detail.Data, &data) == nil {
+		if data.HTTPStatusCode == 429 {
+			return true
+		}
+		var code string
+		if json.Unmarshal(data.CodexErrorInfo, &code) == nil && code == "usageLimitExceeded" {
+			return true
+		}
+		var variants map[string]struct {
+			HTTPStatusCode int `json:"httpStatusCode"`
+		}
+		if json.Unmarshal(data.CodexErrorInfo, &variants) == nil {
+			for code, fields := range variants {
+				if code == "usageLimitExceeded" || fields.HTTPStatusCode == 429 {
+					return true
+				}
+			}
+		}
+	}
+	message := strings.ToLower(err.Detail.Message)
+	return strings.Contains(message, "rate limit") || strings.Contains(message, "too many requests")
+}
@@ -0,0 +1,65 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestResponseErrorIs(t *testing.T) {
+	tests := []struct {
+		name   string
+		detail JSONRPCErrorError
+		target error
+		want   bool
+	}{
+		{name: "method not found", detail: JSONRPCErrorError{Code: CodeMethodNotFound}, target: ErrMethodNotFound, want: true},
+		{name: "invalid params", detail: JSONRPCErrorError{Code: CodeInvalidParams}, target: ErrInvalidParams, want: true},
+		{name: "invalid request", detail: JSONRPCErrorError{Code: CodeInvalidRequest}, target: ErrInvalidRequest, want: true},
+		{name: "parse error", detail: JSONRPCErrorError{Code: CodeParseError}, target: ErrParseError, want: true},
+		{name: "internal error", detail: JSONRPCErrorError{Code: CodeInternalError}, target: ErrInternalError, want: true},
+		{name: "other code", detail: JSONRPCErrorError{Code: CodeInternalError}, target: ErrMethodNotFound},
+		{
+			name:   "usage limit variant",
+			detail: JSONRPCErrorError{Code: CodeInternalError, Data: json.RawMessage(`{"codexErrorInfo":"usageLimitExceeded"}`)},
+			target: ErrRateLimited,
+			want:   true,
+		},
+		{
+			name:   "forwarded 429",
+			detail: JSONRPCErrorError{Code: CodeInternalError, Data: json.RawMessage(`{"codexErrorInfo":{"responseStreamConnectionFailed":{"httpStatusCode":429}}}`)},
+			target: ErrRateLimited,
+			want:   true,
+		},
+		{name: "rate limit message", detail: JSONRPCErrorError{Code: CodeInternalError, Message: "Rate limit reached"}, target: ErrRateLimited, want: true},
+		{
+			name:   "other failure",
+			detail: JSONRPCErrorError{Code: CodeInternalError, Message: "boom", Data: json.RawMessage(`{"codexErrorInfo":"internalServerError"}`)},
+			target: ErrRateLimited,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error = &ResponseError{Detail: tt.detail}
+			if got := errors.Is(err, tt.target); got != tt.want {
+				t.Fatalf("errors.Is(%v, %v) = %v, want %v", err, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseErrorData(t *testing.T) {
+	err := &ResponseError{Detail: JSONRPCErrorError{Code: CodeInvalidParams, Data: json.RawMessage(`{"field":"threadId"}`)}}
+	if err.Code() != CodeInvalidParams {
+		t.Fatalf("code = %d", err.Code())
+	}
+	var data struct {
+		Field string `json:"field"`
+	}
+	if decodeErr := err.DecodeData(&data); decodeErr != nil || data.Field != "threadId" {
+		t.Fatalf("decode data = %+v, %v", data, decodeErr)
+	}
+	if decodeErr := (&ResponseError{}).DecodeData(&data); decodeErr == nil {
+		t.Fatalf("expected error decoding missing data")
+	}
+}
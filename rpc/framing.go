@@ -0,0 +1,132 @@
+package rpc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Framing selects how StdioTransport and ConnTransport delimit messages on
+// the wire.
+type Framing int
+
+const (
+	// FramingLines sends one JSON message per line (JSONL). The codex
+	// app-server uses this framing.
+	FramingLines Framing = iota
+	// FramingContentLength prefixes each message with LSP-style headers
+	// ("Content-Length: N\r\n\r\n"), for deployments that front the
+	// app-server with a Language Server Protocol style bridge.
+	FramingContentLength
+)
+
+func (f Framing) String() string {
+	switch f {
+	case FramingLines:
+		return "lines"
+	case FramingContentLength:
+		return "content-length"
+	default:
+		return fmt.Sprintf("Framing(%d)", int(f))
+	}
+}
+
+// ErrInvalidFrame is returned when a Content-Length framed message has
+// missing, malformed or oversized headers.
+var ErrInvalidFrame = errors.New("invalid message frame")
+
+const (
+	// maxHeaderLine bounds one Content-Length header line, in bytes.
+	maxHeaderLine = 1024
+	// maxHeaders bounds the header lines before a Content-Length body.
+	maxHeaders = 32
+)
+
+// readFrame reads the next message. limit is SizeLimits.MaxLine.
+func readFrame(reader *bufio.Reader, framing Framing, limit int) (string, error) {
+	if framing == FramingContentLength {
+		return readContentLength(reader, limit)
+	}
+	return readLimitedLine(reader, limit)
+}
+
+// writeFrame writes line as one message.
+func writeFrame(w io.Writer, framing Framing, line string) error {
+	line = strings.TrimSuffix(line, "\n")
+	if framing == FramingContentLength {
+		line = "Content-Length: " + strconv.Itoa(len(line)) + "\r\n\r\n" + line
+	} else {
+		line += "\n"
+	}
+	_, err := io.WriteString(w, line)
+	return err
+}
+
+// readContentLength reads one header block and the body it announces.
+// Headers other than Content-Length, such as Content-Type, are ignored. A
+// body over limit is discarded so the stream stays in sync. Header lines
+// over maxHeaderLine bytes and blocks over maxHeaders lines are rejected.
+func readContentLength(reader *bufio.Reader, limit int) (string, error) {
+	length := -1
+	for count := 0; ; count++ {
+		if count == maxHeaders {
+			return "", fmt.Errorf("%w: more than %d headers", ErrInvalidFrame, maxHeaders)
+		}
+		header, err := readHeaderLine(reader)
+		if err != nil {
+			if errors.Is(err, io.EOF) && count == 0 && header == "" {
+				return "", io.EOF
+			}
+			if errors.Is(err, io.EOF) {
+				return "", io.ErrUnexpectedEOF
+			}
+			return "", err
+		}
+		header = strings.TrimRight(header, "\r\n")
+		if header == "" {
+			break
+		}
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			return "", fmt.Errorf("%w: malformed header %q", ErrInvalidFrame, header)
+		}
+		if !strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("%w: bad Content-Length %q", ErrInvalidFrame, value)
+		}
+		length = n
+	}
+	if length < 0 {
+		return "", fmt.Errorf("%w: missing Content-Length", ErrInvalidFrame)
+	}
+	if limit > 0 && length > limit {
+		if _, err := reader.Discard(length); err != nil {
+			return "", err
+		}
+		return "", &MessageTooLargeError{Kind: "line", Size: length, Limit: limit}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		if errors.Is(err, io.EOF) {
+			return "", io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+	return string(body), nil
+}
+
+// readHeaderLine reads one header line, including its newline, without
+// buffering more than the reader's buffer.
+func readHeaderLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadSlice('\n')
+	if len(line) > maxHeaderLine || errors.Is(err, bufio.ErrBufferFull) {
+		return "", fmt.Errorf("%w: header line over %d bytes", ErrInvalidFrame, maxHeaderLine)
+	}
+	return string(line), err
+}
@@ -0,0 +1,102 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadContentLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		limit   int
+		want    []string
+		wantErr error
+	}{
+		{
+			name:  "two frames",
+			input: "Content-Length: 2\r\n\r\n{}Content-Length: 7\r\n\r\n{\"a\":1}",
+			want:  []string{"{}", `{"a":1}`},
+		},
+		{
+			name:  "extra headers and bare newlines",
+			input: "content-length: 2\nContent-Type: application/vscode-jsonrpc; charset=utf-8\n\n{}",
+			want:  []string{"{}"},
+		},
+		{
+			name:    "oversized frame is skipped",
+			input:   "Content-Length: 9\r\n\r\n{\"ab\":12}Content-Length: 2\r\n\r\n{}",
+			limit:   4,
+			want:    []string{"{}"},
+			wantErr: ErrMessageTooLarge,
+		},
+		{name: "missing length", input: "Content-Type: json\r\n\r\n{}", wantErr: ErrInvalidFrame},
+		{name: "bad length", input: "Content-Length: x\r\n\r\n", wantErr: ErrInvalidFrame},
+		{name: "malformed header", input: "garbage\r\n\r\n", wantErr: ErrInvalidFrame},
+		{name: "truncated body", input: "Content-Length: 5\r\n\r\n{}", wantErr: io.ErrUnexpectedEOF},
+		{name: "truncated headers", input: "Content-Length: 5\r\n", wantErr: io.ErrUnexpectedEOF},
+		{name: "empty stream", input: "", wantErr: io.EOF},
+		{name: "long header line", input: "X-Pad: " + strings.Repeat("a", maxHeaderLine) + "\r\n\r\n", wantErr: ErrInvalidFrame},
+		{name: "unterminated header", input: strings.Repeat("a", 8192), wantErr: ErrInvalidFrame},
+		{name: "too many headers", input: strings.Repeat("X-Pad: a\r\n", maxHeaders) + "Content-Length: 2\r\n\r\n{}", wantErr: ErrInvalidFrame},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := bufio.NewReader(strings.NewReader(tt.input))
+			if tt.wantErr != nil {
+				if _, err := readFrame(reader, FramingContentLength, tt.limit); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+			}
+			for _, want := range tt.want {
+				got, err := readFrame(reader, FramingContentLength, tt.limit)
+				if err != nil || got != want {
+					t.Fatalf("readFrame = %q, %v; want %q", got, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteFrame(t *testing.T) {
+	tests := []struct {
+		framing Framing
+		line    string
+		want    string
+	}{
+		{framing: FramingLines, line: "{}", want: "{}\n"},
+		{framing: FramingLines, line: "{}\n", want: "{}\n"},
+		{framing: FramingContentLength, line: `{"a":"é"}`, want: "Content-Length: 10\r\n\r\n{\"a\":\"é\"}"},
+		{framing: FramingContentLength, line: "{}\n", want: "Content-Length: 2\r\n\r\n{}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.framing.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeFrame(&buf, tt.framing, tt.line); err != nil {
+				t.Fatalf("writeFrame: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Fatalf("wrote %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestConnTransportContentLengthFraming(t *testing.T) {
+	clientEnd, serverEnd := NewPipeTransports()
+	defer clientEnd.Close()
+	defer serverEnd.Close()
+	clientEnd.SetFraming(FramingContentLength)
+	serverEnd.SetFraming(FramingContentLength)
+
+	go func() {
+		_ = clientEnd.WriteLine(`{"id":1,"method":"ping"}`)
+	}()
+	if line, err := serverEnd.ReadLine(); err != nil || line != `{"id":1,"method":"ping"}` {
+		t.Fatalf("ReadLine = %q, %v", line, err)
+	}
+}
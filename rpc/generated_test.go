@@ -64,6 +64,50 @@ func TestGeneratedNotifications(t *testing.T) {
 		if note.Method != method {
 			t.Fatalf("unexpected method: %s", note.Method)
 		}
+		// Payloads without a generated or manual type decode into maps.
+		if _, untyped := note.Params.(map[string]any); untyped {
+			t.Errorf("notification %s has no typed params", method)
+		}
+	}
+}
+
+func TestGeneratedDeltaNotifications(t *testing.T) {
+	tests := []struct {
+		method string
+		params string
+		want   any
+	}{
+		{
+			method: "item/agentMessage/delta",
+			params: `{"threadId":"thr","turnId":"t1","itemId":"i1","delta":"Hel"}`,
+			want:   protocol.AgentMessageDeltaNotification{ThreadID: "thr", TurnID: "t1", ItemID: "i1", Delta: "Hel"},
+		},
+		{
+			method: "item/commandExecution/outputDelta",
+			params: `{"threadId":"thr","turnId":"t1","itemId":"i2","delta":"ok\n"}`,
+			want:   protocol.CommandExecutionOutputDeltaNotification{ThreadID: "thr", TurnID: "t1", ItemID: "i2", Delta: "ok\n"},
+		},
+		{
+			method: "command/exec/outputDelta",
+			params: `{"processId":"p1","stream":"stderr","deltaBase64":"b29w","capReached":true}`,
+			want:   protocol.CommandExecOutputDeltaNotification{ProcessID: "p1", Stream: "stderr", DeltaBase64: "b29w", CapReached: true},
+		},
+		{
+			method: "item/started",
+			params: `{"threadId":"thr","turnId":"t1","item":{"type":"reasoning","id":"i3"}}`,
+			want:   protocol.ItemStartedNotification{ThreadID: "thr", TurnID: "t1", Item: json.RawMessage(`{"type":"reasoning","id":"i3"}`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			note, err := parseServerNotification(tt.method, json.RawMessage(tt.params))
+			if err != nil {
+				t.Fatalf("parseServerNotification: %v", err)
+			}
+			if !reflect.DeepEqual(note.Params, tt.want) {
+				t.Fatalf("params = %#v, want %#v", note.Params, tt.want)
+			}
+		})
 	}
 }
 
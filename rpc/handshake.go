@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ErrHandshakeRejected is returned when the peer refuses the credentials
+// sent during an authentication handshake.
+var ErrHandshakeRejected = errors.New("authentication handshake rejected")
+
+// authFailed is the only reason AcceptTokenHandshake gives a refused
+// client, so an unauthenticated peer learns nothing about why.
+const authFailed = "authentication failed"
+
+// Handshake runs on a freshly connected transport before any JSON-RPC
+// traffic, for example to authenticate with a gateway in front of a shared
+// app-server. It may read and write lines; the connection is closed when it
+// returns an error. DialOptions.Handshake runs one for DialTCP.
+type Handshake func(ctx context.Context, transport Transport) error
+
+// authLine is the message exchanged by TokenHandshake and
+// AcceptTokenHandshake. The client sends {"auth":{"token":...}}; the peer
+// replies {"auth":{"ok":true}} or {"auth":{"error":...}}.
+type authLine struct {
+	Auth authPayload `json:"auth"`
+}
+
+type authPayload struct {
+	Token string `json:"token,omitempty"`
+	OK    bool   `json:"ok,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// TokenHandshake returns a Handshake that sends token as one line and waits
+// for the peer to accept it. The peer runs AcceptTokenHandshake. Use TLS so
+// the token is not sent in clear text.
+func TokenHandshake(token string) Handshake {
+	return func(ctx context.Context, transport Transport) error {
+		data, err := json.Marshal(authLine{Auth: authPayload{Token: token}})
+		if err != nil {
+			return err
+		}
+		if err := transport.WriteLine(string(data)); err != nil {
+			return fmt.Errorf("send auth token: %w", err)
+		}
+		line, err := transport.ReadLine()
+		if err != nil {
+			return fmt.Errorf("read auth reply: %w", err)
+		}
+		var reply authLine
+		if err := json.Unmarshal([]byte(line), &reply); err != nil {
+			return fmt.Errorf("decode auth reply: %w", err)
+		}
+		if !reply.Auth.OK {
+			if reply.Auth.Error == "" {
+				return ErrHandshakeRejected
+			}
+			return fmt.Errorf("%w: %s", ErrHandshakeRejected, reply.Auth.Error)
+		}
+		return nil
+	}
+}
+
+// AcceptTokenHandshake runs the gateway side of TokenHandshake. It reads the
+// client's token, passes it to verify and replies with the outcome. A
+// refused client is only told "authentication failed"; the reason is logged
+// with slog's default logger and returned, and the caller should then close
+// the connection.
+func AcceptTokenHandshake(transport Transport, verify func(token string) error) error {
+	line, err := transport.ReadLine()
+	if err != nil {
+		return fmt.Errorf("read auth token: %w", err)
+	}
+	var request authLine
+	if err := json.Unmarshal([]byte(line), &request); err != nil || request.Auth.Token == "" {
+		return rejectAuth(transport, errors.New("expected an auth token"))
+	}
+	if err := verify(request.Auth.Token); err != nil {
+		return rejectAuth(transport, err)
+	}
+	return writeAuthReply(transport, authPayload{OK: true})
+}
+
+func rejectAuth(transport Transport, reason error) error {
+	slog.Default().Warn("rejected auth handshake", slog.Any("error", reason))
+	_ = writeAuthReply(transport, authPayload{Error: authFailed})
+	return fmt.Errorf("%w: %w", ErrHandshakeRejected, reason)
+}
+
+func writeAuthReply(transport Transport, reply authPayload) error {
+	data, err := json.Marshal(authLine{Auth: reply})
+	if err != nil {
+		return err
+	}
+	return transport.WriteLine(string(data))
+}
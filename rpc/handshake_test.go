@@ -0,0 +1,94 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveTokenEcho accepts one connection, authenticates it against token and
+// echoes one line.
+func serveTokenEcho(listener net.Listener, token string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	server := NewConnTransport(conn)
+	defer server.Close()
+	err = AcceptTokenHandshake(server, func(got string) error {
+		if got != token {
+			return errors.New("unknown token")
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	line, err := server.ReadLine()
+	if err != nil {
+		return
+	}
+	_ = server.WriteLine("echo " + line)
+}
+
+func TestDialTCPTokenHandshake(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "accepted", token: "secret"},
+		{name: "rejected", token: "wrong", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("listen: %v", err)
+			}
+			defer listener.Close()
+			go serveTokenEcho(listener, "secret")
+
+			transport, err := DialTCP(context.Background(), listener.Addr().String(), DialOptions{Handshake: TokenHandshake(tt.token)})
+			if tt.wantErr {
+				if !errors.Is(err, ErrHandshakeRejected) {
+					t.Fatalf("DialTCP error = %v, want ErrHandshakeRejected", err)
+				}
+				if strings.Contains(err.Error(), "unknown token") {
+					t.Fatalf("DialTCP error %q leaks the gateway's reason", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DialTCP: %v", err)
+			}
+			defer transport.Close()
+			expectEcho(t, transport)
+		})
+	}
+}
+
+func TestDialTCPHandshakeHonoursContext(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = DialTCP(ctx, listener.Addr().String(), DialOptions{Handshake: TokenHandshake("secret")})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("DialTCP error = %v, want DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,78 @@
+package rpc
+
+import "context"
+
+// CallInvoker performs a client request. Interceptors call it to continue the chain.
+type CallInvoker func(ctx context.Context, method string, params any, result any) error
+
+// CallInterceptor wraps outgoing requests and their responses. It may modify
+// params before invoking next, inspect result or the error afterwards, or
+// short-circuit by returning without calling next.
+type CallInterceptor func(ctx context.Context, method string, params any, result any, next CallInvoker) error
+
+// NotificationInvoker delivers a notification to subscribers.
+type NotificationInvoker func(note Notification)
+
+// NotificationInterceptor wraps delivery of server notifications. Returning
+// without calling next drops the notification.
+type NotificationInterceptor func(note Notification, next NotificationInvoker)
+
+// ServerRequestInvoker dispatches a server-initiated request to the handler.
+type ServerRequestInvoker func(ctx context.Context, req JSONRPCRequest) (any, error)
+
+// ServerRequestInterceptor wraps dispatch of server-initiated requests such as
+// approvals. The returned value is sent back to the server as the result.
+type ServerRequestInterceptor func(ctx context.Context, req JSONRPCRequest, next ServerRequestInvoker) (any, error)
+
+// Interceptor groups optional hooks applied by a Client. Nil fields are skipped.
+// When several interceptors are configured, the first one is outermost.
+type Interceptor struct {
+	Call          CallInterceptor
+	Notification  NotificationInterceptor
+	ServerRequest ServerRequestInterceptor
+}
+
+func chainCall(interceptors []Interceptor, final CallInvoker) CallInvoker {
+	next := final
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor := interceptors[i].Call
+		if interceptor == nil {
+			continue
+		}
+		inner := next
+		next = func(ctx context.Context, method string, params any, result any) error {
+			return interceptor(ctx, method, params, result, inner)
+		}
+	}
+	return next
+}
+
+func chainNotification(interceptors []Interceptor, final NotificationInvoker) NotificationInvoker {
+	next := final
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor := interceptors[i].Notification
+		if interceptor == nil {
+			continue
+		}
+		inner := next
+		next = func(note Notification) {
+			interceptor(note, inner)
+		}
+	}
+	return next
+}
+
+func chainServerRequest(interceptors []Interceptor, final ServerRequestInvoker) ServerRequestInvoker {
+	next := final
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor := interceptors[i].ServerRequest
+		if interceptor == nil {
+			continue
+		}
+		inner := next
+		next = func(ctx context.Context, req JSONRPCRequest) (any, error) {
+			return interceptor(ctx, req, inner)
+		}
+	}
+	return next
+}
@@ -0,0 +1,133 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+func TestCallInterceptorsOrderAndParams(t *testing.T) {
+	transcript := []TranscriptEntry{
+		writeLine(JSONRPCRequest{
+			ID:     NewIntRequestID(1),
+			Method: "ping",
+			Params: mustRaw(map[string]any{"token": "secret"}),
+		}),
+		readLine(JSONRPCResponse{
+			ID:     NewIntRequestID(1),
+			Result: mustRaw(map[string]any{"ok": true}),
+		}),
+	}
+
+	var order []string
+	client := NewClient(NewReplayTransport(transcript), ClientOptions{Interceptors: []Interceptor{
+		{Call: func(ctx context.Context, method string, params any, result any, next CallInvoker) error {
+			order = append(order, "outer")
+			return next(ctx, method, map[string]any{"token": "secret"}, result)
+		}},
+		{},
+		{Call: func(ctx context.Context, method string, params any, result any, next CallInvoker) error {
+			order = append(order, "inner")
+			err := next(ctx, method, params, result)
+			if got := (*result.(*map[string]any))["ok"]; got != true {
+				t.Errorf("expected decoded result inside interceptor, got %v", got)
+			}
+			return err
+		}},
+	}})
+	defer client.Close()
+
+	var result map[string]any
+	if err := client.Call(context.Background(), "ping", nil, &result); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if strings.Join(order, ",") != "outer,inner" {
+		t.Fatalf("unexpected interceptor order: %v", order)
+	}
+}
+
+func TestCallInterceptorShortCircuit(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{Interceptors: []Interceptor{
+		{Call: func(context.Context, string, any, any, CallInvoker) error {
+			return errors.New("chaos")
+		}},
+	}})
+	defer client.Close()
+
+	if err := client.Call(context.Background(), "ping", nil, nil); err == nil || err.Error() != "chaos" {
+		t.Fatalf("expected chaos error, got %v", err)
+	}
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.writes) != 0 {
+		t.Fatalf("expected no writes, got %d", len(transport.writes))
+	}
+}
+
+func TestNotificationInterceptorCanDropAndRewrite(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{Interceptors: []Interceptor{
+		{Notification: func(note Notification, next NotificationInvoker) {
+			if note.Method == "drop/me" {
+				return
+			}
+			note.Method = "seen/" + note.Method
+			next(note)
+		}},
+	}})
+	defer client.Close()
+
+	iter := client.SubscribeNotifications(0)
+	defer iter.Close()
+
+	transport.pushReadLine(mustJSON(JSONRPCNotification{Method: "drop/me"}))
+	transport.pushReadLine(mustJSON(JSONRPCNotification{Method: "keep"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	note, err := iter.Next(ctx)
+	if err != nil {
+		t.Fatalf("next failed: %v", err)
+	}
+	if note.Method != "seen/keep" {
+		t.Fatalf("unexpected notification: %s", note.Method)
+	}
+}
+
+func TestServerRequestInterceptor(t *testing.T) {
+	transport := newChannelTransport()
+	handler := &testHandler{}
+	var seen string
+	client := NewClient(transport, ClientOptions{
+		RequestHandler: handler,
+		Interceptors: []Interceptor{
+			{ServerRequest: func(ctx context.Context, req JSONRPCRequest, next ServerRequestInvoker) (any, error) {
+				seen = req.Method
+				result, err := next(ctx, req)
+				if resp, ok := result.(*protocol.ApplyPatchApprovalResponse); ok {
+					resp.Decision = "denied"
+				}
+				return result, err
+			}},
+		},
+	})
+	defer client.Close()
+
+	transport.pushReadLine(mustJSON(JSONRPCRequest{
+		ID:     NewIntRequestID(7),
+		Method: "applyPatchApproval",
+		Params: mustRaw(map[string]any{"callId": "c", "conversationId": "thr", "fileChanges": map[string]any{}}),
+	}))
+	writes := transport.waitForWrites(t, 1)
+	if seen != "applyPatchApproval" {
+		t.Fatalf("expected interceptor to see request, got %q", seen)
+	}
+	if !strings.Contains(writes[0], `"decision":"denied"`) {
+		t.Fatalf("expected rewritten decision, got %s", writes[0])
+	}
+}
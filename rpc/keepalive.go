@@ -0,0 +1,122 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultKeepaliveInterval         = 30 * time.Second
+	defaultKeepaliveTimeout          = 10 * time.Second
+	defaultKeepaliveFailureThreshold = 3
+	defaultKeepaliveMethod           = "ping"
+)
+
+// ErrKeepaliveFailed is the client error after too many consecutive
+// keepalive probes went unanswered.
+var ErrKeepaliveFailed = errors.New("keepalive failed")
+
+// KeepalivePolicy configures periodic liveness probes. Each probe is a
+// request for Method; any response, including a JSON-RPC error such as
+// "method not found", proves the server is alive. After FailureThreshold
+// consecutive probes time out, the client is closed with ErrKeepaliveFailed
+// so Done fires and a half-open connection is not mistaken for a slow one.
+type KeepalivePolicy struct {
+	// Interval is the delay between probes (defaults to 30s).
+	Interval time.Duration
+	// Timeout bounds the wait for each probe's response (defaults to 10s).
+	Timeout time.Duration
+	// FailureThreshold is the number of consecutive failed probes that mark
+	// the connection dead (defaults to 3).
+	FailureThreshold int
+	// Method is the request sent as a probe (defaults to "ping").
+	Method string
+}
+
+func (p *KeepalivePolicy) normalized() *KeepalivePolicy {
+	if p == nil {
+		return nil
+	}
+	out := *p
+	if out.Interval <= 0 {
+		out.Interval = defaultKeepaliveInterval
+	}
+	if out.Timeout <= 0 {
+		out.Timeout = defaultKeepaliveTimeout
+	}
+	if out.FailureThreshold <= 0 {
+		out.FailureThreshold = defaultKeepaliveFailureThreshold
+	}
+	if out.Method == "" {
+		out.Method = defaultKeepaliveMethod
+	}
+	return &out
+}
+
+// keepalive probes the server every interval until the client stops.
+func (c *Client) keepalive(policy *KeepalivePolicy) {
+	ticker := time.NewTicker(policy.Interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+		if c.isDraining() {
+			continue
+		}
+		err := c.probe(policy)
+		if err == nil {
+			failures = 0
+			continue
+		}
+		failures++
+		c.logger.Warn("json-rpc keepalive probe failed", "failures", failures, "error", err)
+		if failures >= policy.FailureThreshold {
+			c.finish(fmt.Errorf("%w: %d consecutive probes failed: %w", ErrKeepaliveFailed, failures, err))
+			transport, _ := c.currentTransport()
+			_ = transport.Close()
+			return
+		}
+	}
+}
+
+// probe sends one keepalive request. Probes bypass interceptors, metrics
+// and retries so they do not show up as application traffic.
+func (c *Client) probe(policy *KeepalivePolicy) error {
+	ctx, cancel := context.WithTimeout(c.requestContext(), policy.Timeout)
+	defer cancel()
+	return c.ping(ctx, policy.Method)
+}
+
+// Ping sends one liveness probe and returns nil once the server answers.
+// Any response counts, including a JSON-RPC error such as "method not
+// found". The probe is a request for ClientOptions.Keepalive's Method, or
+// "ping" without a keepalive policy, and bypasses interceptors, metrics
+// and retries.
+func (c *Client) Ping(ctx context.Context) error {
+	if err := c.Err(); err != nil {
+		return err
+	}
+	return c.ping(ctx, c.pingMethod)
+}
+
+func (c *Client) ping(ctx context.Context, method string) error {
+	_, err := c.callOnce(ctx, method, nil, nil)
+	var respErr *ResponseError
+	if errors.As(err, &respErr) {
+		return nil
+	}
+	return err
+}
+
+func (c *Client) isDraining() bool {
+	c.activeMu.Lock()
+	defer c.activeMu.Unlock()
+	return c.draining
+}
@@ -0,0 +1,126 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeepaliveMarksUnresponsiveClientDead(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{Keepalive: &KeepalivePolicy{
+		Interval:         5 * time.Millisecond,
+		Timeout:          5 * time.Millisecond,
+		FailureThreshold: 2,
+	}})
+	defer client.Close()
+
+	select {
+	case <-client.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for keepalive to close the client")
+	}
+	if err := client.Err(); !errors.Is(err, ErrKeepaliveFailed) {
+		t.Fatalf("expected keepalive failure, got %v", err)
+	}
+	writes := transport.waitForWrites(t, 2)
+	if !strings.Contains(writes[0], `"method":"ping"`) {
+		t.Fatalf("expected ping probe, got %s", writes[0])
+	}
+}
+
+func TestKeepaliveTreatsErrorResponsesAsAlive(t *testing.T) {
+	transport := newEchoErrorTransport()
+	client := NewClient(transport, ClientOptions{Keepalive: &KeepalivePolicy{
+		Interval:         2 * time.Millisecond,
+		Timeout:          50 * time.Millisecond,
+		FailureThreshold: 1,
+		Method:           "config/read",
+	}})
+	defer client.Close()
+
+	waitFor(t, func() bool { return transport.answered.Load() >= 5 })
+	if err := client.Err(); err != nil {
+		t.Fatalf("expected client to stay open, got %v", err)
+	}
+}
+
+func TestClientPing(t *testing.T) {
+	transport := newEchoErrorTransport()
+	client := NewClient(transport, ClientOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if transport.answered.Load() != 1 {
+		t.Fatalf("answered %d probes, want 1", transport.answered.Load())
+	}
+	_ = client.Close()
+	if err := client.Ping(ctx); err == nil {
+		t.Fatalf("expected Ping to fail on a closed client")
+	}
+}
+
+func TestKeepalivePolicyDefaults(t *testing.T) {
+	policy := (&KeepalivePolicy{}).normalized()
+	if policy.Interval != defaultKeepaliveInterval || policy.Timeout != defaultKeepaliveTimeout ||
+		policy.FailureThreshold != defaultKeepaliveFailureThreshold || policy.Method != defaultKeepaliveMethod {
+		t.Fatalf("unexpected defaults: %+v", policy)
+	}
+	if (*KeepalivePolicy)(nil).normalized() != nil {
+		t.Fatalf("expected nil policy to stay nil")
+	}
+}
+
+// echoErrorTransport answers every request with a method-not-found error.
+type echoErrorTransport struct {
+	mu       sync.Mutex
+	closed   bool
+	reads    chan string
+	answered atomic.Int64
+}
+
+func newEchoErrorTransport() *echoErrorTransport {
+	return &echoErrorTransport{reads: make(chan string, 16)}
+}
+
+func (t *echoErrorTransport) ReadLine() (string, error) {
+	line, ok := <-t.reads
+	if !ok {
+		return "", io.EOF
+	}
+	return line, nil
+}
+
+func (t *echoErrorTransport) WriteLine(line string) error {
+	var req JSONRPCRequest
+	if err := json.Unmarshal([]byte(line), &req); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return io.ErrClosedPipe
+	}
+	t.answered.Add(1)
+	t.reads <- mustJSON(JSONRPCError{ID: req.ID, Error: JSONRPCErrorError{Code: -32601, Message: "method not found"}})
+	return nil
+}
+
+func (t *echoErrorTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.closed {
+		t.closed = true
+		close(t.reads)
+	}
+	return nil
+}
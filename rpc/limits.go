@@ -0,0 +1,103 @@
+package rpc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// ErrMessageTooLarge matches every *MessageTooLargeError.
+var ErrMessageTooLarge = errors.New("message too large")
+
+// SizeLimits bounds incoming messages, so a malformed or hostile server
+// cannot make the client buffer arbitrarily large payloads.
+type SizeLimits struct {
+	// MaxLine caps the bytes of one incoming line. A longer line stops the
+	// client with a *MessageTooLargeError. StdioTransport and ConnTransport
+	// stop reading at the limit instead of buffering the whole line; other
+	// transports are checked after ReadLine returns. Zero means no limit.
+	MaxLine int
+	// MaxResult caps the bytes of a response result. A larger result fails
+	// its Call with a *MessageTooLargeError. Zero means no limit.
+	MaxResult int
+	// Unlimited lists methods whose results are known to be large, such as
+	// thread/read with turns. MaxResult does not apply to them; MaxLine
+	// still does.
+	Unlimited []string
+}
+
+// MessageTooLargeError reports an incoming message over a SizeLimits bound.
+type MessageTooLargeError struct {
+	// Kind is "line" or "result".
+	Kind string
+	// Method is the request method, for results.
+	Method string
+	// Size is the number of bytes read. For lines cut off at the limit it
+	// is the limit plus the bytes of the chunk that crossed it.
+	Size  int
+	Limit int
+}
+
+func (e *MessageTooLargeError) Error() string {
+	if e.Method != "" {
+		return fmt.Sprintf("%s %s of %d bytes exceeds limit of %d bytes", e.Method, e.Kind, e.Size, e.Limit)
+	}
+	return fmt.Sprintf("incoming %s of %d bytes exceeds limit of %d bytes", e.Kind, e.Size, e.Limit)
+}
+
+func (e *MessageTooLargeError) Is(target error) bool {
+	return target == ErrMessageTooLarge
+}
+
+// lineLimiter is implemented by transports that can stop reading a line at
+// a size limit.
+type lineLimiter interface {
+	setMaxLine(limit int)
+}
+
+// checkLine enforces MaxLine on a line a transport has already read.
+func (l *SizeLimits) checkLine(line string) error {
+	if l == nil || l.MaxLine <= 0 || len(line) <= l.MaxLine {
+		return nil
+	}
+	return &MessageTooLargeError{Kind: "line", Size: len(line), Limit: l.MaxLine}
+}
+
+// checkResult enforces MaxResult on the result of a call to method.
+func (l *SizeLimits) checkResult(method string, result []byte) error {
+	if l == nil || l.MaxResult <= 0 || len(result) <= l.MaxResult || slices.Contains(l.Unlimited, method) {
+		return nil
+	}
+	return &MessageTooLargeError{Kind: "result", Method: method, Size: len(result), Limit: l.MaxResult}
+}
+
+// readLimitedLine reads the next line without its trailing newline. With a
+// positive limit it fails once the line grows past limit bytes, leaving the
+// rest of the line unread.
+func readLimitedLine(reader *bufio.Reader, limit int) (string, error) {
+	var line []byte
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		line = append(line, chunk...)
+		size := len(line)
+		if err == nil {
+			size--
+		}
+		if limit > 0 && size > limit {
+			return "", &MessageTooLargeError{Kind: "line", Size: size, Limit: limit}
+		}
+		switch {
+		case err == nil:
+			return strings.TrimRight(string(line), "\n"), nil
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF) && len(line) > 0:
+			return strings.TrimRight(string(line), "\n"), nil
+		default:
+			return "", err
+		}
+	}
+}
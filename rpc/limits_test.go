@@ -0,0 +1,93 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadLimitedLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		limit   int
+		want    []string
+		wantErr bool
+	}{
+		{name: "no limit", input: "abc\nde", want: []string{"abc", "de"}},
+		{name: "at limit", input: "abc\nabcd\n", limit: 4, want: []string{"abc", "abcd"}},
+		{name: "over limit", input: "abc\nabcde\n", limit: 4, want: []string{"abc"}, wantErr: true},
+		{name: "over limit at eof", input: "abcde", limit: 4, wantErr: true},
+		{name: "longer than reader buffer", input: strings.Repeat("x", 40) + "\n", limit: 100, want: []string{strings.Repeat("x", 40)}},
+		{name: "over limit beyond reader buffer", input: strings.Repeat("x", 40) + "\n", limit: 30, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := bufio.NewReaderSize(strings.NewReader(tt.input), 16)
+			for _, want := range tt.want {
+				got, err := readLimitedLine(reader, tt.limit)
+				if err != nil || got != want {
+					t.Fatalf("readLimitedLine() = %q, %v; want %q", got, err, want)
+				}
+			}
+			_, err := readLimitedLine(reader, tt.limit)
+			if tt.wantErr {
+				var tooLarge *MessageTooLargeError
+				if !errors.As(err, &tooLarge) || tooLarge.Limit != tt.limit || !errors.Is(err, ErrMessageTooLarge) {
+					t.Fatalf("expected MessageTooLargeError, got %v", err)
+				}
+			} else if !errors.Is(err, io.EOF) {
+				t.Fatalf("expected EOF, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSizeLimitLineStopsClient(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{SizeLimits: &SizeLimits{MaxLine: 64}})
+	defer client.Close()
+
+	transport.pushReadLine(`{"jsonrpc":"2.0","method":"item/agentMessage/delta","params":{"delta":"` + strings.Repeat("x", 64) + `"}}`)
+	<-client.Done()
+	var tooLarge *MessageTooLargeError
+	if err := client.Err(); !errors.As(err, &tooLarge) || tooLarge.Kind != "line" {
+		t.Fatalf("expected line too large, got %v", err)
+	}
+}
+
+func TestSizeLimitResult(t *testing.T) {
+	large := `"` + strings.Repeat("x", 32) + `"`
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{SizeLimits: &SizeLimits{MaxResult: 16, Unlimited: []string{"thread/read"}}})
+	defer client.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		var result string
+		done <- client.Call(context.Background(), "config/read", nil, &result)
+	}()
+	transport.waitForWrites(t, 1)
+	transport.pushReadLine(`{"jsonrpc":"2.0","id":1,"result":` + large + `}`)
+	err := <-done
+	var tooLarge *MessageTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Method != "config/read" || tooLarge.Size != len(large) {
+		t.Fatalf("expected result too large, got %v", err)
+	}
+	if err.Error() != "config/read result of 34 bytes exceeds limit of 16 bytes" {
+		t.Fatalf("error = %q", err)
+	}
+
+	go func() {
+		var result string
+		done <- client.Call(context.Background(), "thread/read", nil, &result)
+	}()
+	transport.waitForWrites(t, 2)
+	transport.pushReadLine(`{"jsonrpc":"2.0","id":2,"result":` + large + `}`)
+	if err := <-done; err != nil {
+		t.Fatalf("expected unlimited method to succeed, got %v", err)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
 )
 
 func TestRequestIDJSON(t *testing.T) {
@@ -54,40 +57,93 @@ func TestRequestIDJSON(t *testing.T) {
 }
 
 func TestParseMessageVariants(t *testing.T) {
-	msg, err := parseMessage([]byte(`{"id":1,"method":"ping","params":{"ok":true}}`))
+	msg, err := parseMessage([]byte(`{"id":1,"method":"ping","params":{"ok":true}}`), false)
 	if err != nil || msg.kind != messageRequest {
 		t.Fatalf("expected request message, got %#v err=%v", msg, err)
 	}
 
-	msg, err = parseMessage([]byte(`{"method":"notify","params":{"ok":true}}`))
+	msg, err = parseMessage([]byte(`{"method":"notify","params":{"ok":true}}`), false)
 	if err != nil || msg.kind != messageNotification {
 		t.Fatalf("expected notification message, got %#v err=%v", msg, err)
 	}
 
-	msg, err = parseMessage([]byte(`{"id":2,"result":{"ok":true}}`))
+	msg, err = parseMessage([]byte(`{"id":2,"result":{"ok":true}}`), false)
 	if err != nil || msg.kind != messageResponse {
 		t.Fatalf("expected response message, got %#v err=%v", msg, err)
 	}
 
-	msg, err = parseMessage([]byte(`{"id":3,"error":{"code":-1,"message":"bad"}}`))
+	msg, err = parseMessage([]byte(`{"id":3,"error":{"code":-1,"message":"bad"}}`), false)
 	if err != nil || msg.kind != messageError {
 		t.Fatalf("expected error message, got %#v err=%v", msg, err)
 	}
 
-	if _, err := parseMessage([]byte(`{"jsonrpc":"2.0"}`)); err == nil {
+	if _, err := parseMessage([]byte(`{"jsonrpc":"2.0"}`), false); err == nil {
 		t.Fatalf("expected unrecognized message error")
 	}
-	if _, err := parseMessage([]byte(`{"id":{},"method":"ping"}`)); err == nil {
+	if _, err := parseMessage([]byte(`{"id":{},"method":"ping"}`), false); err == nil {
 		t.Fatalf("expected invalid request id error")
 	}
-	if _, err := parseMessage([]byte(`{"id":{},"result":{}}`)); err == nil {
+	if _, err := parseMessage([]byte(`{"id":{},"result":{}}`), false); err == nil {
 		t.Fatalf("expected invalid response id error")
 	}
-	if _, err := parseMessage([]byte(`{"id":{},"error":{"code":-1,"message":"bad"}}`)); err == nil {
+	if _, err := parseMessage([]byte(`{"id":{},"error":{"code":-1,"message":"bad"}}`), false); err == nil {
 		t.Fatalf("expected invalid error id error")
 	}
 }
 
+func TestParseMessageStrict(t *testing.T) {
+	tests := []struct {
+		line    string
+		lenient messageKind
+		wantErr string
+	}{
+		{line: `{"jsonrpc":"2.0","id":1,"method":"ping"}`, lenient: messageRequest},
+		{line: `{"jsonrpc":"2.0","id":2,"result":{}}`, lenient: messageResponse},
+		{line: `{"id":2,"result":{}}`, lenient: messageResponse, wantErr: "missing jsonrpc version"},
+		{line: `{"jsonrpc":"1.0","method":"notify"}`, lenient: messageNotification, wantErr: `jsonrpc version "1.0"`},
+		{line: `{"jsonrpc":"2.0","id":3,"result":{},"error":{"code":-1,"message":"bad"}}`, lenient: messageResponse, wantErr: "both result and error"},
+		{line: `{"jsonrpc":"2.0","id":4,"method":"ping","result":{}}`, lenient: messageRequest, wantErr: "ping carries a result or error"},
+	}
+	for _, tt := range tests {
+		msg, err := parseMessage([]byte(tt.line), false)
+		if err != nil || msg.kind != tt.lenient {
+			t.Fatalf("lenient parse of %s = %v, %v", tt.line, msg.kind, err)
+		}
+		_, err = parseMessage([]byte(tt.line), true)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Fatalf("strict parse of %s: %v", tt.line, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidEnvelope) || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Fatalf("strict parse of %s = %v, want %q", tt.line, err, tt.wantErr)
+		}
+	}
+}
+
+func TestClientReportsMalformedMessages(t *testing.T) {
+	transport := newChannelTransport()
+	reports := make(chan *MalformedMessageError, 2)
+	client := NewClient(transport, ClientOptions{
+		StrictEnvelope:     true,
+		OnMalformedMessage: func(err *MalformedMessageError) { reports <- err },
+	})
+	defer client.Close()
+
+	transport.pushReadLine(`not json`)
+	transport.pushReadLine(`{"method":"turn/started","params":{}}`)
+
+	first, second := <-reports, <-reports
+	var syntaxErr *json.SyntaxError
+	if first.Line != "not json" || !errors.As(first, &syntaxErr) {
+		t.Fatalf("unexpected first report: %v", first)
+	}
+	if !errors.Is(second, ErrInvalidEnvelope) || !strings.Contains(second.Error(), "malformed json-rpc message") {
+		t.Fatalf("unexpected second report: %v", second)
+	}
+}
+
 func TestNotificationUnmarshalParams(t *testing.T) {
 	var payload map[string]bool
 	note := Notification{Raw: json.RawMessage(`{"ok":true}`)}
@@ -113,6 +169,36 @@ func TestNotificationUnmarshalParams(t *testing.T) {
 	}
 }
 
+func TestNotificationJSONRoundTrip(t *testing.T) {
+	note, err := parseServerNotification("turn/started", json.RawMessage(`{"threadId":"thr_1"}`))
+	if err != nil {
+		t.Fatalf("parse notification: %v", err)
+	}
+	data, err := json.Marshal(note)
+	if err != nil {
+		t.Fatalf("marshal notification: %v", err)
+	}
+	if string(data) != `{"method":"turn/started","params":{"threadId":"thr_1"}}` {
+		t.Fatalf("unexpected encoding: %s", data)
+	}
+
+	var decoded Notification
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal notification: %v", err)
+	}
+	if decoded.Method != "turn/started" || string(decoded.Raw) != `{"threadId":"thr_1"}` {
+		t.Fatalf("unexpected decoded notification: %#v", decoded)
+	}
+	if _, ok := decoded.Params.(protocol.TurnStartedNotification); !ok {
+		t.Fatalf("expected typed params, got %T", decoded.Params)
+	}
+
+	data, err = json.Marshal(Notification{Method: "custom", Params: map[string]any{"ok": true}})
+	if err != nil || string(data) != `{"method":"custom","params":{"ok":true}}` {
+		t.Fatalf("unexpected typed-only encoding: %s err=%v", data, err)
+	}
+}
+
 func TestReplayJSONLineHelpers(t *testing.T) {
 	if !equalJSONLine(`{"a":1,"b":2}`, `{"b":2,"a":1}`) {
 		t.Fatalf("expected equal json lines")
@@ -0,0 +1,57 @@
+package rpc
+
+import (
+	"errors"
+	"time"
+)
+
+// Queue names reported through Metrics.QueueDepth.
+const (
+	// QueuePendingRequests counts client requests awaiting a response.
+	QueuePendingRequests = "pending_requests"
+	// QueueNotifications counts notifications buffered for slow subscribers
+	// across all subscriptions, beyond each subscription's channel buffer.
+	QueueNotifications = "notifications"
+)
+
+// Metrics receives client instrumentation callbacks. Implementations must be
+// safe for concurrent use and should return quickly; they run on the client's
+// read and call paths.
+type Metrics interface {
+	// RequestStarted is called when Call begins.
+	RequestStarted(method string)
+	// RequestFinished is called when Call returns. errorCode is the JSON-RPC
+	// error code when the server answered with an error, and zero otherwise.
+	RequestFinished(method string, duration time.Duration, errorCode int64, err error)
+	// NotificationReceived is called for every server notification.
+	NotificationReceived(method string)
+	// QueueDepth reports the current depth of a named queue whenever it changes.
+	QueueDepth(queue string, depth int)
+}
+
+func (c *Client) observeCall(method string, call func() error) error {
+	if c.metrics == nil {
+		return call()
+	}
+	c.metrics.RequestStarted(method)
+	start := time.Now()
+	err := call()
+	var code int64
+	var respErr *ResponseError
+	if errors.As(err, &respErr) {
+		code = respErr.Detail.Code
+	}
+	c.metrics.RequestFinished(method, time.Since(start), code, err)
+	return err
+}
+
+func (c *Client) reportPending(depth int) {
+	if c.metrics != nil {
+		c.metrics.QueueDepth(QueuePendingRequests, depth)
+	}
+}
+
+func (c *Client) adjustQueuedNotifications(delta int) {
+	depth := c.queuedNotes.Add(int64(delta))
+	c.metrics.QueueDepth(QueueNotifications, int(depth))
+}
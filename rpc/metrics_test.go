@@ -0,0 +1,161 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClientMetrics(t *testing.T) {
+	transport := newChannelTransport()
+	metrics := &recordingMetrics{}
+	client := NewClient(transport, ClientOptions{Metrics: metrics})
+	defer client.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Call(context.Background(), "model/list", nil, nil)
+	}()
+	transport.waitForWrites(t, 1)
+	transport.pushReadLine(mustJSON(JSONRPCResponse{ID: NewIntRequestID(1), Result: mustRaw(map[string]any{})}))
+	if err := <-done; err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+
+	go func() {
+		done <- client.Call(context.Background(), "thread/start", nil, nil)
+	}()
+	transport.waitForWrites(t, 2)
+	transport.pushReadLine(mustJSON(JSONRPCError{ID: NewIntRequestID(2), Error: JSONRPCErrorError{Code: -32600, Message: "bad"}}))
+	var respErr *ResponseError
+	if err := <-done; !errors.As(err, &respErr) {
+		t.Fatalf("expected response error, got %v", err)
+	}
+
+	iter := client.SubscribeNotifications(1)
+	defer iter.Close()
+	for range 3 {
+		transport.pushReadLine(mustJSON(JSONRPCNotification{Method: "turn/started"}))
+	}
+	waitFor(t, func() bool { return metrics.queueDepth(QueueNotifications) == 2 })
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for range 3 {
+		if _, err := iter.Next(ctx); err != nil {
+			t.Fatalf("next failed: %v", err)
+		}
+	}
+	waitFor(t, func() bool { return metrics.queueDepth(QueueNotifications) == 0 })
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if !slices.Equal(metrics.started, []string{"model/list", "thread/start"}) {
+		t.Fatalf("unexpected started calls: %v", metrics.started)
+	}
+	if len(metrics.finished) != 2 {
+		t.Fatalf("expected 2 finished calls, got %d", len(metrics.finished))
+	}
+	if got := metrics.finished[0]; got.method != "model/list" || got.code != 0 || got.err != nil {
+		t.Fatalf("unexpected success record: %#v", got)
+	}
+	if got := metrics.finished[1]; got.method != "thread/start" || got.code != -32600 || got.err == nil {
+		t.Fatalf("unexpected error record: %#v", got)
+	}
+	if metrics.notifications["turn/started"] != 3 {
+		t.Fatalf("expected 3 notifications, got %d", metrics.notifications["turn/started"])
+	}
+	if got := metrics.depths[QueuePendingRequests]; !slices.Equal(got, []int{1, 0, 1, 0}) {
+		t.Fatalf("unexpected pending depths: %v", got)
+	}
+}
+
+func TestClientMetricsRequestCanceled(t *testing.T) {
+	transport := newChannelTransport()
+	metrics := &recordingMetrics{}
+	client := NewClient(transport, ClientOptions{Metrics: metrics})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := client.Call(ctx, "model/list", nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if len(metrics.finished) != 1 || !errors.Is(metrics.finished[0].err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected finished records: %#v", metrics.finished)
+	}
+	if got := metrics.depths[QueuePendingRequests]; !slices.Equal(got, []int{1, 0}) {
+		t.Fatalf("unexpected pending depths: %v", got)
+	}
+}
+
+type finishedCall struct {
+	method   string
+	duration time.Duration
+	code     int64
+	err      error
+}
+
+type recordingMetrics struct {
+	mu            sync.Mutex
+	started       []string
+	finished      []finishedCall
+	notifications map[string]int
+	depths        map[string][]int
+}
+
+func (m *recordingMetrics) RequestStarted(method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.started = append(m.started, method)
+}
+
+func (m *recordingMetrics) RequestFinished(method string, duration time.Duration, code int64, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.finished = append(m.finished, finishedCall{method: method, duration: duration, code: code, err: err})
+}
+
+func (m *recordingMetrics) NotificationReceived(method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.notifications == nil {
+		m.notifications = map[string]int{}
+	}
+	m.notifications[method]++
+}
+
+func (m *recordingMetrics) QueueDepth(queue string, depth int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.depths == nil {
+		m.depths = map[string][]int{}
+	}
+	m.depths[queue] = append(m.depths[queue], depth)
+}
+
+func (m *recordingMetrics) queueDepth(queue string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	values := m.depths[queue]
+	if len(values) == 0 {
+		return -1
+	}
+	return values[len(values)-1]
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met before deadline")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
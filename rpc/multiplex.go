@@ -0,0 +1,389 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+const muxBuffer = 64
+
+// ErrMuxOverflow is returned by a MuxTransport whose client fell more than
+// muxBuffer lines behind. The Multiplexer drops such a client rather than
+// stall the others.
+var ErrMuxOverflow = errors.New("multiplexed client fell behind")
+
+// Multiplexer shares one app-server connection between several Clients,
+// for example one per tenant or subsystem, so each does not need its own
+// process. Open returns a Transport for each logical client.
+//
+// Request IDs are rewritten on the shared connection, so clients may use
+// overlapping IDs. Responses go back to the client that sent the request.
+// Notifications and server requests that name a thread go to the client
+// that started, resumed or last used that thread. Notifications without a
+// known thread go to every client, and server requests without one go to
+// the oldest open client.
+//
+// Each client buffers up to muxBuffer routed lines. A client that falls
+// further behind is closed with ErrMuxOverflow, so one stalled consumer
+// cannot hold up the shared connection.
+//
+// The app-server accepts one initialize handshake per connection. The
+// first client's initialize is sent; the others receive its result.
+type Multiplexer struct {
+	base Transport
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	conns   []*MuxTransport
+	pending map[int64]muxPending
+	nextID  int64
+	owners  map[string]*MuxTransport
+	err     error
+
+	// initResult is the result of the first successful initialize.
+	initResult  json.RawMessage
+	initWaiters []muxPending
+	initPending bool
+	initialized bool
+}
+
+// muxPending is a request forwarded to the server, keyed by its rewritten
+// ID.
+type muxPending struct {
+	conn   *MuxTransport
+	id     json.RawMessage
+	method string
+}
+
+// muxEnvelope holds the fields the multiplexer routes on.
+type muxEnvelope struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+}
+
+func (e muxEnvelope) hasID() bool {
+	return len(e.ID) > 0 && string(e.ID) != "null"
+}
+
+// NewMultiplexer starts reading from base. Closing the Multiplexer closes
+// base and every logical transport.
+func NewMultiplexer(base Transport) *Multiplexer {
+	m := &Multiplexer{
+		base:    base,
+		pending: make(map[int64]muxPending),
+		owners:  make(map[string]*MuxTransport),
+	}
+	go m.readLoop()
+	return m
+}
+
+// Open returns a new logical transport on the shared connection.
+func (m *Multiplexer) Open() *MuxTransport {
+	conn := &MuxTransport{mux: m, lines: make(chan string, muxBuffer), closed: make(chan struct{})}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		conn.fail(m.err)
+		return conn
+	}
+	m.conns = append(m.conns, conn)
+	return conn
+}
+
+// Close closes the shared connection and every logical transport.
+func (m *Multiplexer) Close() error {
+	err := m.base.Close()
+	m.stop(io.EOF)
+	return err
+}
+
+func (m *Multiplexer) stop(err error) {
+	m.mu.Lock()
+	if m.err == nil {
+		m.err = err
+	}
+	conns := m.conns
+	m.conns = nil
+	m.mu.Unlock()
+	for _, conn := range conns {
+		conn.fail(err)
+	}
+}
+
+func (m *Multiplexer) readLoop() {
+	for {
+		line, err := m.base.ReadLine()
+		if err != nil {
+			m.stop(err)
+			return
+		}
+		m.route(line)
+	}
+}
+
+// route delivers a line read from the server.
+func (m *Multiplexer) route(line string) {
+	var envelope muxEnvelope
+	if err := json.Unmarshal([]byte(line), &envelope); err != nil {
+		m.broadcast(line)
+		return
+	}
+	if envelope.Method == "" && envelope.hasID() {
+		m.routeResponse(line, envelope)
+		return
+	}
+
+	m.mu.Lock()
+	owner := m.owners[serverRequestThread(envelope.Params)]
+	if owner == nil && envelope.hasID() && len(m.conns) > 0 {
+		owner = m.conns[0]
+	}
+	m.mu.Unlock()
+	if owner != nil {
+		owner.deliver(line)
+		return
+	}
+	m.broadcast(line)
+}
+
+func (m *Multiplexer) routeResponse(line string, envelope muxEnvelope) {
+	id, err := strconv.ParseInt(string(envelope.ID), 10, 64)
+	m.mu.Lock()
+	call, ok := m.pending[id]
+	delete(m.pending, id)
+	if !ok || err != nil {
+		m.mu.Unlock()
+		return
+	}
+	targets := []muxPending{call}
+	switch call.method {
+	case "initialize":
+		targets = append(targets, m.initWaiters...)
+		m.initWaiters = nil
+		m.initPending = false
+		if len(envelope.Result) > 0 {
+			m.initResult = envelope.Result
+		}
+	case "thread/start", "thread/resume", "thread/fork":
+		if thread := resultThreadID(envelope.Result); thread != "" {
+			m.owners[thread] = call.conn
+		}
+	}
+	m.mu.Unlock()
+
+	for _, target := range targets {
+		if rewritten, err := replaceID(line, target.id); err == nil {
+			target.conn.deliver(rewritten)
+		}
+	}
+}
+
+func (m *Multiplexer) broadcast(line string) {
+	m.mu.Lock()
+	conns := slices.Clone(m.conns)
+	m.mu.Unlock()
+	for _, conn := range conns {
+		conn.deliver(line)
+	}
+}
+
+// send forwards a line written by conn.
+func (m *Multiplexer) send(conn *MuxTransport, line string) error {
+	var envelope muxEnvelope
+	if err := json.Unmarshal([]byte(line), &envelope); err != nil || envelope.Method == "" {
+		// Replies to server requests keep the server's ID.
+		return m.write(line)
+	}
+
+	m.mu.Lock()
+	if m.err != nil {
+		err := m.err
+		m.mu.Unlock()
+		return err
+	}
+	if thread := serverRequestThread(envelope.Params); thread != "" {
+		m.owners[thread] = conn
+	}
+	if !envelope.hasID() {
+		skip := envelope.Method == "initialized" && m.initialized
+		m.initialized = m.initialized || envelope.Method == "initialized"
+		m.mu.Unlock()
+		if skip {
+			return nil
+		}
+		return m.write(line)
+	}
+	call := muxPending{conn: conn, id: envelope.ID, method: envelope.Method}
+	if envelope.Method == "initialize" {
+		if m.initResult != nil {
+			result := m.initResult
+			m.mu.Unlock()
+			return m.replyLocally(call, result)
+		}
+		if m.initPending {
+			m.initWaiters = append(m.initWaiters, call)
+			m.mu.Unlock()
+			return nil
+		}
+		m.initPending = true
+	}
+	m.nextID++
+	id := m.nextID
+	m.pending[id] = call
+	m.mu.Unlock()
+
+	rewritten, err := replaceID(line, json.RawMessage(strconv.FormatInt(id, 10)))
+	if err == nil {
+		err = m.write(rewritten)
+	}
+	if err != nil {
+		m.mu.Lock()
+		delete(m.pending, id)
+		m.mu.Unlock()
+	}
+	return err
+}
+
+func (m *Multiplexer) replyLocally(call muxPending, result json.RawMessage) error {
+	data, err := json.Marshal(struct {
+		ID     json.RawMessage `json:"id"`
+		Result json.RawMessage `json:"result"`
+	}{call.id, result})
+	if err != nil {
+		return err
+	}
+	call.conn.deliver(string(data))
+	return nil
+}
+
+func (m *Multiplexer) write(line string) error {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	return m.base.WriteLine(line)
+}
+
+// detach forgets conn after it is closed.
+func (m *Multiplexer) detach(conn *MuxTransport) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.conns = slices.DeleteFunc(m.conns, func(c *MuxTransport) bool { return c == conn })
+	for thread, owner := range m.owners {
+		if owner == conn {
+			delete(m.owners, thread)
+		}
+	}
+	for id, call := range m.pending {
+		if call.conn == conn {
+			delete(m.pending, id)
+		}
+	}
+	m.initWaiters = slices.DeleteFunc(m.initWaiters, func(call muxPending) bool { return call.conn == conn })
+}
+
+// MuxTransport is one logical client's Transport on a Multiplexer.
+type MuxTransport struct {
+	mux   *Multiplexer
+	lines chan string
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	err       error
+}
+
+// ReadLine returns the next line routed to this client.
+func (t *MuxTransport) ReadLine() (string, error) {
+	select {
+	case line := <-t.lines:
+		return line, nil
+	case <-t.closed:
+		// Deliver what was routed before the close.
+		select {
+		case line := <-t.lines:
+			return line, nil
+		default:
+			return "", t.err
+		}
+	}
+}
+
+// WriteLine sends a line on the shared connection.
+func (t *MuxTransport) WriteLine(line string) error {
+	select {
+	case <-t.closed:
+		return t.err
+	default:
+	}
+	return t.mux.send(t, line)
+}
+
+// Close detaches this client. The shared connection stays open.
+func (t *MuxTransport) Close() error {
+	t.fail(io.EOF)
+	t.mux.detach(t)
+	return nil
+}
+
+func (t *MuxTransport) fail(err error) {
+	t.closeOnce.Do(func() {
+		t.err = err
+		close(t.closed)
+	})
+}
+
+// deliver queues a line for ReadLine. It never blocks the read loop: when
+// the client's buffer is full the client is closed with ErrMuxOverflow.
+func (t *MuxTransport) deliver(line string) {
+	select {
+	case <-t.closed:
+		return
+	default:
+	}
+	select {
+	case t.lines <- line:
+	default:
+		t.fail(ErrMuxOverflow)
+		t.mux.detach(t)
+	}
+}
+
+// resultThreadID extracts the thread ID from a thread/start, thread/resume
+// or thread/fork result.
+func resultThreadID(result json.RawMessage) string {
+	var payload struct {
+		ThreadID string `json:"threadId"`
+		Thread   struct {
+			ID string `json:"id"`
+		} `json:"thread"`
+	}
+	if len(result) == 0 || json.Unmarshal(result, &payload) != nil {
+		return ""
+	}
+	if payload.Thread.ID != "" {
+		return payload.Thread.ID
+	}
+	return payload.ThreadID
+}
+
+// replaceID returns line with its "id" member set to id.
+func replaceID(line string, id json.RawMessage) (string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return "", err
+	}
+	if fields == nil {
+		return "", errors.New("message is not an object")
+	}
+	fields["id"] = id
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,216 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+// fakeMuxServer answers initialize and thread/start, and records the
+// requests it sees.
+type fakeMuxServer struct {
+	transport *ConnTransport
+
+	mu      sync.Mutex
+	methods []string
+	ids     []string
+	threads int
+	replies chan JSONRPCResponse
+}
+
+func newFakeMuxServer(transport *ConnTransport) *fakeMuxServer {
+	s := &fakeMuxServer{transport: transport, replies: make(chan JSONRPCResponse, 4)}
+	go s.serve()
+	return s
+}
+
+func (s *fakeMuxServer) serve() {
+	for {
+		line, err := s.transport.ReadLine()
+		if err != nil {
+			return
+		}
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			continue
+		}
+		if msg.Method == "" {
+			var resp JSONRPCResponse
+			_ = json.Unmarshal([]byte(line), &resp)
+			s.replies <- resp
+			continue
+		}
+		s.mu.Lock()
+		s.methods = append(s.methods, msg.Method)
+		s.ids = append(s.ids, string(msg.ID))
+		result := map[string]any{}
+		switch msg.Method {
+		case "initialize":
+			result["userAgent"] = "codex_cli_rs/1.0.0"
+		case "thread/start":
+			s.threads++
+			result["thread"] = map[string]any{"id": fmt.Sprintf("thr_%d", s.threads)}
+		}
+		s.mu.Unlock()
+		if len(msg.ID) > 0 {
+			_ = s.transport.WriteLine(fmt.Sprintf(`{"id":%s,"result":%s}`, msg.ID, mustJSON(result)))
+		}
+	}
+}
+
+func (s *fakeMuxServer) seen() ([]string, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.methods...), append([]string(nil), s.ids...)
+}
+
+func TestMultiplexerSharesOneConnection(t *testing.T) {
+	clientEnd, serverEnd := NewPipeTransports()
+	server := newFakeMuxServer(serverEnd)
+	mux := NewMultiplexer(clientEnd)
+	defer mux.Close()
+
+	handlers := []*testHandler{{called: make(chan struct{}, 4)}, {called: make(chan struct{}, 4)}}
+	clients := make([]*Client, len(handlers))
+	notes := make([]*NotificationIterator, len(handlers))
+	for i, handler := range handlers {
+		clients[i] = NewClient(mux.Open(), ClientOptions{RequestHandler: handler})
+		defer clients[i].Close()
+		notes[i] = clients[i].SubscribeNotifications(8)
+		defer notes[i].Close()
+	}
+
+	ctx := context.Background()
+	threads := make([]string, len(clients))
+	for i, client := range clients {
+		var init struct {
+			UserAgent string `json:"userAgent"`
+		}
+		if err := client.Call(ctx, "initialize", map[string]any{}, &init); err != nil || init.UserAgent == "" {
+			t.Fatalf("client %d initialize = %+v, %v", i, init, err)
+		}
+		if err := client.Notify(ctx, "initialized", nil); err != nil {
+			t.Fatalf("client %d initialized: %v", i, err)
+		}
+		var started protocol.ThreadStartResponse
+		if err := client.Call(ctx, "thread/start", map[string]any{}, &started); err != nil {
+			t.Fatalf("client %d thread/start: %v", i, err)
+		}
+		threads[i] = started.Thread.ID
+	}
+
+	methods, ids := server.seen()
+	if strings.Join(methods, ",") != "initialize,initialized,thread/start,thread/start" {
+		t.Fatalf("server saw %v", methods)
+	}
+	// Both clients used request ID 2 for thread/start; the server saw
+	// distinct IDs.
+	if ids[2] == ids[3] {
+		t.Fatalf("request IDs were not rewritten: %v", ids)
+	}
+
+	_ = serverEnd.WriteLine(`{"method":"turn/started","params":{"threadId":"` + threads[1] + `","turn":{"id":"t1"}}}`)
+	_ = serverEnd.WriteLine(`{"method":"account/updated","params":{}}`)
+	expectNote := func(i int, want string) {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		note, err := notes[i].Next(ctx)
+		if err != nil || note.Method != want {
+			t.Fatalf("client %d note = %s, %v; want %s", i, note.Method, err, want)
+		}
+	}
+	expectNote(1, "turn/started")
+	expectNote(1, "account/updated")
+	expectNote(0, "account/updated")
+
+	_ = serverEnd.WriteLine(approvalRequest(7, "call", threads[0]))
+	select {
+	case <-handlers[0].called:
+	case <-time.After(time.Second):
+		t.Fatalf("server request not routed to the thread owner")
+	}
+	select {
+	case reply := <-server.replies:
+		if reply.ID.String() != "7" {
+			t.Fatalf("reply id = %s, want 7", reply.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("reply not forwarded to the server")
+	}
+	select {
+	case <-handlers[1].called:
+		t.Fatalf("server request delivered to the wrong client")
+	default:
+	}
+}
+
+func TestMultiplexerCloseEndsLogicalTransports(t *testing.T) {
+	clientEnd, serverEnd := NewPipeTransports()
+	defer serverEnd.Close()
+	mux := NewMultiplexer(clientEnd)
+
+	first, second := mux.Open(), mux.Open()
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := first.ReadLine(); err == nil {
+		t.Fatalf("expected ReadLine to fail on a closed logical transport")
+	}
+
+	// Closing one logical transport leaves the others connected.
+	go func() { _ = serverEnd.WriteLine(`{"method":"account/updated"}`) }()
+	if line, err := second.ReadLine(); err != nil || !strings.Contains(line, "account/updated") {
+		t.Fatalf("ReadLine = %q, %v", line, err)
+	}
+
+	_ = mux.Close()
+	if _, err := second.ReadLine(); err == nil {
+		t.Fatalf("expected ReadLine to fail after the multiplexer closed")
+	}
+	if err := mux.Open().WriteLine(`{"method":"x"}`); err == nil {
+		t.Fatalf("expected Open after Close to return a closed transport")
+	}
+}
+
+func TestMultiplexerDropsStalledClient(t *testing.T) {
+	clientEnd, serverEnd := NewPipeTransports()
+	defer serverEnd.Close()
+	mux := NewMultiplexer(clientEnd)
+	defer mux.Close()
+
+	stalled, active := mux.Open(), mux.Open()
+	go func() {
+		for i := range muxBuffer + 1 {
+			_ = serverEnd.WriteLine(fmt.Sprintf(`{"method":"account/updated","params":{"n":%d}}`, i))
+		}
+	}()
+	for range muxBuffer + 1 {
+		if _, err := active.ReadLine(); err != nil {
+			t.Fatalf("active ReadLine: %v", err)
+		}
+	}
+
+	for range muxBuffer {
+		if _, err := stalled.ReadLine(); err != nil {
+			t.Fatalf("stalled ReadLine of buffered line: %v", err)
+		}
+	}
+	if _, err := stalled.ReadLine(); !errors.Is(err, ErrMuxOverflow) {
+		t.Fatalf("stalled ReadLine = %v, want ErrMuxOverflow", err)
+	}
+	if err := stalled.WriteLine(`{"method":"x"}`); !errors.Is(err, ErrMuxOverflow) {
+		t.Fatalf("stalled WriteLine = %v, want ErrMuxOverflow", err)
+	}
+}
@@ -0,0 +1,66 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// RequestMutator edits the params of an outgoing request or notification
+// just before it is marshaled, so cross-cutting defaults such as an
+// originator tag or a default cwd need not be repeated at every call site.
+// params is the JSON object form of the caller's params (empty when the
+// caller passed nil); numbers decode as json.Number. Changes to params are
+// what gets sent. Requests whose params are not a JSON object skip
+// mutators. Returning an error fails the Call or Notify.
+type RequestMutator func(ctx context.Context, method string, params map[string]any) error
+
+// DefaultParams returns a RequestMutator that sets each key in defaults on
+// method's params when the caller left it unset or null.
+func DefaultParams(method string, defaults map[string]any) RequestMutator {
+	return func(_ context.Context, m string, params map[string]any) error {
+		if m != method {
+			return nil
+		}
+		for key, value := range defaults {
+			if current, ok := params[key]; !ok || current == nil {
+				params[key] = value
+			}
+		}
+		return nil
+	}
+}
+
+// mutateParams runs the configured mutators over params and returns the
+// params to send.
+func (c *Client) mutateParams(ctx context.Context, method string, params any) (any, error) {
+	if len(c.mutators) == 0 {
+		return params, nil
+	}
+	fields := map[string]any{}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if decoder.Decode(&fields) != nil || fields == nil {
+			return params, nil
+		}
+	}
+	for _, mutate := range c.mutators {
+		if err := mutate(ctx, method, fields); err != nil {
+			return nil, fmt.Errorf("mutate %s params: %w", method, err)
+		}
+	}
+	if params == nil && len(fields) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(data), nil
+}
@@ -0,0 +1,67 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRequestMutators(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{RequestMutators: []RequestMutator{
+		DefaultParams("turn/start", map[string]any{"cwd": "/work"}),
+		func(_ context.Context, method string, params map[string]any) error {
+			if method == "turn/start" {
+				params["originator"] = "billing-bot"
+			}
+			return nil
+		},
+	}})
+	defer client.Close()
+
+	go func() {
+		_ = client.Call(context.Background(), "turn/start", map[string]any{"threadId": "thr", "count": int64(9007199254740993)}, nil)
+	}()
+	go func() {
+		_ = client.Call(context.Background(), "thread/start", map[string]any{"cwd": nil}, nil)
+	}()
+	writes := transport.waitForWrites(t, 2)
+
+	got := map[string]string{}
+	for _, line := range writes {
+		var req JSONRPCRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		got[req.Method] = string(req.Params)
+	}
+	if want := `{"count":9007199254740993,"cwd":"/work","originator":"billing-bot","threadId":"thr"}`; got["turn/start"] != want {
+		t.Fatalf("turn/start params = %s, want %s", got["turn/start"], want)
+	}
+	if want := `{"cwd":null}`; got["thread/start"] != want {
+		t.Fatalf("thread/start params = %s, want %s", got["thread/start"], want)
+	}
+}
+
+func TestRequestMutatorError(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{RequestMutators: []RequestMutator{
+		func(context.Context, string, map[string]any) error { return errors.New("no tenant") },
+	}})
+	defer client.Close()
+
+	err := client.Notify(context.Background(), "initialized", nil)
+	if err == nil || !strings.Contains(err.Error(), "mutate initialized params: no tenant") {
+		t.Fatalf("expected mutator error, got %v", err)
+	}
+	if err := client.Call(context.Background(), "thread/start", struct{}{}, nil); err == nil {
+		t.Fatalf("expected call to fail")
+	}
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.writes) != 0 {
+		t.Fatalf("expected nothing written, got %v", transport.writes)
+	}
+}
@@ -0,0 +1,133 @@
+package rpc
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+)
+
+// dispatcherBuffer is the subscription buffer used by Client.Dispatch.
+const dispatcherBuffer = 64
+
+// NotificationDispatcher routes notifications to handlers registered per
+// method, as an alternative to draining a NotificationIterator by hand. The
+// generated On<Method> helpers register handlers that receive the typed
+// params; On registers a handler for any method. Handlers may be added
+// while the dispatcher is running.
+type NotificationDispatcher struct {
+	mu       sync.RWMutex
+	handlers map[string][]func(Notification)
+	fallback []func(Notification)
+}
+
+// NewNotificationDispatcher returns an empty dispatcher.
+func NewNotificationDispatcher() *NotificationDispatcher {
+	return &NotificationDispatcher{handlers: make(map[string][]func(Notification))}
+}
+
+// On registers fn for notifications with the given method. Handlers for the
+// same method run in registration order.
+func (d *NotificationDispatcher) On(method string, fn func(Notification)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers[method] = append(d.handlers[method], fn)
+}
+
+// OnOther registers fn for notifications that have no method handler.
+func (d *NotificationDispatcher) OnOther(fn func(Notification)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.fallback = append(d.fallback, fn)
+}
+
+func (d *NotificationDispatcher) handlersFor(method string) []func(Notification) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if handlers := d.handlers[method]; len(handlers) > 0 {
+		return handlers
+	}
+	return d.fallback
+}
+
+// Dispatch subscribes to notifications and routes each one to d's handlers
+// on a pool of workers goroutines (at least one). Notifications for the same
+// thread always go to the same worker, so they are handled in arrival
+// order; notifications for different threads may be handled concurrently. A
+// handler that panics is logged and skipped.
+//
+// Dispatch runs until the client stops or stop is called. stop unsubscribes
+// and waits for running handlers to return.
+func (c *Client) Dispatch(d *NotificationDispatcher, workers int) (stop func()) {
+	if workers < 1 {
+		workers = 1
+	}
+	it := c.SubscribeNotifications(dispatcherBuffer)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	queues := make([]chan Notification, workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan Notification, dispatcherBuffer)
+		wg.Add(1)
+		go func(queue <-chan Notification) {
+			defer wg.Done()
+			for note := range queue {
+				c.runNotificationHandlers(d, note)
+			}
+		}(queues[i])
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() {
+			for _, queue := range queues {
+				close(queue)
+			}
+		}()
+		var next int
+		for {
+			note, err := it.Next(ctx)
+			if err != nil {
+				return
+			}
+			worker := next
+			if thread := serverRequestThread(note.Raw); thread != "" {
+				hash := fnv.New32a()
+				_, _ = hash.Write([]byte(thread))
+				worker = int(hash.Sum32() % uint32(workers))
+			} else {
+				next = (next + 1) % workers
+			}
+			select {
+			case queues[worker] <- note:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			it.Close()
+			wg.Wait()
+		})
+	}
+}
+
+func (c *Client) runNotificationHandlers(d *NotificationDispatcher, note Notification) {
+	for _, fn := range d.handlersFor(note.Method) {
+		func() {
+			defer func() {
+				if value := recover(); value != nil {
+					c.logger.Error("notification handler panicked", slog.String("method", note.Method), slog.Any("panic", value), slog.String("stack", string(debug.Stack())))
+				}
+			}()
+			fn(note)
+		}()
+	}
+}
@@ -0,0 +1,130 @@
+package rpc
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+func TestDispatchRoutesTypedNotifications(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	completed := make(chan protocol.TurnCompletedNotification, 1)
+	other := make(chan string, 1)
+	d := NewNotificationDispatcher()
+	d.OnTurnCompleted(func(params protocol.TurnCompletedNotification) {
+		completed <- params
+	})
+	d.OnOther(func(note Notification) {
+		other <- note.Method
+	})
+	stop := client.Dispatch(d, 2)
+	defer stop()
+
+	transport.pushReadLine(`{"jsonrpc":"2.0","method":"turn/completed","params":{"threadId":"thr","turn":{"id":"t1","status":"completed"}}}`)
+	transport.pushReadLine(`{"jsonrpc":"2.0","method":"custom/event","params":{}}`)
+
+	select {
+	case params := <-completed:
+		if params.ThreadID != "thr" || params.Turn == nil || params.Turn.ID != "t1" {
+			t.Fatalf("unexpected params: %+v", params)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("turn/completed handler not called")
+	}
+	select {
+	case method := <-other:
+		if method != "custom/event" {
+			t.Fatalf("fallback method = %s", method)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("fallback handler not called")
+	}
+}
+
+func TestDispatchPreservesPerThreadOrder(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	var mu sync.Mutex
+	seen := make(map[string][]string)
+	done := make(chan struct{}, 8)
+	d := NewNotificationDispatcher()
+	d.OnTurnStarted(func(params protocol.TurnStartedNotification) {
+		mu.Lock()
+		seen[params.ThreadID] = append(seen[params.ThreadID], params.Turn.ID)
+		mu.Unlock()
+		done <- struct{}{}
+	})
+	stop := client.Dispatch(d, 4)
+	defer stop()
+
+	for _, id := range []string{"1", "2", "3", "4"} {
+		transport.pushReadLine(`{"jsonrpc":"2.0","method":"turn/started","params":{"threadId":"a","turn":{"id":"a` + id + `"}}}`)
+		transport.pushReadLine(`{"jsonrpc":"2.0","method":"turn/started","params":{"threadId":"b","turn":{"id":"b` + id + `"}}}`)
+	}
+	for range 8 {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("handlers not called")
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, thread := range []string{"a", "b"} {
+		got := seen[thread]
+		for i, id := range got {
+			if want := thread + string(rune('1'+i)); id != want {
+				t.Fatalf("thread %s order = %v", thread, got)
+			}
+		}
+	}
+}
+
+func TestDispatchRecoversHandlerPanicAndStops(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	called := make(chan struct{}, 2)
+	d := NewNotificationDispatcher()
+	d.On("custom/event", func(Notification) {
+		called <- struct{}{}
+		panic("boom")
+	})
+	stop := client.Dispatch(d, 1)
+
+	for range 2 {
+		transport.pushReadLine(`{"jsonrpc":"2.0","method":"custom/event"}`)
+		select {
+		case <-called:
+		case <-time.After(time.Second):
+			t.Fatalf("handler not called after panic")
+		}
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatalf("stop did not return")
+	}
+
+	transport.pushReadLine(`{"jsonrpc":"2.0","method":"custom/event"}`)
+	select {
+	case <-called:
+		t.Fatalf("handler called after stop")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
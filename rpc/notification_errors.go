@@ -0,0 +1,45 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownNotification is the cause reported for a notification whose
+// method has no generated type in this SDK.
+var ErrUnknownNotification = errors.New("unknown notification method")
+
+// NotificationDecodeError describes a notification the client could not map
+// to its generated protocol types. It usually means the app-server is newer
+// (or older) than the schema the SDK was generated from.
+type NotificationDecodeError struct {
+	Method string
+	Raw    json.RawMessage
+	// Err is ErrUnknownNotification or the error from decoding Raw.
+	Err error
+}
+
+func (e *NotificationDecodeError) Error() string {
+	return fmt.Sprintf("notification %s: %v", e.Method, e.Err)
+}
+
+func (e *NotificationDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// reportNotificationError passes decoding problems to the strict-mode
+// callback. Without one, undecodable notifications are only logged and
+// unknown methods pass through silently.
+func (c *Client) reportNotificationError(note JSONRPCNotification, err error) {
+	if c.onNotificationError == nil {
+		return
+	}
+	if err == nil {
+		if _, known := notificationParsers[note.Method]; known {
+			return
+		}
+		err = ErrUnknownNotification
+	}
+	c.onNotificationError(&NotificationDecodeError{Method: note.Method, Raw: note.Params, Err: err})
+}
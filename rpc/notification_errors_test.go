@@ -0,0 +1,45 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestOnNotificationError(t *testing.T) {
+	transport := newChannelTransport()
+	reported := make(chan *NotificationDecodeError, 4)
+	client := NewClient(transport, ClientOptions{OnNotificationError: func(err *NotificationDecodeError) {
+		reported <- err
+	}})
+	defer client.Close()
+	iter := client.SubscribeNotifications(4)
+	defer iter.Close()
+
+	transport.pushReadLine(`{"jsonrpc":"2.0","method":"turn/started","params":{"threadId":"thr","turn":{"id":"turn","items":[],"status":"inProgress"}}}`)
+	transport.pushReadLine(`{"jsonrpc":"2.0","method":"future/event","params":{"x":1}}`)
+	transport.pushReadLine(`{"jsonrpc":"2.0","method":"turn/started","params":{"turn":5}}`)
+	transport.waitForReads(t, 3)
+
+	unknown := <-reported
+	if unknown.Method != "future/event" || !errors.Is(unknown, ErrUnknownNotification) || string(unknown.Raw) != `{"x":1}` {
+		t.Fatalf("unexpected unknown report: %+v", unknown)
+	}
+	undecodable := <-reported
+	if undecodable.Method != "turn/started" || errors.Is(undecodable, ErrUnknownNotification) || undecodable.Err == nil {
+		t.Fatalf("unexpected decode report: %+v", undecodable)
+	}
+	select {
+	case extra := <-reported:
+		t.Fatalf("unexpected extra report: %v", extra)
+	default:
+	}
+
+	// Reported notifications are still delivered.
+	for _, want := range []string{"turn/started", "future/event", "turn/started"} {
+		note, err := iter.Next(context.Background())
+		if err != nil || note.Method != want {
+			t.Fatalf("expected %s, got %s (%v)", want, note.Method, err)
+		}
+	}
+}
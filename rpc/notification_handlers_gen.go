@@ -0,0 +1,529 @@
+// DO NOT EDIT.
+// Generated by internal/codegen.
+// Source codex commit: 637f7dd6d737f3961e6bf32fbb3861c4953269c5
+
+package rpc
+
+import "github.com/pmenglund/codex-sdk-go/protocol"
+
+// OnAccountLoginCompleted registers fn for "account/login/completed" notifications.
+func (d *NotificationDispatcher) OnAccountLoginCompleted(fn func(protocol.AccountLoginCompletedNotification)) {
+	d.On("account/login/completed", func(note Notification) {
+		if params, ok := note.Params.(protocol.AccountLoginCompletedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnAccountRateLimitsUpdated registers fn for "account/rateLimits/updated" notifications.
+func (d *NotificationDispatcher) OnAccountRateLimitsUpdated(fn func(protocol.AccountRateLimitsUpdatedNotification)) {
+	d.On("account/rateLimits/updated", func(note Notification) {
+		if params, ok := note.Params.(protocol.AccountRateLimitsUpdatedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnAccountUpdated registers fn for "account/updated" notifications.
+func (d *NotificationDispatcher) OnAccountUpdated(fn func(protocol.AccountUpdatedNotification)) {
+	d.On("account/updated", func(note Notification) {
+		if params, ok := note.Params.(protocol.AccountUpdatedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnAppListUpdated registers fn for "app/list/updated" notifications.
+func (d *NotificationDispatcher) OnAppListUpdated(fn func(protocol.AppListUpdatedNotification)) {
+	d.On("app/list/updated", func(note Notification) {
+		if params, ok := note.Params.(protocol.AppListUpdatedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnCommandExecOutputDelta registers fn for "command/exec/outputDelta" notifications.
+func (d *NotificationDispatcher) OnCommandExecOutputDelta(fn func(protocol.CommandExecOutputDeltaNotification)) {
+	d.On("command/exec/outputDelta", func(note Notification) {
+		if params, ok := note.Params.(protocol.CommandExecOutputDeltaNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnConfigWarning registers fn for "configWarning" notifications.
+func (d *NotificationDispatcher) OnConfigWarning(fn func(protocol.ConfigWarningNotification)) {
+	d.On("configWarning", func(note Notification) {
+		if params, ok := note.Params.(protocol.ConfigWarningNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnDeprecationNotice registers fn for "deprecationNotice" notifications.
+func (d *NotificationDispatcher) OnDeprecationNotice(fn func(protocol.DeprecationNoticeNotification)) {
+	d.On("deprecationNotice", func(note Notification) {
+		if params, ok := note.Params.(protocol.DeprecationNoticeNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnError registers fn for "error" notifications.
+func (d *NotificationDispatcher) OnError(fn func(protocol.ErrorNotification)) {
+	d.On("error", func(note Notification) {
+		if params, ok := note.Params.(protocol.ErrorNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnExternalAgentConfigImportCompleted registers fn for "externalAgentConfig/import/completed" notifications.
+func (d *NotificationDispatcher) OnExternalAgentConfigImportCompleted(fn func(protocol.ExternalAgentConfigImportCompletedNotification)) {
+	d.On("externalAgentConfig/import/completed", func(note Notification) {
+		if params, ok := note.Params.(protocol.ExternalAgentConfigImportCompletedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnFsChanged registers fn for "fs/changed" notifications.
+func (d *NotificationDispatcher) OnFsChanged(fn func(protocol.FsChangedNotification)) {
+	d.On("fs/changed", func(note Notification) {
+		if params, ok := note.Params.(protocol.FsChangedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnFuzzyFileSearchSessionCompleted registers fn for "fuzzyFileSearch/sessionCompleted" notifications.
+func (d *NotificationDispatcher) OnFuzzyFileSearchSessionCompleted(fn func(protocol.FuzzyFileSearchSessionCompletedNotification)) {
+	d.On("fuzzyFileSearch/sessionCompleted", func(note Notification) {
+		if params, ok := note.Params.(protocol.FuzzyFileSearchSessionCompletedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnFuzzyFileSearchSessionUpdated registers fn for "fuzzyFileSearch/sessionUpdated" notifications.
+func (d *NotificationDispatcher) OnFuzzyFileSearchSessionUpdated(fn func(protocol.FuzzyFileSearchSessionUpdatedNotification)) {
+	d.On("fuzzyFileSearch/sessionUpdated", func(note Notification) {
+		if params, ok := note.Params.(protocol.FuzzyFileSearchSessionUpdatedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnGuardianWarning registers fn for "guardianWarning" notifications.
+func (d *NotificationDispatcher) OnGuardianWarning(fn func(protocol.GuardianWarningNotification)) {
+	d.On("guardianWarning", func(note Notification) {
+		if params, ok := note.Params.(protocol.GuardianWarningNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnHookCompleted registers fn for "hook/completed" notifications.
+func (d *NotificationDispatcher) OnHookCompleted(fn func(protocol.HookCompletedNotification)) {
+	d.On("hook/completed", func(note Notification) {
+		if params, ok := note.Params.(protocol.HookCompletedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnHookStarted registers fn for "hook/started" notifications.
+func (d *NotificationDispatcher) OnHookStarted(fn func(protocol.HookStartedNotification)) {
+	d.On("hook/started", func(note Notification) {
+		if params, ok := note.Params.(protocol.HookStartedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnItemAgentMessageDelta registers fn for "item/agentMessage/delta" notifications.
+func (d *NotificationDispatcher) OnItemAgentMessageDelta(fn func(protocol.AgentMessageDeltaNotification)) {
+	d.On("item/agentMessage/delta", func(note Notification) {
+		if params, ok := note.Params.(protocol.AgentMessageDeltaNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnItemAutoApprovalReviewCompleted registers fn for "item/autoApprovalReview/completed" notifications.
+func (d *NotificationDispatcher) OnItemAutoApprovalReviewCompleted(fn func(protocol.ItemGuardianApprovalReviewCompletedNotification)) {
+	d.On("item/autoApprovalReview/completed", func(note Notification) {
+		if params, ok := note.Params.(protocol.ItemGuardianApprovalReviewCompletedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnItemAutoApprovalReviewStarted registers fn for "item/autoApprovalReview/started" notifications.
+func (d *NotificationDispatcher) OnItemAutoApprovalReviewStarted(fn func(protocol.ItemGuardianApprovalReviewStartedNotification)) {
+	d.On("item/autoApprovalReview/started", func(note Notification) {
+		if params, ok := note.Params.(protocol.ItemGuardianApprovalReviewStartedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnItemCommandExecutionOutputDelta registers fn for "item/commandExecution/outputDelta" notifications.
+func (d *NotificationDispatcher) OnItemCommandExecutionOutputDelta(fn func(protocol.CommandExecutionOutputDeltaNotification)) {
+	d.On("item/commandExecution/outputDelta", func(note Notification) {
+		if params, ok := note.Params.(protocol.CommandExecutionOutputDeltaNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnItemCommandExecutionTerminalInteraction registers fn for "item/commandExecution/terminalInteraction" notifications.
+func (d *NotificationDispatcher) OnItemCommandExecutionTerminalInteraction(fn func(protocol.TerminalInteractionNotification)) {
+	d.On("item/commandExecution/terminalInteraction", func(note Notification) {
+		if params, ok := note.Params.(protocol.TerminalInteractionNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnItemCompleted registers fn for "item/completed" notifications.
+func (d *NotificationDispatcher) OnItemCompleted(fn func(protocol.ItemCompletedNotification)) {
+	d.On("item/completed", func(note Notification) {
+		if params, ok := note.Params.(protocol.ItemCompletedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnItemFileChangeOutputDelta registers fn for "item/fileChange/outputDelta" notifications.
+func (d *NotificationDispatcher) OnItemFileChangeOutputDelta(fn func(protocol.FileChangeOutputDeltaNotification)) {
+	d.On("item/fileChange/outputDelta", func(note Notification) {
+		if params, ok := note.Params.(protocol.FileChangeOutputDeltaNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnItemFileChangePatchUpdated registers fn for "item/fileChange/patchUpdated" notifications.
+func (d *NotificationDispatcher) OnItemFileChangePatchUpdated(fn func(protocol.FileChangePatchUpdatedNotification)) {
+	d.On("item/fileChange/patchUpdated", func(note Notification) {
+		if params, ok := note.Params.(protocol.FileChangePatchUpdatedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnItemMcpToolCallProgress registers fn for "item/mcpToolCall/progress" notifications.
+func (d *NotificationDispatcher) OnItemMcpToolCallProgress(fn func(protocol.McpToolCallProgressNotification)) {
+	d.On("item/mcpToolCall/progress", func(note Notification) {
+		if params, ok := note.Params.(protocol.McpToolCallProgressNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnItemPlanDelta registers fn for "item/plan/delta" notifications.
+func (d *NotificationDispatcher) OnItemPlanDelta(fn func(protocol.PlanDeltaNotification)) {
+	d.On("item/plan/delta", func(note Notification) {
+		if params, ok := note.Params.(protocol.PlanDeltaNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnItemReasoningSummaryPartAdded registers fn for "item/reasoning/summaryPartAdded" notifications.
+func (d *NotificationDispatcher) OnItemReasoningSummaryPartAdded(fn func(protocol.ReasoningSummaryPartAddedNotification)) {
+	d.On("item/reasoning/summaryPartAdded", func(note Notification) {
+		if params, ok := note.Params.(protocol.ReasoningSummaryPartAddedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnItemReasoningSummaryTextDelta registers fn for "item/reasoning/summaryTextDelta" notifications.
+func (d *NotificationDispatcher) OnItemReasoningSummaryTextDelta(fn func(protocol.ReasoningSummaryTextDeltaNotification)) {
+	d.On("item/reasoning/summaryTextDelta", func(note Notification) {
+		if params, ok := note.Params.(protocol.ReasoningSummaryTextDeltaNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnItemReasoningTextDelta registers fn for "item/reasoning/textDelta" notifications.
+func (d *NotificationDispatcher) OnItemReasoningTextDelta(fn func(protocol.ReasoningTextDeltaNotification)) {
+	d.On("item/reasoning/textDelta", func(note Notification) {
+		if params, ok := note.Params.(protocol.ReasoningTextDeltaNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnItemStarted registers fn for "item/started" notifications.
+func (d *NotificationDispatcher) OnItemStarted(fn func(protocol.ItemStartedNotification)) {
+	d.On("item/started", func(note Notification) {
+		if params, ok := note.Params.(protocol.ItemStartedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnMcpServerOauthLoginCompleted registers fn for "mcpServer/oauthLogin/completed" notifications.
+func (d *NotificationDispatcher) OnMcpServerOauthLoginCompleted(fn func(protocol.McpServerOauthLoginCompletedNotification)) {
+	d.On("mcpServer/oauthLogin/completed", func(note Notification) {
+		if params, ok := note.Params.(protocol.McpServerOauthLoginCompletedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnMcpServerStartupStatusUpdated registers fn for "mcpServer/startupStatus/updated" notifications.
+func (d *NotificationDispatcher) OnMcpServerStartupStatusUpdated(fn func(protocol.McpServerStatusUpdatedNotification)) {
+	d.On("mcpServer/startupStatus/updated", func(note Notification) {
+		if params, ok := note.Params.(protocol.McpServerStatusUpdatedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnModelRerouted registers fn for "model/rerouted" notifications.
+func (d *NotificationDispatcher) OnModelRerouted(fn func(protocol.ModelReroutedNotification)) {
+	d.On("model/rerouted", func(note Notification) {
+		if params, ok := note.Params.(protocol.ModelReroutedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnModelVerification registers fn for "model/verification" notifications.
+func (d *NotificationDispatcher) OnModelVerification(fn func(protocol.ModelVerificationNotification)) {
+	d.On("model/verification", func(note Notification) {
+		if params, ok := note.Params.(protocol.ModelVerificationNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnServerRequestResolved registers fn for "serverRequest/resolved" notifications.
+func (d *NotificationDispatcher) OnServerRequestResolved(fn func(protocol.ServerRequestResolvedNotification)) {
+	d.On("serverRequest/resolved", func(note Notification) {
+		if params, ok := note.Params.(protocol.ServerRequestResolvedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnSkillsChanged registers fn for "skills/changed" notifications.
+func (d *NotificationDispatcher) OnSkillsChanged(fn func(protocol.SkillsChangedNotification)) {
+	d.On("skills/changed", func(note Notification) {
+		if params, ok := note.Params.(protocol.SkillsChangedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnThreadArchived registers fn for "thread/archived" notifications.
+func (d *NotificationDispatcher) OnThreadArchived(fn func(protocol.ThreadArchivedNotification)) {
+	d.On("thread/archived", func(note Notification) {
+		if params, ok := note.Params.(protocol.ThreadArchivedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnThreadClosed registers fn for "thread/closed" notifications.
+func (d *NotificationDispatcher) OnThreadClosed(fn func(protocol.ThreadClosedNotification)) {
+	d.On("thread/closed", func(note Notification) {
+		if params, ok := note.Params.(protocol.ThreadClosedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnThreadCompacted registers fn for "thread/compacted" notifications.
+func (d *NotificationDispatcher) OnThreadCompacted(fn func(protocol.ContextCompactedNotification)) {
+	d.On("thread/compacted", func(note Notification) {
+		if params, ok := note.Params.(protocol.ContextCompactedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnThreadNameUpdated registers fn for "thread/name/updated" notifications.
+func (d *NotificationDispatcher) OnThreadNameUpdated(fn func(protocol.ThreadNameUpdatedNotification)) {
+	d.On("thread/name/updated", func(note Notification) {
+		if params, ok := note.Params.(protocol.ThreadNameUpdatedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnThreadRealtimeClosed registers fn for "thread/realtime/closed" notifications.
+func (d *NotificationDispatcher) OnThreadRealtimeClosed(fn func(protocol.ThreadRealtimeClosedNotification)) {
+	d.On("thread/realtime/closed", func(note Notification) {
+		if params, ok := note.Params.(protocol.ThreadRealtimeClosedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnThreadRealtimeError registers fn for "thread/realtime/error" notifications.
+func (d *NotificationDispatcher) OnThreadRealtimeError(fn func(protocol.ThreadRealtimeErrorNotification)) {
+	d.On("thread/realtime/error", func(note Notification) {
+		if params, ok := note.Params.(protocol.ThreadRealtimeErrorNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnThreadRealtimeItemAdded registers fn for "thread/realtime/itemAdded" notifications.
+func (d *NotificationDispatcher) OnThreadRealtimeItemAdded(fn func(protocol.ThreadRealtimeItemAddedNotification)) {
+	d.On("thread/realtime/itemAdded", func(note Notification) {
+		if params, ok := note.Params.(protocol.ThreadRealtimeItemAddedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnThreadRealtimeOutputAudioDelta registers fn for "thread/realtime/outputAudio/delta" notifications.
+func (d *NotificationDispatcher) OnThreadRealtimeOutputAudioDelta(fn func(protocol.ThreadRealtimeOutputAudioDeltaNotification)) {
+	d.On("thread/realtime/outputAudio/delta", func(note Notification) {
+		if params, ok := note.Params.(protocol.ThreadRealtimeOutputAudioDeltaNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnThreadRealtimeSdp registers fn for "thread/realtime/sdp" notifications.
+func (d *NotificationDispatcher) OnThreadRealtimeSdp(fn func(protocol.ThreadRealtimeSdpNotification)) {
+	d.On("thread/realtime/sdp", func(note Notification) {
+		if params, ok := note.Params.(protocol.ThreadRealtimeSdpNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnThreadRealtimeStarted registers fn for "thread/realtime/started" notifications.
+func (d *NotificationDispatcher) OnThreadRealtimeStarted(fn func(protocol.ThreadRealtimeStartedNotification)) {
+	d.On("thread/realtime/started", func(note Notification) {
+		if params, ok := note.Params.(protocol.ThreadRealtimeStartedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnThreadRealtimeTranscriptDelta registers fn for "thread/realtime/transcript/delta" notifications.
+func (d *NotificationDispatcher) OnThreadRealtimeTranscriptDelta(fn func(protocol.ThreadRealtimeTranscriptDeltaNotification)) {
+	d.On("thread/realtime/transcript/delta", func(note Notification) {
+		if params, ok := note.Params.(protocol.ThreadRealtimeTranscriptDeltaNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnThreadRealtimeTranscriptDone registers fn for "thread/realtime/transcript/done" notifications.
+func (d *NotificationDispatcher) OnThreadRealtimeTranscriptDone(fn func(protocol.ThreadRealtimeTranscriptDoneNotification)) {
+	d.On("thread/realtime/transcript/done", func(note Notification) {
+		if params, ok := note.Params.(protocol.ThreadRealtimeTranscriptDoneNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnThreadStarted registers fn for "thread/started" notifications.
+func (d *NotificationDispatcher) OnThreadStarted(fn func(protocol.ThreadStartedNotification)) {
+	d.On("thread/started", func(note Notification) {
+		if params, ok := note.Params.(protocol.ThreadStartedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnThreadStatusChanged registers fn for "thread/status/changed" notifications.
+func (d *NotificationDispatcher) OnThreadStatusChanged(fn func(protocol.ThreadStatusChangedNotification)) {
+	d.On("thread/status/changed", func(note Notification) {
+		if params, ok := note.Params.(protocol.ThreadStatusChangedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnThreadTokenUsageUpdated registers fn for "thread/tokenUsage/updated" notifications.
+func (d *NotificationDispatcher) OnThreadTokenUsageUpdated(fn func(protocol.ThreadTokenUsageUpdatedNotification)) {
+	d.On("thread/tokenUsage/updated", func(note Notification) {
+		if params, ok := note.Params.(protocol.ThreadTokenUsageUpdatedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnThreadUnarchived registers fn for "thread/unarchived" notifications.
+func (d *NotificationDispatcher) OnThreadUnarchived(fn func(protocol.ThreadUnarchivedNotification)) {
+	d.On("thread/unarchived", func(note Notification) {
+		if params, ok := note.Params.(protocol.ThreadUnarchivedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnTurnCompleted registers fn for "turn/completed" notifications.
+func (d *NotificationDispatcher) OnTurnCompleted(fn func(protocol.TurnCompletedNotification)) {
+	d.On("turn/completed", func(note Notification) {
+		if params, ok := note.Params.(protocol.TurnCompletedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnTurnDiffUpdated registers fn for "turn/diff/updated" notifications.
+func (d *NotificationDispatcher) OnTurnDiffUpdated(fn func(protocol.TurnDiffUpdatedNotification)) {
+	d.On("turn/diff/updated", func(note Notification) {
+		if params, ok := note.Params.(protocol.TurnDiffUpdatedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnTurnPlanUpdated registers fn for "turn/plan/updated" notifications.
+func (d *NotificationDispatcher) OnTurnPlanUpdated(fn func(protocol.TurnPlanUpdatedNotification)) {
+	d.On("turn/plan/updated", func(note Notification) {
+		if params, ok := note.Params.(protocol.TurnPlanUpdatedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnTurnStarted registers fn for "turn/started" notifications.
+func (d *NotificationDispatcher) OnTurnStarted(fn func(protocol.TurnStartedNotification)) {
+	d.On("turn/started", func(note Notification) {
+		if params, ok := note.Params.(protocol.TurnStartedNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnWarning registers fn for "warning" notifications.
+func (d *NotificationDispatcher) OnWarning(fn func(protocol.WarningNotification)) {
+	d.On("warning", func(note Notification) {
+		if params, ok := note.Params.(protocol.WarningNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnWindowsWorldWritableWarning registers fn for "windows/worldWritableWarning" notifications.
+func (d *NotificationDispatcher) OnWindowsWorldWritableWarning(fn func(protocol.WindowsWorldWritableWarningNotification)) {
+	d.On("windows/worldWritableWarning", func(note Notification) {
+		if params, ok := note.Params.(protocol.WindowsWorldWritableWarningNotification); ok {
+			fn(params)
+		}
+	})
+}
+
+// OnWindowsSandboxSetupCompleted registers fn for "windowsSandbox/setupCompleted" notifications.
+func (d *NotificationDispatcher) OnWindowsSandboxSetupCompleted(fn func(protocol.WindowsSandboxSetupCompletedNotification)) {
+	d.On("windowsSandbox/setupCompleted", func(note Notification) {
+		if params, ok := note.Params.(protocol.WindowsSandboxSetupCompletedNotification); ok {
+			fn(params)
+		}
+	})
+}
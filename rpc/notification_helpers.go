@@ -9,3 +9,35 @@ func (n Notification) UnmarshalParams(v any) error {
 	}
 	return json.Unmarshal(n.Raw, v)
 }
+
+type notificationJSON struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// MarshalJSON encodes the notification as {"method": ..., "params": ...}.
+// The raw wire params are preferred so the encoding matches what the
+// app-server sent.
+func (n Notification) MarshalJSON() ([]byte, error) {
+	payload := notificationJSON{Method: n.Method, Params: n.Raw}
+	if len(payload.Params) == 0 && n.Params != nil {
+		data, err := json.Marshal(n.Params)
+		if err != nil {
+			return nil, err
+		}
+		payload.Params = data
+	}
+	return json.Marshal(payload)
+}
+
+// UnmarshalJSON decodes a notification encoded by MarshalJSON and restores
+// typed Params for known methods.
+func (n *Notification) UnmarshalJSON(data []byte) error {
+	var payload notificationJSON
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return err
+	}
+	note, err := parseServerNotification(payload.Method, payload.Params)
+	*n = note
+	return err
+}
@@ -0,0 +1,77 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+)
+
+// DefaultPauseLimit bounds the notifications Pause buffers.
+const DefaultPauseLimit = 4096
+
+// ErrPauseOverflow ends a paused NotificationIterator whose backlog grew
+// past its pause limit.
+var ErrPauseOverflow = errors.New("notification backlog overflowed while paused")
+
+// Pause stops Next from returning notifications until Resume, for example
+// while a UI shows a modal approval dialog. The client keeps reading and
+// the iterator buffers up to DefaultPauseLimit notifications, so nothing is
+// dropped and the client never blocks.
+func (it *NotificationIterator) Pause() {
+	it.PauseWithLimit(DefaultPauseLimit)
+}
+
+// PauseWithLimit is Pause with a custom bound on the buffered backlog. If
+// the backlog exceeds limit, the iterator ends: its backlog is discarded
+// and Next returns ErrPauseOverflow. A limit of zero or less buffers without
+// bound.
+func (it *NotificationIterator) PauseWithLimit(limit int) {
+	it.pauseMu.Lock()
+	defer it.pauseMu.Unlock()
+	if it.resumed == nil {
+		it.resumed = make(chan struct{})
+	}
+	if it.sub != nil {
+		it.sub.pauseLimit.Store(int64(max(limit, 0)))
+		it.sub.paused.Store(true)
+	}
+}
+
+// Resume restarts delivery after Pause, starting with the buffered
+// notifications.
+func (it *NotificationIterator) Resume() {
+	it.pauseMu.Lock()
+	defer it.pauseMu.Unlock()
+	if it.resumed == nil {
+		return
+	}
+	close(it.resumed)
+	it.resumed = nil
+	if it.sub != nil {
+		it.sub.paused.Store(false)
+	}
+}
+
+// waitResumed blocks while the iterator is paused.
+func (it *NotificationIterator) waitResumed(ctx context.Context) error {
+	it.pauseMu.Lock()
+	resumed := it.resumed
+	it.pauseMu.Unlock()
+	if resumed == nil {
+		return nil
+	}
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-it.done:
+		return it.err()
+	}
+}
+
+// overPauseLimit reports whether queued values exceed the pause limit while
+// the subscription is paused.
+func (s *subscription[T]) overPauseLimit(queued int) bool {
+	limit := int(s.pauseLimit.Load())
+	return limit > 0 && s.paused.Load() && queued > limit
+}
@@ -0,0 +1,74 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNotificationIteratorPauseResume(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+	iter := client.SubscribeNotifications(1)
+	defer iter.Close()
+
+	iter.Pause()
+	for i := range 5 {
+		transport.pushReadLine(fmt.Sprintf(`{"method":"item/started","params":{"n":%d}}`, i))
+	}
+	transport.waitForReads(t, 5)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := iter.Next(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected paused Next to wait, got %v", err)
+	}
+
+	// The paused iterator must not block the client.
+	other := client.SubscribeNotifications(0)
+	defer other.Close()
+	transport.pushReadLine(`{"method":"item/started","params":{"n":5}}`)
+	if _, err := other.Next(context.Background()); err != nil {
+		t.Fatalf("other subscriber: %v", err)
+	}
+
+	iter.Resume()
+	for i := range 6 {
+		note, err := iter.Next(context.Background())
+		if err != nil {
+			t.Fatalf("next %d: %v", i, err)
+		}
+		if want := fmt.Sprintf(`{"n":%d}`, i); string(note.Raw) != want {
+			t.Fatalf("notification %d = %s, want %s", i, note.Raw, want)
+		}
+	}
+}
+
+func TestNotificationIteratorPauseOverflow(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+	iter := client.SubscribeNotifications(1)
+	defer iter.Close()
+
+	iter.PauseWithLimit(3)
+	for range 4 {
+		transport.pushReadLine(`{"method":"item/started","params":{}}`)
+	}
+	transport.waitForReads(t, 4)
+	// The client keeps delivering to others after the overflow.
+	other := client.SubscribeNotifications(0)
+	defer other.Close()
+	transport.pushReadLine(`{"method":"item/completed","params":{}}`)
+	if _, err := other.Next(context.Background()); err != nil {
+		t.Fatalf("other subscriber: %v", err)
+	}
+
+	iter.Resume()
+	if _, err := iter.Next(context.Background()); !errors.Is(err, ErrPauseOverflow) {
+		t.Fatalf("expected ErrPauseOverflow, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package rpc
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// PendingCall describes a request that is waiting for its response.
+type PendingCall struct {
+	ID     RequestID
+	Method string
+	// Age is how long ago the request was registered.
+	Age time.Duration
+}
+
+// pendingCall is the client's record of an in-flight request.
+type pendingCall struct {
+	ch      chan response
+	id      RequestID
+	method  string
+	started time.Time
+}
+
+// PendingCalls returns the requests still waiting for a response, oldest
+// first. Operators can log it to debug a stuck integration, or poll it to
+// implement a watchdog for calls that never complete.
+func (c *Client) PendingCalls() []PendingCall {
+	now := time.Now()
+	c.pendingMu.Lock()
+	calls := make([]PendingCall, 0, len(c.pending))
+	for _, call := range c.pending {
+		calls = append(calls, PendingCall{ID: call.id, Method: call.method, Age: now.Sub(call.started)})
+	}
+	c.pendingMu.Unlock()
+	slices.SortFunc(calls, func(a, b PendingCall) int {
+		return cmp.Compare(b.Age, a.Age)
+	})
+	return calls
+}
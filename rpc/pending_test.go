@@ -0,0 +1,56 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPendingCalls(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	if calls := client.PendingCalls(); len(calls) != 0 {
+		t.Fatalf("expected no pending calls, got %v", calls)
+	}
+	done := make(chan error, 2)
+	go func() { done <- client.Call(context.Background(), "thread/start", nil, nil) }()
+	transport.waitForWrites(t, 1)
+	time.Sleep(5 * time.Millisecond)
+	go func() { done <- client.Call(context.Background(), "turn/start", nil, nil) }()
+	transport.waitForWrites(t, 2)
+
+	calls := client.PendingCalls()
+	if len(calls) != 2 || calls[0].Method != "thread/start" || calls[1].Method != "turn/start" {
+		t.Fatalf("unexpected pending calls: %+v", calls)
+	}
+	if calls[0].ID.String() != "1" || calls[0].Age < calls[1].Age || calls[1].Age <= 0 {
+		t.Fatalf("unexpected ids or ages: %+v", calls)
+	}
+
+	transport.pushReadLine(`{"jsonrpc":"2.0","id":1,"result":{}}`)
+	if err := <-done; err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	if calls := client.PendingCalls(); len(calls) != 1 || calls[0].Method != "turn/start" {
+		t.Fatalf("expected only turn/start pending, got %+v", calls)
+	}
+}
+
+func TestStatsCountsSubscribers(t *testing.T) {
+	client := NewClient(newChannelTransport(), ClientOptions{})
+	defer client.Close()
+
+	notes := client.SubscribeNotifications(0)
+	raw := client.SubscribeRaw(0)
+	client.SubscribeNotifications(0).Close()
+	if stats := client.Stats(); stats.NotificationSubscribers != 1 || stats.RawSubscribers != 1 {
+		t.Fatalf("unexpected subscriber counts: %+v", stats)
+	}
+	notes.Close()
+	raw.Close()
+	if stats := client.Stats(); stats.NotificationSubscribers != 0 || stats.RawSubscribers != 0 {
+		t.Fatalf("expected no subscribers, got %+v", stats)
+	}
+}
@@ -0,0 +1,13 @@
+package rpc
+
+import "net"
+
+// NewPipeTransports returns two connected in-memory transports. Lines
+// written to one are read from the other, so a test or an embedded fake
+// app-server can run in-process: hand client to NewClient and serve on
+// server. Writes are synchronous and block until the other end reads them.
+// Closing one end makes reads on the other return io.EOF.
+func NewPipeTransports() (client, server *ConnTransport) {
+	clientConn, serverConn := net.Pipe()
+	return NewConnTransport(clientConn), NewConnTransport(serverConn)
+}
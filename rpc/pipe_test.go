@@ -0,0 +1,43 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestPipeTransportsServeClient(t *testing.T) {
+	clientEnd, serverEnd := NewPipeTransports()
+	client := NewClient(clientEnd, ClientOptions{})
+	defer client.Close()
+
+	go func() {
+		line, err := serverEnd.ReadLine()
+		if err != nil {
+			return
+		}
+		var req JSONRPCRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			return
+		}
+		_ = serverEnd.WriteLine(mustJSON(JSONRPCResponse{ID: req.ID, Result: mustRaw(map[string]string{"echo": req.Method})}))
+	}()
+
+	var result map[string]string
+	if err := client.Call(context.Background(), "ping", nil, &result); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if result["echo"] != "ping" {
+		t.Fatalf("result = %v", result)
+	}
+
+	if err := serverEnd.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	<-client.Done()
+	if err := client.Err(); !errors.Is(err, io.EOF) {
+		t.Fatalf("client error = %v, want EOF", err)
+	}
+}
@@ -0,0 +1,19 @@
+package rpc
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// awaitLeaderExit blocks until the process pid exits, leaving it
+// unreaped. It reports false when the wait fails.
+func awaitLeaderExit(pid int) bool {
+	var info unix.Siginfo
+	for {
+		err := unix.Waitid(unix.P_PID, pid, &info, unix.WEXITED|unix.WNOWAIT, nil)
+		if !errors.Is(err, unix.EINTR) {
+			return err == nil
+		}
+	}
+}
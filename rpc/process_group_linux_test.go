@@ -0,0 +1,45 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStdioTransportKillsHelpersWhenServerExits(t *testing.T) {
+	// The shell exits right away and leaves a background helper holding the
+	// stderr pipe. EOF on it, without calling Close, proves the group was
+	// killed while its id still belonged to the exited shell.
+	stderrReader, stderrWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	defer stderrReader.Close()
+	transport, err := SpawnStdioWithOptions(context.Background(), "/bin/sh", []string{"-c", "sleep 30 & echo started"}, StdioOptions{
+		Stderr: stderrWriter,
+	})
+	_ = stderrWriter.Close()
+	if err != nil {
+		t.Fatalf("SpawnStdioWithOptions: %v", err)
+	}
+	defer transport.Close()
+	if line, err := transport.ReadLine(); err != nil || line != "started" {
+		t.Fatalf("ReadLine = %q, %v", line, err)
+	}
+
+	drained := make(chan error, 1)
+	go func() {
+		_, err := io.ReadAll(stderrReader)
+		drained <- err
+	}()
+	select {
+	case err := <-drained:
+		if err != nil {
+			t.Fatalf("read stderr: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("background helper outlived the server")
+	}
+}
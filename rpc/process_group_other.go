@@ -0,0 +1,34 @@
+//go:build !unix && !windows
+
+package rpc
+
+import (
+	"os"
+	"os/exec"
+)
+
+func prepareProcessGroup(cmd *exec.Cmd) {}
+
+// processGroup signals only the spawned process on platforms without
+// process groups.
+type processGroup struct {
+	process *os.Process
+}
+
+func newProcessGroup(cmd *exec.Cmd) (*processGroup, error) {
+	return &processGroup{process: cmd.Process}, nil
+}
+
+func (g *processGroup) terminate() error {
+	return g.process.Signal(os.Interrupt)
+}
+
+func (g *processGroup) kill() error {
+	return g.process.Kill()
+}
+
+func (g *processGroup) release() {}
+
+func (g *processGroup) exiting() {}
+
+func (g *processGroup) exited() {}
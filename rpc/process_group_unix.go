@@ -0,0 +1,89 @@
+//go:build unix
+
+package rpc
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+)
+
+// prepareProcessGroup starts cmd in a process group of its own, so the
+// helpers it spawns can be signalled together with it. The group is not the
+// terminal's foreground group, so a Ctrl+C in the terminal does not reach
+// the process; the SDK stops it through Close.
+func prepareProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// processGroup is the process group led by a spawned process. The group
+// is addressed by the leader's PID, which the system may reuse once the
+// leader is reaped, so it is only signalled before then.
+type processGroup struct {
+	pgid int
+
+	mu     sync.Mutex
+	reaped bool
+}
+
+func newProcessGroup(cmd *exec.Cmd) (*processGroup, error) {
+	return &processGroup{pgid: cmd.Process.Pid}, nil
+}
+
+// terminate sends SIGTERM to every process in the group.
+func (g *processGroup) terminate() error {
+	return g.signal(syscall.SIGTERM)
+}
+
+// kill sends SIGKILL to every process in the group. It returns
+// os.ErrProcessDone when none is left.
+func (g *processGroup) kill() error {
+	return g.signal(syscall.SIGKILL)
+}
+
+func (g *processGroup) release() {}
+
+// exiting is called before the leader is reaped. Where the platform can
+// wait for the leader without reaping it, it does so and kills the rest of
+// the group while the exited leader still holds the group's ID.
+func (g *processGroup) exiting() {
+	if !awaitLeaderExit(g.pgid) {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	_ = g.signalLocked(syscall.SIGKILL)
+	g.reaped = true
+}
+
+// exited is called once the leader has been reaped; the group is not
+// signalled after that.
+func (g *processGroup) exited() {
+	g.mu.Lock()
+	g.reaped = true
+	g.mu.Unlock()
+}
+
+func (g *processGroup) signal(sig syscall.Signal) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.signalLocked(sig)
+}
+
+func (g *processGroup) signalLocked(sig syscall.Signal) error {
+	if g.reaped {
+		return os.ErrProcessDone
+	}
+	if err := syscall.Kill(-g.pgid, sig); err != nil {
+		if errors.Is(err, syscall.ESRCH) {
+			return os.ErrProcessDone
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build unix && !linux
+
+package rpc
+
+// awaitLeaderExit reports false: without waitid the leader cannot be
+// waited for without reaping it, so helpers left running when the leader
+// exits on its own are not killed.
+func awaitLeaderExit(pid int) bool {
+	return false
+}
@@ -0,0 +1,88 @@
+//go:build unix
+
+package rpc
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestStdioTransportCloseKillsProcessTree(t *testing.T) {
+	// The background sleep inherits the stderr pipe, so reading it to EOF
+	// proves the helper died with its parent.
+	stderrReader, stderrWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	defer stderrReader.Close()
+	transport, err := SpawnStdioWithOptions(context.Background(), "/bin/sh", []string{"-c", "sleep 30 & echo started; wait"}, StdioOptions{
+		Stderr:         stderrWriter,
+		ExitGrace:      10 * time.Millisecond,
+		TerminateGrace: time.Second,
+	})
+	_ = stderrWriter.Close()
+	if err != nil {
+		t.Fatalf("SpawnStdioWithOptions: %v", err)
+	}
+	if line, err := transport.ReadLine(); err != nil || line != "started" {
+		t.Fatalf("ReadLine = %q, %v", line, err)
+	}
+	_ = transport.Close()
+
+	drained := make(chan error, 1)
+	go func() {
+		_, err := io.ReadAll(stderrReader)
+		drained <- err
+	}()
+	select {
+	case err := <-drained:
+		if err != nil {
+			t.Fatalf("read stderr: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("background helper survived Close")
+	}
+}
+
+func TestStdioTransportCloseDoesNotWaitForEscapedHelper(t *testing.T) {
+	if _, err := exec.LookPath("setsid"); err != nil {
+		t.Skip("setsid is not available")
+	}
+	// The helper leaves the process group but keeps the stderr pipe open,
+	// so only the wait delay lets Close reap the shell.
+	var stderr safeBuffer
+	transport, err := SpawnStdioWithOptions(context.Background(), "/bin/sh", []string{"-c", "setsid sleep 30 >/dev/null & echo $!; wait"}, StdioOptions{
+		Stderr:         &stderr,
+		ExitGrace:      10 * time.Millisecond,
+		TerminateGrace: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("SpawnStdioWithOptions: %v", err)
+	}
+	line, err := transport.ReadLine()
+	if err != nil {
+		t.Fatalf("ReadLine: %v", err)
+	}
+	pid, err := strconv.Atoi(line)
+	if err != nil {
+		t.Fatalf("helper pid %q: %v", line, err)
+	}
+	defer syscall.Kill(pid, syscall.SIGKILL)
+
+	closed := make(chan struct{})
+	go func() {
+		_ = transport.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(stdioWaitDelay + 2*time.Second):
+		t.Fatalf("Close blocked on a helper holding stderr")
+	}
+}
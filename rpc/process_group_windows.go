@@ -0,0 +1,133 @@
+package rpc
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procCreateJobObjectW         = kernel32.NewProc("CreateJobObjectW")
+	procSetInformationJobObject  = kernel32.NewProc("SetInformationJobObject")
+	procAssignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+	procTerminateJobObject       = kernel32.NewProc("TerminateJobObject")
+	procGenerateConsoleCtrlEvent = kernel32.NewProc("GenerateConsoleCtrlEvent")
+
+	ntdll               = syscall.NewLazyDLL("ntdll.dll")
+	procNtResumeProcess = ntdll.NewProc("NtResumeProcess")
+)
+
+const (
+	processTerminate     = 0x0001
+	processSetQuota      = 0x0100
+	processSuspendResume = 0x0800
+
+	createSuspended       = 0x00000004
+	createNewProcessGroup = 0x00000200
+	ctrlBreakEvent        = 1
+
+	jobObjectExtendedLimitInformation = 9
+	jobObjectLimitKillOnJobClose      = 0x00002000
+)
+
+// jobObjectExtendedLimit mirrors JOBOBJECT_EXTENDED_LIMIT_INFORMATION.
+type jobObjectExtendedLimit struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+	IoInfo                  [6]uint64
+	ProcessMemoryLimit      uintptr
+	JobMemoryLimit          uintptr
+	PeakProcessMemoryUsed   uintptr
+	PeakJobMemoryUsed       uintptr
+}
+
+// prepareProcessGroup starts cmd in a console process group of its own, so
+// terminate can send it CTRL_BREAK without reaching the parent, and a
+// Ctrl+C in the parent's console does not kill it before Close runs. The
+// process starts suspended; newProcessGroup resumes it once it is in the
+// job, so nothing it starts escapes the job.
+func prepareProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= createNewProcessGroup | createSuspended
+}
+
+// processGroup is a job object holding a spawned process and every process
+// it starts. The job kills its processes when its last handle is closed,
+// so they also end when the SDK's own process dies without running Close.
+type processGroup struct {
+	process *os.Process
+	job     syscall.Handle
+}
+
+// newProcessGroup assigns the suspended process to a new job object and
+// resumes it. When no job can be created the process runs without one.
+func newProcessGroup(cmd *exec.Cmd) (*processGroup, error) {
+	g := &processGroup{process: cmd.Process}
+	handle, err := syscall.OpenProcess(processTerminate|processSetQuota|processSuspendResume, false, uint32(cmd.Process.Pid))
+	if err != nil {
+		return g, fmt.Errorf("open process: %w", err)
+	}
+	defer syscall.CloseHandle(handle)
+	g.job = newJob(handle)
+	if status, _, _ := procNtResumeProcess.Call(uintptr(handle)); status != 0 {
+		return g, fmt.Errorf("resume process: NTSTATUS %#x", status)
+	}
+	return g, nil
+}
+
+// newJob creates a kill-on-close job holding the process, or returns 0.
+func newJob(process syscall.Handle) syscall.Handle {
+	job, _, _ := procCreateJobObjectW.Call(0, 0)
+	if job == 0 {
+		return 0
+	}
+	limit := jobObjectExtendedLimit{LimitFlags: jobObjectLimitKillOnJobClose}
+	_, _, _ = procSetInformationJobObject.Call(job, jobObjectExtendedLimitInformation, uintptr(unsafe.Pointer(&limit)), unsafe.Sizeof(limit))
+	if ok, _, _ := procAssignProcessToJobObject.Call(job, uintptr(process)); ok == 0 {
+		_ = syscall.CloseHandle(syscall.Handle(job))
+		return 0
+	}
+	return syscall.Handle(job)
+}
+
+// terminate sends CTRL_BREAK to the process group, the console
+// counterpart of SIGTERM. It fails when the SDK's process has no console,
+// as in a service; Close then goes straight to kill.
+func (g *processGroup) terminate() error {
+	if ok, _, err := procGenerateConsoleCtrlEvent.Call(ctrlBreakEvent, uintptr(g.process.Pid)); ok == 0 {
+		return fmt.Errorf("send CTRL_BREAK: %w", err)
+	}
+	return nil
+}
+
+// kill terminates every process in the job, or only the spawned process
+// when no job could be created.
+func (g *processGroup) kill() error {
+	if g.job != 0 {
+		_, _, _ = procTerminateJobObject.Call(uintptr(g.job), 1)
+	}
+	return g.process.Kill()
+}
+
+func (g *processGroup) exiting() {}
+
+func (g *processGroup) exited() {}
+
+func (g *processGroup) release() {
+	if g.job != 0 {
+		_ = syscall.CloseHandle(g.job)
+		g.job = 0
+	}
+}
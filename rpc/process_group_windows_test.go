@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStdioTransportCloseKillsJob(t *testing.T) {
+	// ping runs as a child of cmd.exe and inherits the stderr pipe, so
+	// reading it to EOF proves the job took the child down too.
+	stderrReader, stderrWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	defer stderrReader.Close()
+	transport, err := SpawnStdioWithOptions(context.Background(), "cmd.exe", []string{"/c", "echo started & ping -n 30 127.0.0.1 >NUL"}, StdioOptions{
+		Stderr:         stderrWriter,
+		ExitGrace:      10 * time.Millisecond,
+		TerminateGrace: 500 * time.Millisecond,
+	})
+	_ = stderrWriter.Close()
+	if err != nil {
+		t.Fatalf("SpawnStdioWithOptions: %v", err)
+	}
+	if line, err := transport.ReadLine(); err != nil || strings.TrimSpace(line) != "started" {
+		t.Fatalf("ReadLine = %q, %v", line, err)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		_ = transport.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Close blocked")
+	}
+
+	drained := make(chan error, 1)
+	go func() {
+		_, err := io.ReadAll(stderrReader)
+		drained <- err
+	}()
+	select {
+	case <-drained:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("child process survived Close")
+	}
+}
+
+func TestStdioTransportReadUnblocksOnClose(t *testing.T) {
+	// A read blocked on a silent server must end once Close kills it.
+	transport, err := SpawnStdioWithOptions(context.Background(), "cmd.exe", []string{"/c", "ping -n 30 127.0.0.1 >NUL"}, StdioOptions{
+		ExitGrace:      10 * time.Millisecond,
+		TerminateGrace: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("SpawnStdioWithOptions: %v", err)
+	}
+	read := make(chan error, 1)
+	go func() {
+		_, err := transport.ReadLine()
+		read <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	_ = transport.Close()
+	select {
+	case err := <-read:
+		if err == nil {
+			t.Fatalf("ReadLine succeeded after Close")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("ReadLine stayed blocked after Close")
+	}
+}
@@ -0,0 +1,352 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// queueCloseTimeout bounds publishing the close notice in Close.
+const queueCloseTimeout = 2 * time.Second
+
+// MessageBus publishes and receives messages on named subjects, for example
+// NATS subjects or Redis lists. Adapt a broker client to it to use
+// QueueTransport and ServeQueue.
+type MessageBus interface {
+	Publish(ctx context.Context, subject string, data []byte) error
+	Subscribe(ctx context.Context, subject string) (BusSubscription, error)
+}
+
+// BusSubscription delivers the messages published on one subject in order.
+type BusSubscription interface {
+	Next(ctx context.Context) ([]byte, error)
+	Close() error
+}
+
+// QueueOptions configures NewQueueTransport.
+type QueueOptions struct {
+	// Subject receives the requests of every session; ServeQueue listens on
+	// it.
+	Subject string
+	// Session identifies this client. Replies arrive on
+	// ReplySubject(Subject, Session). Empty picks a random ID.
+	Session string
+}
+
+// queueEnvelope carries one line of a session on the shared subject.
+type queueEnvelope struct {
+	Session string `json:"session"`
+	Line    string `json:"line,omitempty"`
+	Close   bool   `json:"close,omitempty"`
+}
+
+// ReplySubject returns the subject that carries the server's lines for
+// session.
+func ReplySubject(subject, session string) string {
+	return subject + "." + session
+}
+
+// QueueTransport bridges JSON-RPC lines over a message bus, so the SDK can
+// drive an app-server in another pod without direct connectivity. Lines to
+// the server are published on the shared subject, tagged with the session;
+// lines from the server arrive on the session's reply subject. ServeQueue
+// runs the other end next to the app-server.
+type QueueTransport struct {
+	bus     MessageBus
+	sub     BusSubscription
+	subject string
+	session string
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewQueueTransport subscribes to the session's reply subject. ctx bounds
+// only the subscription.
+func NewQueueTransport(ctx context.Context, bus MessageBus, opts QueueOptions) (*QueueTransport, error) {
+	if opts.Subject == "" {
+		return nil, errors.New("queue subject is empty")
+	}
+	session := opts.Session
+	if session == "" {
+		session = NewCorrelationID()
+	}
+	sub, err := bus.Subscribe(ctx, ReplySubject(opts.Subject, session))
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to replies: %w", err)
+	}
+	lifecycle, cancel := context.WithCancel(context.Background())
+	return &QueueTransport{
+		bus:     bus,
+		sub:     sub,
+		subject: opts.Subject,
+		session: session,
+		ctx:     lifecycle,
+		cancel:  cancel,
+	}, nil
+}
+
+// Session returns the session ID.
+func (t *QueueTransport) Session() string {
+	return t.session
+}
+
+// ReadLine returns the next line from the server.
+func (t *QueueTransport) ReadLine() (string, error) {
+	data, err := t.sub.Next(t.ctx)
+	if err != nil {
+		if t.ctx.Err() != nil {
+			return "", ErrTransportClosed
+		}
+		return "", err
+	}
+	return string(data), nil
+}
+
+// WriteLine publishes line for the server.
+func (t *QueueTransport) WriteLine(line string) error {
+	if t.ctx.Err() != nil {
+		return ErrTransportClosed
+	}
+	return t.publish(t.ctx, queueEnvelope{Session: t.session, Line: line})
+}
+
+// Close tells the server the session is over and unsubscribes.
+func (t *QueueTransport) Close() error {
+	t.closeOnce.Do(func() {
+		t.cancel()
+		ctx, cancel := context.WithTimeout(context.Background(), queueCloseTimeout)
+		defer cancel()
+		t.closeErr = errors.Join(
+			t.publish(ctx, queueEnvelope{Session: t.session, Close: true}),
+			t.sub.Close(),
+		)
+	})
+	return t.closeErr
+}
+
+func (t *QueueTransport) publish(ctx context.Context, envelope queueEnvelope) error {
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	return t.bus.Publish(ctx, t.subject, data)
+}
+
+// ServeQueue runs the server end of QueueTransport. It listens on subject
+// and calls open for the first line of each new session, typically to
+// spawn an app-server, then relays the session's lines in both directions.
+// Each session's transport is written by its own goroutine, so a slow
+// app-server only holds up its own session.
+//
+// A session ends when its client closes it, its transport fails, or more
+// than queueSessionBacklog lines wait to be written to it. Requests that
+// cannot be delivered, because open failed or the session has ended, are
+// answered with a JSON-RPC error so the client's call returns. ServeQueue
+// returns when ctx is done or the subscription fails, closing every open
+// transport.
+func ServeQueue(ctx context.Context, bus MessageBus, subject string, open func(session string) (Transport, error)) error {
+	sub, err := bus.Subscribe(ctx, subject)
+	if err != nil {
+		return fmt.Errorf("subscribe to %s: %w", subject, err)
+	}
+	defer sub.Close()
+
+	var (
+		mu       sync.Mutex
+		sessions = map[string]*queueSession{}
+		ended    endedSessions
+	)
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, session := range sessions {
+			session.stop()
+		}
+	}()
+	end := func(id string, session *queueSession) {
+		mu.Lock()
+		if sessions[id] == session {
+			delete(sessions, id)
+			ended.add(id)
+		}
+		mu.Unlock()
+		session.stop()
+	}
+	reject := func(id, line, message string) {
+		if data, ok := queueErrorReply(line, message); ok {
+			_ = bus.Publish(ctx, ReplySubject(subject, id), data)
+		}
+	}
+
+	for {
+		data, err := sub.Next(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("receive from %s: %w", subject, err)
+		}
+		var envelope queueEnvelope
+		if err := json.Unmarshal(data, &envelope); err != nil || envelope.Session == "" {
+			continue
+		}
+
+		id := envelope.Session
+		mu.Lock()
+		session, known := sessions[id]
+		gone := ended.has(id)
+		mu.Unlock()
+		if envelope.Close {
+			if known {
+				end(id, session)
+			}
+			continue
+		}
+		if gone {
+			reject(id, envelope.Line, "queue session has ended")
+			continue
+		}
+		if !known {
+			transport, err := open(id)
+			if err != nil {
+				mu.Lock()
+				ended.add(id)
+				mu.Unlock()
+				reject(id, envelope.Line, fmt.Sprintf("open queue session: %v", err))
+				continue
+			}
+			session = newQueueSession(transport)
+			mu.Lock()
+			sessions[id] = session
+			mu.Unlock()
+			go session.relay(ctx, bus, ReplySubject(subject, id), func() { end(id, session) })
+		}
+		if !session.enqueue(envelope.Line) {
+			end(id, session)
+			reject(id, envelope.Line, "queue session backlog overflowed")
+		}
+	}
+}
+
+const (
+	// queueSessionBacklog bounds the lines ServeQueue holds for one session
+	// while its transport is busy.
+	queueSessionBacklog = 256
+	// queueEndedSessions bounds how many ended sessions ServeQueue remembers
+	// to reject their late lines. Lines for a session forgotten since open
+	// a new one.
+	queueEndedSessions = 1024
+)
+
+// queueSession is one session served by ServeQueue.
+type queueSession struct {
+	transport Transport
+	lines     chan string
+	done      chan struct{}
+	stopOnce  sync.Once
+}
+
+func newQueueSession(transport Transport) *queueSession {
+	return &queueSession{
+		transport: transport,
+		lines:     make(chan string, queueSessionBacklog),
+		done:      make(chan struct{}),
+	}
+}
+
+// enqueue hands line to the session's writer, reporting false when the
+// backlog is full.
+func (s *queueSession) enqueue(line string) bool {
+	select {
+	case s.lines <- line:
+		return true
+	default:
+		return false
+	}
+}
+
+// relay writes queued lines to the transport and publishes the lines it
+// reads on reply until either direction fails, then calls end.
+func (s *queueSession) relay(ctx context.Context, bus MessageBus, reply string, end func()) {
+	go func() {
+		for {
+			select {
+			case line := <-s.lines:
+				if err := s.transport.WriteLine(line); err != nil {
+					end()
+					return
+				}
+			case <-s.done:
+				return
+			}
+		}
+	}()
+	for {
+		line, err := s.transport.ReadLine()
+		if err != nil {
+			end()
+			return
+		}
+		if err := bus.Publish(ctx, reply, []byte(line)); err != nil {
+			end()
+			return
+		}
+	}
+}
+
+func (s *queueSession) stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		_ = s.transport.Close()
+	})
+}
+
+// endedSessions remembers the most recent queueEndedSessions ended
+// sessions.
+type endedSessions struct {
+	ids   map[string]struct{}
+	order []string
+}
+
+func (e *endedSessions) add(id string) {
+	if e.ids == nil {
+		e.ids = map[string]struct{}{}
+	}
+	if _, ok := e.ids[id]; ok {
+		return
+	}
+	e.ids[id] = struct{}{}
+	e.order = append(e.order, id)
+	if len(e.order) > queueEndedSessions {
+		delete(e.ids, e.order[0])
+		e.order = e.order[1:]
+	}
+}
+
+func (e *endedSessions) has(id string) bool {
+	_, ok := e.ids[id]
+	return ok
+}
+
+// queueErrorReply returns a JSON-RPC error answering line, or false when
+// line is not a request.
+func queueErrorReply(line, message string) ([]byte, bool) {
+	var request struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if json.Unmarshal([]byte(line), &request) != nil || request.Method == "" || len(request.ID) == 0 || string(request.ID) == "null" {
+		return nil, false
+	}
+	data, err := json.Marshal(struct {
+		ID    json.RawMessage   `json:"id"`
+		Error JSONRPCErrorError `json:"error"`
+	}{request.ID, JSONRPCErrorError{Code: CodeInternalError, Message: message}})
+	return data, err == nil
+}
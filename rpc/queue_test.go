@@ -0,0 +1,231 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryBus delivers each subject's messages through a buffered channel.
+type memoryBus struct {
+	mu       sync.Mutex
+	subjects map[string]chan []byte
+}
+
+func (b *memoryBus) channel(subject string) chan []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subjects == nil {
+		b.subjects = map[string]chan []byte{}
+	}
+	ch, ok := b.subjects[subject]
+	if !ok {
+		ch = make(chan []byte, 64)
+		b.subjects[subject] = ch
+	}
+	return ch
+}
+
+func (b *memoryBus) Publish(ctx context.Context, subject string, data []byte) error {
+	b.channel(subject) <- data
+	return nil
+}
+
+func (b *memoryBus) Subscribe(ctx context.Context, subject string) (BusSubscription, error) {
+	return memorySubscription(b.channel(subject)), nil
+}
+
+type memorySubscription chan []byte
+
+func (s memorySubscription) Next(ctx context.Context) ([]byte, error) {
+	select {
+	case data := <-s:
+		return data, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s memorySubscription) Close() error { return nil }
+
+func TestQueueTransportSessions(t *testing.T) {
+	bus := &memoryBus{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opened := make(chan string, 2)
+	closed := make(chan string, 2)
+	served := make(chan error, 1)
+	go func() {
+		served <- ServeQueue(ctx, bus, "codex", func(session string) (Transport, error) {
+			opened <- session
+			appServer, gatewayEnd := NewPipeTransports()
+			go func() {
+				defer func() { closed <- session }()
+				defer appServer.Close()
+				for {
+					line, err := appServer.ReadLine()
+					if err != nil {
+						return
+					}
+					_ = appServer.WriteLine("echo " + line)
+				}
+			}()
+			return gatewayEnd, nil
+		})
+	}()
+
+	first, err := NewQueueTransport(ctx, bus, QueueOptions{Subject: "codex", Session: "a"})
+	if err != nil {
+		t.Fatalf("NewQueueTransport: %v", err)
+	}
+	second, err := NewQueueTransport(ctx, bus, QueueOptions{Subject: "codex"})
+	if err != nil {
+		t.Fatalf("NewQueueTransport: %v", err)
+	}
+	if second.Session() == "" || second.Session() == "a" {
+		t.Fatalf("generated session = %q", second.Session())
+	}
+	expectEcho(t, first)
+	expectEcho(t, second)
+
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	select {
+	case session := <-closed:
+		if session != "a" {
+			t.Fatalf("closed session %q, want a", session)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("closing the client did not end its session")
+	}
+	if _, err := first.ReadLine(); !errors.Is(err, ErrTransportClosed) {
+		t.Fatalf("ReadLine after Close = %v, want ErrTransportClosed", err)
+	}
+	if len(opened) != 2 {
+		t.Fatalf("opened %d sessions, want 2", len(opened))
+	}
+
+	cancel()
+	select {
+	case err := <-served:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("ServeQueue = %v, want Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("ServeQueue did not stop")
+	}
+}
+
+func TestServeQueueRejectsRequestsWhenOpenFails(t *testing.T) {
+	bus := &memoryBus{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = ServeQueue(ctx, bus, "codex", func(session string) (Transport, error) {
+			return nil, errors.New("no capacity")
+		})
+	}()
+
+	client, err := NewQueueTransport(ctx, bus, QueueOptions{Subject: "codex", Session: "a"})
+	if err != nil {
+		t.Fatalf("NewQueueTransport: %v", err)
+	}
+	tests := []struct {
+		request string
+		want    string
+	}{
+		{`{"id":1,"method":"thread/start","params":{}}`, `{"id":1,"error":{"code":-32603,"message":"open queue session: no capacity"}}`},
+		{`{"id":2,"method":"thread/start","params":{}}`, `{"id":2,"error":{"code":-32603,"message":"queue session has ended"}}`},
+	}
+	for _, tt := range tests {
+		if err := client.WriteLine(tt.request); err != nil {
+			t.Fatalf("WriteLine: %v", err)
+		}
+		line, err := client.ReadLine()
+		if err != nil {
+			t.Fatalf("ReadLine: %v", err)
+		}
+		if line != tt.want {
+			t.Fatalf("reply = %s, want %s", line, tt.want)
+		}
+	}
+}
+
+// blockingTransport never completes a write until it is closed.
+type blockingTransport struct {
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (b *blockingTransport) ReadLine() (string, error) {
+	<-b.closed
+	return "", ErrTransportClosed
+}
+
+func (b *blockingTransport) WriteLine(string) error {
+	<-b.closed
+	return ErrTransportClosed
+}
+
+func (b *blockingTransport) Close() error {
+	b.closeOnce.Do(func() { close(b.closed) })
+	return nil
+}
+
+func TestServeQueueSlowSessionDoesNotBlockOthers(t *testing.T) {
+	bus := &memoryBus{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = ServeQueue(ctx, bus, "codex", func(session string) (Transport, error) {
+			if session == "slow" {
+				return &blockingTransport{closed: make(chan struct{})}, nil
+			}
+			appServer, gatewayEnd := NewPipeTransports()
+			go func() {
+				defer appServer.Close()
+				for {
+					line, err := appServer.ReadLine()
+					if err != nil {
+						return
+					}
+					_ = appServer.WriteLine("echo " + line)
+				}
+			}()
+			return gatewayEnd, nil
+		})
+	}()
+
+	slow, err := NewQueueTransport(ctx, bus, QueueOptions{Subject: "codex", Session: "slow"})
+	if err != nil {
+		t.Fatalf("NewQueueTransport: %v", err)
+	}
+	for range 3 {
+		if err := slow.WriteLine(`{"method":"initialized"}`); err != nil {
+			t.Fatalf("WriteLine: %v", err)
+		}
+	}
+	fast, err := NewQueueTransport(ctx, bus, QueueOptions{Subject: "codex", Session: "fast"})
+	if err != nil {
+		t.Fatalf("NewQueueTransport: %v", err)
+	}
+	expectEcho(t, fast)
+}
+
+func TestEndedSessionsAreBounded(t *testing.T) {
+	var ended endedSessions
+	for i := range queueEndedSessions + 10 {
+		ended.add(fmt.Sprint(i))
+	}
+	if len(ended.ids) != queueEndedSessions || len(ended.order) != queueEndedSessions {
+		t.Fatalf("remembered %d/%d sessions, want %d", len(ended.ids), len(ended.order), queueEndedSessions)
+	}
+	if ended.has("0") || !ended.has(fmt.Sprint(queueEndedSessions+9)) {
+		t.Fatalf("expected the oldest sessions to be forgotten")
+	}
+}
@@ -0,0 +1,94 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// SubscribeRaw returns an iterator over every line read from the transport
+// (responses, errors, server requests and notifications) exactly as it
+// arrived, before any decoding. Lines that are not valid JSON-RPC are
+// included too; only blank lines are skipped. It is meant for debugging
+// tools and protocol analyzers. A buffer of zero or less uses a default of 64.
+func (c *Client) SubscribeRaw(buffer int) *RawIterator {
+	sub := newSubscription[json.RawMessage](buffer)
+	go sub.run()
+
+	c.subsMu.Lock()
+	if c.stopped() {
+		// finish has already closed the subscriptions; nothing would close
+		// this one.
+		c.subsMu.Unlock()
+		sub.close()
+		return &RawIterator{ch: sub.out, done: c.done, err: c.errOrClosed}
+	}
+	if c.rawSubs == nil {
+		c.rawSubs = make(map[int]*subscription[json.RawMessage])
+	}
+	id := c.nextSub
+	c.nextSub++
+	c.rawSubs[id] = sub
+	c.subsMu.Unlock()
+
+	return &RawIterator{
+		ch:   sub.out,
+		done: c.done,
+		err:  c.errOrClosed,
+		cancel: func() {
+			c.subsMu.Lock()
+			sub := c.rawSubs[id]
+			delete(c.rawSubs, id)
+			c.subsMu.Unlock()
+			if sub != nil {
+				sub.close()
+			}
+		},
+	}
+}
+
+func (c *Client) publishRaw(line string) {
+	c.subsMu.Lock()
+	if len(c.rawSubs) == 0 {
+		c.subsMu.Unlock()
+		return
+	}
+	subs := make([]*subscription[json.RawMessage], 0, len(c.rawSubs))
+	for _, sub := range c.rawSubs {
+		subs = append(subs, sub)
+	}
+	c.subsMu.Unlock()
+
+	for _, sub := range subs {
+		sub.publish(json.RawMessage(line))
+	}
+}
+
+// RawIterator iterates raw incoming lines; see Client.SubscribeRaw.
+type RawIterator struct {
+	ch     <-chan json.RawMessage
+	done   <-chan struct{}
+	err    func() error
+	cancel func()
+}
+
+// Next returns the next raw line or an error.
+func (it *RawIterator) Next(ctx context.Context) (json.RawMessage, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-it.done:
+		return nil, it.err()
+	case line, ok := <-it.ch:
+		if !ok {
+			return nil, it.err()
+		}
+		return line, nil
+	}
+}
+
+// Close unsubscribes the iterator.
+func (it *RawIterator) Close() {
+	if it.cancel != nil {
+		it.cancel()
+	}
+}
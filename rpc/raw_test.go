@@ -0,0 +1,60 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSubscribeRawYieldsEveryLine(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+	raw := client.SubscribeRaw(0)
+	defer raw.Close()
+
+	lines := []string{
+		`{"jsonrpc":"2.0","method":"turn/started","params":{"turn":5}}`,
+		`{"jsonrpc":"2.0","id":7,"result":{}}`,
+		`not json`,
+		`{"jsonrpc":"2.0","id":9,"method":"item/tool/call","params":{}}`,
+	}
+	for _, line := range lines {
+		transport.pushReadLine(line)
+	}
+	transport.pushReadLine("  ")
+	transport.waitForReads(t, len(lines)+1)
+
+	for _, want := range lines {
+		got, err := raw.Next(context.Background())
+		if err != nil {
+			t.Fatalf("next: %v", err)
+		}
+		if string(got) != want {
+			t.Fatalf("raw line = %s, want %s", got, want)
+		}
+	}
+
+	raw.Close()
+	if _, err := raw.Next(context.Background()); err == nil {
+		t.Fatalf("expected error after close")
+	}
+}
+
+func TestSubscribeAfterCloseReturnsClosedIterators(t *testing.T) {
+	client := NewClient(newChannelTransport(), ClientOptions{})
+	_ = client.Close()
+
+	raw := client.SubscribeRaw(0)
+	defer raw.Close()
+	if _, err := raw.Next(context.Background()); err == nil {
+		t.Fatalf("expected raw Next to fail on a closed client")
+	}
+	notes := client.SubscribeNotifications(0)
+	defer notes.Close()
+	if _, err := notes.Next(context.Background()); err == nil {
+		t.Fatalf("expected notification Next to fail on a closed client")
+	}
+	if stats := client.Stats(); stats.RawSubscribers != 0 || stats.NotificationSubscribers != 0 {
+		t.Fatalf("subscriptions registered after close: %+v", stats)
+	}
+}
@@ -0,0 +1,297 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRedialMaxAttempts    = 5
+	defaultRedialInitialBackoff = 200 * time.Millisecond
+	defaultRedialMaxBackoff     = 10 * time.Second
+)
+
+// ErrTransportClosed is returned by a ReconnectingTransport after Close.
+var ErrTransportClosed = errors.New("transport is closed")
+
+// ConnectionState is the state reported by a ReconnectingTransport.
+type ConnectionState int
+
+const (
+	// StateConnected means a new connection was dialed after a failure.
+	StateConnected ConnectionState = iota
+	// StateDisconnected means the connection failed and is being re-dialed.
+	StateDisconnected
+	// StateFailed means re-dialing gave up; the transport stays broken.
+	StateFailed
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	case StateFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("ConnectionState(%d)", int(s))
+	}
+}
+
+// ConnectionEvent reports a ReconnectingTransport state change.
+type ConnectionEvent struct {
+	State ConnectionState
+	// Attempt is the number of dials made since the connection failed.
+	Attempt int
+	// Err is the failure that caused the transition, if any.
+	Err error
+}
+
+// RedialPolicy configures NewReconnectingTransport.
+type RedialPolicy struct {
+	// MaxAttempts bounds the consecutive dials after one failure (defaults
+	// to 5).
+	MaxAttempts int
+	// InitialBackoff is the delay after the first failed dial (defaults to
+	// 200ms). Each later dial doubles the delay.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between dials (defaults to 10s).
+	MaxBackoff time.Duration
+	// OnStateChange is called for every state change. It runs while reads
+	// and writes wait for the new connection, so it must not use the
+	// transport.
+	OnStateChange func(ConnectionEvent)
+}
+
+func (p RedialPolicy) normalized() RedialPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaultRedialMaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaultRedialInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultRedialMaxBackoff
+	}
+	return p
+}
+
+// backoff returns the delay after the given failed dial (1-based).
+func (p RedialPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxBackoff)
+}
+
+// ReconnectingTransport re-dials its connection when a read or write fails,
+// so a Client on top of it survives network blips. A failed write is
+// repeated on the new connection.
+//
+// The Client does not see the reconnect. Requests in flight when the
+// connection failed get no response, so give calls a deadline. If the
+// server keeps session state per connection, use OnStateChange to redo the
+// initialize handshake; codex.Options.Reconnect does that for the facade.
+type ReconnectingTransport struct {
+	dial   func(context.Context) (Transport, error)
+	policy RedialPolicy
+	// ctx is passed to dial and canceled by Close.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	current Transport
+	err     error
+	maxLine int
+	// redialing is closed once an in-progress redial has finished.
+	redialing chan struct{}
+}
+
+// NewReconnectingTransport dials the first connection and returns a
+// transport that re-dials with backoff whenever it fails. The context
+// passed to dial is canceled when the transport is closed.
+func NewReconnectingTransport(dial func(context.Context) (Transport, error), policy RedialPolicy) (*ReconnectingTransport, error) {
+	if dial == nil {
+		return nil, errors.New("dial function is nil")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	current, err := dial(ctx)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("dial transport: %w", err)
+	}
+	return &ReconnectingTransport{
+		dial:    dial,
+		policy:  policy.normalized(),
+		ctx:     ctx,
+		cancel:  cancel,
+		current: current,
+	}, nil
+}
+
+// ReadLine reads from the current connection, re-dialing on failure.
+func (t *ReconnectingTransport) ReadLine() (string, error) {
+	for {
+		current, err := t.transport()
+		if err != nil {
+			return "", err
+		}
+		line, err := current.ReadLine()
+		if err == nil {
+			return line, nil
+		}
+		var tooLarge *MessageTooLargeError
+		if errors.As(err, &tooLarge) {
+			return "", err
+		}
+		if err := t.reconnect(current, err); err != nil {
+			return "", err
+		}
+	}
+}
+
+// WriteLine writes to the current connection. If the write fails, it
+// re-dials and writes the line to the new connection.
+func (t *ReconnectingTransport) WriteLine(line string) error {
+	for {
+		current, err := t.transport()
+		if err != nil {
+			return err
+		}
+		err = current.WriteLine(line)
+		if err == nil {
+			return nil
+		}
+		if err := t.reconnect(current, err); err != nil {
+			return err
+		}
+	}
+}
+
+// Close closes the current connection and stops re-dialing, canceling a
+// dial in progress.
+func (t *ReconnectingTransport) Close() error {
+	t.cancel()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err == nil {
+		t.err = ErrTransportClosed
+	}
+	if t.current == nil {
+		return nil
+	}
+	err := t.current.Close()
+	t.current = nil
+	return err
+}
+
+func (t *ReconnectingTransport) setMaxLine(limit int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.maxLine = limit
+	if limiter, ok := t.current.(lineLimiter); ok {
+		limiter.setMaxLine(limit)
+	}
+}
+
+// transport returns the current connection, waiting out a redial in
+// progress.
+func (t *ReconnectingTransport) transport() (Transport, error) {
+	for {
+		t.mu.Lock()
+		if t.err != nil {
+			err := t.err
+			t.mu.Unlock()
+			return nil, err
+		}
+		redialing := t.redialing
+		if redialing == nil {
+			current := t.current
+			t.mu.Unlock()
+			return current, nil
+		}
+		t.mu.Unlock()
+		<-redialing
+	}
+}
+
+// reconnect replaces failed after it returned cause. If another reader or
+// writer already replaced it, or is replacing it, the new connection is
+// used as is. The lock is not held while dialing or backing off, so Close
+// can interrupt both.
+func (t *ReconnectingTransport) reconnect(failed Transport, cause error) error {
+	t.mu.Lock()
+	if t.err != nil || t.current != failed || t.redialing != nil {
+		err := t.err
+		t.mu.Unlock()
+		return err
+	}
+	redialing := make(chan struct{})
+	t.redialing = redialing
+	t.current = nil
+	t.mu.Unlock()
+	defer close(redialing)
+
+	_ = failed.Close()
+	t.notify(ConnectionEvent{State: StateDisconnected, Err: cause})
+	next, attempt, cause := t.redial(cause)
+
+	t.mu.Lock()
+	t.redialing = nil
+	if t.err != nil {
+		err := t.err
+		t.mu.Unlock()
+		if next != nil {
+			_ = next.Close()
+		}
+		return err
+	}
+	if next == nil {
+		t.err = fmt.Errorf("redial transport after %d attempts: %w", attempt, cause)
+		err := t.err
+		t.mu.Unlock()
+		t.notify(ConnectionEvent{State: StateFailed, Attempt: attempt, Err: cause})
+		return err
+	}
+	if limiter, ok := next.(lineLimiter); ok && t.maxLine > 0 {
+		limiter.setMaxLine(t.maxLine)
+	}
+	t.current = next
+	t.mu.Unlock()
+	t.notify(ConnectionEvent{State: StateConnected, Attempt: attempt})
+	return nil
+}
+
+// redial dials with backoff until it succeeds, MaxAttempts dials have
+// failed, or the transport is closed. It returns the new connection, or nil
+// with the last error, and the number of dials made.
+func (t *ReconnectingTransport) redial(cause error) (Transport, int, error) {
+	for attempt := 1; ; attempt++ {
+		next, err := t.dial(t.ctx)
+		if err == nil {
+			return next, attempt, nil
+		}
+		cause = err
+		if attempt >= t.policy.MaxAttempts || t.ctx.Err() != nil {
+			return nil, attempt, cause
+		}
+		timer := time.NewTimer(t.policy.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-t.ctx.Done():
+			timer.Stop()
+			return nil, attempt, cause
+		}
+	}
+}
+
+func (t *ReconnectingTransport) notify(event ConnectionEvent) {
+	if t.policy.OnStateChange != nil {
+		t.policy.OnStateChange(event)
+	}
+}
@@ -0,0 +1,250 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pipeDialer dials in-memory connections and hands the server ends to the
+// test.
+type pipeDialer struct {
+	mu      sync.Mutex
+	fail    int
+	servers chan net.Conn
+}
+
+func newPipeDialer() *pipeDialer {
+	return &pipeDialer{servers: make(chan net.Conn, 4)}
+}
+
+func (d *pipeDialer) dial(context.Context) (Transport, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.fail > 0 {
+		d.fail--
+		return nil, errors.New("connection refused")
+	}
+	client, server := net.Pipe()
+	d.servers <- server
+	return NewConnTransport(client), nil
+}
+
+func (d *pipeDialer) failNext(n int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.fail = n
+}
+
+func (d *pipeDialer) server(t *testing.T) net.Conn {
+	t.Helper()
+	select {
+	case conn := <-d.servers:
+		return conn
+	case <-time.After(time.Second):
+		t.Fatalf("no connection dialed")
+		return nil
+	}
+}
+
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []ConnectionEvent
+}
+
+func (r *eventRecorder) record(event ConnectionEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *eventRecorder) states() []ConnectionState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var states []ConnectionState
+	for _, event := range r.events {
+		states = append(states, event.State)
+	}
+	return states
+}
+
+func TestReconnectingTransportRedialsAfterReadFailure(t *testing.T) {
+	dialer := newPipeDialer()
+	var events eventRecorder
+	transport, err := NewReconnectingTransport(dialer.dial, RedialPolicy{InitialBackoff: time.Millisecond, OnStateChange: events.record})
+	if err != nil {
+		t.Fatalf("NewReconnectingTransport: %v", err)
+	}
+	defer transport.Close()
+
+	first := dialer.server(t)
+	dialer.failNext(2)
+	go func() {
+		_, _ = first.Write([]byte("one\n"))
+		_ = first.Close()
+	}()
+	if line, err := transport.ReadLine(); err != nil || line != "one" {
+		t.Fatalf("ReadLine = %q, %v", line, err)
+	}
+
+	read := make(chan string, 1)
+	go func() {
+		line, _ := transport.ReadLine()
+		read <- line
+	}()
+	second := dialer.server(t)
+	defer second.Close()
+	_, _ = second.Write([]byte("two\n"))
+	select {
+	case line := <-read:
+		if line != "two" {
+			t.Fatalf("ReadLine after reconnect = %q", line)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("ReadLine did not resume on the new connection")
+	}
+
+	if got := events.states(); len(got) != 2 || got[0] != StateDisconnected || got[1] != StateConnected {
+		t.Fatalf("events = %v", got)
+	}
+	events.mu.Lock()
+	attempt := events.events[1].Attempt
+	events.mu.Unlock()
+	if attempt != 3 {
+		t.Fatalf("connected after %d attempts, want 3", attempt)
+	}
+}
+
+func TestReconnectingTransportRetriesFailedWrite(t *testing.T) {
+	dialer := newPipeDialer()
+	transport, err := NewReconnectingTransport(dialer.dial, RedialPolicy{InitialBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewReconnectingTransport: %v", err)
+	}
+	defer transport.Close()
+
+	_ = dialer.server(t).Close()
+	written := make(chan error, 1)
+	go func() { written <- transport.WriteLine("hello") }()
+
+	second := dialer.server(t)
+	defer second.Close()
+	line, err := bufio.NewReader(second).ReadString('\n')
+	if err != nil || line != "hello\n" {
+		t.Fatalf("server read = %q, %v", line, err)
+	}
+	if err := <-written; err != nil {
+		t.Fatalf("WriteLine: %v", err)
+	}
+}
+
+func TestReconnectingTransportGivesUp(t *testing.T) {
+	dialer := newPipeDialer()
+	var events eventRecorder
+	transport, err := NewReconnectingTransport(dialer.dial, RedialPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, OnStateChange: events.record})
+	if err != nil {
+		t.Fatalf("NewReconnectingTransport: %v", err)
+	}
+	defer transport.Close()
+
+	dialer.failNext(5)
+	_ = dialer.server(t).Close()
+	if _, err := transport.ReadLine(); err == nil {
+		t.Fatalf("expected ReadLine to fail once redialing gives up")
+	}
+	if err := transport.WriteLine("late"); err == nil {
+		t.Fatalf("expected WriteLine to fail on a broken transport")
+	}
+	if got := events.states(); len(got) != 2 || got[1] != StateFailed {
+		t.Fatalf("events = %v", got)
+	}
+}
+
+func TestReconnectingTransportClose(t *testing.T) {
+	if _, err := NewReconnectingTransport(func(context.Context) (Transport, error) {
+		return nil, errors.New("refused")
+	}, RedialPolicy{}); err == nil {
+		t.Fatalf("expected error when the first dial fails")
+	}
+
+	dialer := newPipeDialer()
+	transport, err := NewReconnectingTransport(dialer.dial, RedialPolicy{InitialBackoff: time.Hour})
+	if err != nil {
+		t.Fatalf("NewReconnectingTransport: %v", err)
+	}
+	server := dialer.server(t)
+	defer server.Close()
+
+	dialer.failNext(1)
+	read := make(chan error, 1)
+	go func() {
+		_, err := transport.ReadLine()
+		read <- err
+	}()
+	_ = server.Close()
+	// The first redial fails and the transport waits out the backoff; Close
+	// must interrupt it.
+	time.Sleep(20 * time.Millisecond)
+	if err := transport.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	select {
+	case err := <-read:
+		if !errors.Is(err, ErrTransportClosed) {
+			t.Fatalf("ReadLine error = %v, want ErrTransportClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Close did not interrupt redialing")
+	}
+}
+
+func TestReconnectingTransportCloseCancelsDial(t *testing.T) {
+	var dials int
+	redialing := make(chan struct{})
+	transport, err := NewReconnectingTransport(func(ctx context.Context) (Transport, error) {
+		dials++
+		if dials == 1 {
+			// The first connection fails as soon as it is read.
+			client, server := net.Pipe()
+			_ = server.Close()
+			return NewConnTransport(client), nil
+		}
+		close(redialing)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, RedialPolicy{})
+	if err != nil {
+		t.Fatalf("NewReconnectingTransport: %v", err)
+	}
+
+	read := make(chan error, 1)
+	go func() {
+		_, err := transport.ReadLine()
+		read <- err
+	}()
+	<-redialing
+	// The redial blocks until its context ends; Close must not wait for it
+	// to release the lock.
+	closed := make(chan error, 1)
+	go func() { closed <- transport.Close() }()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Close blocked on a dial in progress")
+	}
+	select {
+	case err := <-read:
+		if !errors.Is(err, ErrTransportClosed) {
+			t.Fatalf("ReadLine error = %v, want ErrTransportClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Close did not cancel the dial")
+	}
+}
@@ -0,0 +1,89 @@
+package rpc
+
+import "encoding/json"
+
+// Replay selects retained notifications to deliver to a new subscriber
+// before live ones. It requires ClientOptions.ReplayBuffer.
+type Replay struct {
+	// Last replays up to the last Last retained notifications.
+	Last int
+	// TurnID replays every retained notification from the first one that
+	// belongs to this turn onward, including later notifications for other
+	// turns. It takes precedence over Last.
+	TurnID string
+}
+
+// replayBuffer is a ring of the most recent notifications. It is guarded by
+// Client.subsMu so that recording a notification and registering a
+// subscriber are ordered: each notification reaches a new subscriber either
+// through replay or live, never both.
+type replayBuffer struct {
+	notes []Notification
+	start int
+	count int
+}
+
+func newReplayBuffer(size int) *replayBuffer {
+	if size <= 0 {
+		return nil
+	}
+	return &replayBuffer{notes: make([]Notification, size)}
+}
+
+func (b *replayBuffer) add(note Notification) {
+	if b == nil {
+		return
+	}
+	end := (b.start + b.count) % len(b.notes)
+	b.notes[end] = note
+	if b.count < len(b.notes) {
+		b.count++
+		return
+	}
+	b.start = (b.start + 1) % len(b.notes)
+}
+
+// snapshot returns the retained notifications selected by replay, oldest
+// first.
+func (b *replayBuffer) snapshot(replay Replay) []Notification {
+	if b == nil || b.count == 0 {
+		return nil
+	}
+	notes := make([]Notification, b.count)
+	for i := range notes {
+		notes[i] = b.notes[(b.start+i)%len(b.notes)]
+	}
+	if replay.TurnID != "" {
+		for i, note := range notes {
+			if notificationTurnID(note.Raw) == replay.TurnID {
+				return notes[i:]
+			}
+		}
+		return nil
+	}
+	if replay.Last <= 0 {
+		return nil
+	}
+	if replay.Last < len(notes) {
+		notes = notes[len(notes)-replay.Last:]
+	}
+	return notes
+}
+
+// notificationTurnID reads the turn a notification belongs to from its
+// turnId param, or from turn.id for turn/started and turn/completed.
+func notificationTurnID(params json.RawMessage) string {
+	var scope struct {
+		TurnID string `json:"turnId"`
+		Turn   struct {
+			ID string `json:"id"`
+		} `json:"turn"`
+	}
+	if len(params) == 0 || json.Unmarshal(params, &scope) != nil {
+		return ""
+	}
+	if scope.TurnID != "" {
+		return scope.TurnID
+	}
+	return scope.Turn.ID
+}
@@ -0,0 +1,83 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSubscribeNotificationsReplay(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{ReplayBuffer: 3})
+	defer client.Close()
+
+	live := client.SubscribeNotifications(0)
+	defer live.Close()
+	lines := []string{
+		`{"jsonrpc":"2.0","method":"turn/started","params":{"threadId":"thr","turn":{"id":"t1"}}}`,
+		`{"jsonrpc":"2.0","method":"item/started","params":{"threadId":"thr","turnId":"t1"}}`,
+		`{"jsonrpc":"2.0","method":"turn/started","params":{"threadId":"thr","turn":{"id":"t2"}}}`,
+		`{"jsonrpc":"2.0","method":"item/completed","params":{"threadId":"thr","turnId":"t2"}}`,
+	}
+	for _, line := range lines {
+		transport.pushReadLine(line)
+	}
+	for range lines {
+		if _, err := live.Next(context.Background()); err != nil {
+			t.Fatalf("live next: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		replay Replay
+		want   []string
+	}{
+		{name: "none", want: nil},
+		{name: "last two", replay: Replay{Last: 2}, want: []string{"turn/started", "item/completed"}},
+		{name: "more than retained", replay: Replay{Last: 10}, want: []string{"item/started", "turn/started", "item/completed"}},
+		{name: "since turn", replay: Replay{TurnID: "t2"}, want: []string{"turn/started", "item/completed"}},
+		{name: "partly evicted turn", replay: Replay{TurnID: "t1"}, want: []string{"item/started", "turn/started", "item/completed"}},
+		{name: "unknown turn", replay: Replay{TurnID: "t9", Last: 2}, want: nil},
+	}
+	subs := make([]*NotificationIterator, len(tests))
+	for i, tt := range tests {
+		subs[i] = client.SubscribeNotificationsReplay(0, tt.replay)
+		defer subs[i].Close()
+	}
+	transport.pushReadLine(`{"jsonrpc":"2.0","method":"turn/completed","params":{"threadId":"thr","turn":{"id":"t2"}}}`)
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, want := range append(tt.want, "turn/completed") {
+				note, err := subs[i].Next(context.Background())
+				if err != nil {
+					t.Fatalf("next: %v", err)
+				}
+				if note.Method != want {
+					t.Fatalf("method = %s, want %s", note.Method, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSubscribeNotificationsReplayDisabled(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	live := client.SubscribeNotifications(0)
+	defer live.Close()
+	transport.pushReadLine(`{"jsonrpc":"2.0","method":"item/started","params":{"turnId":"t1"}}`)
+	if _, err := live.Next(context.Background()); err != nil {
+		t.Fatalf("live next: %v", err)
+	}
+
+	late := client.SubscribeNotificationsReplay(0, Replay{Last: 5})
+	defer late.Close()
+	transport.pushReadLine(`{"jsonrpc":"2.0","method":"item/completed","params":{"turnId":"t1"}}`)
+	note, err := late.Next(context.Background())
+	if err != nil || note.Method != "item/completed" {
+		t.Fatalf("expected only live notification, got %v, %v", note.Method, err)
+	}
+}
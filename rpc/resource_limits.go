@@ -0,0 +1,38 @@
+package rpc
+
+import "time"
+
+// ResourceLimits caps the resources of a spawned process. The processes it
+// starts, such as sandboxed commands, inherit the limits, so a runaway
+// command tree cannot exhaust the host. Zero fields are left unlimited.
+//
+// The limits are rlimits, applied with prlimit right after the process
+// starts; they are only supported on Linux. Go cannot run code between fork
+// and exec, so there is a short window in which the process runs
+// unlimited, and children it starts in that window keep no limits. The
+// app-server starts no commands before initialize, which a Client only
+// sends once SpawnStdioWithOptions has returned, but a wrapper script at
+// the spawned path that starts helpers right away escapes the limits.
+type ResourceLimits struct {
+	// MaxMemory caps the virtual address space of each process in bytes
+	// (RLIMIT_AS). Allocations beyond it fail.
+	MaxMemory int64
+	// MaxCPUTime caps the CPU time of each process (RLIMIT_CPU), rounded
+	// up to whole seconds. A process that exceeds it receives SIGXCPU.
+	MaxCPUTime time.Duration
+	// MaxProcesses caps the number of processes the user running the
+	// app-server may have (RLIMIT_NPROC). The count includes processes
+	// outside the app-server's tree, so run it as a dedicated user for a
+	// meaningful cap.
+	MaxProcesses int
+}
+
+// IsZero reports whether l sets no limit.
+func (l ResourceLimits) IsZero() bool {
+	return l == ResourceLimits{}
+}
+
+// cpuSeconds returns MaxCPUTime in whole seconds, rounded up.
+func (l ResourceLimits) cpuSeconds() uint64 {
+	return uint64((l.MaxCPUTime + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,33 @@
+package rpc
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// applyResourceLimits sets the limits on the process pid.
+func applyResourceLimits(pid int, limits ResourceLimits) error {
+	if limits.MaxMemory > 0 {
+		if err := prlimit(pid, unix.RLIMIT_AS, uint64(limits.MaxMemory)); err != nil {
+			return fmt.Errorf("limit memory: %w", err)
+		}
+	}
+	if limits.MaxCPUTime > 0 {
+		if err := prlimit(pid, unix.RLIMIT_CPU, limits.cpuSeconds()); err != nil {
+			return fmt.Errorf("limit CPU time: %w", err)
+		}
+	}
+	if limits.MaxProcesses > 0 {
+		if err := prlimit(pid, unix.RLIMIT_NPROC, uint64(limits.MaxProcesses)); err != nil {
+			return fmt.Errorf("limit processes: %w", err)
+		}
+	}
+	return nil
+}
+
+// prlimit sets both the soft and the hard limit, so the process cannot
+// raise it again.
+func prlimit(pid, resource int, value uint64) error {
+	return unix.Prlimit(pid, resource, &unix.Rlimit{Cur: value, Max: value}, nil)
+}
@@ -0,0 +1,36 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpawnStdioAppliesResourceLimits(t *testing.T) {
+	transport, err := SpawnStdioWithOptions(context.Background(), "/bin/sh", []string{"-c", "exec sleep 10"}, StdioOptions{
+		ExitGrace: 10 * time.Millisecond,
+		Limits:    ResourceLimits{MaxMemory: 512 << 20, MaxCPUTime: 1500 * time.Millisecond, MaxProcesses: 4096},
+	})
+	if err != nil {
+		t.Fatalf("SpawnStdioWithOptions: %v", err)
+	}
+	defer transport.Close()
+
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/limits", transport.cmd.Process.Pid))
+	if err != nil {
+		t.Fatalf("read limits: %v", err)
+	}
+	limits := string(data)
+	for _, want := range []string{
+		"Max address space         536870912            536870912",
+		"Max cpu time              2                    2",
+		"Max processes             4096                 4096",
+	} {
+		if !strings.Contains(limits, want) {
+			t.Fatalf("limits missing %q:\n%s", want, limits)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package rpc
+
+import "errors"
+
+func applyResourceLimits(pid int, limits ResourceLimits) error {
+	return errors.New("resource limits are only supported on Linux")
+}
@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 100 * time.Millisecond
+	defaultRetryMaxBackoff     = 5 * time.Second
+)
+
+// RetryPolicy configures automatic retries in Client.Call. Only methods listed
+// in Methods are retried, so callers must opt in per idempotent method.
+type RetryPolicy struct {
+	// Methods lists the idempotent JSON-RPC methods eligible for retry.
+	Methods []string
+	// ErrorCodes lists JSON-RPC error codes that trigger a retry. Transport
+	// write failures are always retried for eligible methods.
+	ErrorCodes []int64
+	// MaxAttempts is the total number of attempts, including the first
+	// (defaults to 3).
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry (defaults to 100ms).
+	// Each later retry doubles the delay.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts (defaults to 5s).
+	MaxBackoff time.Duration
+}
+
+func (p *RetryPolicy) normalized() *RetryPolicy {
+	if p == nil {
+		return nil
+	}
+	out := *p
+	out.Methods = slices.Clone(p.Methods)
+	out.ErrorCodes = slices.Clone(p.ErrorCodes)
+	if out.MaxAttempts <= 0 {
+		out.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if out.InitialBackoff <= 0 {
+		out.InitialBackoff = defaultRetryInitialBackoff
+	}
+	if out.MaxBackoff <= 0 {
+		out.MaxBackoff = defaultRetryMaxBackoff
+	}
+	return &out
+}
+
+func (p *RetryPolicy) allows(method string) bool {
+	return slices.Contains(p.Methods, method)
+}
+
+func (p *RetryPolicy) retryable(writeFailed bool, err error) bool {
+	if writeFailed {
+		return true
+	}
+	var respErr *ResponseError
+	if errors.As(err, &respErr) {
+		return slices.Contains(p.ErrorCodes, respErr.Detail.Code)
+	}
+	return false
+}
+
+// backoff returns the delay before the given retry (1-based) using
+// exponential growth with jitter in [d/2, d].
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < retry && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+func (c *Client) callWithRetry(ctx context.Context, method string, params any, result any) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		var writeFailed bool
+		writeFailed, err = c.callOnce(ctx, method, params, result)
+		if err == nil || attempt >= c.retry.MaxAttempts || !c.retry.retryable(writeFailed, err) {
+			return err
+		}
+
+		delay := c.retry.backoff(attempt)
+		c.logger.Warn("retrying json-rpc call",
+			slog.String("method", method),
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			slog.Any("error", err),
+		)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-c.done:
+			timer.Stop()
+			return c.errOrClosed()
+		case <-timer.C:
+		}
+	}
+}
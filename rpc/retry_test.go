@@ -0,0 +1,154 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCallRetriesWriteFailure(t *testing.T) {
+	transport := &flakyWriteTransport{channelTransport: newChannelTransport(), failures: 2}
+	client := NewClient(transport, ClientOptions{Retry: &RetryPolicy{
+		Methods:        []string{"model/list"},
+		InitialBackoff: time.Millisecond,
+	}})
+	defer client.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Call(context.Background(), "model/list", nil, nil)
+	}()
+	writes := transport.waitForWrites(t, 1)
+	if !strings.Contains(writes[0], `"id":3`) {
+		t.Fatalf("expected third attempt to be written, got %s", writes[0])
+	}
+	transport.pushReadLine(mustJSON(JSONRPCResponse{ID: NewIntRequestID(3), Result: mustRaw(map[string]any{})}))
+	if err := <-done; err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+}
+
+func TestCallRetriesQueuedWriteFailure(t *testing.T) {
+	transport := &flakyWriteTransport{channelTransport: newChannelTransport(), failures: 1}
+	client := NewClient(transport, ClientOptions{
+		WriteQueue: &WriteQueuePolicy{},
+		Retry:      &RetryPolicy{Methods: []string{"model/list"}, InitialBackoff: time.Millisecond},
+	})
+	defer client.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Call(context.Background(), "model/list", nil, nil)
+	}()
+	writes := transport.waitForWrites(t, 1)
+	if !strings.Contains(writes[0], `"id":2`) {
+		t.Fatalf("expected second attempt to be written, got %s", writes[0])
+	}
+	transport.pushReadLine(mustJSON(JSONRPCResponse{ID: NewIntRequestID(2), Result: mustRaw(map[string]any{})}))
+	if err := <-done; err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if err := client.Err(); err != nil {
+		t.Fatalf("a failed request write stopped the client: %v", err)
+	}
+}
+
+func TestCallDoesNotRetryUnlistedMethod(t *testing.T) {
+	transport := &flakyWriteTransport{channelTransport: newChannelTransport(), failures: 1}
+	client := NewClient(transport, ClientOptions{Retry: &RetryPolicy{
+		Methods:        []string{"model/list"},
+		InitialBackoff: time.Millisecond,
+	}})
+	defer client.Close()
+
+	if err := client.Call(context.Background(), "turn/start", nil, nil); err == nil {
+		t.Fatalf("expected write failure")
+	}
+	if transport.attempts() != 1 {
+		t.Fatalf("expected a single attempt, got %d", transport.attempts())
+	}
+}
+
+func TestCallRetriesConfiguredErrorCodes(t *testing.T) {
+	transport := newScriptedTransport()
+	transport.enqueueError(-32000, "busy")
+	transport.enqueueError(-32000, "busy")
+	transport.enqueueError(-32001, "fatal")
+	client := NewClient(transport, ClientOptions{Retry: &RetryPolicy{
+		Methods:        []string{"model/list"},
+		ErrorCodes:     []int64{-32000},
+		MaxAttempts:    5,
+		InitialBackoff: time.Millisecond,
+	}})
+	defer client.Close()
+
+	err := client.Call(context.Background(), "model/list", nil, nil)
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) || respErr.Detail.Code != -32001 {
+		t.Fatalf("expected non-retryable error to surface, got %v", err)
+	}
+}
+
+func TestCallRetryExhaustsAttempts(t *testing.T) {
+	transport := &flakyWriteTransport{channelTransport: newChannelTransport(), failures: 10}
+	client := NewClient(transport, ClientOptions{Retry: &RetryPolicy{
+		Methods:        []string{"model/list"},
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+	}})
+	defer client.Close()
+
+	if err := client.Call(context.Background(), "model/list", nil, nil); err == nil {
+		t.Fatalf("expected error after exhausting attempts")
+	}
+	if transport.attempts() != 2 {
+		t.Fatalf("expected 2 attempts, got %d", transport.attempts())
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := (&RetryPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 25 * time.Millisecond}).normalized()
+	tests := []struct {
+		retry    int
+		min, max time.Duration
+	}{
+		{retry: 1, min: 5 * time.Millisecond, max: 10 * time.Millisecond},
+		{retry: 2, min: 10 * time.Millisecond, max: 20 * time.Millisecond},
+		{retry: 3, min: 12500 * time.Microsecond, max: 25 * time.Millisecond},
+		{retry: 10, min: 12500 * time.Microsecond, max: 25 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			if got := policy.backoff(tt.retry); got < tt.min || got > tt.max {
+				t.Fatalf("retry %d: backoff %s outside [%s, %s]", tt.retry, got, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+type flakyWriteTransport struct {
+	*channelTransport
+	countMu  sync.Mutex
+	failures int
+	tries    int
+}
+
+func (t *flakyWriteTransport) WriteLine(line string) error {
+	t.countMu.Lock()
+	t.tries++
+	fail := t.tries <= t.failures
+	t.countMu.Unlock()
+	if fail {
+		return errors.New("broken pipe")
+	}
+	return t.channelTransport.WriteLine(line)
+}
+
+func (t *flakyWriteTransport) attempts() int {
+	t.countMu.Lock()
+	defer t.countMu.Unlock()
+	return t.tries
+}
@@ -0,0 +1,121 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// ServerInfo describes the app-server, parsed from the initialize response.
+type ServerInfo struct {
+	// UserAgent is the server's user agent string, for example
+	// "codex_cli_rs/0.50.0 (Mac OS 15.6.0; arm64)".
+	UserAgent string
+	// Name and Version come from the response's serverInfo object or, when
+	// it is absent, from the leading "name/version" token of UserAgent.
+	Name    string
+	Version string
+	// ProtocolVersion is the app-server protocol revision the server speaks,
+	// when it reports one.
+	ProtocolVersion string
+	// Capabilities lists what the server advertised, if anything.
+	Capabilities Capabilities
+	// Raw is the unmodified initialize result.
+	Raw json.RawMessage
+}
+
+// Capabilities are the features an app-server advertises at initialize.
+// Servers that advertise nothing leave every field empty.
+type Capabilities struct {
+	// Methods lists the request methods the server accepts. Nil means the
+	// server did not advertise them.
+	Methods []string `json:"methods,omitempty"`
+	// Notifications lists the notification methods the server may send.
+	Notifications []string `json:"notifications,omitempty"`
+	// ExperimentalAPI reports whether experimental methods are enabled.
+	ExperimentalAPI bool `json:"experimentalApi,omitempty"`
+}
+
+// ServerInfo returns what the server reported at initialize, or nil before
+// the initialize handshake has completed.
+func (c *Client) ServerInfo() *ServerInfo {
+	c.infoMu.Lock()
+	defer c.infoMu.Unlock()
+	return c.serverInfo
+}
+
+// SupportsMethod reports whether the server is expected to accept method.
+// A method is unsupported if the server advertised a method list without
+// it, or if the server already rejected it with "method not found". When
+// neither is known the method is assumed to be supported.
+func (c *Client) SupportsMethod(method string) bool {
+	c.infoMu.Lock()
+	defer c.infoMu.Unlock()
+	if c.unsupported[method] {
+		return false
+	}
+	if c.serverInfo != nil && c.serverInfo.Capabilities.Methods != nil {
+		return slices.Contains(c.serverInfo.Capabilities.Methods, method)
+	}
+	return true
+}
+
+// advertisesMethod reports whether the server listed method at initialize
+// and has not since rejected it.
+func (c *Client) advertisesMethod(method string) bool {
+	c.infoMu.Lock()
+	defer c.infoMu.Unlock()
+	if c.unsupported[method] || c.serverInfo == nil {
+		return false
+	}
+	return slices.Contains(c.serverInfo.Capabilities.Methods, method)
+}
+
+// observeResponse records negotiation details carried by a response to
+// method: the initialize result, and methods the server does not know.
+func (c *Client) observeResponse(method string, result json.RawMessage, err error) {
+	if method == "initialize" && err == nil {
+		info := parseServerInfo(result)
+		c.infoMu.Lock()
+		c.serverInfo = info
+		c.infoMu.Unlock()
+		return
+	}
+	if errors.Is(err, ErrMethodNotFound) {
+		c.infoMu.Lock()
+		if c.unsupported == nil {
+			c.unsupported = make(map[string]bool)
+		}
+		c.unsupported[method] = true
+		c.infoMu.Unlock()
+	}
+}
+
+func parseServerInfo(result json.RawMessage) *ServerInfo {
+	info := &ServerInfo{Raw: result}
+	var payload struct {
+		UserAgent       string `json:"userAgent"`
+		ProtocolVersion string `json:"protocolVersion"`
+		ServerInfo      *struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+		Capabilities Capabilities `json:"capabilities"`
+	}
+	if err := json.Unmarshal(result, &payload); err != nil {
+		return info
+	}
+	info.UserAgent = payload.UserAgent
+	info.ProtocolVersion = payload.ProtocolVersion
+	info.Capabilities = payload.Capabilities
+	if payload.ServerInfo != nil {
+		info.Name = payload.ServerInfo.Name
+		info.Version = payload.ServerInfo.Version
+	}
+	if info.Name == "" && info.Version == "" {
+		product, _, _ := strings.Cut(payload.UserAgent, " ")
+		info.Name, info.Version, _ = strings.Cut(product, "/")
+	}
+	return info
+}
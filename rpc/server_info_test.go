@@ -0,0 +1,108 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+func TestParseServerInfo(t *testing.T) {
+	tests := []struct {
+		name   string
+		result map[string]any
+		want   ServerInfo
+	}{
+		{
+			name:   "user agent only",
+			result: map[string]any{"userAgent": "codex_cli_rs/0.50.0 (Mac OS 15.6.0; arm64) vscode/1.0"},
+			want:   ServerInfo{UserAgent: "codex_cli_rs/0.50.0 (Mac OS 15.6.0; arm64) vscode/1.0", Name: "codex_cli_rs", Version: "0.50.0"},
+		},
+		{
+			name: "server info and capabilities",
+			result: map[string]any{
+				"userAgent":       "codex_cli_rs/0.50.0",
+				"protocolVersion": "v2",
+				"serverInfo":      map[string]any{"name": "codex-app-server", "version": "0.51.0"},
+				"capabilities":    map[string]any{"methods": []string{"turn/start"}, "experimentalApi": true},
+			},
+			want: ServerInfo{
+				UserAgent:       "codex_cli_rs/0.50.0",
+				Name:            "codex-app-server",
+				Version:         "0.51.0",
+				ProtocolVersion: "v2",
+				Capabilities:    Capabilities{Methods: []string{"turn/start"}, ExperimentalAPI: true},
+			},
+		},
+		{
+			name:   "empty",
+			result: map[string]any{},
+			want:   ServerInfo{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := parseServerInfo(mustRaw(tt.result))
+			if info.UserAgent != tt.want.UserAgent || info.Name != tt.want.Name || info.Version != tt.want.Version ||
+				info.ProtocolVersion != tt.want.ProtocolVersion ||
+				info.Capabilities.ExperimentalAPI != tt.want.Capabilities.ExperimentalAPI ||
+				len(info.Capabilities.Methods) != len(tt.want.Capabilities.Methods) {
+				t.Fatalf("unexpected server info: %+v", info)
+			}
+		})
+	}
+}
+
+func TestClientStoresServerInfoFromInitialize(t *testing.T) {
+	transport := NewReplayTransport([]TranscriptEntry{
+		writeLine(JSONRPCRequest{ID: NewIntRequestID(1), Method: "initialize", Params: mustRaw(protocol.InitializeParams{ClientInfo: protocol.ClientInfo{Name: "test", Version: "1"}})}),
+		readLine(JSONRPCResponse{ID: NewIntRequestID(1), Result: mustRaw(map[string]any{
+			"userAgent":    "codex_cli_rs/0.50.0",
+			"capabilities": map[string]any{"methods": []string{"turn/start", "thread/start"}},
+		})}),
+	})
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	if client.ServerInfo() != nil {
+		t.Fatalf("expected no server info before initialize")
+	}
+	if _, err := client.Initialize(context.Background(), protocol.InitializeParams{ClientInfo: protocol.ClientInfo{Name: "test", Version: "1"}}); err != nil {
+		t.Fatalf("initialize error: %v", err)
+	}
+	info := client.ServerInfo()
+	if info == nil || info.Version != "0.50.0" {
+		t.Fatalf("unexpected server info: %+v", info)
+	}
+	if !client.SupportsMethod("turn/start") {
+		t.Fatalf("expected advertised method to be supported")
+	}
+	if client.SupportsMethod("turn/interrupt") {
+		t.Fatalf("expected unadvertised method to be unsupported")
+	}
+}
+
+func TestSupportsMethodLearnsFromMethodNotFound(t *testing.T) {
+	transport := NewReplayTransport([]TranscriptEntry{
+		writeLine(JSONRPCRequest{ID: NewIntRequestID(1), Method: "turn/interrupt"}),
+		readLine(JSONRPCError{ID: NewIntRequestID(1), Error: JSONRPCErrorError{Code: CodeMethodNotFound, Message: "method not found"}}),
+	})
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	if !client.SupportsMethod("turn/interrupt") {
+		t.Fatalf("expected methods to be assumed supported without capabilities")
+	}
+	err := client.Call(context.Background(), "turn/interrupt", nil, nil)
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) {
+		t.Fatalf("expected response error, got %v", err)
+	}
+	if client.SupportsMethod("turn/interrupt") {
+		t.Fatalf("expected method to be unsupported after method not found")
+	}
+	if !client.SupportsMethod("turn/start") {
+		t.Fatalf("expected other methods to stay supported")
+	}
+}
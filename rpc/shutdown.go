@@ -0,0 +1,136 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// shutdownRequestTimeout bounds the wait for a reply to
+// ClientOptions.ShutdownMethod.
+const shutdownRequestTimeout = 2 * time.Second
+
+// ErrShuttingDown is returned by Call and Notify once Shutdown has begun.
+var ErrShuttingDown = errors.New("client is shutting down")
+
+// Shutdown gracefully closes the client. It stops accepting new calls and
+// notifications, waits for in-flight calls to receive their responses and for
+// running server-request handlers to reply, then closes the transport. If ctx
+// ends first, the client is closed immediately, failing whatever is still
+// pending, and ctx's error is returned.
+//
+// Transports that implement CloseContext(ctx) error (such as StdioTransport)
+// are given until ctx ends to close before being forced.
+func (c *Client) Shutdown(ctx context.Context) error {
+	idle := c.beginDrain()
+	select {
+	case <-idle:
+	case <-c.done:
+	case <-ctx.Done():
+		return errors.Join(ctx.Err(), c.closeContext(ctx))
+	}
+	if err := c.flushWrites(ctx); err != nil {
+		return errors.Join(err, c.closeContext(ctx))
+	}
+	_ = c.RequestShutdown(ctx)
+	return c.closeContext(ctx)
+}
+
+// RequestShutdown sends ClientOptions.ShutdownMethod so the server can
+// persist its state and exit on its own, and waits up to 2s for the reply.
+// The request is only sent when the server listed the method in its
+// initialize capabilities. It is admitted while Shutdown drains the client.
+// It returns nil when no method is configured, the server did not advertise
+// it, or the connection ends in response; callers close the client
+// afterwards either way.
+func (c *Client) RequestShutdown(ctx context.Context) error {
+	if c.shutdownMethod == "" || !c.advertisesMethod(c.shutdownMethod) {
+		return nil
+	}
+	select {
+	case <-c.done:
+		return nil
+	default:
+	}
+	ctx, cancel := context.WithTimeout(ctx, shutdownRequestTimeout)
+	defer cancel()
+	_, err := c.callOnce(ctx, c.shutdownMethod, nil, nil)
+	if err == nil || errors.Is(err, ErrMethodNotFound) {
+		return nil
+	}
+	select {
+	case <-c.done:
+		return nil
+	default:
+	}
+	c.logger.Debug("json-rpc shutdown request failed", slog.String("method", c.shutdownMethod), slog.Any("error", err))
+	return fmt.Errorf("request shutdown: %w", err)
+}
+
+func (c *Client) closeContext(ctx context.Context) error {
+	c.finish(errors.New("client closed"))
+	var err error
+	transport, _ := c.currentTransport()
+	if closer, ok := transport.(interface{ CloseContext(context.Context) error }); ok {
+		err = closer.CloseContext(ctx)
+	} else {
+		err = transport.Close()
+	}
+	return errors.Join(err, c.waitAudit(ctx))
+}
+
+// waitAudit waits for the audit sink to archive queued notifications.
+func (c *Client) waitAudit(ctx context.Context) error {
+	if c.audit == nil {
+		return nil
+	}
+	select {
+	case <-c.audit.drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("audit sink: %w", ctx.Err())
+	}
+}
+
+// beginDrain rejects new work and returns a channel closed once all active
+// work has finished.
+func (c *Client) beginDrain() <-chan struct{} {
+	c.activeMu.Lock()
+	defer c.activeMu.Unlock()
+	c.draining = true
+	if c.idle == nil {
+		c.idle = make(chan struct{})
+		if c.active == 0 {
+			close(c.idle)
+		}
+	}
+	return c.idle
+}
+
+// beginWork registers a unit of in-flight work. Client work (calls and
+// notifications) is refused while draining; server-request handlers are
+// always admitted so in-flight turns can still be approved.
+func (c *Client) beginWork(fromClient bool) error {
+	c.activeMu.Lock()
+	defer c.activeMu.Unlock()
+	if fromClient && c.draining {
+		return ErrShuttingDown
+	}
+	c.active++
+	return nil
+}
+
+func (c *Client) endWork() {
+	c.activeMu.Lock()
+	defer c.activeMu.Unlock()
+	c.active--
+	if c.active == 0 && c.idle != nil {
+		select {
+		case <-c.idle:
+		default:
+			close(c.idle)
+		}
+	}
+}
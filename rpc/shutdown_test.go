@@ -0,0 +1,195 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShutdownWaitsForInFlightCalls(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{})
+
+	callDone := make(chan error, 1)
+	go func() {
+		callDone <- client.Call(context.Background(), "model/list", nil, nil)
+	}()
+	transport.waitForWrites(t, 1)
+
+	shutdownDone := make(chan error, 1)
+	go func() {
+		shutdownDone <- client.Shutdown(context.Background())
+	}()
+	waitFor(t, func() bool {
+		client.activeMu.Lock()
+		defer client.activeMu.Unlock()
+		return client.draining
+	})
+	if err := client.Call(context.Background(), "model/list", nil, nil); !errors.Is(err, ErrShuttingDown) {
+		t.Fatalf("expected call to be refused, got %v", err)
+	}
+	if err := client.Notify(context.Background(), "initialized", nil); !errors.Is(err, ErrShuttingDown) {
+		t.Fatalf("expected notify to be refused, got %v", err)
+	}
+
+	select {
+	case err := <-shutdownDone:
+		t.Fatalf("shutdown returned before in-flight call finished: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	transport.pushReadLine(mustJSON(JSONRPCResponse{ID: NewIntRequestID(1), Result: mustRaw(map[string]any{})}))
+	if err := <-callDone; err != nil {
+		t.Fatalf("in-flight call failed: %v", err)
+	}
+	if err := <-shutdownDone; err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	if err := client.ensureOpen(); err == nil {
+		t.Fatalf("expected client to be closed")
+	}
+}
+
+func TestShutdownWaitsForServerRequestHandlers(t *testing.T) {
+	transport := newChannelTransport()
+	release := make(chan struct{})
+	client := NewClient(transport, ClientOptions{
+		RequestHandler: &testHandler{},
+		Interceptors: []Interceptor{{ServerRequest: func(ctx context.Context, req JSONRPCRequest, next ServerRequestInvoker) (any, error) {
+			<-release
+			return next(ctx, req)
+		}}},
+	})
+
+	transport.pushReadLine(mustJSON(JSONRPCRequest{
+		ID:     NewIntRequestID(9),
+		Method: "applyPatchApproval",
+		Params: mustRaw(map[string]any{"callId": "c", "conversationId": "thr", "fileChanges": map[string]any{}}),
+	}))
+	transport.waitForReads(t, 1)
+
+	shutdownDone := make(chan error, 1)
+	go func() {
+		shutdownDone <- client.Shutdown(context.Background())
+	}()
+	select {
+	case err := <-shutdownDone:
+		t.Fatalf("shutdown returned before handler replied: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-shutdownDone; err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	writes := transport.waitForWrites(t, 1)
+	if !strings.Contains(writes[0], `"id":9`) {
+		t.Fatalf("expected handler reply before close, got %v", writes)
+	}
+}
+
+func TestShutdownDeadlineClosesClient(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{})
+
+	callDone := make(chan error, 1)
+	go func() {
+		callDone <- client.Call(context.Background(), "model/list", nil, nil)
+	}()
+	transport.waitForWrites(t, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := client.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if err := <-callDone; err == nil {
+		t.Fatalf("expected pending call to fail after forced close")
+	}
+}
+
+func TestShutdownIdleClient(t *testing.T) {
+	client := NewClient(newChannelTransport(), ClientOptions{})
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Fatalf("second shutdown failed: %v", err)
+	}
+}
+
+func TestShutdownRequestsShutdownMethod(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{ShutdownMethod: "shutdown"})
+	advertiseMethods(client, "shutdown")
+
+	shutdownDone := make(chan error, 1)
+	go func() {
+		shutdownDone <- client.Shutdown(context.Background())
+	}()
+	writes := transport.waitForWrites(t, 1)
+	if !strings.Contains(writes[0], `"method":"shutdown"`) {
+		t.Fatalf("expected a shutdown request, got %s", writes[0])
+	}
+	transport.pushReadLine(mustJSON(JSONRPCResponse{ID: NewIntRequestID(1), Result: mustRaw(map[string]any{})}))
+	if err := <-shutdownDone; err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+}
+
+func TestRequestShutdownIgnoresMethodNotFound(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{ShutdownMethod: "shutdown"})
+	defer client.Close()
+	advertiseMethods(client, "shutdown")
+
+	requested := make(chan error, 1)
+	go func() {
+		requested <- client.RequestShutdown(context.Background())
+	}()
+	transport.waitForWrites(t, 1)
+	transport.pushReadLine(mustJSON(JSONRPCError{ID: NewIntRequestID(1), Error: JSONRPCErrorError{Code: CodeMethodNotFound, Message: "unknown method"}}))
+	if err := <-requested; err != nil {
+		t.Fatalf("RequestShutdown = %v, want nil for an unsupported method", err)
+	}
+	if err := client.RequestShutdown(context.Background()); err != nil {
+		t.Fatalf("second RequestShutdown: %v", err)
+	}
+	if writes := transport.waitForWrites(t, 1); len(writes) != 1 {
+		t.Fatalf("sent the unsupported method again: %q", writes)
+	}
+}
+
+func TestRequestShutdownSkipsUnadvertisedMethod(t *testing.T) {
+	tests := []struct {
+		name      string
+		advertise []string
+	}{
+		{name: "no capabilities"},
+		{name: "method not listed", advertise: []string{"turn/start"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := newChannelTransport()
+			client := NewClient(transport, ClientOptions{ShutdownMethod: "shutdown"})
+			if tt.advertise != nil {
+				advertiseMethods(client, tt.advertise...)
+			}
+			if err := client.Shutdown(context.Background()); err != nil {
+				t.Fatalf("Shutdown: %v", err)
+			}
+			transport.mu.Lock()
+			defer transport.mu.Unlock()
+			if len(transport.writes) != 0 {
+				t.Fatalf("sent %q to a server that did not advertise it", transport.writes)
+			}
+		})
+	}
+}
+
+// advertiseMethods records an initialize result listing methods.
+func advertiseMethods(client *Client, methods ...string) {
+	client.observeResponse("initialize", mustRaw(map[string]any{"capabilities": map[string]any{"methods": methods}}), nil)
+}
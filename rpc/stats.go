@@ -0,0 +1,153 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+const (
+	defaultClockSkewThreshold = 5 * time.Second
+	clockSkewWindow           = 32
+)
+
+// ClientStats is a snapshot of client health measurements.
+type ClientStats struct {
+	// ClockSkew estimates how far the local clock is ahead of the server clock
+	// (negative when behind). It is derived from server timestamps in
+	// notifications and includes at least the minimum observed delivery
+	// latency. Zero when ClockSkewSamples is zero.
+	ClockSkew time.Duration
+	// ClockSkewSamples is the number of timestamped notifications observed.
+	ClockSkewSamples int
+	// UnmatchedResponses counts responses dropped because no pending request
+	// matched their ID. See ClientOptions.OnUnmatchedResponse.
+	UnmatchedResponses int64
+	// NotificationSubscribers and RawSubscribers count the open
+	// notification and raw line subscriptions.
+	NotificationSubscribers int
+	RawSubscribers          int
+	// AuditDropped counts notifications left out of the audit record
+	// because the audit queue was full. See AuditQueuePolicy.
+	AuditDropped int64
+}
+
+// Stats returns a snapshot of client measurements.
+func (c *Client) Stats() ClientStats {
+	c.subsMu.Lock()
+	stats := ClientStats{
+		UnmatchedResponses:      c.unmatched.Load(),
+		AuditDropped:            c.audit.droppedCount(),
+		NotificationSubscribers: len(c.subs),
+		RawSubscribers:          len(c.rawSubs),
+	}
+	c.subsMu.Unlock()
+
+	c.skewMu.Lock()
+	defer c.skewMu.Unlock()
+	stats.ClockSkew = c.skew.estimate()
+	stats.ClockSkewSamples = c.skew.total
+	return stats
+}
+
+// skewEstimator keeps the minimum of recent (arrival - server timestamp)
+// offsets. Delivery latency only ever adds to an offset, so the minimum over
+// a window is the tightest estimate of the true skew.
+type skewEstimator struct {
+	offsets []time.Duration
+	next    int
+	total   int
+}
+
+func (e *skewEstimator) add(offset time.Duration) {
+	if len(e.offsets) < clockSkewWindow {
+		e.offsets = append(e.offsets, offset)
+	} else {
+		e.offsets[e.next] = offset
+		e.next = (e.next + 1) % clockSkewWindow
+	}
+	e.total++
+}
+
+func (e *skewEstimator) estimate() time.Duration {
+	if len(e.offsets) == 0 {
+		return 0
+	}
+	best := e.offsets[0]
+	for _, offset := range e.offsets[1:] {
+		if offset < best {
+			best = offset
+		}
+	}
+	return best
+}
+
+// observeClockSkew records the server timestamp carried by a notification,
+// if any, and warns when the skew estimate first exceeds the threshold.
+func (c *Client) observeClockSkew(method string, params json.RawMessage, arrived time.Time) {
+	server, ok := notificationTimestamp(method, params)
+	if !ok {
+		return
+	}
+
+	c.skewMu.Lock()
+	c.skew.add(arrived.Sub(server))
+	skew := c.skew.estimate()
+	exceeded := c.skewThreshold > 0 && (skew > c.skewThreshold || skew < -c.skewThreshold)
+	warn := exceeded && !c.skewWarned
+	c.skewWarned = exceeded
+	c.skewMu.Unlock()
+
+	if warn {
+		c.logger.Warn("server clock skew exceeds threshold", slog.Duration("skew", skew), slog.Duration("threshold", c.skewThreshold))
+	}
+}
+
+// notificationTimestamp extracts a server timestamp in Unix milliseconds from
+// a top-level timestampMs field or from the item timestamps of item/started
+// and item/completed notifications. Params without such a field name, such
+// as the stream of deltas, are skipped without being decoded.
+func notificationTimestamp(method string, params json.RawMessage) (time.Time, bool) {
+	if !hasTimestampField(method, params) {
+		return time.Time{}, false
+	}
+	var payload struct {
+		TimestampMs *int64 `json:"timestampMs"`
+		Item        *struct {
+			StartedAtMs   *int64 `json:"startedAtMs"`
+			CompletedAtMs *int64 `json:"completedAtMs"`
+		} `json:"item"`
+	}
+	if err := json.Unmarshal(params, &payload); err != nil {
+		return time.Time{}, false
+	}
+	var ms *int64
+	switch {
+	case payload.TimestampMs != nil:
+		ms = payload.TimestampMs
+	case payload.Item != nil && method == "item/started":
+		ms = payload.Item.StartedAtMs
+	case payload.Item != nil && method == "item/completed":
+		ms = payload.Item.CompletedAtMs
+	}
+	if ms == nil || *ms <= 0 {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(*ms), true
+}
+
+// hasTimestampField reports whether params may carry a timestamp that
+// notificationTimestamp reads.
+func hasTimestampField(method string, params json.RawMessage) bool {
+	if bytes.Contains(params, []byte(`"timestampMs"`)) {
+		return true
+	}
+	switch method {
+	case "item/started":
+		return bytes.Contains(params, []byte(`"startedAtMs"`))
+	case "item/completed":
+		return bytes.Contains(params, []byte(`"completedAtMs"`))
+	}
+	return false
+}
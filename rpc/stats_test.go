@@ -0,0 +1,116 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotificationTimestamp(t *testing.T) {
+	tests := []struct {
+		method string
+		params string
+		want   int64
+	}{
+		{method: "turn/started", params: `{"timestampMs": 1000}`, want: 1000},
+		{method: "item/started", params: `{"item": {"startedAtMs": 2000, "completedAtMs": 3000}}`, want: 2000},
+		{method: "item/completed", params: `{"item": {"startedAtMs": 2000, "completedAtMs": 3000}}`, want: 3000},
+		{method: "item/updated", params: `{"item": {"startedAtMs": 2000}}`},
+		{method: "turn/started", params: `{"timestampMs": 0}`},
+		{method: "turn/started", params: `[1]`},
+		{method: "turn/started"},
+		{method: "item/agentMessage/delta", params: `{"itemId": "msg_1", "delta": "hi"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+tt.params, func(t *testing.T) {
+			got, ok := notificationTimestamp(tt.method, json.RawMessage(tt.params))
+			if ok != (tt.want != 0) {
+				t.Fatalf("expected ok=%v, got %v", tt.want != 0, ok)
+			}
+			if ok && got.UnixMilli() != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, got.UnixMilli())
+			}
+		})
+	}
+}
+
+func TestNotificationTimestampSkipsUntimedParams(t *testing.T) {
+	params := json.RawMessage(`{"threadId": "thr_1", "turnId": "turn_1", "itemId": "msg_1", "delta": "` + strings.Repeat("x", 256) + `"}`)
+	allocs := testing.AllocsPerRun(100, func() {
+		notificationTimestamp("item/agentMessage/delta", params)
+	})
+	if allocs != 0 {
+		t.Fatalf("decoded untimed params: %v allocations", allocs)
+	}
+}
+
+func TestSkewEstimatorUsesWindowMinimum(t *testing.T) {
+	var e skewEstimator
+	e.add(-time.Second)
+	for range clockSkewWindow - 1 {
+		e.add(3 * time.Second)
+	}
+	if got := e.estimate(); got != -time.Second {
+		t.Fatalf("expected -1s, got %s", got)
+	}
+	e.add(2 * time.Second)
+	if got := e.estimate(); got != 2*time.Second {
+		t.Fatalf("expected oldest sample to age out, got %s", got)
+	}
+	if e.total != clockSkewWindow+1 {
+		t.Fatalf("unexpected sample count: %d", e.total)
+	}
+}
+
+func TestClientClockSkewStatsAndWarning(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{Logger: logger, ClockSkewThreshold: time.Second})
+	defer client.Close()
+
+	server := time.Unix(1_700_000_000, 0)
+	params := json.RawMessage(`{"timestampMs": ` + strconv.FormatInt(server.UnixMilli(), 10) + `}`)
+	client.observeClockSkew("turn/started", params, server.Add(500*time.Millisecond))
+	client.observeClockSkew("turn/started", params, server.Add(3*time.Second))
+	if strings.Contains(buf.String(), "clock skew") {
+		t.Fatalf("unexpected warning while under threshold: %s", buf.String())
+	}
+
+	client.observeClockSkew("item/started", json.RawMessage(`{"item":{}}`), server)
+	stats := client.Stats()
+	if stats.ClockSkew != 500*time.Millisecond || stats.ClockSkewSamples != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	ahead := server.Add(2 * time.Second)
+	for range clockSkewWindow {
+		client.observeClockSkew("turn/started", params, ahead)
+	}
+	client.observeClockSkew("turn/started", params, ahead)
+	if got := strings.Count(buf.String(), "server clock skew exceeds threshold"); got != 1 {
+		t.Fatalf("expected a single warning, got %d: %s", got, buf.String())
+	}
+	if got := client.Stats().ClockSkew; got != 2*time.Second {
+		t.Fatalf("expected 2s skew, got %s", got)
+	}
+}
+
+func TestClientObservesSkewFromNotifications(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{ClockSkewThreshold: -1})
+	defer client.Close()
+
+	iter := client.SubscribeNotifications(0)
+	defer iter.Close()
+	past := time.Now().Add(-time.Hour).UnixMilli()
+	transport.pushReadLine(`{"method":"turn/started","params":{"timestampMs":` + strconv.FormatInt(past, 10) + `}}`)
+	waitFor(t, func() bool { return client.Stats().ClockSkewSamples == 1 })
+	if skew := client.Stats().ClockSkew; skew < time.Hour {
+		t.Fatalf("expected at least 1h skew, got %s", skew)
+	}
+}
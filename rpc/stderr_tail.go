@@ -0,0 +1,99 @@
+package rpc
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stderrSettle bounds how long a failed read waits for the process to exit,
+// so the stderr tail holds its last words.
+const stderrSettle = 100 * time.Millisecond
+
+// ProcessError wraps an error caused by a spawned process with the end of
+// its stderr, which usually holds the actual cause. StdioTransport returns
+// it when StdioOptions.StderrTail is set.
+type ProcessError struct {
+	Err error
+	// Stderr is the captured tail of the process's stderr.
+	Stderr string
+}
+
+func (e *ProcessError) Error() string {
+	return e.Err.Error() + "\nstderr:\n" + e.Stderr
+}
+
+func (e *ProcessError) Unwrap() error {
+	return e.Err
+}
+
+// tailBuffer keeps the last size bytes written to it.
+type tailBuffer struct {
+	mu   sync.Mutex
+	buf  []byte
+	size int
+}
+
+func newTailBuffer(size int) *tailBuffer {
+	return &tailBuffer{size: size}
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - b.size; over > 0 {
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
+
+// StderrTail returns the captured end of the process's stderr, or "" when
+// StdioOptions.StderrTail was not set.
+func (t *StdioTransport) StderrTail() string {
+	if t.tail == nil {
+		return ""
+	}
+	return strings.TrimRight(t.tail.String(), "\n")
+}
+
+// AttachStderr wraps err in a *ProcessError carrying the stderr tail, so
+// callers can report why the process failed, for example after the
+// initialize handshake. It returns err unchanged when err is nil, already
+// carries the tail, or nothing was captured.
+func (t *StdioTransport) AttachStderr(err error) error {
+	var processErr *ProcessError
+	if err == nil || errors.As(err, &processErr) {
+		return err
+	}
+	tail := t.StderrTail()
+	if tail == "" {
+		return err
+	}
+	return &ProcessError{Err: err, Stderr: tail}
+}
+
+// readError attaches the stderr tail to a read that failed because the
+// process closed its stdout, after giving it a moment to exit.
+func (t *StdioTransport) readError(err error) error {
+	if t.tail == nil || !(errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)) {
+		return err
+	}
+	if t.exited != nil {
+		timer := time.NewTimer(stderrSettle)
+		defer timer.Stop()
+		select {
+		case <-t.exited:
+		case <-timer.C:
+		}
+	}
+	return t.AttachStderr(err)
+}
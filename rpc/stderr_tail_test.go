@@ -0,0 +1,65 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestTailBufferKeepsLastBytes(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{name: "under size", writes: []string{"ab", "c"}, want: "abc"},
+		{name: "trims oldest", writes: []string{"abcd", "ef"}, want: "cdef"},
+		{name: "single large write", writes: []string{"abcdefgh"}, want: "efgh"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tail := newTailBuffer(4)
+			for _, w := range tt.writes {
+				if n, err := tail.Write([]byte(w)); err != nil || n != len(w) {
+					t.Fatalf("Write(%q) = %d, %v", w, n, err)
+				}
+			}
+			if got := tail.String(); got != tt.want {
+				t.Fatalf("tail = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStdioTransportAttachesStderrOnExit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell test is unix-only")
+	}
+	var stderr safeBuffer
+	transport, err := SpawnStdioWithOptions(context.Background(), "/bin/sh", []string{"-c", "echo 'error: invalid config.toml' >&2; exit 1"}, StdioOptions{
+		Stderr:     &stderr,
+		StderrTail: 1024,
+	})
+	if err != nil {
+		t.Fatalf("SpawnStdioWithOptions: %v", err)
+	}
+	defer transport.Close()
+
+	_, err = transport.ReadLine()
+	var processErr *ProcessError
+	if !errors.As(err, &processErr) || !errors.Is(err, io.EOF) {
+		t.Fatalf("ReadLine error = %v, want a *ProcessError wrapping io.EOF", err)
+	}
+	if processErr.Stderr != "error: invalid config.toml" {
+		t.Fatalf("Stderr = %q", processErr.Stderr)
+	}
+	if !strings.Contains(stderr.String(), "invalid config.toml") {
+		t.Fatalf("stderr was not also written to Stderr: %q", stderr.String())
+	}
+	if got := transport.AttachStderr(err); got != err {
+		t.Fatalf("AttachStderr wrapped an error that already carries the tail")
+	}
+}
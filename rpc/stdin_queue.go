@@ -0,0 +1,109 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+)
+
+// stdinQueue feeds a StdioTransport's stdin from a single writer goroutine.
+// flush closes closing to refuse new lines and release blocked senders, then
+// takes sendMu to wait out sends already in progress before it closes
+// sealed, which tells run to drain what is left and stop.
+type stdinQueue struct {
+	lines   chan string
+	closing chan struct{}
+	sealed  chan struct{}
+	done    chan struct{}
+	once    sync.Once
+
+	sendMu sync.RWMutex
+	closed bool
+
+	mu  sync.Mutex
+	err error
+}
+
+func newStdinQueue(size int) *stdinQueue {
+	return &stdinQueue{
+		lines:   make(chan string, size),
+		closing: make(chan struct{}),
+		sealed:  make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// enqueue queues line, waiting while the queue is full. It returns the
+// error of an earlier failed write, since the caller that queued that line
+// has already returned.
+func (q *stdinQueue) enqueue(line string) error {
+	if err := q.failure(); err != nil {
+		return err
+	}
+	q.sendMu.RLock()
+	defer q.sendMu.RUnlock()
+	if q.closed {
+		return ErrTransportClosed
+	}
+	select {
+	case q.lines <- line:
+		return nil
+	case <-q.closing:
+		return ErrTransportClosed
+	}
+}
+
+// run writes queued lines until flush is called and the queue is empty.
+// After a failed write, later lines are dropped.
+func (q *stdinQueue) run(write func(string) error) {
+	defer close(q.done)
+	for {
+		select {
+		case line := <-q.lines:
+			q.write(write, line)
+		case <-q.sealed:
+			for {
+				select {
+				case line := <-q.lines:
+					q.write(write, line)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (q *stdinQueue) write(write func(string) error, line string) {
+	if q.failure() != nil {
+		return
+	}
+	if err := write(line); err != nil {
+		q.mu.Lock()
+		q.err = err
+		q.mu.Unlock()
+	}
+}
+
+// flush stops accepting lines and waits until the queued ones are written
+// or ctx ends. It returns the first write error.
+func (q *stdinQueue) flush(ctx context.Context) error {
+	q.once.Do(func() {
+		close(q.closing)
+		q.sendMu.Lock()
+		q.closed = true
+		q.sendMu.Unlock()
+		close(q.sealed)
+	})
+	select {
+	case <-q.done:
+		return q.failure()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *stdinQueue) failure() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.err
+}
@@ -0,0 +1,126 @@
+package rpc
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// BidiStream is the client side of a bidirectional message stream, such as
+// the stream client protoc-gen-go-grpc generates for the Connect method in
+// tunnel.proto. M is the generated message type.
+type BidiStream[M any] interface {
+	Send(M) error
+	Recv() (M, error)
+	CloseSend() error
+}
+
+// StreamTransport tunnels JSON-RPC lines through a bidirectional message
+// stream, one line per message. It lets the app-server protocol run over
+// gRPC, reusing the authentication, load balancing and mTLS already set up
+// for service-to-service traffic; the package itself does not depend on
+// gRPC.
+type StreamTransport[M any] struct {
+	stream BidiStream[M]
+	encode func(line string) M
+	decode func(M) string
+
+	sendMu    sync.Mutex
+	recvMu    sync.Mutex
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewStreamTransport wraps stream. encode builds a message from a line and
+// decode extracts it. Callers generate their own stubs for tunnel.proto; with
+// them in a package yourpb:
+//
+//	rpc.NewStreamTransport(stream,
+//		func(line string) *yourpb.Line { return &yourpb.Line{Line: line} },
+//		(*yourpb.Line).GetLine)
+func NewStreamTransport[M any](stream BidiStream[M], encode func(line string) M, decode func(M) string) *StreamTransport[M] {
+	return &StreamTransport[M]{stream: stream, encode: encode, decode: decode}
+}
+
+// ReadLine receives the next message. The end of the stream is io.EOF.
+func (t *StreamTransport[M]) ReadLine() (string, error) {
+	t.recvMu.Lock()
+	defer t.recvMu.Unlock()
+	msg, err := t.stream.Recv()
+	if err != nil {
+		return "", err
+	}
+	return t.decode(msg), nil
+}
+
+// WriteLine sends line as one message. gRPC streams do not allow
+// concurrent sends, so writes are serialized.
+func (t *StreamTransport[M]) WriteLine(line string) error {
+	t.sendMu.Lock()
+	defer t.sendMu.Unlock()
+	return t.stream.Send(t.encode(line))
+}
+
+// Close half-closes the stream with CloseSend. The tunnel server is
+// expected to end the stream in response, which ends pending reads; cancel
+// the stream's context to abort it outright.
+func (t *StreamTransport[M]) Close() error {
+	t.closeOnce.Do(func() {
+		t.sendMu.Lock()
+		defer t.sendMu.Unlock()
+		t.closeErr = t.stream.CloseSend()
+	})
+	return t.closeErr
+}
+
+// ServerStream is the server side of a bidirectional message stream.
+type ServerStream[M any] interface {
+	Send(M) error
+	Recv() (M, error)
+}
+
+// ServeStream relays lines between stream and transport until either side
+// ends, for implementing the tunnel server in front of an app-server. It
+// closes transport before returning. A stream that ends cleanly returns
+// nil.
+func ServeStream[M any](stream ServerStream[M], transport Transport, encode func(line string) M, decode func(M) string) error {
+	inbound := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				inbound <- err
+				return
+			}
+			if err := transport.WriteLine(decode(msg)); err != nil {
+				inbound <- err
+				return
+			}
+		}
+	}()
+	outbound := make(chan error, 1)
+	go func() {
+		for {
+			line, err := transport.ReadLine()
+			if err != nil {
+				outbound <- err
+				return
+			}
+			if err := stream.Send(encode(line)); err != nil {
+				outbound <- err
+				return
+			}
+		}
+	}()
+
+	var err error
+	select {
+	case err = <-inbound:
+	case err = <-outbound:
+	}
+	_ = transport.Close()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
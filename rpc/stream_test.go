@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+type tunnelLine struct {
+	Line string
+}
+
+// memoryStream connects a client and a server stream through channels, the
+// way a gRPC bidi stream delivers messages in order in each direction.
+type memoryStream struct {
+	up   chan *tunnelLine
+	down chan *tunnelLine
+}
+
+type memoryClientStream struct{ *memoryStream }
+
+func (s memoryClientStream) Send(msg *tunnelLine) error { s.up <- msg; return nil }
+
+func (s memoryClientStream) Recv() (*tunnelLine, error) {
+	msg, ok := <-s.down
+	if !ok {
+		return nil, io.EOF
+	}
+	return msg, nil
+}
+
+func (s memoryClientStream) CloseSend() error { close(s.up); return nil }
+
+type memoryServerStream struct{ *memoryStream }
+
+func (s memoryServerStream) Send(msg *tunnelLine) error { s.down <- msg; return nil }
+
+func (s memoryServerStream) Recv() (*tunnelLine, error) {
+	msg, ok := <-s.up
+	if !ok {
+		return nil, io.EOF
+	}
+	return msg, nil
+}
+
+func encodeTunnelLine(line string) *tunnelLine { return &tunnelLine{Line: line} }
+
+func decodeTunnelLine(msg *tunnelLine) string { return msg.Line }
+
+func TestStreamTransportTunnelsLines(t *testing.T) {
+	stream := &memoryStream{up: make(chan *tunnelLine, 4), down: make(chan *tunnelLine, 4)}
+	appServer, gatewayEnd := NewPipeTransports()
+	go func() {
+		defer appServer.Close()
+		for {
+			line, err := appServer.ReadLine()
+			if err != nil {
+				return
+			}
+			_ = appServer.WriteLine("echo " + line)
+		}
+	}()
+	served := make(chan error, 1)
+	go func() {
+		served <- ServeStream(memoryServerStream{stream}, gatewayEnd, encodeTunnelLine, decodeTunnelLine)
+	}()
+
+	transport := NewStreamTransport(memoryClientStream{stream}, encodeTunnelLine, decodeTunnelLine)
+	expectEcho(t, transport)
+
+	if err := transport.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := transport.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Fatalf("ServeStream: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("ServeStream did not return after CloseSend")
+	}
+}
@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTransportSwapped is returned by calls that were waiting for a response
+// when SwapTransport replaced the transport they were sent on.
+var ErrTransportSwapped = errors.New("transport was swapped")
+
+// SwapTransport atomically replaces the client's transport, for example
+// after respawning the app-server process. Subscriptions, the server request
+// handler, the write queue and other client state carry over, so higher
+// layers keep their registrations. Calls still waiting for a response fail
+// with ErrTransportSwapped because their replies would arrive on the old
+// transport, which is closed.
+//
+// The client does not repeat the initialize handshake; callers that connect
+// to a new server must send it themselves. SwapTransport must be called
+// while the client is running: once the old transport fails on its own the
+// client stops, and SwapTransport returns its error. Wrap the transport in a
+// ReconnectingTransport to survive unplanned disconnects instead.
+func (c *Client) SwapTransport(next Transport) error {
+	if next == nil {
+		return errors.New("swap transport: transport is nil")
+	}
+	next = c.prepareTransport(next)
+
+	c.transportMu.Lock()
+	select {
+	case <-c.done:
+		c.transportMu.Unlock()
+		return fmt.Errorf("swap transport: %w", c.Err())
+	default:
+	}
+	previous := c.transport
+	c.transport = next
+	c.transportGen++
+	c.transportMu.Unlock()
+
+	c.failPending(ErrTransportSwapped)
+	c.logger.Info("json-rpc transport swapped")
+	_ = previous.Close()
+	return nil
+}
+
+// prepareTransport applies the client's transport decorations and limits to
+// transport.
+func (c *Client) prepareTransport(transport Transport) Transport {
+	if c.transportMetrics != nil {
+		transport = NewMeteredTransport(transport, c.transportMetrics)
+	}
+	if limiter, ok := transport.(lineLimiter); ok && c.limits != nil && c.limits.MaxLine > 0 {
+		limiter.setMaxLine(c.limits.MaxLine)
+	}
+	return transport
+}
+
+// currentTransport returns the transport in use and its generation, which
+// SwapTransport increments.
+func (c *Client) currentTransport() (Transport, uint64) {
+	c.transportMu.Lock()
+	defer c.transportMu.Unlock()
+	return c.transport, c.transportGen
+}
+
+// failPending fails every call waiting for a response with err.
+func (c *Client) failPending(err error) {
+	c.pendingMu.Lock()
+	calls := c.pending
+	c.pending = map[string]pendingCall{}
+	c.pendingMu.Unlock()
+	for _, call := range calls {
+		call.ch <- response{err: err}
+	}
+	if len(calls) > 0 {
+		c.reportPending(0)
+	}
+}
@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSwapTransportKeepsSubscriptions(t *testing.T) {
+	first := newChannelTransport()
+	client := NewClient(first, ClientOptions{})
+	defer client.Close()
+	iter := client.SubscribeNotifications(4)
+	defer iter.Close()
+
+	callErr := make(chan error, 1)
+	go func() {
+		callErr <- client.Call(context.Background(), "thread/list", nil, nil)
+	}()
+	first.waitForWrites(t, 1)
+
+	second := newChannelTransport()
+	if err := client.SwapTransport(second); err != nil {
+		t.Fatalf("SwapTransport: %v", err)
+	}
+	select {
+	case err := <-callErr:
+		if !errors.Is(err, ErrTransportSwapped) {
+			t.Fatalf("in-flight call error = %v, want ErrTransportSwapped", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("in-flight call did not fail")
+	}
+
+	second.pushReadLine(mustJSON(JSONRPCNotification{Method: "turn/started"}))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	note, err := iter.Next(ctx)
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if note.Method != "turn/started" {
+		t.Fatalf("method = %q, want turn/started", note.Method)
+	}
+
+	if err := client.Notify(context.Background(), "initialized", nil); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	writes := second.waitForWrites(t, 1)
+	if !strings.Contains(writes[0], `"initialized"`) {
+		t.Fatalf("write on new transport = %s", writes[0])
+	}
+	select {
+	case <-client.Done():
+		t.Fatalf("client stopped after swap: %v", client.Err())
+	default:
+	}
+}
+
+func TestSwapTransportAfterClose(t *testing.T) {
+	client := NewClient(newChannelTransport(), ClientOptions{})
+	_ = client.Close()
+	if err := client.SwapTransport(newChannelTransport()); err == nil {
+		t.Fatalf("expected SwapTransport to fail on a closed client")
+	}
+}
@@ -0,0 +1,163 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// DialOptions configures DialTCP.
+type DialOptions struct {
+	// Timeout bounds connection setup, including the TLS handshake; zero
+	// means no limit beyond ctx.
+	Timeout time.Duration
+	// KeepAlive is the TCP keep-alive period. Zero uses the net package
+	// default; a negative value disables keep-alives.
+	KeepAlive time.Duration
+	// TLS, when set, wraps the connection in TLS. An empty ServerName is
+	// taken from addr, and the server certificate is verified against it.
+	// Set Certificates (see TLSFiles) for mutual TLS.
+	TLS *tls.Config
+	// Framing selects the wire framing (FramingLines by default).
+	Framing Framing
+	// Compression, when set, negotiates stream compression with the peer
+	// using NegotiateCompression, offering these algorithms in order of
+	// preference. The peer must run AcceptCompression.
+	Compression []Compression
+	// Handshake, when set, runs after TLS and compression are established
+	// and before the transport is returned, for example TokenHandshake to
+	// authenticate with a gateway.
+	Handshake Handshake
+}
+
+// TLSFiles names PEM files for building a client tls.Config.
+type TLSFiles struct {
+	// CAFile holds the certificates trusted to sign the server's
+	// certificate. Empty uses the system roots.
+	CAFile string
+	// CertFile and KeyFile hold the client certificate presented for mutual
+	// TLS. Both or neither must be set.
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the name the server certificate is verified
+	// against.
+	ServerName string
+}
+
+// Config loads the files into a tls.Config for DialOptions.TLS.
+func (f TLSFiles) Config() (*tls.Config, error) {
+	config := &tls.Config{ServerName: f.ServerName, MinVersion: tls.VersionTLS12}
+	if f.CAFile != "" {
+		data, err := os.ReadFile(f.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates in CA file %s", f.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if (f.CertFile == "") != (f.KeyFile == "") {
+		return nil, errors.New("client certificate needs both CertFile and KeyFile")
+	}
+	if f.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(f.CertFile, f.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// DialTCP connects to an app-server listening on addr ("host:port") and
+// returns a JSONL transport over the connection, for example to reach an
+// app-server running in a container. ctx bounds only the dial and the
+// compression and authentication handshakes; closing the transport closes the connection.
+func DialTCP(ctx context.Context, addr string, opts DialOptions) (*ConnTransport, error) {
+	if addr == "" {
+		return nil, errors.New("app-server address is empty")
+	}
+	dialer := &net.Dialer{Timeout: opts.Timeout, KeepAlive: opts.KeepAlive}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if opts.TLS != nil {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: opts.TLS}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dial app-server: %w", err)
+	}
+	transport, err := setupConn(ctx, conn, opts)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return transport, nil
+}
+
+// DialUnix connects to an app-server listening on the Unix domain socket
+// at path, such as one an IDE started. TLS in opts is ignored; the other
+// options apply as for DialTCP.
+func DialUnix(ctx context.Context, path string, opts DialOptions) (*ConnTransport, error) {
+	if path == "" {
+		return nil, errors.New("app-server socket path is empty")
+	}
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	conn, err := dialer.DialContext(ctx, "unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("dial app-server: %w", err)
+	}
+	transport, err := setupConn(ctx, conn, opts)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return transport, nil
+}
+
+// setupConn runs the compression and authentication handshakes on conn.
+// Cancelling ctx interrupts them by expiring the connection deadline.
+func setupConn(ctx context.Context, conn net.Conn, opts DialOptions) (*ConnTransport, error) {
+	if len(opts.Compression) == 0 && opts.Handshake == nil {
+		transport := NewConnTransport(conn)
+		transport.SetFraming(opts.Framing)
+		return transport, nil
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Unix(1, 0)) })
+	transport, err := handshakeConn(ctx, conn, opts)
+	if !stop() || (err != nil && ctx.Err() != nil) {
+		return nil, errors.Join(err, ctx.Err())
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return transport, err
+}
+
+func handshakeConn(ctx context.Context, conn net.Conn, opts DialOptions) (*ConnTransport, error) {
+	var stream io.ReadWriteCloser = conn
+	if len(opts.Compression) > 0 {
+		var err error
+		stream, _, err = NegotiateCompression(conn, opts.Compression...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	transport := NewConnTransport(stream)
+	transport.SetFraming(opts.Framing)
+	if opts.Handshake != nil {
+		if err := opts.Handshake(ctx, transport); err != nil {
+			return nil, fmt.Errorf("handshake: %w", err)
+		}
+	}
+	return transport, nil
+}
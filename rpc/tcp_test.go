@@ -0,0 +1,221 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDialTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	go serveEcho(listener)
+
+	transport, err := DialTCP(context.Background(), listener.Addr().String(), DialOptions{})
+	if err != nil {
+		t.Fatalf("DialTCP: %v", err)
+	}
+	defer transport.Close()
+	expectEcho(t, transport)
+}
+
+func TestDialTCPErrors(t *testing.T) {
+	if _, err := DialTCP(context.Background(), "", DialOptions{}); err == nil {
+		t.Fatalf("expected error for empty address")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+	if _, err := DialTCP(context.Background(), addr, DialOptions{}); err == nil {
+		t.Fatalf("expected error dialing closed port")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := DialTCP(ctx, addr, DialOptions{}); err == nil {
+		t.Fatalf("expected error for canceled context")
+	}
+}
+
+func TestDialUnix(t *testing.T) {
+	// Socket paths are limited to about 100 bytes, which t.TempDir can
+	// exceed.
+	dir, err := os.MkdirTemp("", "codex")
+	if err != nil {
+		t.Fatalf("temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app-server.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets are unavailable: %v", err)
+	}
+	defer listener.Close()
+	go serveEcho(listener)
+
+	transport, err := DialUnix(context.Background(), path, DialOptions{})
+	if err != nil {
+		t.Fatalf("DialUnix: %v", err)
+	}
+	defer transport.Close()
+	expectEcho(t, transport)
+
+	if _, err := DialUnix(context.Background(), "", DialOptions{}); err == nil {
+		t.Fatalf("expected error for empty path")
+	}
+	if _, err := DialUnix(context.Background(), filepath.Join(dir, "missing.sock"), DialOptions{}); err == nil {
+		t.Fatalf("expected error dialing a missing socket")
+	}
+}
+
+func TestDialTCPWithTLS(t *testing.T) {
+	// httptest's certificate is valid for 127.0.0.1 and example.com.
+	server := httptest.NewUnstartedServer(nil)
+	server.StartTLS()
+	defer server.Close()
+	serverConfig := server.TLS.Clone()
+	// The test certificate is only valid for server auth, so the server
+	// checks that a client certificate is presented without verifying it.
+	serverConfig.ClientAuth = tls.RequireAnyClientCert
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			if err := serveEcho(listener); err != nil {
+				return
+			}
+		}
+	}()
+	addr := listener.Addr().String()
+
+	files := writeTLSFiles(t, server)
+	config, err := files.Config()
+	if err != nil {
+		t.Fatalf("Config: %v", err)
+	}
+	transport, err := DialTCP(context.Background(), addr, DialOptions{TLS: config})
+	if err != nil {
+		t.Fatalf("DialTCP: %v", err)
+	}
+	defer transport.Close()
+	expectEcho(t, transport)
+
+	tests := []struct {
+		name   string
+		config *tls.Config
+	}{
+		{name: "untrusted server", config: &tls.Config{Certificates: config.Certificates}},
+		{name: "wrong server name", config: &tls.Config{RootCAs: config.RootCAs, Certificates: config.Certificates, ServerName: "codex.invalid"}},
+		{name: "no client certificate", config: &tls.Config{RootCAs: config.RootCAs}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := DialTCP(context.Background(), addr, DialOptions{TLS: tt.config})
+			if err != nil {
+				return
+			}
+			defer transport.Close()
+			// With TLS 1.3 a rejected client certificate surfaces on first use.
+			if err := transport.WriteLine("hello"); err != nil {
+				return
+			}
+			if _, err := transport.ReadLine(); err == nil {
+				t.Fatalf("expected TLS failure")
+			}
+		})
+	}
+}
+
+func TestTLSFilesConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.pem")
+	if err := os.WriteFile(empty, nil, 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	tests := []struct {
+		name  string
+		files TLSFiles
+	}{
+		{name: "missing CA file", files: TLSFiles{CAFile: filepath.Join(dir, "missing.pem")}},
+		{name: "empty CA file", files: TLSFiles{CAFile: empty}},
+		{name: "cert without key", files: TLSFiles{CertFile: empty}},
+		{name: "bad key pair", files: TLSFiles{CertFile: empty, KeyFile: empty}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.files.Config(); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
+
+// serveEcho accepts one connection and echoes its first line back.
+func serveEcho(listener net.Listener) error {
+	conn, err := listener.Accept()
+	if err != nil {
+		return err
+	}
+	go func() {
+		defer conn.Close()
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte("echo " + line))
+	}()
+	return nil
+}
+
+func expectEcho(t *testing.T, transport Transport) {
+	t.Helper()
+	if err := transport.WriteLine("hello"); err != nil {
+		t.Fatalf("WriteLine: %v", err)
+	}
+	if line, err := transport.ReadLine(); err != nil || line != "echo hello" {
+		t.Fatalf("ReadLine = %q, %v", line, err)
+	}
+}
+
+// writeTLSFiles writes the httptest server's certificate as both the CA and
+// the client certificate.
+func writeTLSFiles(t *testing.T, server *httptest.Server) TLSFiles {
+	t.Helper()
+	dir := t.TempDir()
+	cert := server.TLS.Certificates[0]
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	files := TLSFiles{
+		CAFile:   filepath.Join(dir, "ca.pem"),
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key})
+	for path, data := range map[string][]byte{files.CAFile: certPEM, files.CertFile: certPEM, files.KeyFile: keyPEM} {
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	return files
+}
@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// teeTimeFormat is the timestamp format of TeeTransport output.
+const teeTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// TeeTransport mirrors every line read and written to a writer, for
+// watching an interactive session live (for example with tail -f). Each
+// line is prefixed with a timestamp and a direction marker: "<-" for lines
+// read from the server, "->" for lines written to it and "!!" for read and
+// write errors. Unlike RecordTransport it keeps nothing in memory, and
+// unlike ClientOptions.WireLog it does not redact. Errors writing to the
+// mirror are ignored so they cannot break the session.
+type TeeTransport struct {
+	transport Transport
+	now       func() time.Time
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewTeeTransport wraps transport and mirrors its traffic to w.
+func NewTeeTransport(transport Transport, w io.Writer) *TeeTransport {
+	return &TeeTransport{transport: transport, w: w, now: time.Now}
+}
+
+// ReadLine reads from the underlying transport and mirrors the line.
+func (t *TeeTransport) ReadLine() (string, error) {
+	line, err := t.transport.ReadLine()
+	if err != nil {
+		t.mirror("!!", "read error: "+err.Error())
+		return line, err
+	}
+	t.mirror("<-", line)
+	return line, nil
+}
+
+// WriteLine writes to the underlying transport and mirrors the line.
+func (t *TeeTransport) WriteLine(line string) error {
+	if err := t.transport.WriteLine(line); err != nil {
+		t.mirror("!!", "write error: "+err.Error())
+		return err
+	}
+	t.mirror("->", line)
+	return nil
+}
+
+// Close closes the underlying transport.
+func (t *TeeTransport) Close() error {
+	return t.transport.Close()
+}
+
+func (t *TeeTransport) mirror(marker, line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = io.WriteString(t.w, t.now().Format(teeTimeFormat)+" "+marker+" "+line+"\n")
+}
@@ -0,0 +1,39 @@
+package rpc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestTeeTransport(t *testing.T) {
+	base := &lineListTransport{lines: []string{`{"method":"turn/started"}`}, readErr: io.EOF}
+	var out bytes.Buffer
+	transport := NewTeeTransport(base, &out)
+	transport.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 6e6, time.UTC) }
+
+	if err := transport.WriteLine(`{"id":1,"method":"initialize"}`); err != nil {
+		t.Fatalf("WriteLine: %v", err)
+	}
+	if _, err := transport.ReadLine(); err != nil {
+		t.Fatalf("ReadLine: %v", err)
+	}
+	if _, err := transport.ReadLine(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+	base.writeErr = errors.New("broken pipe")
+	if err := transport.WriteLine(`{"method":"initialized"}`); err == nil {
+		t.Fatalf("expected write error")
+	}
+
+	want := `2026-01-02T03:04:05.006Z -> {"id":1,"method":"initialize"}
+2026-01-02T03:04:05.006Z <- {"method":"turn/started"}
+2026-01-02T03:04:05.006Z !! read error: EOF
+2026-01-02T03:04:05.006Z !! write error: broken pipe
+`
+	if out.String() != want {
+		t.Fatalf("mirror output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
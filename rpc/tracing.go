@@ -0,0 +1,83 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// TraceMetaKey is the reserved params key that carries injected trace context,
+// for example {"_meta": {"traceparent": "00-..."}}.
+const TraceMetaKey = "_meta"
+
+// Tracer starts spans and injects trace context. It mirrors the small subset
+// of OpenTelemetry the SDK needs, so an OTel tracer and propagator can be
+// adapted without the SDK depending on OTel directly.
+type Tracer interface {
+	// Start begins a span as a child of any span in ctx.
+	Start(ctx context.Context, name string) (context.Context, Span)
+	// Inject writes the trace context of ctx into carrier (for example
+	// traceparent and tracestate).
+	Inject(ctx context.Context, carrier map[string]string)
+}
+
+// Span is an in-progress trace span.
+type Span interface {
+	SetAttribute(key, value string)
+	// End finishes the span, recording err when it is non-nil.
+	End(err error)
+}
+
+// TracingInterceptor returns an Interceptor that creates a span per Call,
+// annotates it with the method and any threadId/turnId params, and injects
+// trace context into the request params under TraceMetaKey.
+func TracingInterceptor(tracer Tracer) Interceptor {
+	return Interceptor{Call: func(ctx context.Context, method string, params any, result any, next CallInvoker) error {
+		ctx, span := tracer.Start(ctx, method)
+		span.SetAttribute("rpc.system", "jsonrpc")
+		span.SetAttribute("rpc.method", method)
+		err := next(ctx, method, traceParams(ctx, tracer, span, params), result)
+		span.End(err)
+		return err
+	}}
+}
+
+// traceParams annotates span from object params and returns params with the
+// trace context injected. Non-object params are returned unchanged.
+func traceParams(ctx context.Context, tracer Tracer, span Span, params any) any {
+	fields := map[string]json.RawMessage{}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil || json.Unmarshal(data, &fields) != nil || fields == nil {
+			return params
+		}
+	}
+	for key, attr := range map[string]string{"threadId": "codex.thread_id", "turnId": "codex.turn_id"} {
+		var value string
+		if raw, ok := fields[key]; ok && json.Unmarshal(raw, &value) == nil && value != "" {
+			span.SetAttribute(attr, value)
+		}
+	}
+
+	carrier := map[string]string{}
+	tracer.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return params
+	}
+	meta := map[string]any{}
+	if raw, ok := fields[TraceMetaKey]; ok {
+		_ = json.Unmarshal(raw, &meta)
+	}
+	for key, value := range carrier {
+		meta[key] = value
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return params
+	}
+	fields[TraceMetaKey] = data
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return params
+	}
+	return json.RawMessage(encoded)
+}
@@ -0,0 +1,113 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestTracingInterceptorInjectsTraceContext(t *testing.T) {
+	transcript := []TranscriptEntry{
+		writeLine(JSONRPCRequest{
+			ID:     NewIntRequestID(1),
+			Method: "turn/start",
+			Params: mustRaw(map[string]any{
+				"threadId": "thr_1",
+				"_meta":    map[string]any{"progressToken": "p", "traceparent": "00-trace"},
+			}),
+		}),
+		readLine(JSONRPCError{ID: NewIntRequestID(1), Error: JSONRPCErrorError{Code: -32000, Message: "busy"}}),
+		writeLine(JSONRPCRequest{ID: NewIntRequestID(2), Method: "model/list", Params: mustRaw(map[string]any{"_meta": map[string]any{"traceparent": "00-trace"}})}),
+		readLine(JSONRPCResponse{ID: NewIntRequestID(2), Result: mustRaw(map[string]any{})}),
+	}
+	tracer := &fakeTracer{carrier: map[string]string{"traceparent": "00-trace"}}
+	client := NewClient(NewReplayTransport(transcript), ClientOptions{Interceptors: []Interceptor{TracingInterceptor(tracer)}})
+	defer client.Close()
+
+	err := client.Call(context.Background(), "turn/start", map[string]any{
+		"threadId": "thr_1",
+		"_meta":    map[string]any{"progressToken": "p"},
+	}, nil)
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) {
+		t.Fatalf("expected response error, got %v", err)
+	}
+	if err := client.Call(context.Background(), "model/list", nil, nil); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	if len(tracer.spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if span.name != "turn/start" || span.attrs["rpc.method"] != "turn/start" || span.attrs["codex.thread_id"] != "thr_1" {
+		t.Fatalf("unexpected span: %#v", span)
+	}
+	if !span.ended || !errors.As(span.err, &respErr) {
+		t.Fatalf("expected span to end with response error, got %#v", span)
+	}
+	if _, ok := tracer.spans[1].attrs["codex.thread_id"]; ok {
+		t.Fatalf("unexpected thread attribute on model/list span")
+	}
+}
+
+func TestTracingInterceptorSkipsEmptyCarrier(t *testing.T) {
+	tracer := &fakeTracer{}
+	params := []string{"positional"}
+	var got any
+	interceptor := TracingInterceptor(tracer)
+	err := interceptor.Call(context.Background(), "custom", params, nil, func(ctx context.Context, method string, p any, result any) error {
+		got = p
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := got.([]string); !ok {
+		t.Fatalf("expected params to pass through unchanged, got %T", got)
+	}
+}
+
+type fakeTracer struct {
+	mu      sync.Mutex
+	carrier map[string]string
+	spans   []*fakeSpan
+}
+
+type fakeSpan struct {
+	tracer *fakeTracer
+	name   string
+	attrs  map[string]string
+	ended  bool
+	err    error
+}
+
+func (t *fakeTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span := &fakeSpan{tracer: t, name: name, attrs: map[string]string{}}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func (t *fakeTracer) Inject(ctx context.Context, carrier map[string]string) {
+	for key, value := range t.carrier {
+		carrier[key] = value
+	}
+}
+
+func (s *fakeSpan) SetAttribute(key, value string) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.attrs[key] = value
+}
+
+func (s *fakeSpan) End(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.ended = true
+	s.err = err
+}
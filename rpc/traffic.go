@@ -0,0 +1,184 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TransportMetrics receives traffic callbacks from a MeteredTransport.
+// Implementations must be safe for concurrent use and should return
+// quickly. A Metrics implementation in ClientOptions.Metrics that also
+// implements TransportMetrics receives them for the client's transport
+// without further setup.
+type TransportMetrics interface {
+	// LineRead is called for every message read, with its size in bytes
+	// excluding framing.
+	LineRead(bytes int)
+	// LineWritten is called for every message written, with its size in
+	// bytes excluding framing.
+	LineWritten(bytes int)
+	// TransportError is called when a read or write fails. op is "read" or
+	// "write". The clean end of stream (io.EOF) is not reported.
+	TransportError(op string, err error)
+}
+
+// TrafficStats is a snapshot of a MeteredTransport's counters.
+type TrafficStats struct {
+	BytesRead    int64
+	BytesWritten int64
+	LinesRead    int64
+	LinesWritten int64
+	ReadErrors   int64
+	WriteErrors  int64
+	// ReadLinesPerSecond and WriteLinesPerSecond count the lines in the
+	// last complete one-second window.
+	ReadLinesPerSecond  float64
+	WriteLinesPerSecond float64
+}
+
+// MeteredTransport decorates a Transport with traffic counters, so capacity
+// problems on the stdio pipe or socket can be observed.
+type MeteredTransport struct {
+	base    Transport
+	metrics TransportMetrics
+	now     func() time.Time
+
+	bytesRead, bytesWritten atomic.Int64
+	linesRead, linesWritten atomic.Int64
+	readErrors, writeErrors atomic.Int64
+	readRate, writeRate     rateWindow
+}
+
+// NewMeteredTransport wraps base. metrics may be nil when only Stats is
+// needed.
+func NewMeteredTransport(base Transport, metrics TransportMetrics) *MeteredTransport {
+	return &MeteredTransport{base: base, metrics: metrics, now: time.Now}
+}
+
+// ReadLine reads from the wrapped transport and counts the result.
+func (t *MeteredTransport) ReadLine() (string, error) {
+	return t.readLineContext(context.Background())
+}
+
+func (t *MeteredTransport) readLineContext(ctx context.Context) (string, error) {
+	line, err := transportRead(ctx, t.base)
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			t.readErrors.Add(1)
+			if t.metrics != nil {
+				t.metrics.TransportError("read", err)
+			}
+		}
+		return line, err
+	}
+	t.bytesRead.Add(int64(len(line)))
+	t.linesRead.Add(1)
+	t.readRate.add(t.now())
+	if t.metrics != nil {
+		t.metrics.LineRead(len(line))
+	}
+	return line, nil
+}
+
+// WriteLine writes to the wrapped transport and counts the result.
+func (t *MeteredTransport) WriteLine(line string) error {
+	return t.writeLineContext(context.Background(), line)
+}
+
+func (t *MeteredTransport) writeLineContext(ctx context.Context, line string) error {
+	if err := transportWrite(ctx, t.base, line); err != nil {
+		t.writeErrors.Add(1)
+		if t.metrics != nil {
+			t.metrics.TransportError("write", err)
+		}
+		return err
+	}
+	t.bytesWritten.Add(int64(len(line)))
+	t.linesWritten.Add(1)
+	t.writeRate.add(t.now())
+	if t.metrics != nil {
+		t.metrics.LineWritten(len(line))
+	}
+	return nil
+}
+
+// Close closes the wrapped transport.
+func (t *MeteredTransport) Close() error {
+	return t.base.Close()
+}
+
+// CloseContext closes the wrapped transport, passing ctx on when it
+// supports a graceful close.
+func (t *MeteredTransport) CloseContext(ctx context.Context) error {
+	if closer, ok := t.base.(interface{ CloseContext(context.Context) error }); ok {
+		return closer.CloseContext(ctx)
+	}
+	return t.base.Close()
+}
+
+func (t *MeteredTransport) setMaxLine(limit int) {
+	if limiter, ok := t.base.(lineLimiter); ok {
+		limiter.setMaxLine(limit)
+	}
+}
+
+// Stats returns the current counters.
+func (t *MeteredTransport) Stats() TrafficStats {
+	now := t.now()
+	return TrafficStats{
+		BytesRead:           t.bytesRead.Load(),
+		BytesWritten:        t.bytesWritten.Load(),
+		LinesRead:           t.linesRead.Load(),
+		LinesWritten:        t.linesWritten.Load(),
+		ReadErrors:          t.readErrors.Load(),
+		WriteErrors:         t.writeErrors.Load(),
+		ReadLinesPerSecond:  t.readRate.rate(now),
+		WriteLinesPerSecond: t.writeRate.rate(now),
+	}
+}
+
+// rateWindow counts events in one-second windows.
+type rateWindow struct {
+	mu      sync.Mutex
+	start   time.Time
+	current int64
+	last    int64
+}
+
+func (w *rateWindow) add(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.roll(now)
+	w.current++
+}
+
+func (w *rateWindow) rate(now time.Time) float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.roll(now)
+	return float64(w.last)
+}
+
+// roll starts a new window once the current one is over. A window with no
+// events in between leaves a rate of zero.
+func (w *rateWindow) roll(now time.Time) {
+	if w.start.IsZero() {
+		w.start = now
+		return
+	}
+	elapsed := now.Sub(w.start)
+	if elapsed < time.Second {
+		return
+	}
+	if elapsed < 2*time.Second {
+		w.last = w.current
+	} else {
+		w.last = 0
+	}
+	w.current = 0
+	w.start = w.start.Add(elapsed.Truncate(time.Second))
+}
@@ -0,0 +1,134 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type trafficMetrics struct {
+	recordingMetrics
+	mu      sync.Mutex
+	read    []int
+	written []int
+	errs    []string
+}
+
+func (m *trafficMetrics) LineRead(bytes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.read = append(m.read, bytes)
+}
+
+func (m *trafficMetrics) LineWritten(bytes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.written = append(m.written, bytes)
+}
+
+func (m *trafficMetrics) TransportError(op string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errs = append(m.errs, op)
+}
+
+func TestClientReportsTransportTraffic(t *testing.T) {
+	transport := newChannelTransport()
+	metrics := &trafficMetrics{}
+	client := NewClient(transport, ClientOptions{Metrics: metrics})
+	defer client.Close()
+
+	if err := client.Notify(context.Background(), "initialized", nil); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	line := mustJSON(JSONRPCNotification{Method: "turn/started"})
+	transport.pushReadLine(line)
+	waitFor(t, func() bool {
+		metrics.mu.Lock()
+		defer metrics.mu.Unlock()
+		return len(metrics.read) == 1
+	})
+
+	transport.waitForWrites(t, 1)
+	transport.mu.Lock()
+	written := len(transport.writes[0])
+	transport.mu.Unlock()
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if len(metrics.written) != 1 || metrics.written[0] != written {
+		t.Fatalf("written = %v, want [%d]", metrics.written, written)
+	}
+	if metrics.read[0] != len(line) {
+		t.Fatalf("read = %v, want [%d]", metrics.read, len(line))
+	}
+}
+
+func TestMeteredTransportStats(t *testing.T) {
+	base := &lineListTransport{lines: []string{"abc", "de"}, readErr: errors.New("broken pipe")}
+	metrics := &trafficMetrics{}
+	transport := NewMeteredTransport(base, metrics)
+	clock := time.Unix(100, 0)
+	transport.now = func() time.Time { return clock }
+
+	for range 2 {
+		if _, err := transport.ReadLine(); err != nil {
+			t.Fatalf("ReadLine: %v", err)
+		}
+	}
+	if _, err := transport.ReadLine(); err == nil {
+		t.Fatalf("expected read error")
+	}
+	if err := transport.WriteLine("hello"); err != nil {
+		t.Fatalf("WriteLine: %v", err)
+	}
+	base.writeErr = errors.New("closed")
+	if err := transport.WriteLine("lost"); err == nil {
+		t.Fatalf("expected write error")
+	}
+
+	want := TrafficStats{BytesRead: 5, BytesWritten: 5, LinesRead: 2, LinesWritten: 1, ReadErrors: 1, WriteErrors: 1}
+	if got := transport.Stats(); got != want {
+		t.Fatalf("Stats() = %+v, want %+v", got, want)
+	}
+
+	clock = clock.Add(1500 * time.Millisecond)
+	if got := transport.Stats(); got.ReadLinesPerSecond != 2 || got.WriteLinesPerSecond != 1 {
+		t.Fatalf("rates after one second = %v/%v, want 2/1", got.ReadLinesPerSecond, got.WriteLinesPerSecond)
+	}
+	clock = clock.Add(time.Second)
+	if got := transport.Stats(); got.ReadLinesPerSecond != 0 || got.WriteLinesPerSecond != 0 {
+		t.Fatalf("rates after an idle second = %v/%v, want 0/0", got.ReadLinesPerSecond, got.WriteLinesPerSecond)
+	}
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if len(metrics.errs) != 2 || metrics.errs[0] != "read" || metrics.errs[1] != "write" {
+		t.Fatalf("errors = %v", metrics.errs)
+	}
+}
+
+// lineListTransport returns lines, then readErr.
+type lineListTransport struct {
+	lines    []string
+	readErr  error
+	writeErr error
+}
+
+func (t *lineListTransport) ReadLine() (string, error) {
+	if len(t.lines) == 0 {
+		return "", t.readErr
+	}
+	line := t.lines[0]
+	t.lines = t.lines[1:]
+	return line, nil
+}
+
+func (t *lineListTransport) WriteLine(string) error {
+	return t.writeErr
+}
+
+func (t *lineListTransport) Close() error {
+	return nil
+}
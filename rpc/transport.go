@@ -8,12 +8,19 @@ import (
 	"io"
 	"os"
 	"os/exec"
-	"strings"
 	"sync"
 	"time"
 )
 
-const stdioCloseTimeout = 2 * time.Second
+const (
+	defaultStdioExitGrace      = 2 * time.Second
+	defaultStdioTerminateGrace = 2 * time.Second
+	// stdioWaitDelay bounds how long reaping the process waits for stderr
+	// to drain after it exits. A descendant that escaped the process group
+	// or job can hold the pipe open indefinitely, which would otherwise
+	// block Close.
+	stdioWaitDelay = time.Second
+)
 
 // Transport reads and writes JSON-RPC lines.
 type Transport interface {
@@ -28,66 +35,269 @@ type StdioTransport struct {
 	stdin  io.WriteCloser
 	stdout *bufio.Reader
 	mu     sync.Mutex
+	// maxLine starts as StdioOptions.MaxLineSize; NewClient replaces it
+	// from SizeLimits.MaxLine.
+	maxLine int
+	framing Framing
+	// writes is set when StdioOptions.WriteQueueSize is positive.
+	writes *stdinQueue
+	// stdoutFile is the read end of the stdout pipe. The transport owns it,
+	// so waiting for the process does not close it before every line is
+	// read.
+	stdoutFile *os.File
+	// exited is closed once the process has exited, with exitErr set to the
+	// result of cmd.Wait.
+	exited  chan struct{}
+	exitErr error
+	// exitGrace and terminateGrace time the stages of CloseContext.
+	exitGrace      time.Duration
+	terminateGrace time.Duration
+	// group holds the process and the helpers it spawns, so Close ends
+	// them together.
+	group *processGroup
+	// tail captures the end of stderr when StdioOptions.StderrTail is set.
+	tail *tailBuffer
+}
+
+// defaultStdioBufferSize is the initial stdout buffer. App-server lines
+// carrying diffs or images are often far larger than bufio's 4 KiB default.
+const defaultStdioBufferSize = 64 << 10
+
+// StdioOptions configures SpawnStdioWithOptions.
+type StdioOptions struct {
+	// Stderr receives the process's stderr.
+	Stderr io.Writer
+	// ReadBufferSize is the size of the stdout read buffer (defaults to
+	// 64 KiB). Longer lines are assembled from several reads, so this only
+	// tunes performance.
+	ReadBufferSize int
+	// MaxLineSize caps one incoming message. ReadLine fails with a
+	// *MessageTooLargeError for a longer one instead of buffering it. Zero
+	// means no cap. ClientOptions.SizeLimits.MaxLine replaces it when set.
+	MaxLineSize int
+	// Framing selects the wire framing (FramingLines by default).
+	Framing Framing
+	// WriteQueueSize, when positive, queues up to this many messages for a
+	// dedicated writer goroutine, so WriteLine returns without waiting for
+	// the process to read its stdin. WriteLine blocks only while the queue
+	// is full. A failed write is returned by the next WriteLine and by
+	// Close, and Close flushes the queue before closing stdin. Zero writes
+	// on the caller's goroutine. ClientOptions.WriteQueue offers the same
+	// for any transport.
+	WriteQueueSize int
+	// Env is the process environment, as in exec.Cmd.Env: nil inherits the
+	// parent's environment and an empty non-nil slice starts with none.
+	Env []string
+	// Dir is the process's working directory. Empty uses the parent's.
+	Dir string
+	// OnExit, when set, is called once the process exits, including after
+	// Close, with the error from waiting for it (nil for a clean exit) and
+	// its exit code (-1 when it was killed by a signal).
+	OnExit func(err error, exitCode int)
+	// ExitGrace is how long Close waits for the process to exit after
+	// closing its stdin before sending SIGTERM (defaults to 2s).
+	ExitGrace time.Duration
+	// TerminateGrace is how long Close waits after SIGTERM before killing
+	// the process (defaults to 2s). The app-server uses it to flush rollout
+	// files and persist thread state.
+	TerminateGrace time.Duration
+	// StderrTail, when positive, keeps the last StderrTail bytes of stderr
+	// in memory, in addition to writing it to Stderr. A read that fails
+	// because the process exited then returns a *ProcessError carrying
+	// them; see also StderrTail and AttachStderr.
+	StderrTail int
+	// Limits caps the memory, CPU time and process count of the process
+	// and everything it starts. Spawning fails when the limits cannot be
+	// applied, including on platforms other than Linux.
+	Limits ResourceLimits
 }
 
 // SpawnStdio starts a command and uses its stdin/stdout for JSON-RPC.
 func SpawnStdio(ctx context.Context, binary string, args []string, stderr io.Writer) (*StdioTransport, error) {
+	return SpawnStdioWithOptions(ctx, binary, args, StdioOptions{Stderr: stderr})
+}
+
+// SpawnStdioWithOptions is SpawnStdio with buffer sizing, a line size cap,
+// framing, an explicit environment and a working directory.
+func SpawnStdioWithOptions(ctx context.Context, binary string, args []string, opts StdioOptions) (*StdioTransport, error) {
 	if binary == "" {
 		return nil, errors.New("codex binary path is empty")
 	}
 
 	cmd := exec.CommandContext(ctx, binary, args...)
-	cmd.Stderr = stderr
+	cmd.Stderr = opts.Stderr
+	var tail *tailBuffer
+	if opts.StderrTail > 0 {
+		tail = newTailBuffer(opts.StderrTail)
+		cmd.Stderr = tail
+		if opts.Stderr != nil {
+			cmd.Stderr = io.MultiWriter(opts.Stderr, tail)
+		}
+	}
+	cmd.Env = opts.Env
+	cmd.Dir = opts.Dir
+	cmd.WaitDelay = stdioWaitDelay
+	prepareProcessGroup(cmd)
 
-	stdout, err := cmd.StdoutPipe()
+	stdout, stdoutWriter, err := os.Pipe()
 	if err != nil {
 		return nil, err
 	}
+	cmd.Stdout = stdoutWriter
 
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
+		_ = stdout.Close()
+		_ = stdoutWriter.Close()
 		return nil, err
 	}
 
 	if err := cmd.Start(); err != nil {
+		_ = stdout.Close()
+		_ = stdoutWriter.Close()
+		return nil, err
+	}
+	_ = stdoutWriter.Close()
+	group, err := newProcessGroup(cmd)
+	if err != nil {
+		_ = group.kill()
+		group.release()
+		_ = cmd.Wait()
+		_ = stdout.Close()
 		return nil, err
 	}
+	if !opts.Limits.IsZero() {
+		if err := applyResourceLimits(cmd.Process.Pid, opts.Limits); err != nil {
+			_ = group.kill()
+			group.release()
+			_ = cmd.Wait()
+			_ = stdout.Close()
+			return nil, fmt.Errorf("apply resource limits: %w", err)
+		}
+	}
 
-	return &StdioTransport{
-		cmd:    cmd,
-		stdin:  stdin,
-		stdout: bufio.NewReader(stdout),
-	}, nil
+	size := opts.ReadBufferSize
+	if size <= 0 {
+		size = defaultStdioBufferSize
+	}
+	t := &StdioTransport{
+		cmd:        cmd,
+		stdin:      stdin,
+		stdout:     bufio.NewReaderSize(stdout, size),
+		stdoutFile: stdout,
+		maxLine:    opts.MaxLineSize,
+		framing:    opts.Framing,
+		exited:     make(chan struct{}),
+		group:      group,
+		tail:       tail,
+
+		exitGrace:      opts.ExitGrace,
+		terminateGrace: opts.TerminateGrace,
+	}
+	if t.exitGrace <= 0 {
+		t.exitGrace = defaultStdioExitGrace
+	}
+	if t.terminateGrace <= 0 {
+		t.terminateGrace = defaultStdioTerminateGrace
+	}
+	if opts.WriteQueueSize > 0 {
+		t.writes = newStdinQueue(opts.WriteQueueSize)
+		go t.writes.run(t.writeDirect)
+	}
+	go t.wait(opts.OnExit)
+	return t, nil
 }
 
-// ReadLine reads a single line from stdout.
+// wait reaps the process as soon as it exits.
+func (t *StdioTransport) wait(onExit func(err error, exitCode int)) {
+	t.group.exiting()
+	t.exitErr = t.cmd.Wait()
+	t.group.exited()
+	close(t.exited)
+	if onExit != nil {
+		onExit(t.exitErr, t.cmd.ProcessState.ExitCode())
+	}
+}
+
+// Exited returns a channel that is closed once the process has exited. It
+// is nil for a transport that did not spawn a process.
+func (t *StdioTransport) Exited() <-chan struct{} {
+	return t.exited
+}
+
+// ExitError returns the error from waiting for the process once Exited is
+// closed: nil for a clean exit, an *exec.ExitError for a non-zero exit
+// code or a signal.
+func (t *StdioTransport) ExitError() error {
+	select {
+	case <-t.exited:
+		return t.exitErr
+	default:
+		return nil
+	}
+}
+
+// ReadLine reads a single message from stdout.
 func (t *StdioTransport) ReadLine() (string, error) {
-	line, err := t.stdout.ReadString('\n')
+	line, err := readFrame(t.stdout, t.framing, t.maxLine)
 	if err != nil {
-		if errors.Is(err, io.EOF) && line != "" {
-			return strings.TrimRight(line, "\n"), nil
-		}
-		return "", err
+		return line, t.readError(err)
 	}
-	return strings.TrimRight(line, "\n"), nil
+	return line, nil
+}
+
+// SetFraming selects the wire framing (FramingLines by default). Call it
+// before the transport is used.
+func (t *StdioTransport) SetFraming(framing Framing) {
+	t.framing = framing
 }
 
-// WriteLine writes a single line to stdin.
+func (t *StdioTransport) setMaxLine(limit int) {
+	t.maxLine = limit
+}
+
+// WriteLine writes a single message to stdin, or queues it when
+// StdioOptions.WriteQueueSize is set.
 func (t *StdioTransport) WriteLine(line string) error {
+	if t.writes != nil {
+		return t.writes.enqueue(line)
+	}
+	return t.writeDirect(line)
+}
+
+func (t *StdioTransport) writeDirect(line string) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if !strings.HasSuffix(line, "\n") {
-		line += "\n"
-	}
-
-	_, err := io.WriteString(t.stdin, line)
-	return err
+	return writeFrame(t.stdin, t.framing, line)
 }
 
-// Close shuts down the process.
+// Close shuts down the process gracefully: it closes stdin and waits
+// ExitGrace for the process to exit, then sends SIGTERM and waits
+// TerminateGrace, then kills it. The process runs in a process group of its
+// own (a job object on Windows), and signals go to the whole group, so
+// sandbox helpers and shells it spawned end with it.
 func (t *StdioTransport) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), t.closeTimeout())
+	defer cancel()
+	return t.CloseContext(ctx)
+}
+
+// closeTimeout bounds Close: both grace periods, plus a moment to reap the
+// killed process.
+func (t *StdioTransport) closeTimeout() time.Duration {
+	return t.exitGrace + t.terminateGrace + 100*time.Millisecond
+}
+
+// CloseContext flushes queued writes and runs the termination sequence of
+// Close. When ctx ends first, the process is killed at once.
+func (t *StdioTransport) CloseContext(ctx context.Context) error {
 	var errs []error
+	if t.writes != nil {
+		if err := t.writes.flush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("flush stdin: %w", err))
+		}
+	}
 	if t.stdin != nil {
 		if err := t.stdin.Close(); err != nil {
 			errs = append(errs, fmt.Errorf("close stdin: %w", err))
@@ -97,35 +307,75 @@ func (t *StdioTransport) Close() error {
 		return errors.Join(errs...)
 	}
 
-	waitCh := make(chan error, 1)
-	go func() {
-		waitCh <- t.cmd.Wait()
-	}()
-
-	select {
-	case err := <-waitCh:
-		if err != nil {
-			errs = append(errs, fmt.Errorf("wait for process: %w", err))
+	switch t.awaitExit(ctx, t.exitGrace) {
+	case exitedInTime:
+		if t.exitErr != nil {
+			errs = append(errs, fmt.Errorf("wait for process: %w", t.exitErr))
 		}
-	case <-time.After(stdioCloseTimeout):
-		if t.cmd.Process != nil {
-			if err := t.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
-				errs = append(errs, fmt.Errorf("kill process: %w", err))
+	case graceExpired:
+		if err := t.group.terminate(); err == nil && t.awaitExit(ctx, t.terminateGrace) == exitedInTime {
+			if t.exitErr != nil {
+				errs = append(errs, fmt.Errorf("wait after terminate: %w", t.exitErr))
 			}
+			break
 		}
-		if err := <-waitCh; err != nil {
-			errs = append(errs, fmt.Errorf("wait after kill: %w", err))
-		}
+		errs = append(errs, t.kill()...)
+	case contextDone:
+		errs = append(errs, t.kill()...)
+	}
+	// Sandbox helpers and shells the app-server started were killed with
+	// it where the platform allows; see processGroup.exiting.
+	t.group.release()
+	if t.stdoutFile != nil {
+		_ = t.stdoutFile.Close()
 	}
 
 	return errors.Join(errs...)
 }
 
+type exitWait int
+
+const (
+	exitedInTime exitWait = iota
+	graceExpired
+	contextDone
+)
+
+// awaitExit waits up to grace for the process to exit.
+func (t *StdioTransport) awaitExit(ctx context.Context, grace time.Duration) exitWait {
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-t.exited:
+		return exitedInTime
+	case <-timer.C:
+		return graceExpired
+	case <-ctx.Done():
+		return contextDone
+	}
+}
+
+// kill kills the process group and waits for the process to be reaped.
+func (t *StdioTransport) kill() []error {
+	var errs []error
+	if err := t.group.kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		errs = append(errs, fmt.Errorf("kill process: %w", err))
+	}
+	<-t.exited
+	if t.exitErr != nil {
+		errs = append(errs, fmt.Errorf("wait after kill: %w", t.exitErr))
+	}
+	return errs
+}
+
 // ConnTransport wraps an io.ReadWriteCloser.
 type ConnTransport struct {
 	conn   io.ReadWriteCloser
 	reader *bufio.Reader
 	mu     sync.Mutex
+	// maxLine is set by NewClient from SizeLimits.MaxLine.
+	maxLine int
+	framing Framing
 }
 
 // NewConnTransport wraps the connection in a Transport.
@@ -133,29 +383,27 @@ func NewConnTransport(conn io.ReadWriteCloser) *ConnTransport {
 	return &ConnTransport{conn: conn, reader: bufio.NewReader(conn)}
 }
 
-// ReadLine reads a line from the connection.
+// ReadLine reads a message from the connection.
 func (t *ConnTransport) ReadLine() (string, error) {
-	line, err := t.reader.ReadString('\n')
-	if err != nil {
-		if errors.Is(err, io.EOF) && line != "" {
-			return strings.TrimRight(line, "\n"), nil
-		}
-		return "", err
-	}
-	return strings.TrimRight(line, "\n"), nil
+	return readFrame(t.reader, t.framing, t.maxLine)
+}
+
+// SetFraming selects the wire framing (FramingLines by default). Call it
+// before the transport is used.
+func (t *ConnTransport) SetFraming(framing Framing) {
+	t.framing = framing
 }
 
-// WriteLine writes a line to the connection.
+func (t *ConnTransport) setMaxLine(limit int) {
+	t.maxLine = limit
+}
+
+// WriteLine writes a message to the connection.
 func (t *ConnTransport) WriteLine(line string) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if !strings.HasSuffix(line, "\n") {
-		line += "\n"
-	}
-
-	_, err := io.WriteString(t.conn, line)
-	return err
+	return writeFrame(t.conn, t.framing, line)
 }
 
 // Close closes the connection.
@@ -0,0 +1,147 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+)
+
+// TransportContext is a transport whose reads and writes can be cancelled.
+// Wrap an implementation with TransportFromContext to pass it to NewClient;
+// the client then reads with a context that is cancelled when it stops and
+// writes with the context of the call that produced the line.
+type TransportContext interface {
+	ReadLine(ctx context.Context) (string, error)
+	WriteLine(ctx context.Context, line string) error
+	Close() error
+}
+
+// contextTransport is implemented by transports that can forward contexts
+// to a TransportContext. The client uses it when available.
+type contextTransport interface {
+	readLineContext(ctx context.Context) (string, error)
+	writeLineContext(ctx context.Context, line string) error
+}
+
+// transportRead reads from transport, passing ctx on when it supports one.
+func transportRead(ctx context.Context, transport Transport) (string, error) {
+	if ct, ok := transport.(contextTransport); ok {
+		return ct.readLineContext(ctx)
+	}
+	return transport.ReadLine()
+}
+
+// transportWrite writes to transport, passing ctx on when it supports one.
+func transportWrite(ctx context.Context, transport Transport, line string) error {
+	if ct, ok := transport.(contextTransport); ok {
+		return ct.writeLineContext(ctx, line)
+	}
+	return transport.WriteLine(line)
+}
+
+// TransportFromContext adapts a TransportContext to Transport. ReadLine and
+// WriteLine without a context run until Close.
+func TransportFromContext(transport TransportContext) Transport {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &fromContext{base: transport, ctx: ctx, cancel: cancel}
+}
+
+type fromContext struct {
+	base   TransportContext
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (t *fromContext) ReadLine() (string, error) {
+	return t.base.ReadLine(t.ctx)
+}
+
+func (t *fromContext) WriteLine(line string) error {
+	return t.base.WriteLine(t.ctx, line)
+}
+
+func (t *fromContext) Close() error {
+	t.cancel()
+	return t.base.Close()
+}
+
+func (t *fromContext) readLineContext(ctx context.Context) (string, error) {
+	return t.base.ReadLine(ctx)
+}
+
+func (t *fromContext) writeLineContext(ctx context.Context, line string) error {
+	return t.base.WriteLine(ctx, line)
+}
+
+// TransportWithContext adapts a Transport to TransportContext. Transports
+// built with TransportFromContext are unwrapped. Other transports cannot be
+// interrupted, so a cancelled ReadLine returns ctx.Err() and leaves the
+// underlying read running; its line is returned by the next ReadLine, so
+// nothing is lost. A cancelled WriteLine returns ctx.Err() as well, but the
+// line may still reach the peer.
+func TransportWithContext(transport Transport) TransportContext {
+	if adapted, ok := transport.(*fromContext); ok {
+		return adapted.base
+	}
+	return &withContext{base: transport}
+}
+
+type withContext struct {
+	base Transport
+
+	readMu sync.Mutex
+	// inflight holds the result of a read whose caller gave up.
+	inflight chan lineResult
+}
+
+type lineResult struct {
+	line string
+	err  error
+}
+
+func (t *withContext) ReadLine(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	t.readMu.Lock()
+	defer t.readMu.Unlock()
+	result := t.inflight
+	if result == nil {
+		if ctx.Done() == nil {
+			return t.base.ReadLine()
+		}
+		result = make(chan lineResult, 1)
+		go func() {
+			line, err := t.base.ReadLine()
+			result <- lineResult{line: line, err: err}
+		}()
+	}
+	select {
+	case r := <-result:
+		t.inflight = nil
+		return r.line, r.err
+	case <-ctx.Done():
+		t.inflight = result
+		return "", ctx.Err()
+	}
+}
+
+func (t *withContext) WriteLine(ctx context.Context, line string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ctx.Done() == nil {
+		return t.base.WriteLine(line)
+	}
+	result := make(chan error, 1)
+	go func() { result <- t.base.WriteLine(line) }()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *withContext) Close() error {
+	return t.base.Close()
+}
@@ -0,0 +1,102 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+type ctxKey struct{}
+
+// recordingContextTransport blocks reads until their context is cancelled
+// and records the contexts of writes.
+type recordingContextTransport struct {
+	readErr chan error
+	writes  chan context.Context
+}
+
+func (t *recordingContextTransport) ReadLine(ctx context.Context) (string, error) {
+	<-ctx.Done()
+	t.readErr <- ctx.Err()
+	return "", ctx.Err()
+}
+
+func (t *recordingContextTransport) WriteLine(ctx context.Context, line string) error {
+	t.writes <- ctx
+	return nil
+}
+
+func (t *recordingContextTransport) Close() error {
+	return nil
+}
+
+func TestClientPassesContextsToTransport(t *testing.T) {
+	base := &recordingContextTransport{readErr: make(chan error, 1), writes: make(chan context.Context, 1)}
+	client := NewClient(TransportFromContext(base), ClientOptions{})
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "call")
+	if err := client.Notify(ctx, "initialized", nil); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if got := (<-base.writes).Value(ctxKey{}); got != "call" {
+		t.Fatalf("write context value = %v, want the caller's context", got)
+	}
+
+	_ = client.Close()
+	select {
+	case err := <-base.readErr:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("read error = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Close did not cancel the read")
+	}
+}
+
+func TestTransportWithContextKeepsAbandonedRead(t *testing.T) {
+	base := newChannelTransport()
+	transport := TransportWithContext(base)
+	defer transport.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := transport.ReadLine(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ReadLine error = %v, want DeadlineExceeded", err)
+	}
+
+	base.pushReadLine("first")
+	line, err := transport.ReadLine(context.Background())
+	if err != nil || line != "first" {
+		t.Fatalf("ReadLine = %q, %v; want the line of the abandoned read", line, err)
+	}
+	base.pushReadLine("second")
+	readCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+	if line, err := transport.ReadLine(readCtx); err != nil || line != "second" {
+		t.Fatalf("ReadLine = %q, %v; want second", line, err)
+	}
+
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	if err := transport.WriteLine(cancelled, "late"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("WriteLine error = %v, want Canceled", err)
+	}
+	if err := transport.WriteLine(context.Background(), "sent"); err != nil {
+		t.Fatalf("WriteLine: %v", err)
+	}
+	if writes := base.waitForWrites(t, 1); len(writes) != 1 || writes[0] != "sent" {
+		t.Fatalf("writes = %v, want [sent]", writes)
+	}
+}
+
+func TestTransportWithContextUnwraps(t *testing.T) {
+	base := &recordingContextTransport{}
+	if got := TransportWithContext(TransportFromContext(base)); got != base {
+		t.Fatalf("TransportWithContext did not unwrap the adapter")
+	}
+	if _, err := TransportWithContext(&errorTransport{}).ReadLine(context.Background()); !errors.Is(err, io.EOF) {
+		t.Fatalf("ReadLine error = %v, want io.EOF", err)
+	}
+}
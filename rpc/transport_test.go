@@ -2,13 +2,21 @@ package rpc
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestConnTransportReadWrite(t *testing.T) {
@@ -210,3 +218,325 @@ func (w *writeCloser) Write(p []byte) (int, error) {
 func (w *writeCloser) Close() error {
 	return w.closeErr
 }
+
+func TestStdioTransportCloseContextKillsAtDeadline(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell sleep test is unix-only")
+	}
+
+	transport, err := SpawnStdio(context.Background(), "/bin/sh", []string{"-c", "exec sleep 30"}, nil)
+	if err != nil {
+		t.Fatalf("SpawnStdio error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = transport.CloseContext(ctx)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("close took too long: %s", elapsed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "wait after kill") {
+		t.Fatalf("expected kill error, got %v", err)
+	}
+}
+
+func TestStdioTransportLongLines(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell output test is unix-only")
+	}
+	script := "head -c 200000 /dev/zero | tr '\\0' x; echo"
+
+	tests := []struct {
+		name    string
+		opts    StdioOptions
+		wantErr bool
+	}{
+		{name: "small buffer grows", opts: StdioOptions{ReadBufferSize: 16}},
+		{name: "default buffer"},
+		{name: "cap exceeded", opts: StdioOptions{MaxLineSize: 1000}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := SpawnStdioWithOptions(context.Background(), "/bin/sh", []string{"-c", script}, tt.opts)
+			if err != nil {
+				t.Fatalf("SpawnStdioWithOptions: %v", err)
+			}
+			defer func() {
+				// The process may still be blocked writing the unread rest.
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+				defer cancel()
+				_ = transport.CloseContext(ctx)
+			}()
+
+			line, err := transport.ReadLine()
+			if tt.wantErr {
+				var tooLarge *MessageTooLargeError
+				if !errors.As(err, &tooLarge) || tooLarge.Limit != 1000 {
+					t.Fatalf("expected MessageTooLargeError with limit 1000, got %v", err)
+				}
+				return
+			}
+			if err != nil || len(line) != 200000 || strings.Trim(line, "x") != "" {
+				t.Fatalf("ReadLine = %d bytes, %v", len(line), err)
+			}
+		})
+	}
+}
+
+func TestStdioTransportWriteQueue(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell test is unix-only")
+	}
+	out := filepath.Join(t.TempDir(), "stdin.txt")
+	// The child ignores stdin for a while, so unqueued writes past the pipe
+	// buffer would block.
+	transport, err := SpawnStdioWithOptions(context.Background(), "/bin/sh", []string{"-c", "sleep 0.3; cat > " + out}, StdioOptions{WriteQueueSize: 64})
+	if err != nil {
+		t.Fatalf("SpawnStdioWithOptions: %v", err)
+	}
+
+	line := strings.Repeat("x", 4096)
+	start := time.Now()
+	for i := range 40 {
+		if err := transport.WriteLine(fmt.Sprintf("%02d%s", i, line)); err != nil {
+			t.Fatalf("WriteLine %d: %v", i, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Fatalf("queued writes blocked for %v", elapsed)
+	}
+	if err := transport.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 40 {
+		t.Fatalf("child received %d lines, want 40", len(lines))
+	}
+	for i, got := range lines {
+		if !strings.HasPrefix(got, fmt.Sprintf("%02d", i)) {
+			t.Fatalf("line %d out of order: %.4s", i, got)
+		}
+	}
+}
+
+func TestStdioTransportWriteQueueReportsFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell test is unix-only")
+	}
+	transport, err := SpawnStdioWithOptions(context.Background(), "/bin/sh", []string{"-c", "exec 0<&-; sleep 5"}, StdioOptions{WriteQueueSize: 4})
+	if err != nil {
+		t.Fatalf("SpawnStdioWithOptions: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_ = transport.CloseContext(ctx)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if err := transport.WriteLine("ping"); err != nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("WriteLine never reported the failed write")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStdinQueueRefusesLinesAfterFlush(t *testing.T) {
+	for range 50 {
+		q := newStdinQueue(4)
+		var mu sync.Mutex
+		written := map[string]bool{}
+		go q.run(func(line string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			written[line] = true
+			return nil
+		})
+
+		accepted := make(chan string, 16)
+		var senders sync.WaitGroup
+		for i := range 16 {
+			senders.Go(func() {
+				line := strconv.Itoa(i)
+				if err := q.enqueue(line); err == nil {
+					accepted <- line
+				} else if !errors.Is(err, ErrTransportClosed) {
+					t.Errorf("enqueue: %v", err)
+				}
+			})
+		}
+		if err := q.flush(context.Background()); err != nil {
+			t.Fatalf("flush: %v", err)
+		}
+		if err := q.enqueue("late"); !errors.Is(err, ErrTransportClosed) {
+			t.Fatalf("enqueue after flush = %v, want ErrTransportClosed", err)
+		}
+		senders.Wait()
+		close(accepted)
+
+		mu.Lock()
+		for line := range accepted {
+			if !written[line] {
+				t.Fatalf("accepted line %q was never written", line)
+			}
+		}
+		if written["late"] {
+			t.Fatalf("line enqueued after flush was written")
+		}
+		mu.Unlock()
+	}
+}
+
+func TestStdioTransportEnv(t *testing.T) {
+	t.Setenv("CODEX_SDK_TEST_PARENT", "parent")
+	transport, err := SpawnStdioWithOptions(context.Background(), "/bin/sh", []string{"-c", `echo "home=$CODEX_HOME parent=$CODEX_SDK_TEST_PARENT"`}, StdioOptions{
+		Env: []string{"CODEX_HOME=/tmp/codex"},
+	})
+	if err != nil {
+		t.Fatalf("SpawnStdioWithOptions: %v", err)
+	}
+	defer transport.Close()
+	line, err := transport.ReadLine()
+	if err != nil {
+		t.Fatalf("ReadLine: %v", err)
+	}
+	if line != "home=/tmp/codex parent=" {
+		t.Fatalf("child saw %q, want only the given environment", line)
+	}
+}
+
+func TestStdioTransportDir(t *testing.T) {
+	dir := t.TempDir()
+	transport, err := SpawnStdioWithOptions(context.Background(), "/bin/sh", []string{"-c", "pwd -P"}, StdioOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("SpawnStdioWithOptions: %v", err)
+	}
+	defer transport.Close()
+	line, err := transport.ReadLine()
+	if err != nil {
+		t.Fatalf("ReadLine: %v", err)
+	}
+	want, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatalf("EvalSymlinks: %v", err)
+	}
+	if line != want {
+		t.Fatalf("child ran in %q, want %q", line, want)
+	}
+}
+
+func TestStdioTransportOnExit(t *testing.T) {
+	type exit struct {
+		err  error
+		code int
+	}
+	exits := make(chan exit, 1)
+	transport, err := SpawnStdioWithOptions(context.Background(), "/bin/sh", []string{"-c", "echo bye; exit 3"}, StdioOptions{
+		OnExit: func(err error, code int) { exits <- exit{err: err, code: code} },
+	})
+	if err != nil {
+		t.Fatalf("SpawnStdioWithOptions: %v", err)
+	}
+	defer transport.Close()
+
+	select {
+	case got := <-exits:
+		var exitErr *exec.ExitError
+		if got.code != 3 || !errors.As(got.err, &exitErr) {
+			t.Fatalf("OnExit(%v, %d), want exit code 3", got.err, got.code)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("OnExit was not called")
+	}
+	select {
+	case <-transport.Exited():
+	default:
+		t.Fatalf("Exited not closed after the process exited")
+	}
+	if transport.ExitError() == nil {
+		t.Fatalf("ExitError() = nil, want the exit status")
+	}
+	if line, err := transport.ReadLine(); err != nil || line != "bye" {
+		t.Fatalf("ReadLine after exit = %q, %v; want the buffered line", line, err)
+	}
+}
+
+func TestStdioTransportCloseTerminationSequence(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signal test is unix-only")
+	}
+	tests := []struct {
+		name    string
+		script  string
+		wantErr string
+		stderr  string
+	}{
+		{
+			name:   "exits on SIGTERM",
+			script: `trap 'echo flushed >&2; exit 0' TERM; while :; do sleep 0.01; done`,
+			stderr: "flushed",
+		},
+		{
+			name:    "killed after ignoring SIGTERM",
+			script:  `trap '' TERM; while :; do sleep 0.01; done`,
+			wantErr: "wait after kill",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr safeBuffer
+			transport, err := SpawnStdioWithOptions(context.Background(), "/bin/sh", []string{"-c", tt.script}, StdioOptions{
+				Stderr:         &stderr,
+				ExitGrace:      20 * time.Millisecond,
+				TerminateGrace: 200 * time.Millisecond,
+			})
+			if err != nil {
+				t.Fatalf("SpawnStdioWithOptions: %v", err)
+			}
+			time.Sleep(20 * time.Millisecond)
+			start := time.Now()
+			err = transport.Close()
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Fatalf("Close took %v", elapsed)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Close error = %v, want %q", err, tt.wantErr)
+			}
+			if !strings.Contains(stderr.String(), tt.stderr) {
+				t.Fatalf("stderr = %q, want %q", stderr.String(), tt.stderr)
+			}
+		})
+	}
+}
+
+// safeBuffer is a bytes.Buffer safe for the concurrent writes of a process's
+// stderr copier and the test's reads.
+type safeBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *safeBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
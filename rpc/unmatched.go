@@ -0,0 +1,108 @@
+package rpc
+
+import (
+	"encoding/json"
+	"log/slog"
+)
+
+// settledWindow bounds how many completed request IDs are remembered to
+// classify unmatched responses.
+const settledWindow = 1024
+
+// UnmatchedReason classifies a response that matched no pending request.
+type UnmatchedReason string
+
+const (
+	// UnmatchedOrphan is a response to an ID this client never sent, or sent
+	// too long ago to remember.
+	UnmatchedOrphan UnmatchedReason = "orphan"
+	// UnmatchedDuplicate is a second response to a request that was already
+	// answered.
+	UnmatchedDuplicate UnmatchedReason = "duplicate"
+	// UnmatchedLate is a response that arrived after the caller stopped
+	// waiting, for example because its context ended.
+	UnmatchedLate UnmatchedReason = "late"
+)
+
+// UnmatchedResponse describes a response the client dropped because no
+// pending request matched its ID.
+type UnmatchedResponse struct {
+	ID     RequestID
+	Reason UnmatchedReason
+	// Result is set for a success response and Error for an error response.
+	Result json.RawMessage
+	Error  *JSONRPCErrorError
+}
+
+type settledOutcome int
+
+const (
+	settledAnswered settledOutcome = iota + 1
+	settledAbandoned
+)
+
+// settledRequests remembers the outcome of recently completed requests in a
+// fixed-size ring.
+type settledRequests struct {
+	outcomes map[string]settledOutcome
+	order    []string
+	next     int
+}
+
+func (s *settledRequests) add(key string, outcome settledOutcome) {
+	if s.outcomes == nil {
+		s.outcomes = make(map[string]settledOutcome)
+	}
+	if len(s.order) < settledWindow {
+		s.order = append(s.order, key)
+	} else {
+		delete(s.outcomes, s.order[s.next])
+		s.order[s.next] = key
+		s.next = (s.next + 1) % settledWindow
+	}
+	s.outcomes[key] = outcome
+}
+
+func (s *settledRequests) reason(key string) UnmatchedReason {
+	switch s.outcomes[key] {
+	case settledAnswered:
+		return UnmatchedDuplicate
+	case settledAbandoned:
+		return UnmatchedLate
+	default:
+		return UnmatchedOrphan
+	}
+}
+
+// takePending removes and returns the pending channel for id. When there is
+// none it returns why.
+func (c *Client) takePending(id RequestID) (chan response, UnmatchedReason) {
+	key := id.Key()
+	c.pendingMu.Lock()
+	call, ok := c.pending[key]
+	delete(c.pending, key)
+	depth := len(c.pending)
+	var reason UnmatchedReason
+	if ok {
+		c.settled.add(key, settledAnswered)
+	} else {
+		reason = c.settled.reason(key)
+	}
+	c.pendingMu.Unlock()
+	if ok {
+		c.reportPending(depth)
+	}
+	return call.ch, reason
+}
+
+func (c *Client) reportUnmatched(resp UnmatchedResponse) {
+	c.unmatched.Add(1)
+	level := slog.LevelWarn
+	if resp.Reason == UnmatchedLate {
+		level = slog.LevelDebug
+	}
+	c.logger.Log(c.requestContext(), level, "dropped unmatched response", slog.String("id", resp.ID.String()), slog.String("reason", string(resp.Reason)))
+	if c.onUnmatched != nil {
+		c.onUnmatched(resp)
+	}
+}
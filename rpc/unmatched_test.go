@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUnmatchedResponses(t *testing.T) {
+	transport := newChannelTransport()
+	reports := make(chan UnmatchedResponse, 4)
+	client := NewClient(transport, ClientOptions{OnUnmatchedResponse: func(resp UnmatchedResponse) {
+		reports <- resp
+	}})
+	defer client.Close()
+
+	// Request 1 is answered, request 2 is abandoned by its caller.
+	answered := make(chan error, 1)
+	go func() { answered <- client.Call(context.Background(), "model/list", nil, nil) }()
+	transport.waitForWrites(t, 1)
+	transport.pushReadLine(`{"jsonrpc":"2.0","id":1,"result":{}}`)
+	if err := <-answered; err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := client.Call(ctx, "model/list", nil, nil); err == nil {
+		t.Fatalf("expected abandoned call to fail")
+	}
+
+	transport.pushReadLine(`{"jsonrpc":"2.0","id":1,"result":{}}`)
+	transport.pushReadLine(`{"jsonrpc":"2.0","id":2,"error":{"code":-1,"message":"slow"}}`)
+	transport.pushReadLine(`{"jsonrpc":"2.0","id":99,"result":{"x":1}}`)
+
+	want := []struct {
+		id     string
+		reason UnmatchedReason
+	}{
+		{id: "1", reason: UnmatchedDuplicate},
+		{id: "2", reason: UnmatchedLate},
+		{id: "99", reason: UnmatchedOrphan},
+	}
+	for _, w := range want {
+		select {
+		case got := <-reports:
+			if got.ID.String() != w.id || got.Reason != w.reason {
+				t.Fatalf("got %s/%s, want %s/%s", got.ID, got.Reason, w.id, w.reason)
+			}
+			if w.reason == UnmatchedLate && (got.Error == nil || got.Error.Message != "slow") {
+				t.Fatalf("expected error detail on late response, got %+v", got)
+			}
+			if w.reason == UnmatchedOrphan && string(got.Result) != `{"x":1}` {
+				t.Fatalf("expected result on orphan response, got %s", got.Result)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s report", w.reason)
+		}
+	}
+	if got := client.Stats().UnmatchedResponses; got != 3 {
+		t.Fatalf("UnmatchedResponses = %d, want 3", got)
+	}
+}
+
+func TestSettledRequestsWindow(t *testing.T) {
+	var settled settledRequests
+	settled.add("first", settledAnswered)
+	for i := range settledWindow {
+		settled.add(NewIntRequestID(int64(i)).Key(), settledAbandoned)
+	}
+	if got := settled.reason("first"); got != UnmatchedOrphan {
+		t.Fatalf("expected evicted id to be an orphan, got %s", got)
+	}
+	if got := settled.reason(NewIntRequestID(0).Key()); got != UnmatchedLate {
+		t.Fatalf("expected recent id to be late, got %s", got)
+	}
+	if len(settled.outcomes) != settledWindow {
+		t.Fatalf("expected window of %d, got %d", settledWindow, len(settled.outcomes))
+	}
+}
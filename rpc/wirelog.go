@@ -0,0 +1,117 @@
+package rpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+)
+
+// Redactor rewrites a decoded JSON value before a wire line is logged. key is
+// the object key holding value, or "" for array elements and the root. The
+// returned value replaces value; objects and arrays in the result are then
+// walked recursively.
+type Redactor func(key string, value any) any
+
+// DefaultWireRedactors strips common credential fields and truncates long strings.
+func DefaultWireRedactors() []Redactor {
+	return []Redactor{
+		RedactKeys("authorization", "token", "accessToken", "refreshToken", "idToken", "apiKey", "password", "secret"),
+		TruncateStrings(2048),
+	}
+}
+
+// RedactKeys replaces the values of the named object keys (matched
+// case-insensitively) with "[REDACTED]".
+func RedactKeys(keys ...string) Redactor {
+	set := keySet(keys)
+	return func(key string, value any) any {
+		if set[strings.ToLower(key)] {
+			return "[REDACTED]"
+		}
+		return value
+	}
+}
+
+// HashKeys replaces string values of the named object keys with a SHA-256
+// digest, so large or sensitive content (for example file contents) can still
+// be correlated across log lines.
+func HashKeys(keys ...string) Redactor {
+	set := keySet(keys)
+	return func(key string, value any) any {
+		text, ok := value.(string)
+		if !ok || !set[strings.ToLower(key)] {
+			return value
+		}
+		sum := sha256.Sum256([]byte(text))
+		return "sha256:" + hex.EncodeToString(sum[:])
+	}
+}
+
+// TruncateStrings shortens string values longer than max bytes. The cut
+// backs up to a rune boundary, so it never splits a UTF-8 character.
+func TruncateStrings(max int) Redactor {
+	return func(key string, value any) any {
+		text, ok := value.(string)
+		if !ok || len(text) <= max {
+			return value
+		}
+		cut := max
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		return fmt.Sprintf("%s...(%d bytes truncated)", text[:cut], len(text)-cut)
+	}
+}
+
+func keySet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[strings.ToLower(key)] = true
+	}
+	return set
+}
+
+// logWire logs a raw JSON-RPC line at debug level after redaction.
+func (c *Client) logWire(direction TranscriptDirection, line string) {
+	if !c.wireLog || !c.logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	c.logger.Debug("json-rpc wire", slog.String("direction", string(direction)), slog.String("line", redactLine(line, c.redactors)))
+}
+
+func redactLine(line string, redactors []Redactor) string {
+	if len(redactors) == 0 {
+		return line
+	}
+	var value any
+	if err := json.Unmarshal([]byte(line), &value); err != nil {
+		return "[unparseable line redacted]"
+	}
+	data, err := json.Marshal(redactValue("", value, redactors))
+	if err != nil {
+		return "[unencodable line redacted]"
+	}
+	return string(data)
+}
+
+func redactValue(key string, value any, redactors []Redactor) any {
+	for _, redact := range redactors {
+		value = redact(key, value)
+	}
+	switch typed := value.(type) {
+	case map[string]any:
+		for k, v := range typed {
+			typed[k] = redactValue(k, v, redactors)
+		}
+	case []any:
+		for i, v := range typed {
+			typed[i] = redactValue("", v, redactors)
+		}
+	}
+	return value
+}
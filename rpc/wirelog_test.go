@@ -0,0 +1,126 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestWireLogRedactsTraffic(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	transcript := []TranscriptEntry{
+		writeLine(JSONRPCRequest{ID: NewIntRequestID(1), Method: "account/login", Params: mustRaw(map[string]any{"apiKey": "sk-secret"})}),
+		readLine(JSONRPCResponse{ID: NewIntRequestID(1), Result: mustRaw(map[string]any{"accessToken": "tok", "note": strings.Repeat("x", 3000)})}),
+		writeLine(JSONRPCNotification{Method: "initialized"}),
+	}
+	client := NewClient(NewReplayTransport(transcript), ClientOptions{Logger: logger, WireLog: true})
+	defer client.Close()
+
+	if err := client.Call(context.Background(), "account/login", map[string]any{"apiKey": "sk-secret"}, nil); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if err := client.Notify(context.Background(), "initialized", nil); err != nil {
+		t.Fatalf("notify failed: %v", err)
+	}
+
+	var entries []wireLogEntry
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry wireLogEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		if entry.Msg == "json-rpc wire" {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 wire log entries, got %d: %s", len(entries), buf.String())
+	}
+	if entries[0].Direction != "write" || entries[1].Direction != "read" || entries[2].Direction != "write" {
+		t.Fatalf("unexpected directions: %+v", entries)
+	}
+	logged := buf.String()
+	if strings.Contains(logged, "sk-secret") || strings.Contains(logged, `"tok"`) {
+		t.Fatalf("secrets leaked into log: %s", logged)
+	}
+	if !strings.Contains(entries[1].Line, "bytes truncated") {
+		t.Fatalf("expected long string to be truncated: %s", entries[1].Line)
+	}
+}
+
+func TestWireLogDisabledByDefault(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{Logger: logger})
+	defer client.Close()
+
+	if err := client.Notify(context.Background(), "initialized", nil); err != nil {
+		t.Fatalf("notify failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "json-rpc wire") {
+		t.Fatalf("unexpected wire log: %s", buf.String())
+	}
+}
+
+func TestRedactLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		redactors []Redactor
+		want      string
+	}{
+		{
+			name:      "nested keys",
+			line:      `{"params":{"Authorization":"Bearer x","items":[{"password":"p"}]}}`,
+			redactors: []Redactor{RedactKeys("authorization", "password")},
+			want:      `{"params":{"Authorization":"[REDACTED]","items":[{"password":"[REDACTED]"}]}}`,
+		},
+		{
+			name:      "hash contents",
+			line:      `{"contents":"hello","other":"hello"}`,
+			redactors: []Redactor{HashKeys("contents")},
+			want:      `{"contents":"sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824","other":"hello"}`,
+		},
+		{
+			name:      "truncate",
+			line:      `["abcdef"]`,
+			redactors: []Redactor{TruncateStrings(3)},
+			want:      `["abc...(3 bytes truncated)"]`,
+		},
+		{
+			name:      "truncate at rune boundary",
+			line:      `["aé日本"]`,
+			redactors: []Redactor{TruncateStrings(4)},
+			want:      `["aé...(6 bytes truncated)"]`,
+		},
+		{
+			name: "no redactors",
+			line: `not json`,
+			want: `not json`,
+		},
+		{
+			name:      "unparseable",
+			line:      `not json`,
+			redactors: DefaultWireRedactors(),
+			want:      `[unparseable line redacted]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactLine(tt.line, tt.redactors); got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+type wireLogEntry struct {
+	Msg       string `json:"msg"`
+	Direction string `json:"direction"`
+	Line      string `json:"line"`
+}
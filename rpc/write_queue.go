@@ -0,0 +1,211 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const defaultWriteQueueSize = 256
+
+// QueueWrites counts lines waiting in the outbound write queue.
+const QueueWrites = "writes"
+
+// ErrWriteQueueFull is returned by Notify, and by replies to server
+// requests, when the outbound write queue is full and its overflow policy
+// is OverflowError.
+var ErrWriteQueueFull = errors.New("outbound write queue is full")
+
+// OverflowPolicy decides what a notification or reply does when the
+// outbound write queue is full. Requests sent by Call always wait for space,
+// since the caller waits for the response anyway. AuditQueuePolicy uses it
+// for the audit queue.
+type OverflowPolicy int
+
+const (
+	// OverflowError fails the write immediately with ErrWriteQueueFull.
+	OverflowError OverflowPolicy = iota
+	// OverflowBlock waits for space or for the caller's context to end.
+	OverflowBlock
+)
+
+// WriteQueuePolicy configures the outbound write queue. With a queue, a
+// single writer goroutine owns the transport and every outgoing line is
+// written in the order it was queued, so Notify and server request replies
+// return without waiting on a slow or full pipe.
+type WriteQueuePolicy struct {
+	// Size bounds the number of queued lines (defaults to 256).
+	Size int
+	// Overflow applies to notifications and replies when the queue is full.
+	Overflow OverflowPolicy
+}
+
+func (p *WriteQueuePolicy) normalized() *WriteQueuePolicy {
+	if p == nil {
+		return nil
+	}
+	out := *p
+	if out.Size <= 0 {
+		out.Size = defaultWriteQueueSize
+	}
+	return &out
+}
+
+type writeQueue struct {
+	lines    chan queuedLine
+	overflow OverflowPolicy
+	onDepth  func(depth int)
+
+	// pending counts lines queued but not yet written, so flush can wait
+	// for them.
+	mu      sync.Mutex
+	pending int
+	flushed []chan struct{}
+}
+
+// queuedLine is a line waiting in the write queue. written, when set,
+// receives the result of writing it.
+type queuedLine struct {
+	line    string
+	written chan error
+}
+
+func newWriteQueue(policy *WriteQueuePolicy, onDepth func(depth int)) *writeQueue {
+	return &writeQueue{
+		lines:    make(chan queuedLine, policy.Size),
+		overflow: policy.Overflow,
+		onDepth:  onDepth,
+	}
+}
+
+// write sends line through the write queue when one is configured, and
+// directly otherwise. wait is set for requests sent by Call: they wait for
+// queue space regardless of the overflow policy, and then for the line to be
+// written, so a transport write failure is returned to the caller (and can
+// be retried) as it is without a queue.
+func (c *Client) write(ctx context.Context, line string, wait bool) error {
+	if c.writes == nil {
+		return c.writeLine(ctx, line)
+	}
+	item := queuedLine{line: line}
+	if wait {
+		item.written = make(chan error, 1)
+	}
+	if err := c.enqueue(ctx, item, wait); err != nil {
+		return err
+	}
+	if item.written == nil {
+		return nil
+	}
+	select {
+	case err := <-item.written:
+		return err
+	case <-ctx.Done():
+		// The line may still be written; the caller abandons the call.
+		return ctx.Err()
+	case <-c.done:
+		select {
+		case err := <-item.written:
+			return err
+		default:
+		}
+		return c.errOrClosed()
+	}
+}
+
+func (c *Client) enqueue(ctx context.Context, item queuedLine, wait bool) error {
+	q := c.writes
+	q.add(1)
+	select {
+	case q.lines <- item:
+		q.reportDepth()
+		return nil
+	default:
+	}
+	if !wait && q.overflow == OverflowError {
+		q.add(-1)
+		return ErrWriteQueueFull
+	}
+	select {
+	case q.lines <- item:
+		q.reportDepth()
+		return nil
+	case <-ctx.Done():
+		q.add(-1)
+		return ctx.Err()
+	case <-c.done:
+		q.add(-1)
+		return c.errOrClosed()
+	}
+}
+
+// writeLoop writes queued lines until the client stops. A failed write is
+// returned to a caller waiting for it; otherwise, since the caller that
+// queued the line has already returned, it stops the client.
+func (c *Client) writeLoop() {
+	q := c.writes
+	for {
+		select {
+		case <-c.done:
+			return
+		case item := <-q.lines:
+			q.reportDepth()
+			err := c.writeLine(c.lifecycle, item.line)
+			q.add(-1)
+			if item.written != nil {
+				item.written <- err
+				continue
+			}
+			if err != nil {
+				c.finish(fmt.Errorf("write to transport: %w", err))
+				return
+			}
+		}
+	}
+}
+
+// flushWrites waits until every queued line has been written.
+func (c *Client) flushWrites(ctx context.Context) error {
+	if c.writes == nil {
+		return nil
+	}
+	select {
+	case <-c.writes.flushedCh():
+		return nil
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *writeQueue) add(delta int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending += delta
+	if q.pending == 0 {
+		for _, ch := range q.flushed {
+			close(ch)
+		}
+		q.flushed = nil
+	}
+}
+
+func (q *writeQueue) flushedCh() <-chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	ch := make(chan struct{})
+	if q.pending == 0 {
+		close(ch)
+		return ch
+	}
+	q.flushed = append(q.flushed, ch)
+	return ch
+}
+
+func (q *writeQueue) reportDepth() {
+	if q.onDepth != nil {
+		q.onDepth(len(q.lines))
+	}
+}
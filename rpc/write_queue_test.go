@@ -0,0 +1,150 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriteQueueNotifyDoesNotBlockOnStalledTransport(t *testing.T) {
+	transport := newStalledWriteTransport()
+	client := NewClient(transport, ClientOptions{WriteQueue: &WriteQueuePolicy{Size: 1}})
+	defer client.Close()
+	defer transport.release()
+
+	ctx := context.Background()
+	// The writer picks up the first line and stalls on it; the second fills
+	// the queue.
+	if err := client.Notify(ctx, "first", nil); err != nil {
+		t.Fatalf("first notify: %v", err)
+	}
+	<-transport.writing
+	if err := client.Notify(ctx, "second", nil); err != nil {
+		t.Fatalf("second notify: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- client.Notify(ctx, "third", nil) }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrWriteQueueFull) {
+			t.Fatalf("expected ErrWriteQueueFull, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Notify blocked on a full queue")
+	}
+}
+
+func TestWriteQueueOverflowBlockHonorsContext(t *testing.T) {
+	transport := newStalledWriteTransport()
+	client := NewClient(transport, ClientOptions{WriteQueue: &WriteQueuePolicy{Size: 1, Overflow: OverflowBlock}})
+	defer client.Close()
+	defer transport.release()
+
+	if err := client.Notify(context.Background(), "first", nil); err != nil {
+		t.Fatalf("first notify: %v", err)
+	}
+	<-transport.writing
+	if err := client.Notify(context.Background(), "second", nil); err != nil {
+		t.Fatalf("second notify: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := client.Notify(ctx, "third", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestWriteQueuePreservesOrderAndFlushesOnShutdown(t *testing.T) {
+	transport := newChannelTransport()
+	client := NewClient(transport, ClientOptions{WriteQueue: &WriteQueuePolicy{}})
+
+	methods := []string{"a", "b", "c", "d"}
+	for _, method := range methods {
+		if err := client.Notify(context.Background(), method, nil); err != nil {
+			t.Fatalf("notify %s: %v", method, err)
+		}
+	}
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	transport.mu.Lock()
+	writes := append([]string(nil), transport.writes...)
+	transport.mu.Unlock()
+	if len(writes) != len(methods) {
+		t.Fatalf("expected %d writes, got %v", len(methods), writes)
+	}
+	for i, method := range methods {
+		if !strings.Contains(writes[i], `"method":"`+method+`"`) {
+			t.Fatalf("write %d = %s, want method %s", i, writes[i], method)
+		}
+	}
+}
+
+func TestWriteQueueWriteErrorStopsClient(t *testing.T) {
+	transport := &writeErrorTransport{newStalledWriteTransport()}
+	client := NewClient(transport, ClientOptions{WriteQueue: &WriteQueuePolicy{}})
+	defer client.Close()
+
+	if err := client.Notify(context.Background(), "initialized", nil); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	select {
+	case <-client.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("client did not stop after a failed write")
+	}
+	if err := client.Err(); err == nil || !strings.Contains(err.Error(), "write to transport: write failed") {
+		t.Fatalf("expected write error, got %v", err)
+	}
+}
+
+type writeErrorTransport struct {
+	*stalledWriteTransport
+}
+
+func (t *writeErrorTransport) WriteLine(string) error {
+	return errors.New("write failed")
+}
+
+// stalledWriteTransport blocks every write until release is called.
+type stalledWriteTransport struct {
+	writing  chan struct{}
+	unblock  chan struct{}
+	once     sync.Once
+	closed   chan struct{}
+	closeOne sync.Once
+}
+
+func newStalledWriteTransport() *stalledWriteTransport {
+	return &stalledWriteTransport{
+		writing: make(chan struct{}, 16),
+		unblock: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+}
+
+func (t *stalledWriteTransport) release() {
+	t.once.Do(func() { close(t.unblock) })
+}
+
+func (t *stalledWriteTransport) ReadLine() (string, error) {
+	<-t.closed
+	return "", io.EOF
+}
+
+func (t *stalledWriteTransport) WriteLine(string) error {
+	t.writing <- struct{}{}
+	<-t.unblock
+	return nil
+}
+
+func (t *stalledWriteTransport) Close() error {
+	t.closeOne.Do(func() { close(t.closed) })
+	return nil
+}
@@ -0,0 +1,121 @@
+package codex
+
+// EventSchemaID identifies the current version of the schema returned by
+// EventSchema. It changes only when the encoding changes incompatibly.
+const EventSchemaID = "https://github.com/pmenglund/codex-sdk-go/schemas/events/v1.json"
+
+// EventSchema returns the JSON Schema (draft 2020-12) for the SDK's JSON
+// encodings of notification events (rpc.Notification) and TurnResult. Non-Go
+// consumers can generate bindings from it. The root schema describes a
+// TurnResult; the event shape is available under "$defs/event".
+func EventSchema() RawJSON {
+	return RawJSON(eventSchema)
+}
+
+const eventSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "` + EventSchemaID + `",
+  "title": "TurnResult",
+  "description": "Aggregated notifications and items for a completed turn.",
+  "type": "object",
+  "properties": {
+    "turnId": {
+      "type": "string",
+      "description": "Identifier of the turn reported by the app-server."
+    },
+    "notifications": {
+      "type": ["array", "null"],
+      "description": "Every notification observed during the turn, in arrival order.",
+      "items": {"$ref": "#/$defs/event"}
+    },
+    "items": {
+      "type": ["array", "null"],
+      "description": "Raw JSON payloads of completed thread items.",
+      "items": {"type": "object"}
+    },
+    "finalResponse": {
+      "type": "string",
+      "description": "Text of the last completed item that carried text."
+    },
+    "itemTimings": {
+      "type": ["array", "null"],
+      "description": "Start and completion timestamps per item, in start order.",
+      "items": {"$ref": "#/$defs/itemTiming"}
+    },
+    "diff": {
+      "type": "string",
+      "description": "Unified diff of the file changes made during the turn, from the last turn/diff/updated notification."
+    },
+    "usage": {"$ref": "#/$defs/tokenUsage"},
+    "provenance": {"$ref": "#/$defs/provenance"}
+  },
+  "required": ["turnId", "notifications", "items", "finalResponse", "itemTimings"],
+  "$defs": {
+    "provenance": {
+      "title": "Provenance",
+      "description": "The spawned codex binary that served the turn. Present only when provenance recording is enabled.",
+      "type": "object",
+      "properties": {
+        "path": {"type": "string", "description": "Absolute path with symlinks resolved."},
+        "version": {"type": "string", "description": "Output of codex --version."},
+        "sha256": {"type": "string", "description": "Hex-encoded SHA-256 of the binary."}
+      },
+      "required": ["path", "version", "sha256"]
+    },
+    "tokenUsage": {
+      "title": "TokenUsage",
+      "description": "Token usage of the thread from the last thread/tokenUsage/updated notification.",
+      "type": "object",
+      "properties": {
+        "threadId": {"type": "string"},
+        "turnId": {"type": "string"},
+        "last": {"$ref": "#/$defs/tokenCounts", "description": "Tokens of the most recent model request."},
+        "total": {"$ref": "#/$defs/tokenCounts", "description": "Tokens of every request in the thread."},
+        "contextWindow": {"type": "integer", "description": "Model context window in tokens, when reported."}
+      },
+      "required": ["last", "total"]
+    },
+    "tokenCounts": {
+      "title": "TokenCounts",
+      "description": "Token counts by kind. cachedInput is part of input and reasoningOutput part of output.",
+      "type": "object",
+      "properties": {
+        "input": {"type": "integer"},
+        "cachedInput": {"type": "integer"},
+        "output": {"type": "integer"},
+        "reasoningOutput": {"type": "integer"},
+        "total": {"type": "integer"}
+      },
+      "required": ["input", "cachedInput", "output", "reasoningOutput", "total"]
+    },
+    "itemTiming": {
+      "title": "ItemTiming",
+      "description": "When a thread item started and completed. Timestamps are RFC 3339.",
+      "type": "object",
+      "properties": {
+        "itemId": {"type": "string"},
+        "type": {"type": "string"},
+        "startedAt": {"type": "string", "format": "date-time", "description": "Arrival of item/started."},
+        "completedAt": {"type": "string", "format": "date-time", "description": "Arrival of item/completed."},
+        "serverStartedAt": {"type": "string", "format": "date-time", "description": "Server-reported start, when present."},
+        "serverCompletedAt": {"type": "string", "format": "date-time", "description": "Server-reported completion, when present."}
+      },
+      "required": ["itemId"]
+    },
+    "event": {
+      "title": "Event",
+      "description": "A server notification as sent by the app-server.",
+      "type": "object",
+      "properties": {
+        "method": {
+          "type": "string",
+          "description": "JSON-RPC notification method, for example \"turn/completed\"."
+        },
+        "params": {
+          "description": "Method-specific payload as sent on the wire."
+        }
+      },
+      "required": ["method"]
+    }
+  }
+}`
@@ -0,0 +1,96 @@
+package codex
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestEventSchemaMatchesTurnResultEncoding(t *testing.T) {
+	var schema struct {
+		ID         string                     `json:"$id"`
+		Properties map[string]json.RawMessage `json:"properties"`
+		Defs       map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(EventSchema(), &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	if schema.ID != EventSchemaID {
+		t.Fatalf("unexpected schema id: %s", schema.ID)
+	}
+
+	result := TurnResult{
+		TurnID:        "turn_1",
+		Notifications: []rpc.Notification{{Method: "turn/started", Raw: MustJSON(map[string]any{"threadId": "thr_1"})}},
+		Items:         []json.RawMessage{MustJSON(map[string]any{"text": "hi"})},
+		FinalResponse: "hi",
+		ItemTimings: []ItemTiming{{
+			ItemID:            "item_1",
+			Type:              "agentMessage",
+			StartedAt:         time.Unix(1, 0),
+			CompletedAt:       time.Unix(2, 0),
+			ServerStartedAt:   time.Unix(1, 0),
+			ServerCompletedAt: time.Unix(2, 0),
+		}},
+		Diff:       "diff --git a/main.go b/main.go\n",
+		Usage:      &TokenUsage{ThreadID: "thr_1", TurnID: "turn_1", Total: TokenCounts{Total: 10}, ContextWindow: 1000},
+		Provenance: &Provenance{Path: "/usr/local/bin/codex", Version: "codex-cli 1.0.0", SHA256: "abc"},
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("marshal result: %v", err)
+	}
+	var encoded struct {
+		Fields        map[string]json.RawMessage
+		Notifications []map[string]json.RawMessage `json:"notifications"`
+		ItemTimings   []map[string]json.RawMessage `json:"itemTimings"`
+		Usage         map[string]json.RawMessage   `json:"usage"`
+		Provenance    map[string]json.RawMessage   `json:"provenance"`
+	}
+	if err := json.Unmarshal(data, &encoded.Fields); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		t.Fatalf("unmarshal notifications: %v", err)
+	}
+
+	assertEqual(t, "turn result keys", sortedKeys(encoded.Fields), sortedKeys(schema.Properties))
+	assertEqual(t, "event keys", sortedKeys(encoded.Notifications[0]), sortedKeys(schema.Defs["event"].Properties))
+	assertEqual(t, "item timing keys", sortedKeys(encoded.ItemTimings[0]), sortedKeys(schema.Defs["itemTiming"].Properties))
+	assertEqual(t, "token usage keys", sortedKeys(encoded.Usage), sortedKeys(schema.Defs["tokenUsage"].Properties))
+	assertEqual(t, "provenance keys", sortedKeys(encoded.Provenance), sortedKeys(schema.Defs["provenance"].Properties))
+}
+
+func sortedKeys(values map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestEventSchemaRequiresAlwaysEncodedFields(t *testing.T) {
+	var schema struct {
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(EventSchema(), &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	data, err := json.Marshal(TurnResult{})
+	if err != nil {
+		t.Fatalf("marshal result: %v", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+	required := append([]string(nil), schema.Required...)
+	sort.Strings(required)
+	assertEqual(t, "required turn result keys", sortedKeys(fields), required)
+}
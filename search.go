@@ -0,0 +1,53 @@
+package codex
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+// FileMatch is a file found by Thread.SearchFiles.
+type FileMatch struct {
+	// Path is relative to Root, the directory that was searched.
+	Path string
+	Root string
+	// Score ranks the match; higher is better.
+	Score int
+	// Indices are the positions in Path of the characters that matched the
+	// query, for highlighting.
+	Indices []int
+}
+
+// SearchFiles fuzzy-matches query against the file names in the thread's
+// working directory, as the codex TUI does for @-mentions, and returns at
+// most limit matches, best first. A limit of zero or less returns every
+// match the app-server reports.
+func (t *Thread) SearchFiles(ctx context.Context, query string, limit int) ([]FileMatch, error) {
+	if err := t.ensureReady(); err != nil {
+		return nil, err
+	}
+	if t.cwd == "" {
+		return nil, errors.New("thread working directory is not known")
+	}
+	params := protocol.FuzzyFileSearchParams{Query: query, Roots: []string{t.cwd}}
+	var response protocol.FuzzyFileSearchResponse
+	if err := t.call(ctx, "fuzzyFileSearch", params, &response); err != nil {
+		return nil, err
+	}
+	matches := make([]FileMatch, 0, len(response.Files))
+	for _, file := range response.Files {
+		matches = append(matches, FileMatch{Path: file.Path, Root: file.Root, Score: file.Score, Indices: file.Indices})
+	}
+	// The app-server ranks matches already; a stable sort keeps its order
+	// for equal scores.
+	slices.SortStableFunc(matches, func(a, b FileMatch) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
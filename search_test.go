@@ -0,0 +1,62 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestThreadSearchFiles(t *testing.T) {
+	server, codex := newFakeAppServer(t, map[string]fakeHandler{
+		"thread/start": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			return map[string]any{"thread": map[string]any{"id": "thr_1"}, "cwd": "/repo"}, nil
+		},
+		"fuzzyFileSearch": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			return map[string]any{"files": []any{
+				map[string]any{"root": "/repo", "path": "src/main.go", "file_name": "main.go", "score": 80, "indices": []int{4, 5}, "match_type": "file"},
+				map[string]any{"root": "/repo", "path": "main.go", "file_name": "main.go", "score": 95, "match_type": "file"},
+				map[string]any{"root": "/repo", "path": "cmd/main_test.go", "file_name": "main_test.go", "score": 40, "match_type": "file"},
+			}}, nil
+		},
+	})
+	ctx := context.Background()
+	thread, err := codex.StartThread(ctx, ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("StartThread: %v", err)
+	}
+	if thread.Cwd() != "/repo" {
+		t.Fatalf("Cwd = %q, want the server's", thread.Cwd())
+	}
+	matches, err := thread.SearchFiles(ctx, "main", 2)
+	if err != nil {
+		t.Fatalf("SearchFiles: %v", err)
+	}
+	want := []FileMatch{
+		{Path: "main.go", Root: "/repo", Score: 95},
+		{Path: "src/main.go", Root: "/repo", Score: 80, Indices: []int{4, 5}},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Fatalf("matches = %+v, want %+v", matches, want)
+	}
+	if got := string(server.request(t, "fuzzyFileSearch").Params); got != `{"query":"main","roots":["/repo"]}` {
+		t.Fatalf("fuzzyFileSearch params = %s", got)
+	}
+}
+
+func TestThreadSearchFilesNeedsCwd(t *testing.T) {
+	_, codex := newFakeAppServer(t, map[string]fakeHandler{
+		"thread/start": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			return map[string]any{"thread": map[string]any{"id": "thr_1"}}, nil
+		},
+	})
+	thread, err := codex.StartThread(context.Background(), ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("StartThread: %v", err)
+	}
+	if _, err := thread.SearchFiles(context.Background(), "main", 0); err == nil {
+		t.Fatalf("SearchFiles succeeded without a working directory")
+	}
+}
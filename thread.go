@@ -1,18 +1,34 @@
 package codex
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
 	"log/slog"
+	"sync"
+	"time"
 
+	"github.com/pmenglund/codex-sdk-go/protocol"
 	"github.com/pmenglund/codex-sdk-go/rpc"
 )
 
 // Thread represents an active conversation thread.
 type Thread struct {
 	client *rpc.Client
-	id     string
+	// owner supplies the current client after a reconnect; nil for threads
+	// built directly around a client.
+	owner *Codex
+	id    string
+	// cwd is the thread's working directory, when known.
+	cwd    string
 	logger *slog.Logger
+	tracer rpc.Tracer
+	turns  *turnRegistry
+
+	// lease is the thread's current Lease when Options.Leases is set.
+	leaseMu sync.Mutex
+	lease   *Lease
 }
 
 // ID returns the thread id.
@@ -20,39 +36,80 @@ func (t *Thread) ID() string {
 	return t.id
 }
 
+// Cwd returns the thread's working directory as the app-server reported
+// it, or "" when it is not known.
+func (t *Thread) Cwd() string {
+	return t.cwd
+}
+
+// Interrupt asks the app-server to stop a running turn of the thread. The
+// turn then ends with a turn/completed notification whose status is
+// "interrupted".
+func (t *Thread) Interrupt(ctx context.Context, turnID string) error {
+	if err := t.ensureReady(); err != nil {
+		return err
+	}
+	return t.interrupt(ctx, t.id, turnID)
+}
+
+// interrupt stops a turn on threadID, which differs from t.id for turns on
+// threads t started, such as detached reviews.
+func (t *Thread) interrupt(ctx context.Context, threadID, turnID string) error {
+	if turnID == "" {
+		return errors.New("turn id is empty")
+	}
+	resolveLogger(t.logger).Info("codex interrupting turn", "thread_id", threadID, "turn_id", turnID)
+	var response protocol.TurnInterruptResponse
+	return t.call(ctx, "turn/interrupt", protocol.TurnInterruptParams{ThreadID: threadID, TurnID: turnID}, &response)
+}
+
 // Run sends a text prompt and waits for the turn to finish.
 func (t *Thread) Run(ctx context.Context, prompt string, opts *TurnOptions) (*TurnResult, error) {
 	return t.RunInputs(ctx, []Input{TextInput(prompt)}, opts)
 }
 
 // RunInputs sends structured inputs and waits for the turn to finish.
+// When the turn fails after it has started (a failure notification, transport
+// loss, or ctx ending), the partially accumulated result is returned together
+// with a *PartialResultError wrapping the cause.
 func (t *Thread) RunInputs(ctx context.Context, inputs []Input, opts *TurnOptions) (*TurnResult, error) {
 	if err := t.ensureReady(); err != nil {
 		return nil, err
 	}
 
-	logger := resolveLogger(t.logger)
 	stream, err := t.RunStreamed(ctx, inputs, opts)
 	if err != nil {
 		return nil, err
 	}
+	return t.collectTurn(ctx, stream)
+}
+
+// collectTurn reads stream until the turn ends and aggregates what it
+// delivered. It closes the stream.
+func (t *Thread) collectTurn(ctx context.Context, stream *TurnStream) (*TurnResult, error) {
 	defer stream.Close()
+	logger := stream.logger
+	threadID := stream.threadID
 
 	result := &TurnResult{}
+	if t.owner != nil {
+		result.Provenance = t.owner.currentProvenance()
+	}
 	for {
 		note, err := stream.Next(ctx)
 		if err != nil {
-			return nil, err
+			return result, &PartialResultError{Result: result, Err: err}
 		}
 		result.Notifications = append(result.Notifications, note)
 		updateTurnResult(result, note)
+		recordItemTiming(result, note, time.Now())
 
 		if note.Method == "turn/completed" {
 			if turnErr := notificationError(note); turnErr != nil {
-				logger.Error("codex turn failed", "thread_id", t.id, "turn_id", result.TurnID, "error", turnErr)
-				return nil, turnErr
+				logger.Error("codex turn failed", "thread_id", threadID, "turn_id", result.TurnID, "error", turnErr)
+				return result, &PartialResultError{Result: result, Err: turnErr}
 			}
-			logger.Info("codex turn completed", "thread_id", t.id, "turn_id", result.TurnID)
+			logger.Info("codex turn completed", "thread_id", threadID, "turn_id", result.TurnID)
 			return result, nil
 		}
 		if note.Method == "turn/failed" {
@@ -60,13 +117,13 @@ func (t *Thread) RunInputs(ctx context.Context, inputs []Input, opts *TurnOption
 			if turnErr == nil {
 				turnErr = errors.New("turn failed")
 			}
-			logger.Error("codex turn failed", "thread_id", t.id, "turn_id", result.TurnID, "error", turnErr)
-			return nil, turnErr
+			logger.Error("codex turn failed", "thread_id", threadID, "turn_id", result.TurnID, "error", turnErr)
+			return result, &PartialResultError{Result: result, Err: turnErr}
 		}
 		if note.Method == "error" {
 			if turnErr := notificationError(note); turnErr != nil {
-				logger.Error("codex turn failed", "thread_id", t.id, "turn_id", result.TurnID, "error", turnErr)
-				return nil, turnErr
+				logger.Error("codex turn failed", "thread_id", threadID, "turn_id", result.TurnID, "error", turnErr)
+				return result, &PartialResultError{Result: result, Err: turnErr}
 			}
 		}
 	}
@@ -75,35 +132,126 @@ func (t *Thread) RunInputs(ctx context.Context, inputs []Input, opts *TurnOption
 // RunStreamed sends structured inputs and returns a streaming iterator.
 // The iterator includes thread-scoped events and any notifications that omit
 // threadId (for example account/session updates).
+//
+// Every log record of the turn, including the rpc client's records for
+// turn/start, carries a correlation_id attribute. It is taken from ctx when
+// set with rpc.WithCorrelationID, and generated otherwise.
 func (t *Thread) RunStreamed(ctx context.Context, inputs []Input, opts *TurnOptions) (*TurnStream, error) {
 	if err := t.ensureReady(); err != nil {
 		return nil, err
 	}
+	return t.startTurn(ctx, turnRequest{
+		method:   "turn/start",
+		params:   func() (any, error) { return buildTurnParams(t.id, inputs, opts) },
+		logAttrs: []any{"input_count", len(inputs)},
+	})
+}
+
+// turnRequest describes a request that starts a turn; see startTurn.
+type turnRequest struct {
+	method string
+	// params builds the request params once the client is acquired.
+	params func() (any, error)
+	// threadID returns the thread the turn runs on, from the response. The
+	// turn runs on t when it is nil or returns "".
+	threadID func(response json.RawMessage) string
+	// logAttrs are added to the "codex starting turn" record.
+	logAttrs []any
+}
 
-	logger := resolveLogger(t.logger)
-	iter := t.client.SubscribeNotifications(0)
+// startTurn sends a request that starts a turn and returns a stream of the
+// turn's notifications, subscribed before the request so none are missed.
+func (t *Thread) startTurn(ctx context.Context, request turnRequest) (*TurnStream, error) {
+	correlationID := rpc.CorrelationID(ctx)
+	if correlationID == "" {
+		correlationID = rpc.NewCorrelationID()
+		ctx = rpc.WithCorrelationID(ctx, correlationID)
+	}
+	logger := resolveLogger(t.logger).With("correlation_id", correlationID)
+	if err := t.checkLease(ctx); err != nil {
+		logger.Error("codex turn start failed", "thread_id", t.id, "error", err)
+		return nil, err
+	}
+	client, release, err := t.acquireClient(ctx)
+	if err != nil {
+		logger.Error("codex turn start failed", "thread_id", t.id, "error", err)
+		return nil, err
+	}
+	defer release()
+	iter := client.SubscribeNotifications(0)
 
-	params, err := buildTurnParams(t.id, inputs, opts)
+	params, err := request.params()
 	if err != nil {
 		logger.Error("codex turn start failed", "thread_id", t.id, "error", err)
 		iter.Close()
 		return nil, err
 	}
-	logger.Info("codex starting turn", "thread_id", t.id, "input_count", len(inputs))
-	if err := t.client.Call(ctx, "turn/start", params, nil); err != nil {
+	var span rpc.Span
+	if t.tracer != nil {
+		ctx, span = t.tracer.Start(ctx, "codex.turn")
+		span.SetAttribute("codex.thread_id", t.id)
+	}
+	logger.Info("codex starting turn", append([]any{"thread_id", t.id}, request.logAttrs...)...)
+	var response json.RawMessage
+	err = client.Call(ctx, request.method, params, &response)
+	if t.owner != nil && t.owner.retriesCall(ctx, client, err) {
+		iter.Close()
+		next, reconnectErr := t.owner.awaitReconnect(ctx, client)
+		if reconnectErr != nil {
+			err = errors.Join(err, reconnectErr)
+		} else {
+			logger.Info("codex retrying turn start after reconnect", "thread_id", t.id)
+			client = next
+			iter = client.SubscribeNotifications(0)
+			err = client.Call(ctx, request.method, params, &response)
+		}
+	}
+	err = classifyError(client, request.method, err)
+	if err != nil {
 		logger.Error("codex turn start failed", "thread_id", t.id, "error", err)
 		iter.Close()
+		if span != nil {
+			span.End(err)
+		}
 		return nil, err
 	}
 
-	return &TurnStream{iter: iter, threadID: t.id}, nil
+	turnID := startedTurnID(response)
+	threadID := t.id
+	if request.threadID != nil {
+		threadID = cmp.Or(request.threadID(response), t.id)
+	}
+	return &TurnStream{
+		iter:     iter,
+		threadID: threadID,
+		span:     span,
+		turns:    t.turns,
+		active:   t.turns.add(threadID, turnID),
+		thread:   t,
+		client:   client,
+		turnID:   turnID,
+		logger:   logger,
+
+		correlationID: correlationID,
+	}, nil
+}
+
+// startedTurnID extracts the turn id from a turn/start response, if present.
+func startedTurnID(response json.RawMessage) string {
+	var payload struct {
+		Turn *protocol.TurnNotificationTurn `json:"turn"`
+	}
+	if err := json.Unmarshal(response, &payload); err != nil || payload.Turn == nil {
+		return ""
+	}
+	return payload.Turn.ID
 }
 
 func (t *Thread) ensureReady() error {
 	if t == nil {
 		return errors.New("thread is nil")
 	}
-	if t.client == nil {
+	if t.rpcClient() == nil {
 		return errors.New("thread client is not initialized")
 	}
 	if t.id == "" {
@@ -111,3 +259,33 @@ func (t *Thread) ensureReady() error {
 	}
 	return nil
 }
+
+// acquireClient returns the client for starting a turn, respawning an
+// app-server stopped by Options.IdleShutdown. See Codex.acquireClient.
+func (t *Thread) acquireClient(ctx context.Context) (*rpc.Client, func(), error) {
+	if t.owner != nil {
+		return t.owner.acquireClient(ctx)
+	}
+	return t.client, func() {}, nil
+}
+
+// call sends a request about the thread. Through a Codex it takes the same
+// path as Codex calls, respawning idle app-servers and retrying after
+// reconnects.
+func (t *Thread) call(ctx context.Context, method string, params any, result any) error {
+	if t.owner != nil {
+		_, err := t.owner.call(ctx, method, params, result)
+		return err
+	}
+	return classifyError(t.client, method, t.client.Call(ctx, method, params, result))
+}
+
+// rpcClient returns the client the thread currently talks through.
+func (t *Thread) rpcClient() *rpc.Client {
+	if t.owner != nil {
+		if client := t.owner.currentClient(); client != nil {
+			return client
+		}
+	}
+	return t.client
+}
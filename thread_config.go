@@ -0,0 +1,108 @@
+package codex
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ThreadConfig holds typed overrides of config.toml keys for a thread.
+// Unset fields are left to the user's configuration. Keys it does not cover
+// go in ThreadStartOptions.Config, which is merged over it.
+type ThreadConfig struct {
+	Model         string `json:"model,omitempty"`
+	ModelProvider string `json:"model_provider,omitempty"`
+	// Profile selects a profile defined in config.toml.
+	Profile         string          `json:"profile,omitempty"`
+	ReasoningEffort ReasoningEffort `json:"model_reasoning_effort,omitempty"`
+	// ReasoningSummary is "auto", "concise", "detailed" or "none".
+	ReasoningSummary string `json:"model_reasoning_summary,omitempty"`
+	// Verbosity is "low", "medium" or "high".
+	Verbosity             string                  `json:"model_verbosity,omitempty"`
+	ApprovalPolicy        ApprovalPolicy          `json:"approval_policy,omitempty"`
+	SandboxMode           SandboxMode             `json:"sandbox_mode,omitempty"`
+	SandboxWorkspaceWrite *SandboxWorkspaceWrite  `json:"sandbox_workspace_write,omitempty"`
+	ShellEnvironment      *ShellEnvironmentPolicy `json:"shell_environment_policy,omitempty"`
+	// MCPServers adds or replaces MCP servers, keyed by server name.
+	MCPServers map[string]MCPServerConfig `json:"mcp_servers,omitempty"`
+	Tools      *ToolsConfig               `json:"tools,omitempty"`
+	// Features turns feature flags on or off, keyed by flag name.
+	Features map[string]bool `json:"features,omitempty"`
+}
+
+// SandboxWorkspaceWrite configures the workspace-write sandbox.
+type SandboxWorkspaceWrite struct {
+	// WritableRoots are writable in addition to the working directory.
+	WritableRoots       []string `json:"writable_roots,omitempty"`
+	NetworkAccess       *bool    `json:"network_access,omitempty"`
+	ExcludeTmpdirEnvVar *bool    `json:"exclude_tmpdir_env_var,omitempty"`
+	ExcludeSlashTmp     *bool    `json:"exclude_slash_tmp,omitempty"`
+}
+
+// ShellEnvironmentPolicy controls the environment of commands the agent
+// runs.
+type ShellEnvironmentPolicy struct {
+	// Inherit is "all", "core" or "none".
+	Inherit               string `json:"inherit,omitempty"`
+	IgnoreDefaultExcludes *bool  `json:"ignore_default_excludes,omitempty"`
+	// Exclude and IncludeOnly are glob patterns of variable names.
+	Exclude     []string          `json:"exclude,omitempty"`
+	IncludeOnly []string          `json:"include_only,omitempty"`
+	Set         map[string]string `json:"set,omitempty"`
+}
+
+// MCPServerConfig configures an MCP server. Set Command for a stdio server
+// or URL for a streamable HTTP one.
+type MCPServerConfig struct {
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	Cwd     string            `json:"cwd,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	// BearerTokenEnvVar names the variable holding the HTTP bearer token.
+	BearerTokenEnvVar string   `json:"bearer_token_env_var,omitempty"`
+	Enabled           *bool    `json:"enabled,omitempty"`
+	StartupTimeoutSec *float64 `json:"startup_timeout_sec,omitempty"`
+	ToolTimeoutSec    *float64 `json:"tool_timeout_sec,omitempty"`
+	EnabledTools      []string `json:"enabled_tools,omitempty"`
+	DisabledTools     []string `json:"disabled_tools,omitempty"`
+}
+
+// ToolsConfig turns built-in tools on or off.
+type ToolsConfig struct {
+	WebSearch *bool `json:"web_search,omitempty"`
+	ViewImage *bool `json:"view_image,omitempty"`
+}
+
+// configParams returns the "config" param for typed and raw, or nil when
+// both are empty. raw is merged over typed: tables present in both are
+// merged key by key, and other values in raw replace typed ones.
+func configParams(typed *ThreadConfig, raw map[string]any) (*map[string]any, error) {
+	if typed == nil {
+		if raw == nil {
+			return nil, nil
+		}
+		return &raw, nil
+	}
+	data, err := json.Marshal(typed)
+	if err != nil {
+		return nil, fmt.Errorf("encode thread config: %w", err)
+	}
+	var config map[string]any
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("encode thread config: %w", err)
+	}
+	mergeConfig(config, raw)
+	return &config, nil
+}
+
+func mergeConfig(dst, src map[string]any) {
+	for key, value := range src {
+		if srcTable, ok := value.(map[string]any); ok {
+			if dstTable, ok := dst[key].(map[string]any); ok {
+				mergeConfig(dstTable, srcTable)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
@@ -0,0 +1,104 @@
+package codex
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestThreadConfigParams(t *testing.T) {
+	network := true
+	timeout := 30.0
+	tests := []struct {
+		name    string
+		typed   *ThreadConfig
+		raw     map[string]any
+		want    string
+		wantNil bool
+	}{
+		{name: "neither", wantNil: true},
+		{name: "raw only", raw: map[string]any{"foo": "bar"}, want: `{"foo":"bar"}`},
+		{
+			name: "typed only",
+			typed: &ThreadConfig{
+				Model:                 "gpt-5",
+				ReasoningEffort:       ReasoningEffortHigh,
+				SandboxMode:           SandboxModeWorkspaceWrite,
+				SandboxWorkspaceWrite: &SandboxWorkspaceWrite{WritableRoots: []string{"/cache"}, NetworkAccess: &network},
+				ShellEnvironment:      &ShellEnvironmentPolicy{Inherit: "core", Set: map[string]string{"CI": "1"}},
+				MCPServers: map[string]MCPServerConfig{
+					"docs": {Command: "docs-mcp", Args: []string{"--stdio"}, StartupTimeoutSec: &timeout},
+				},
+				Tools: &ToolsConfig{WebSearch: &network},
+			},
+			want: `{"mcp_servers":{"docs":{"args":["--stdio"],"command":"docs-mcp","startup_timeout_sec":30}},` +
+				`"model":"gpt-5","model_reasoning_effort":"high","sandbox_mode":"workspace-write",` +
+				`"sandbox_workspace_write":{"network_access":true,"writable_roots":["/cache"]},` +
+				`"shell_environment_policy":{"inherit":"core","set":{"CI":"1"}},"tools":{"web_search":true}}`,
+		},
+		{
+			name: "raw merged over typed",
+			typed: &ThreadConfig{
+				Model:            "gpt-5",
+				ShellEnvironment: &ShellEnvironmentPolicy{Inherit: "core", Exclude: []string{"AWS_*"}},
+			},
+			raw: map[string]any{
+				"model":                    "gpt-5-codex",
+				"shell_environment_policy": map[string]any{"inherit": "all", "experimental_use_profile": true},
+				"hide_agent_reasoning":     true,
+			},
+			want: `{"hide_agent_reasoning":true,"model":"gpt-5-codex",` +
+				`"shell_environment_policy":{"exclude":["AWS_*"],"experimental_use_profile":true,"inherit":"all"}}`,
+		},
+		{name: "empty typed", typed: &ThreadConfig{}, want: `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := configParams(tt.typed, tt.raw)
+			if err != nil {
+				t.Fatalf("configParams: %v", err)
+			}
+			if tt.wantNil {
+				if got != nil {
+					t.Fatalf("config = %v, want nil", *got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("config is nil, want %s", tt.want)
+			}
+			data, err := json.Marshal(*got)
+			if err != nil {
+				t.Fatalf("marshal config: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Fatalf("config = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestThreadConfigDoesNotModifyRawConfig(t *testing.T) {
+	raw := map[string]any{"tools": map[string]any{"view_image": false}}
+	enabled := true
+	if _, err := configParams(&ThreadConfig{Tools: &ToolsConfig{WebSearch: &enabled}}, raw); err != nil {
+		t.Fatalf("configParams: %v", err)
+	}
+	data, _ := json.Marshal(raw)
+	if string(data) != `{"tools":{"view_image":false}}` {
+		t.Fatalf("raw config changed to %s", data)
+	}
+}
+
+func TestThreadStartOptionsThreadConfig(t *testing.T) {
+	params, err := (ThreadStartOptions{
+		ThreadConfig: &ThreadConfig{ApprovalPolicy: ApprovalPolicyNever},
+		Config:       map[string]any{"foo": "bar"},
+	}).toParams()
+	if err != nil {
+		t.Fatalf("toParams: %v", err)
+	}
+	if params.Config == nil {
+		t.Fatalf("expected config")
+	}
+	assertEqual(t, "config", *params.Config, map[string]any{"approval_policy": "never", "foo": "bar"})
+}
@@ -0,0 +1,201 @@
+package codex
+
+import (
+	"context"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+// ThreadSortKey orders the threads returned by ListThreads.
+type ThreadSortKey = protocol.ThreadSortKey
+
+const (
+	ThreadSortKeyCreatedAt ThreadSortKey = protocol.ThreadSortKeyCreatedAt
+	ThreadSortKeyUpdatedAt ThreadSortKey = protocol.ThreadSortKeyUpdatedAt
+)
+
+// ThreadListOptions filters and pages ListThreads and ListThreadsPage.
+type ThreadListOptions struct {
+	// Cwd, when set, returns only threads started in one of these
+	// directories.
+	Cwd []string
+	// SearchTerm, when set, returns only threads whose title contains it.
+	SearchTerm string
+	// Archived returns archived threads instead of active ones.
+	Archived bool
+	// UpdatedSince, when set, returns only threads updated at or after it.
+	// The app-server cannot filter by date, so the filter is applied to each
+	// page after it is received and pages may hold fewer than PageSize
+	// threads.
+	UpdatedSince time.Time
+	// SortKey defaults to ThreadSortKeyCreatedAt. Threads are returned newest
+	// first unless Ascending is set.
+	SortKey   ThreadSortKey
+	Ascending bool
+	// PageSize is the number of threads requested per page; the app-server
+	// picks a default when it is zero.
+	PageSize int
+	// Cursor starts listing after a page returned by ListThreadsPage.
+	Cursor string
+}
+
+// ThreadSummary describes a persisted thread. ResumeThread reopens it by ID.
+type ThreadSummary struct {
+	ID string
+	// Name is the user-assigned title, or "" when the thread has none.
+	Name string
+	// Preview is the start of the first user message.
+	Preview       string
+	Cwd           string
+	ModelProvider string
+	CLIVersion    string
+	// Path is the rollout file on disk.
+	Path      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// GitInfo is nil when no git metadata was recorded.
+	GitInfo *GitInfo
+}
+
+// ThreadPage is one page of ListThreadsPage.
+type ThreadPage struct {
+	Threads []ThreadSummary
+	// NextCursor is set as ThreadListOptions.Cursor to fetch the next page.
+	// It is "" on the last page.
+	NextCursor string
+}
+
+// ListThreadsPage returns one page of persisted threads.
+func (c *Codex) ListThreadsPage(ctx context.Context, options ThreadListOptions) (*ThreadPage, error) {
+	if err := c.ensureReady(); err != nil {
+		return nil, err
+	}
+	var cursor *string
+	if options.Cursor != "" {
+		cursor = stringPtr(options.Cursor)
+	}
+	var page ThreadPage
+	next, err := c.listThreads(ctx, options, cursor, &page.Threads)
+	if err != nil {
+		return nil, err
+	}
+	if next != nil {
+		page.NextCursor = *next
+	}
+	return &page, nil
+}
+
+// ListThreads returns every persisted thread matching options, following
+// pagination cursors until the list is complete.
+func (c *Codex) ListThreads(ctx context.Context, options ThreadListOptions) ([]ThreadSummary, error) {
+	if err := c.ensureReady(); err != nil {
+		return nil, err
+	}
+	var threads []ThreadSummary
+	first := true
+	err := listAll("thread/list", func(cursor *string) (*string, error) {
+		if first && options.Cursor != "" {
+			cursor = stringPtr(options.Cursor)
+		}
+		first = false
+		return c.listThreads(ctx, options, cursor, &threads)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return threads, nil
+}
+
+// listThreads fetches the page at cursor, appends its matching threads to
+// threads and returns the next cursor.
+func (c *Codex) listThreads(ctx context.Context, options ThreadListOptions, cursor *string, threads *[]ThreadSummary) (*string, error) {
+	params := protocol.ThreadListParams{Cursor: cursor}
+	if len(options.Cwd) > 0 {
+		params.Cwd = options.Cwd
+	}
+	if options.SearchTerm != "" {
+		params.SearchTerm = stringPtr(options.SearchTerm)
+	}
+	if options.Archived {
+		archived := true
+		params.Archived = &archived
+	}
+	if options.SortKey != "" {
+		params.SortKey = options.SortKey
+	}
+	if options.Ascending {
+		params.SortDirection = protocol.SortDirectionAsc
+	}
+	if options.PageSize > 0 {
+		limit := options.PageSize
+		params.Limit = &limit
+	}
+	var response protocol.ThreadListResponse
+	if _, err := c.call(ctx, "thread/list", params, &response); err != nil {
+		return nil, err
+	}
+	for _, entry := range response.Data {
+		summary := threadSummary(entry)
+		if !options.UpdatedSince.IsZero() && summary.UpdatedAt.Before(options.UpdatedSince) {
+			continue
+		}
+		*threads = append(*threads, summary)
+	}
+	return response.NextCursor, nil
+}
+
+func threadSummary(entry protocol.ThreadListEntry) ThreadSummary {
+	summary := ThreadSummary{
+		ID:            entry.ID,
+		Preview:       entry.Preview,
+		Cwd:           entry.Cwd,
+		ModelProvider: entry.ModelProvider,
+		CLIVersion:    entry.CliVersion,
+		GitInfo:       gitInfo(entry.GitInfo),
+	}
+	if entry.Name != nil {
+		summary.Name = *entry.Name
+	}
+	if entry.Path != nil {
+		summary.Path = *entry.Path
+	}
+	if entry.CreatedAt != 0 {
+		summary.CreatedAt = time.Unix(entry.CreatedAt, 0)
+	}
+	if entry.UpdatedAt != 0 {
+		summary.UpdatedAt = time.Unix(entry.UpdatedAt, 0)
+	}
+	return summary
+}
+
+// ArchiveThread archives a persisted thread, moving it out of ListThreads
+// results unless ThreadListOptions.Archived is set. An archived thread is no
+// longer resumed on reconnect.
+func (c *Codex) ArchiveThread(ctx context.Context, threadID string) error {
+	if err := c.ensureReady(); err != nil {
+		return err
+	}
+	var response protocol.ThreadArchiveResponse
+	if _, err := c.call(ctx, "thread/archive", protocol.ThreadArchiveParams{ThreadID: threadID}, &response); err != nil {
+		return err
+	}
+	c.untrackThread(threadID)
+	return nil
+}
+
+// UnarchiveThread restores an archived thread and returns its summary.
+func (c *Codex) UnarchiveThread(ctx context.Context, threadID string) (*ThreadSummary, error) {
+	if err := c.ensureReady(); err != nil {
+		return nil, err
+	}
+	var response protocol.ThreadUnarchiveResponse
+	if _, err := c.call(ctx, "thread/unarchive", protocol.ThreadUnarchiveParams{ThreadID: threadID}, &response); err != nil {
+		return nil, err
+	}
+	summary := ThreadSummary{ID: threadID}
+	if response.Thread != nil {
+		summary = threadSummary(*response.Thread)
+	}
+	return &summary, nil
+}
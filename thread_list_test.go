@@ -0,0 +1,150 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func threadListPages(t *testing.T) map[string]fakeHandler {
+	t.Helper()
+	return map[string]fakeHandler{
+		"thread/list": func(params json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			var request struct {
+				Cursor string `json:"cursor"`
+			}
+			if err := json.Unmarshal(params, &request); err != nil {
+				t.Errorf("decode thread/list params: %v", err)
+			}
+			switch request.Cursor {
+			case "":
+				return map[string]any{
+					"data": []any{map[string]any{
+						"id": "thr_2", "name": "Fix tests", "preview": "fix the tests", "cwd": "/repo",
+						"modelProvider": "openai", "cliVersion": "0.1.0", "path": "/home/me/.codex/sessions/thr_2.jsonl",
+						"createdAt": 200, "updatedAt": 300,
+						"gitInfo": map[string]any{"branch": "main", "sha": "abc123"},
+					}},
+					"nextCursor": "page-2",
+				}, nil
+			case "page-2":
+				return map[string]any{"data": []any{map[string]any{"id": "thr_1", "cwd": "/repo", "createdAt": 100, "updatedAt": 100}}}, nil
+			}
+			return nil, &rpc.JSONRPCErrorError{Code: -32600, Message: "unknown cursor " + request.Cursor}
+		},
+	}
+}
+
+func TestListThreadsFollowsCursors(t *testing.T) {
+	server, codex := newFakeAppServer(t, threadListPages(t))
+	threads, err := codex.ListThreads(context.Background(), ThreadListOptions{
+		Cwd:        []string{"/repo"},
+		SearchTerm: "tests",
+		SortKey:    ThreadSortKeyUpdatedAt,
+		PageSize:   1,
+	})
+	if err != nil {
+		t.Fatalf("ListThreads: %v", err)
+	}
+	want := []ThreadSummary{
+		{
+			ID: "thr_2", Name: "Fix tests", Preview: "fix the tests", Cwd: "/repo",
+			ModelProvider: "openai", CLIVersion: "0.1.0", Path: "/home/me/.codex/sessions/thr_2.jsonl",
+			CreatedAt: time.Unix(200, 0), UpdatedAt: time.Unix(300, 0),
+			GitInfo: &GitInfo{Branch: "main", Sha: "abc123"},
+		},
+		{ID: "thr_1", Cwd: "/repo", CreatedAt: time.Unix(100, 0), UpdatedAt: time.Unix(100, 0)},
+	}
+	if !reflect.DeepEqual(threads, want) {
+		t.Fatalf("threads = %+v, want %+v", threads, want)
+	}
+	if got := string(server.request(t, "thread/list").Params); got != `{"cwd":["/repo"],"limit":1,"searchTerm":"tests","sortKey":"updated_at"}` {
+		t.Fatalf("thread/list params = %s", got)
+	}
+}
+
+func TestListThreadsPage(t *testing.T) {
+	tests := []struct {
+		name    string
+		options ThreadListOptions
+		wantIDs []string
+		next    string
+	}{
+		{name: "first page", options: ThreadListOptions{}, wantIDs: []string{"thr_2"}, next: "page-2"},
+		{name: "last page", options: ThreadListOptions{Cursor: "page-2"}, wantIDs: []string{"thr_1"}},
+		{name: "updated since", options: ThreadListOptions{UpdatedSince: time.Unix(250, 0)}, wantIDs: []string{"thr_2"}, next: "page-2"},
+		{name: "all filtered", options: ThreadListOptions{Cursor: "page-2", UpdatedSince: time.Unix(250, 0)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, codex := newFakeAppServer(t, threadListPages(t))
+			page, err := codex.ListThreadsPage(context.Background(), tt.options)
+			if err != nil {
+				t.Fatalf("ListThreadsPage: %v", err)
+			}
+			var ids []string
+			for _, thread := range page.Threads {
+				ids = append(ids, thread.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) || page.NextCursor != tt.next {
+				t.Fatalf("page = %v next %q, want %v next %q", ids, page.NextCursor, tt.wantIDs, tt.next)
+			}
+		})
+	}
+}
+
+func TestListThreadsStartsAtCursor(t *testing.T) {
+	_, codex := newFakeAppServer(t, threadListPages(t))
+	threads, err := codex.ListThreads(context.Background(), ThreadListOptions{Cursor: "page-2", Archived: true, Ascending: true})
+	if err != nil {
+		t.Fatalf("ListThreads: %v", err)
+	}
+	if len(threads) != 1 || threads[0].ID != "thr_1" {
+		t.Fatalf("threads = %+v, want only thr_1", threads)
+	}
+}
+
+func TestArchiveAndUnarchiveThread(t *testing.T) {
+	server, codex := newFakeAppServer(t, map[string]fakeHandler{
+		"thread/unarchive": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			return map[string]any{"thread": map[string]any{"id": "thr_1", "name": "Old work", "updatedAt": 100}}, nil
+		},
+	})
+	ctx := context.Background()
+	codex.trackThread("thr_1")
+	if err := codex.ArchiveThread(ctx, "thr_1"); err != nil {
+		t.Fatalf("ArchiveThread: %v", err)
+	}
+	if codex.tracksThread("thr_1") {
+		t.Fatalf("archived thread is still resumed on reconnect")
+	}
+	if got := string(server.request(t, "thread/archive").Params); got != `{"threadId":"thr_1"}` {
+		t.Fatalf("thread/archive params = %s", got)
+	}
+	summary, err := codex.UnarchiveThread(ctx, "thr_1")
+	if err != nil {
+		t.Fatalf("UnarchiveThread: %v", err)
+	}
+	want := &ThreadSummary{ID: "thr_1", Name: "Old work", UpdatedAt: time.Unix(100, 0)}
+	if !reflect.DeepEqual(summary, want) {
+		t.Fatalf("summary = %+v, want %+v", summary, want)
+	}
+	if got := string(server.request(t, "thread/unarchive").Params); got != `{"threadId":"thr_1"}` {
+		t.Fatalf("thread/unarchive params = %s", got)
+	}
+}
+
+func TestUnarchiveThreadError(t *testing.T) {
+	_, codex := newFakeAppServer(t, map[string]fakeHandler{
+		"thread/unarchive": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			return nil, &rpc.JSONRPCErrorError{Code: -32600, Message: "thread not archived"}
+		},
+	})
+	if _, err := codex.UnarchiveThread(context.Background(), "thr_1"); err == nil {
+		t.Fatalf("UnarchiveThread succeeded after a server error")
+	}
+}
@@ -16,7 +16,11 @@ type ThreadStartOptions struct {
 	ApprovalPolicy any
 	// SandboxPolicy is marshaled as JSON and sent as "sandbox".
 	// Prefer SandboxMode* constants for simple policies.
-	SandboxPolicy         any
+	SandboxPolicy any
+	// ThreadConfig overrides config.toml keys with typed fields.
+	ThreadConfig *ThreadConfig
+	// Config overrides config.toml keys the typed fields do not cover. It is
+	// merged over ThreadConfig, and its values win.
 	Config                map[string]any
 	BaseInstructions      string
 	DeveloperInstructions string
@@ -44,10 +48,11 @@ func (o ThreadStartOptions) toParams() (protocol.ThreadStartParams, error) {
 	} else if raw != nil {
 		params.Sandbox = raw
 	}
-	if o.Config != nil {
-		config := o.Config
-		params.Config = &config
+	config, err := configParams(o.ThreadConfig, o.Config)
+	if err != nil {
+		return params, err
 	}
+	params.Config = config
 	if o.BaseInstructions != "" {
 		params.BaseInstructions = stringPtr(o.BaseInstructions)
 	}
@@ -85,7 +90,10 @@ type ThreadResumeOptions struct {
 	ApprovalPolicy any
 	// Sandbox is marshaled as JSON and sent as "sandbox".
 	// Prefer SandboxMode* constants for simple policies.
-	Sandbox               any
+	Sandbox any
+	// ThreadConfig and Config override config.toml keys as they do in
+	// ThreadStartOptions.
+	ThreadConfig          *ThreadConfig
 	Config                map[string]any
 	BaseInstructions      string
 	DeveloperInstructions string
@@ -122,10 +130,11 @@ func (o ThreadResumeOptions) toParams() (protocol.ThreadResumeParams, error) {
 	} else if raw != nil {
 		params.Sandbox = raw
 	}
-	if o.Config != nil {
-		config := o.Config
-		params.Config = &config
+	config, err := configParams(o.ThreadConfig, o.Config)
+	if err != nil {
+		return params, err
 	}
+	params.Config = config
 	if o.BaseInstructions != "" {
 		params.BaseInstructions = stringPtr(o.BaseInstructions)
 	}
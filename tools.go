@@ -0,0 +1,240 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+// Tool is an application function the agent can call during turns. Tools
+// registered with Options.Tools are advertised to the app-server as dynamic
+// tools on every thread started through the client, and the app-server's
+// tool calls are dispatched to Handler.
+type Tool struct {
+	// Name identifies the tool to the model and must be unique.
+	Name        string
+	Description string
+	// InputSchema is the JSON schema of the tool's arguments, marshaled as
+	// JSON. It defaults to an object schema with no declared properties.
+	InputSchema any
+	Handler     ToolHandler
+}
+
+// ToolHandler runs one call of a tool. An error is reported to the model as
+// a failed call rather than failing the turn.
+type ToolHandler func(ctx context.Context, call ToolCall) (ToolResult, error)
+
+// ToolCall is a call of a registered tool by the agent.
+type ToolCall struct {
+	ThreadID string
+	TurnID   string
+	CallID   string
+	Name     string
+	// Arguments is the JSON value the model passed, which should match the
+	// tool's InputSchema.
+	Arguments RawJSON
+}
+
+// DecodeArguments unmarshals the call's arguments into v.
+func (c ToolCall) DecodeArguments(v any) error {
+	if len(c.Arguments) == 0 {
+		return json.Unmarshal([]byte("{}"), v)
+	}
+	if err := json.Unmarshal(c.Arguments, v); err != nil {
+		return fmt.Errorf("decode %s arguments: %w", c.Name, err)
+	}
+	return nil
+}
+
+// ToolResult is the output of a tool call returned to the model.
+type ToolResult struct {
+	Text string
+	// ImageURLs are returned as image outputs after Text; data: URLs are
+	// accepted.
+	ImageURLs []string
+	// Failed tells the model the call did not succeed.
+	Failed bool
+}
+
+// defaultToolSchema accepts any arguments object.
+var defaultToolSchema = json.RawMessage(`{"type":"object","properties":{}}`)
+
+// toolRegistry holds the tools registered with Options.Tools.
+type toolRegistry struct {
+	tools  map[string]Tool
+	specs  []protocol.DynamicToolSpec
+	logger *slog.Logger
+}
+
+func newToolRegistry(tools []Tool, logger *slog.Logger) (*toolRegistry, error) {
+	if len(tools) == 0 {
+		return nil, nil
+	}
+	r := &toolRegistry{tools: make(map[string]Tool, len(tools)), logger: logger}
+	for _, tool := range tools {
+		if tool.Name == "" {
+			return nil, errors.New("tool name is empty")
+		}
+		if tool.Handler == nil {
+			return nil, fmt.Errorf("tool %q has no handler", tool.Name)
+		}
+		if _, ok := r.tools[tool.Name]; ok {
+			return nil, fmt.Errorf("tool %q is registered twice", tool.Name)
+		}
+		schema, err := normalizeJSONValue(fmt.Sprintf("tool %q input schema", tool.Name), tool.InputSchema)
+		if err != nil {
+			return nil, err
+		}
+		if schema == nil {
+			schema = defaultToolSchema
+		}
+		r.tools[tool.Name] = tool
+		r.specs = append(r.specs, protocol.DynamicToolSpec{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: schema,
+		})
+	}
+	return r, nil
+}
+
+// threadStartParams adds the registered tools to thread/start params. The
+// generated params type omits the experimental dynamicTools field.
+type threadStartParams struct {
+	protocol.ThreadStartParams
+	DynamicTools []protocol.DynamicToolSpec `json:"dynamicTools,omitempty"`
+}
+
+func (r *toolRegistry) startParams(params protocol.ThreadStartParams) any {
+	if r == nil {
+		return params
+	}
+	return threadStartParams{ThreadStartParams: params, DynamicTools: r.specs}
+}
+
+// initializeParams opts into the experimental API that dynamic tools are
+// part of.
+func (r *toolRegistry) initializeParams(info protocol.ClientInfo) protocol.InitializeParams {
+	params := protocol.InitializeParams{ClientInfo: info}
+	if r != nil {
+		params.Capabilities = protocol.InitializeCapabilities{ExperimentalApi: true}
+	}
+	return params
+}
+
+// call runs a tool call. It reports false when no tool has the name.
+func (r *toolRegistry) call(ctx context.Context, params protocol.DynamicToolCallParams) (*protocol.DynamicToolCallResponse, bool) {
+	tool, ok := r.tools[params.Tool]
+	if !ok {
+		return nil, false
+	}
+	call := ToolCall{ThreadID: params.ThreadID, TurnID: params.TurnID, CallID: params.CallID, Name: params.Tool}
+	// The arguments were decoded from JSON, so they always re-encode.
+	call.Arguments, _ = JSON(params.Arguments)
+
+	r.logger.Info("codex tool call", "thread_id", call.ThreadID, "turn_id", call.TurnID, "call_id", call.CallID, "tool", call.Name)
+	result, err := tool.Handler(ctx, call)
+	if err != nil {
+		r.logger.Warn("codex tool call failed", "call_id", call.CallID, "tool", call.Name, "error", err)
+		result = ToolResult{Text: err.Error(), Failed: true}
+	}
+	return result.response(), true
+}
+
+func (r ToolResult) response() *protocol.DynamicToolCallResponse {
+	response := &protocol.DynamicToolCallResponse{Success: !r.Failed}
+	if r.Text != "" || len(r.ImageURLs) == 0 {
+		response.ContentItems = append(response.ContentItems, map[string]any{"type": "inputText", "text": r.Text})
+	}
+	for _, url := range r.ImageURLs {
+		response.ContentItems = append(response.ContentItems, map[string]any{"type": "inputImage", "imageUrl": url})
+	}
+	return response
+}
+
+// handler wraps next so tool calls go to the registered tools. Other server
+// requests, and calls of tools that are not registered, go to next.
+func (r *toolRegistry) handler(next rpc.ServerRequestHandler) rpc.ServerRequestHandler {
+	if r == nil {
+		return next
+	}
+	return &toolRequestHandler{tools: r, next: next}
+}
+
+type toolRequestHandler struct {
+	tools *toolRegistry
+	next  rpc.ServerRequestHandler
+}
+
+var errNoRequestHandler = errors.New("no handler configured")
+
+func (h *toolRequestHandler) ItemToolCall(ctx context.Context, params protocol.DynamicToolCallParams) (*protocol.DynamicToolCallResponse, error) {
+	if response, ok := h.tools.call(ctx, params); ok {
+		return response, nil
+	}
+	if h.next == nil {
+		return nil, fmt.Errorf("unknown tool %q", params.Tool)
+	}
+	return h.next.ItemToolCall(ctx, params)
+}
+
+func (h *toolRequestHandler) AccountChatgptAuthTokensRefresh(ctx context.Context, params protocol.ChatgptAuthTokensRefreshParams) (*protocol.ChatgptAuthTokensRefreshResponse, error) {
+	if h.next == nil {
+		return nil, errNoRequestHandler
+	}
+	return h.next.AccountChatgptAuthTokensRefresh(ctx, params)
+}
+
+func (h *toolRequestHandler) ApplyPatchApproval(ctx context.Context, params protocol.ApplyPatchApprovalParams) (*protocol.ApplyPatchApprovalResponse, error) {
+	if h.next == nil {
+		return nil, errNoRequestHandler
+	}
+	return h.next.ApplyPatchApproval(ctx, params)
+}
+
+func (h *toolRequestHandler) ExecCommandApproval(ctx context.Context, params protocol.ExecCommandApprovalParams) (*protocol.ExecCommandApprovalResponse, error) {
+	if h.next == nil {
+		return nil, errNoRequestHandler
+	}
+	return h.next.ExecCommandApproval(ctx, params)
+}
+
+func (h *toolRequestHandler) ItemCommandExecutionRequestApproval(ctx context.Context, params protocol.CommandExecutionRequestApprovalParams) (*protocol.CommandExecutionRequestApprovalResponse, error) {
+	if h.next == nil {
+		return nil, errNoRequestHandler
+	}
+	return h.next.ItemCommandExecutionRequestApproval(ctx, params)
+}
+
+func (h *toolRequestHandler) ItemFileChangeRequestApproval(ctx context.Context, params protocol.FileChangeRequestApprovalParams) (*protocol.FileChangeRequestApprovalResponse, error) {
+	if h.next == nil {
+		return nil, errNoRequestHandler
+	}
+	return h.next.ItemFileChangeRequestApproval(ctx, params)
+}
+
+func (h *toolRequestHandler) ItemPermissionsRequestApproval(ctx context.Context, params protocol.PermissionsRequestApprovalParams) (*protocol.PermissionsRequestApprovalResponse, error) {
+	if h.next == nil {
+		return nil, errNoRequestHandler
+	}
+	return h.next.ItemPermissionsRequestApproval(ctx, params)
+}
+
+func (h *toolRequestHandler) ItemToolRequestUserInput(ctx context.Context, params protocol.ToolRequestUserInputParams) (*protocol.ToolRequestUserInputResponse, error) {
+	if h.next == nil {
+		return nil, errNoRequestHandler
+	}
+	return h.next.ItemToolRequestUserInput(ctx, params)
+}
+
+func (h *toolRequestHandler) McpServerElicitationRequest(ctx context.Context, params protocol.McpServerElicitationRequestParams) (*protocol.McpServerElicitationRequestResponse, error) {
+	if h.next == nil {
+		return nil, errNoRequestHandler
+	}
+	return h.next.McpServerElicitationRequest(ctx, params)
+}
@@ -0,0 +1,121 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestToolsAreAdvertisedAndCalled(t *testing.T) {
+	type weatherArgs struct {
+		City string `json:"city"`
+	}
+	var got ToolCall
+	tools := []Tool{
+		{
+			Name:        "weather",
+			Description: "Current weather for a city",
+			InputSchema: map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+			Handler: func(ctx context.Context, call ToolCall) (ToolResult, error) {
+				got = call
+				var args weatherArgs
+				if err := call.DecodeArguments(&args); err != nil {
+					return ToolResult{}, err
+				}
+				return ToolResult{Text: "sunny in " + args.City}, nil
+			},
+		},
+		{
+			Name: "broken",
+			Handler: func(context.Context, ToolCall) (ToolResult, error) {
+				return ToolResult{}, errors.New("backend unavailable")
+			},
+		},
+	}
+	server, codex := newFakeAppServerOptions(t, map[string]fakeHandler{
+		"thread/start": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			return map[string]any{"thread": map[string]any{"id": "thr_1"}}, nil
+		},
+	}, Options{Tools: tools})
+
+	initialize := server.request(t, "initialize")
+	if !strings.Contains(string(initialize.Params), `"experimentalApi":true`) {
+		t.Fatalf("initialize params = %s, want the experimental API", initialize.Params)
+	}
+	if _, err := codex.StartThread(context.Background(), ThreadStartOptions{Model: "gpt-5-codex"}); err != nil {
+		t.Fatalf("StartThread: %v", err)
+	}
+	start := server.request(t, "thread/start")
+	var params struct {
+		Model        string `json:"model"`
+		DynamicTools []struct {
+			Name        string          `json:"name"`
+			InputSchema json.RawMessage `json:"inputSchema"`
+		} `json:"dynamicTools"`
+	}
+	if err := json.Unmarshal(start.Params, &params); err != nil {
+		t.Fatalf("decode thread/start params: %v", err)
+	}
+	if params.Model != "gpt-5-codex" || len(params.DynamicTools) != 2 || params.DynamicTools[0].Name != "weather" {
+		t.Fatalf("thread/start params = %s", start.Params)
+	}
+	if schema := string(params.DynamicTools[1].InputSchema); schema != `{"type":"object","properties":{}}` {
+		t.Fatalf("default schema = %s", schema)
+	}
+
+	reply := server.ask(t, "item/tool/call", map[string]any{
+		"threadId": "thr_1", "turnId": "turn_1", "callId": "call_1", "tool": "weather", "arguments": map[string]any{"city": "Oslo"},
+	})
+	if !strings.Contains(string(reply), `"contentItems":[{"text":"sunny in Oslo","type":"inputText"}],"success":true`) {
+		t.Fatalf("weather reply = %s", reply)
+	}
+	if got.ThreadID != "thr_1" || got.TurnID != "turn_1" || got.CallID != "call_1" || got.Name != "weather" {
+		t.Fatalf("call = %+v", got)
+	}
+
+	reply = server.ask(t, "item/tool/call", map[string]any{"threadId": "thr_1", "turnId": "turn_1", "callId": "call_2", "tool": "broken", "arguments": map[string]any{}})
+	if !strings.Contains(string(reply), `"text":"backend unavailable"`) || !strings.Contains(string(reply), `"success":false`) {
+		t.Fatalf("broken reply = %s", reply)
+	}
+
+	reply = server.ask(t, "item/tool/call", map[string]any{"threadId": "thr_1", "turnId": "turn_1", "callId": "call_3", "tool": "missing"})
+	if !strings.Contains(string(reply), `"error"`) || !strings.Contains(string(reply), `unknown tool \"missing\"`) {
+		t.Fatalf("unknown tool reply = %s", reply)
+	}
+	reply = server.ask(t, "item/fileChange/requestApproval", map[string]any{"threadId": "thr_1", "turnId": "turn_1", "itemId": "item_1"})
+	if !strings.Contains(string(reply), "no handler configured") {
+		t.Fatalf("approval reply without ApprovalHandler = %s", reply)
+	}
+}
+
+func TestNewRejectsInvalidTools(t *testing.T) {
+	handler := func(context.Context, ToolCall) (ToolResult, error) { return ToolResult{}, nil }
+	tests := []struct {
+		name  string
+		tools []Tool
+	}{
+		{name: "empty name", tools: []Tool{{Handler: handler}}},
+		{name: "no handler", tools: []Tool{{Name: "a"}}},
+		{name: "duplicate", tools: []Tool{{Name: "a", Handler: handler}, {Name: "a", Handler: handler}}},
+		{name: "bad schema", tools: []Tool{{Name: "a", Handler: handler, InputSchema: json.RawMessage("{")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := rpc.NewPipeTransports()
+			if _, err := New(context.Background(), Options{Transport: client, Tools: tt.tools}); err == nil {
+				t.Fatalf("New accepted invalid tools")
+			}
+		})
+	}
+}
+
+func TestToolResultImages(t *testing.T) {
+	response := ToolResult{ImageURLs: []string{"data:image/png;base64,AAAA"}}.response()
+	if got := string(mustRaw(response)); got != `{"contentItems":[{"imageUrl":"data:image/png;base64,AAAA","type":"inputImage"}],"success":true}` {
+		t.Fatalf("response = %s", got)
+	}
+}
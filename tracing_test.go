@@ -0,0 +1,86 @@
+package codex
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestTracerSpansTurnsAndCalls(t *testing.T) {
+	tracer := &recordingTracer{}
+	client, err := New(context.Background(), Options{
+		Transport: rpc.NewReplayTransport(repairTranscript([]repairTurn{{prompt: "hi", response: "done"}})),
+		Tracer:    tracer,
+	})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	defer client.Close()
+
+	thread := &Thread{client: client.Client(), id: "thr_123", tracer: client.tracer}
+	if _, err := thread.Run(context.Background(), "hi", nil); err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	var names []string
+	for _, span := range tracer.spans {
+		names = append(names, span.name)
+	}
+	assertEqual(t, "span names", names, []string{"initialize", "codex.turn", "turn/start"})
+
+	turn := tracer.spans[1]
+	assertEqual(t, "turn attrs", turn.attrs, map[string]string{"codex.thread_id": "thr_123", "codex.turn_id": "turn_1"})
+	if !turn.ended || turn.err != nil {
+		t.Fatalf("expected turn span to end cleanly: %#v", turn)
+	}
+	if tracer.spans[2].parent != turn {
+		t.Fatalf("expected turn/start span to be a child of the turn span")
+	}
+}
+
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordingSpan
+}
+
+type recordingSpan struct {
+	tracer *recordingTracer
+	name   string
+	parent *recordingSpan
+	attrs  map[string]string
+	ended  bool
+	err    error
+}
+
+type spanContextKey struct{}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, rpc.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	parent, _ := ctx.Value(spanContextKey{}).(*recordingSpan)
+	span := &recordingSpan{tracer: t, name: name, parent: parent, attrs: map[string]string{}}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+func (t *recordingTracer) Inject(context.Context, map[string]string) {}
+
+func (s *recordingSpan) SetAttribute(key, value string) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	if key == "rpc.system" || key == "rpc.method" {
+		return
+	}
+	s.attrs[key] = value
+}
+
+func (s *recordingSpan) End(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.ended = true
+	s.err = err
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"sync"
 
 	"github.com/pmenglund/codex-sdk-go/protocol"
 	"github.com/pmenglund/codex-sdk-go/rpc"
@@ -34,12 +36,58 @@ type TurnOptions struct {
 }
 
 // TurnResult aggregates notifications for a completed turn.
+// Its JSON encoding is described by EventSchema.
 type TurnResult struct {
-	TurnID        string
-	Notifications []rpc.Notification
+	TurnID        string             `json:"turnId"`
+	Notifications []rpc.Notification `json:"notifications"`
 	// Items holds the raw JSON payloads for completed items.
-	Items         []json.RawMessage
-	FinalResponse string
+	Items         []json.RawMessage `json:"items"`
+	FinalResponse string            `json:"finalResponse"`
+	// ItemTimings holds start/complete timestamps per item, in start order.
+	ItemTimings []ItemTiming `json:"itemTimings"`
+	// Diff is the unified diff of every file change in the turn, from the
+	// last turn/diff/updated notification. It is empty when the turn changed
+	// no files.
+	Diff string `json:"diff,omitempty"`
+	// Usage is the thread's token usage from the last
+	// thread/tokenUsage/updated notification, or nil when none arrived.
+	Usage *TokenUsage `json:"usage,omitempty"`
+	// Provenance identifies the spawned codex binary when
+	// SpawnOptions.RecordProvenance is set.
+	Provenance *Provenance `json:"provenance,omitempty"`
+}
+
+// DecodeItems decodes Items into typed thread items, in completion order.
+// Items of types the SDK does not know are returned as
+// *protocol.UnknownItem.
+func (r *TurnResult) DecodeItems() ([]protocol.ThreadItem, error) {
+	if r == nil {
+		return nil, nil
+	}
+	items := make([]protocol.ThreadItem, 0, len(r.Items))
+	for _, raw := range r.Items {
+		item, err := protocol.DecodeThreadItem(raw)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// PartialResultError reports a turn that failed after it started. Result
+// holds everything collected before the failure.
+type PartialResultError struct {
+	Result *TurnResult
+	Err    error
+}
+
+func (e *PartialResultError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PartialResultError) Unwrap() error {
+	return e.Err
 }
 
 // TurnStream iterates notifications for a running turn.
@@ -48,38 +96,275 @@ type TurnResult struct {
 type TurnStream struct {
 	iter     *rpc.NotificationIterator
 	threadID string
+	// span traces the turn when a tracer is configured. It ends when the turn
+	// completes or fails, or when the stream is closed.
+	span rpc.Span
+	// turns tracks the stream's turn while it runs so Codex.CloseContext can
+	// interrupt it.
+	turns  *turnRegistry
+	active *activeTurn
+
+	// thread and client allow the stream to re-attach to the turn after the
+	// connection is lost; see resume. turnID and seenItems let it replay only
+	// what was missed, and pending holds those replayed notifications.
+	// reattached is set once resume has run, after which item notifications
+	// for items already delivered are skipped.
+	// turnIDMu guards writes of turnID and reads from Interrupt, which may
+	// run on another goroutine than Next.
+	thread     *Thread
+	client     *rpc.Client
+	turnIDMu   sync.Mutex
+	turnID     string
+	seenItems  map[string]bool
+	pending    []rpc.Notification
+	finished   bool
+	reattached bool
+
+	// logger carries the turn's correlation_id.
+	logger        *slog.Logger
+	correlationID string
+
+	// pauseMu guards the pause state and iter, which resume replaces while
+	// Pause, Resume or Close may run on other goroutines; resumed is closed
+	// by Resume.
+	pauseMu    sync.Mutex
+	paused     bool
+	pauseLimit int
+	resumed    chan struct{}
+}
+
+// CorrelationID returns the ID attached to the turn's log records.
+func (s *TurnStream) CorrelationID() string {
+	return s.correlationID
+}
+
+// TurnID returns the ID of the turn, or "" until the app-server has
+// reported it.
+func (s *TurnStream) TurnID() string {
+	s.turnIDMu.Lock()
+	defer s.turnIDMu.Unlock()
+	return s.turnID
+}
+
+func (s *TurnStream) setTurnID(turnID string) {
+	s.turnIDMu.Lock()
+	s.turnID = turnID
+	s.turnIDMu.Unlock()
+}
+
+// Interrupt asks the app-server to stop the turn. It is safe to call while
+// another goroutine reads the stream; the turn then ends with a
+// turn/completed notification whose status is "interrupted".
+func (s *TurnStream) Interrupt(ctx context.Context) error {
+	if s == nil || s.thread == nil {
+		return errors.New("turn stream is not initialized")
+	}
+	turnID := s.TurnID()
+	if turnID == "" {
+		return errors.New("turn id is not known yet")
+	}
+	return s.thread.interrupt(ctx, s.threadID, turnID)
 }
 
 // Next returns the next notification for this turn.
 // Notifications without threadId are treated as belonging to the active stream.
 func (s *TurnStream) Next(ctx context.Context) (rpc.Notification, error) {
-	if s == nil || s.iter == nil {
+	if s == nil || s.currentIter() == nil {
 		return rpc.Notification{}, errors.New("turn stream is not initialized")
 	}
 
+	if err := s.waitResumed(ctx); err != nil {
+		return rpc.Notification{}, err
+	}
 	for {
-		note, err := s.iter.Next(ctx)
+		if len(s.pending) > 0 {
+			note := s.pending[0]
+			s.pending = s.pending[1:]
+			return s.deliver(note), nil
+		}
+		note, err := s.currentIter().Next(ctx)
 		if err != nil {
-			return note, err
+			if !s.canResume(ctx) {
+				return note, err
+			}
+			if resumeErr := s.resume(ctx); resumeErr != nil {
+				return note, errors.Join(err, resumeErr)
+			}
+			continue
+		}
+		if s.redelivered(note) {
+			continue
+		}
+		if s.threadID == "" || matchesThreadID(note, s.threadID) {
+			return s.deliver(note), nil
+		}
+	}
+}
+
+func (s *TurnStream) deliver(note rpc.Notification) rpc.Notification {
+	if s.logger != nil {
+		s.logger.Debug("codex notification routed", "thread_id", s.threadID, "turn_id", s.turnID, "method", note.Method)
+	}
+	s.traceNotification(note)
+	s.trackTurn(note)
+	s.trackResumeState(note)
+	return note
+}
+
+func (s *TurnStream) traceNotification(note rpc.Notification) {
+	if s.span == nil {
+		return
+	}
+	switch note.Method {
+	case "turn/started", "turn/completed", "turn/failed":
+		if payload, err := parseTurnNotification(note); err == nil && payload.Turn != nil && payload.Turn.ID != "" {
+			s.span.SetAttribute("codex.turn_id", payload.Turn.ID)
 		}
-		if s.threadID == "" {
-			return note, nil
+	}
+	switch note.Method {
+	case "turn/completed":
+		s.endSpan(notificationError(note))
+	case "turn/failed":
+		err := notificationError(note)
+		if err == nil {
+			err = errors.New("turn failed")
+		}
+		s.endSpan(err)
+
+	case "error":
+		if err := notificationError(note); err != nil {
+			s.endSpan(err)
 		}
-		if matchesThreadID(note, s.threadID) {
-			return note, nil
+	}
+}
+
+func (s *TurnStream) trackTurn(note rpc.Notification) {
+	if s.active == nil {
+		return
+	}
+	switch note.Method {
+	case "turn/started":
+		if payload, err := parseTurnNotification(note); err == nil && payload.Turn != nil {
+			s.turns.setTurnID(s.active, payload.Turn.ID)
 		}
+	case "turn/completed", "turn/failed":
+		s.turns.remove(s.active)
+		s.active = nil
+	}
+}
+
+func (s *TurnStream) endSpan(err error) {
+	if s.span != nil {
+		s.span.End(err)
+		s.span = nil
+	}
+}
+
+// Pause stops Next from returning notifications until Resume, for example
+// while a UI shows an approval dialog. Notifications are buffered, up to
+// rpc.DefaultPauseLimit, without blocking the client; see
+// rpc.NotificationIterator.Pause. The pause survives a reconnect.
+func (s *TurnStream) Pause() {
+	s.PauseWithLimit(rpc.DefaultPauseLimit)
+}
+
+// PauseWithLimit is Pause with a custom bound on the buffered backlog. If it
+// is exceeded, Next returns rpc.ErrPauseOverflow.
+func (s *TurnStream) PauseWithLimit(limit int) {
+	if s == nil {
+		return
+	}
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+	if s.iter == nil {
+		return
+	}
+	if !s.paused {
+		s.paused = true
+		s.resumed = make(chan struct{})
+	}
+	s.pauseLimit = limit
+	s.iter.PauseWithLimit(limit)
+}
+
+// Resume restarts delivery after Pause, starting with the buffered
+// notifications.
+func (s *TurnStream) Resume() {
+	if s == nil {
+		return
+	}
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+	if s.iter == nil || !s.paused {
+		return
+	}
+	s.paused = false
+	close(s.resumed)
+	s.resumed = nil
+	s.iter.Resume()
+}
+
+func (s *TurnStream) waitResumed(ctx context.Context) error {
+	s.pauseMu.Lock()
+	resumed := s.resumed
+	s.pauseMu.Unlock()
+	if resumed == nil {
+		return nil
+	}
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *TurnStream) currentIter() *rpc.NotificationIterator {
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+	return s.iter
+}
+
+// replaceIter swaps in the iterator of a new connection, carrying over an
+// active pause.
+func (s *TurnStream) replaceIter(iter *rpc.NotificationIterator) {
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+	s.iter.Close()
+	s.iter = iter
+	if s.paused {
+		iter.PauseWithLimit(s.pauseLimit)
 	}
 }
 
 // Close stops the iterator.
 func (s *TurnStream) Close() {
-	if s == nil || s.iter == nil {
+	if s == nil {
 		return
 	}
-	s.iter.Close()
+	iter := s.currentIter()
+	if iter == nil {
+		return
+	}
+	s.endSpan(nil)
+	s.turns.remove(s.active)
+	s.active = nil
+	s.finished = true
+	iter.Close()
 }
 
 func updateTurnResult(result *TurnResult, note rpc.Notification) {
+	if note.Method == "turn/diff/updated" {
+		var payload protocol.TurnDiffUpdatedNotification
+		if err := note.UnmarshalParams(&payload); err == nil {
+			result.Diff = payload.Diff
+		}
+		return
+	}
+	if usage, ok := ParseTokenUsage(note); ok {
+		result.Usage = usage
+		return
+	}
 	if note.Method != "item/completed" && note.Method != "turn/started" && note.Method != "turn/completed" && note.Method != "turn/failed" {
 		return
 	}
@@ -109,15 +394,12 @@ func notificationError(note rpc.Notification) error {
 	if note.Method == "error" {
 		payload, err := parseTurnNotification(note)
 		if err != nil {
-			return errors.New("turn error")
+			return newTurnError(nil, "", "turn error")
 		}
 		if payload.WillRetry != nil && *payload.WillRetry {
 			return nil
 		}
-		if payload.Error != nil && payload.Error.Message != "" {
-			return errors.New(payload.Error.Message)
-		}
-		return errors.New("turn error")
+		return newTurnError(payload.Error, "", "turn error")
 	}
 	if note.Method == "turn/completed" {
 		payload, err := parseTurnNotification(note)
@@ -125,21 +407,19 @@ func notificationError(note rpc.Notification) error {
 			return nil
 		}
 		if payload.Turn != nil && payload.Turn.Status == "failed" {
-			if message := payloadErrorMessage(payload); message != "" {
-				return errors.New(message)
-			}
-			return errors.New("turn failed")
+			return newTurnError(payloadError(payload), payload.Turn.Status, "turn failed")
 		}
 	}
 	if note.Method == "turn/failed" {
 		payload, err := parseTurnNotification(note)
 		if err != nil {
-			return errors.New("turn failed")
+			return newTurnError(nil, "", "turn failed")
 		}
-		if message := payloadErrorMessage(payload); message != "" {
-			return errors.New(message)
+		status := ""
+		if payload.Turn != nil {
+			status = payload.Turn.Status
 		}
-		return errors.New("turn failed")
+		return newTurnError(payloadError(payload), status, "turn failed")
 	}
 	return nil
 }
@@ -221,14 +501,14 @@ func parseTurnNotification(note rpc.Notification) (turnNotificationPayload, erro
 	return payload, nil
 }
 
-func payloadErrorMessage(payload turnNotificationPayload) string {
+func payloadError(payload turnNotificationPayload) *protocol.TurnNotificationError {
 	if payload.Turn != nil && payload.Turn.Error != nil && payload.Turn.Error.Message != "" {
-		return payload.Turn.Error.Message
+		return payload.Turn.Error
 	}
 	if payload.Error != nil && payload.Error.Message != "" {
-		return payload.Error.Message
+		return payload.Error
 	}
-	return ""
+	return nil
 }
 
 func buildTurnParams(threadID string, inputs []Input, opts *TurnOptions) (protocol.TurnStartParams, error) {
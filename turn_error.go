@@ -0,0 +1,128 @@
+package codex
+
+import (
+	"strings"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+)
+
+// TurnFailureReason classifies why a turn failed.
+type TurnFailureReason string
+
+const (
+	// TurnFailureModel covers model and provider failures such as an
+	// exceeded context window, a rejected request or a dropped response stream.
+	TurnFailureModel TurnFailureReason = "model"
+	// TurnFailureSandbox covers commands or edits refused by the sandbox.
+	TurnFailureSandbox TurnFailureReason = "sandbox"
+	// TurnFailureInterrupted covers turns interrupted or aborted by the user.
+	TurnFailureInterrupted TurnFailureReason = "interrupted"
+	// TurnFailureRateLimit covers usage limits and HTTP 429 responses.
+	TurnFailureRateLimit TurnFailureReason = "rateLimit"
+	// TurnFailureInternal covers app-server errors and failures that do not
+	// match another reason.
+	TurnFailureInternal TurnFailureReason = "internal"
+)
+
+// TurnError is returned by Thread.Run and RunInputs when the app-server
+// reports that a turn failed. Use errors.As to branch on Reason.
+type TurnError struct {
+	Reason  TurnFailureReason
+	Message string
+	// Code is the app-server's codexErrorInfo variant (for example
+	// "usageLimitExceeded"); empty when the server sent none.
+	Code string
+	// HTTPStatusCode is the upstream HTTP status, when the server forwarded one.
+	HTTPStatusCode int
+	// Status is the turn status reported with the failure, if any.
+	Status string
+}
+
+func (e *TurnError) Error() string {
+	return e.Message
+}
+
+// Is reports whether the turn failure matches target. A rate-limited turn
+// matches ErrRateLimited.
+func (e *TurnError) Is(target error) bool {
+	return target == ErrRateLimited && e.Reason == TurnFailureRateLimit
+}
+
+func newTurnError(detail *protocol.TurnNotificationError, status, fallback string) *TurnError {
+	turnErr := &TurnError{Message: fallback, Status: status}
+	if detail != nil {
+		if detail.Message != "" {
+			turnErr.Message = detail.Message
+		}
+		turnErr.Code, turnErr.HTTPStatusCode = parseCodexErrorInfo(detail.CodexErrorInfo)
+	}
+	turnErr.Reason = classifyTurnFailure(turnErr)
+	return turnErr
+}
+
+// parseCodexErrorInfo reads the variant name and forwarded HTTP status from
+// a codexErrorInfo value.
+func parseCodexErrorInfo(info any) (string, int) {
+	switch value := info.(type) {
+	case string:
+		return value, 0
+	case map[string]any:
+		for code, body := range value {
+			status := 0
+			if fields, ok := body.(map[string]any); ok {
+				if number, ok := fields["httpStatusCode"].(float64); ok {
+					status = int(number)
+				}
+			}
+			return code, status
+		}
+	}
+	return "", 0
+}
+
+// classifyTurnFailure prefers the structured error code and falls back to
+// the turn status and message text.
+func classifyTurnFailure(e *TurnError) TurnFailureReason {
+	switch e.Code {
+	case "usageLimitExceeded":
+		return TurnFailureRateLimit
+	case "sandboxError":
+		return TurnFailureSandbox
+	case "contextWindowExceeded", "badRequest", "httpConnectionFailed",
+		"responseStreamConnectionFailed", "responseStreamDisconnected", "responseTooManyFailedAttempts":
+		if e.HTTPStatusCode == 429 {
+			return TurnFailureRateLimit
+		}
+		return TurnFailureModel
+	case "internalServerError", "unauthorized":
+		return TurnFailureInternal
+	}
+	if e.HTTPStatusCode == 429 {
+		return TurnFailureRateLimit
+	}
+	if e.Status == "interrupted" {
+		return TurnFailureInterrupted
+	}
+
+	message := strings.ToLower(e.Message)
+	switch {
+	case containsAny(message, "rate limit", "too many requests", "usage limit", "429"):
+		return TurnFailureRateLimit
+	case containsAny(message, "sandbox", "permission denied", "operation not permitted"):
+		return TurnFailureSandbox
+	case containsAny(message, "interrupted", "aborted", "cancelled", "canceled"):
+		return TurnFailureInterrupted
+	case containsAny(message, "context window", "model", "stream disconnected", "bad request"):
+		return TurnFailureModel
+	}
+	return TurnFailureInternal
+}
+
+func containsAny(text string, substrings ...string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(text, substring) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package codex
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestNotificationErrorClassifiesFailures(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		params     map[string]any
+		wantReason TurnFailureReason
+		wantCode   string
+		wantStatus int
+	}{
+		{
+			name:       "usage limit code",
+			method:     "turn/completed",
+			params:     failedTurnParams(map[string]any{"message": "slow down", "codexErrorInfo": "usageLimitExceeded"}),
+			wantReason: TurnFailureRateLimit,
+			wantCode:   "usageLimitExceeded",
+		},
+		{
+			name:   "forwarded 429",
+			method: "turn/completed",
+			params: failedTurnParams(map[string]any{
+				"message":        "upstream refused",
+				"codexErrorInfo": map[string]any{"responseTooManyFailedAttempts": map[string]any{"httpStatusCode": 429}},
+			}),
+			wantReason: TurnFailureRateLimit,
+			wantCode:   "responseTooManyFailedAttempts",
+			wantStatus: 429,
+		},
+		{
+			name:       "sandbox code",
+			method:     "turn/failed",
+			params:     failedTurnParams(map[string]any{"message": "blocked", "codexErrorInfo": "sandboxError"}),
+			wantReason: TurnFailureSandbox,
+			wantCode:   "sandboxError",
+		},
+		{
+			name:       "context window",
+			method:     "error",
+			params:     map[string]any{"error": map[string]any{"message": "too long", "codexErrorInfo": "contextWindowExceeded"}},
+			wantReason: TurnFailureModel,
+			wantCode:   "contextWindowExceeded",
+		},
+		{
+			name:   "stream disconnected",
+			method: "turn/completed",
+			params: failedTurnParams(map[string]any{
+				"message":        "stream closed",
+				"codexErrorInfo": map[string]any{"responseStreamDisconnected": map[string]any{"httpStatusCode": 502}},
+			}),
+			wantReason: TurnFailureModel,
+			wantCode:   "responseStreamDisconnected",
+			wantStatus: 502,
+		},
+		{
+			name:       "internal code",
+			method:     "turn/failed",
+			params:     failedTurnParams(map[string]any{"message": "oops", "codexErrorInfo": "internalServerError"}),
+			wantReason: TurnFailureInternal,
+			wantCode:   "internalServerError",
+		},
+		{
+			name:       "interrupted status",
+			method:     "turn/failed",
+			params:     map[string]any{"turn": map[string]any{"id": "turn_1", "status": "interrupted"}},
+			wantReason: TurnFailureInterrupted,
+		},
+		{
+			name:       "rate limit message",
+			method:     "error",
+			params:     map[string]any{"error": map[string]any{"message": "Rate limit reached"}},
+			wantReason: TurnFailureRateLimit,
+		},
+		{
+			name:       "sandbox message",
+			method:     "turn/failed",
+			params:     failedTurnParams(map[string]any{"message": "command denied by sandbox"}),
+			wantReason: TurnFailureSandbox,
+		},
+		{
+			name:       "unrecognized",
+			method:     "turn/failed",
+			params:     failedTurnParams(map[string]any{"message": "boom"}),
+			wantReason: TurnFailureInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := notificationError(turnErrorNotification(t, tt.method, tt.params))
+			var turnErr *TurnError
+			if !errors.As(err, &turnErr) {
+				t.Fatalf("expected TurnError, got %T (%v)", err, err)
+			}
+			if turnErr.Reason != tt.wantReason || turnErr.Code != tt.wantCode || turnErr.HTTPStatusCode != tt.wantStatus {
+				t.Fatalf("unexpected classification: %+v", turnErr)
+			}
+		})
+	}
+}
+
+func TestNotificationErrorKeepsMessage(t *testing.T) {
+	err := notificationError(turnErrorNotification(t, "turn/failed", failedTurnParams(map[string]any{"message": "boom", "codexErrorInfo": "badRequest"})))
+	if err == nil || err.Error() != "boom" {
+		t.Fatalf("expected boom, got %v", err)
+	}
+	if err := notificationError(turnErrorNotification(t, "turn/completed", map[string]any{"turn": turnPayload("turn_1", "completed")})); err != nil {
+		t.Fatalf("expected no error for completed turn, got %v", err)
+	}
+}
+
+func failedTurnParams(detail map[string]any) map[string]any {
+	return map[string]any{"threadId": "thr_123", "turn": map[string]any{"id": "turn_1", "status": "failed", "error": detail}}
+}
+
+func turnErrorNotification(t *testing.T, method string, params map[string]any) rpc.Notification {
+	t.Helper()
+	raw, err := json.Marshal(map[string]any{"method": method, "params": params})
+	if err != nil {
+		t.Fatalf("marshal notification: %v", err)
+	}
+	var note rpc.Notification
+	if err := json.Unmarshal(raw, &note); err != nil {
+		t.Fatalf("decode notification: %v", err)
+	}
+	return note
+}
@@ -1,8 +1,14 @@
 package codex
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -41,6 +47,136 @@ func TestThreadRunWithReplay(t *testing.T) {
 	}
 }
 
+func TestThreadRunLogsCorrelationID(t *testing.T) {
+	ctx := context.Background()
+	info := protocol.ClientInfo{
+		Name:    "codex-go-test",
+		Title:   stringPtr("Codex Go SDK Test"),
+		Version: "test",
+	}
+	var buf syncBuffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	client, err := New(ctx, Options{
+		Transport:  rpc.NewReplayTransport(runTranscript(info, "hello", "final")),
+		ClientInfo: info,
+		Logger:     logger,
+	})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	defer client.Close()
+
+	thread, err := client.StartThread(ctx, ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("start thread error: %v", err)
+	}
+	if _, err := thread.Run(rpc.WithCorrelationID(ctx, "corr-1"), "hello", nil); err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+
+	seen := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record struct {
+			Msg           string `json:"msg"`
+			Method        string `json:"method"`
+			CorrelationID string `json:"correlation_id"`
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("decode log line %q: %v", line, err)
+		}
+		switch {
+		case record.Method == "thread/start":
+			if record.CorrelationID == "" || record.CorrelationID == "corr-1" {
+				t.Fatalf("expected thread/start to get its own correlation id: %s", line)
+			}
+		case record.Msg == "codex starting turn", record.Msg == "codex turn completed",
+			record.Msg == "codex notification routed", record.Method == "turn/start":
+			if record.CorrelationID != "corr-1" {
+				t.Fatalf("expected correlation id corr-1: %s", line)
+			}
+			seen[record.Msg] = true
+		}
+	}
+	for _, msg := range []string{"codex starting turn", "json-rpc request sent", "json-rpc response received", "codex notification routed", "codex turn completed"} {
+		if !seen[msg] {
+			t.Fatalf("missing %q log record in:\n%s", msg, buf.String())
+		}
+	}
+}
+
+func TestTurnStreamPauseResume(t *testing.T) {
+	ctx := context.Background()
+	info := protocol.ClientInfo{Name: "codex-go-test", Title: stringPtr("Codex Go SDK Test"), Version: "test"}
+	client, err := New(ctx, Options{Transport: rpc.NewReplayTransport(runTranscript(info, "hello", "final")), ClientInfo: info})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	defer client.Close()
+	thread, err := client.StartThread(ctx, ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("start thread error: %v", err)
+	}
+	stream, err := thread.RunStreamed(ctx, []Input{TextInput("hello")}, nil)
+	if err != nil {
+		t.Fatalf("run streamed error: %v", err)
+	}
+	defer stream.Close()
+
+	stream.Pause()
+	waitCtx, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancel()
+	if _, err := stream.Next(waitCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected paused Next to wait, got %v", err)
+	}
+
+	stream.Resume()
+	for _, want := range []string{"turn/started", "item/completed", "turn/completed"} {
+		note, err := stream.Next(ctx)
+		if err != nil || note.Method != want {
+			t.Fatalf("next = %s, %v; want %s", note.Method, err, want)
+		}
+	}
+}
+
+func TestTurnStreamPauseDuringIterReplace(t *testing.T) {
+	ctx := context.Background()
+	info := protocol.ClientInfo{Name: "codex-go-test", Title: stringPtr("Codex Go SDK Test"), Version: "test"}
+	client, err := New(ctx, Options{Transport: rpc.NewReplayTransport(runTranscript(info, "hello", "final")), ClientInfo: info})
+	if err != nil {
+		t.Fatalf("new client error: %v", err)
+	}
+	defer client.Close()
+	thread, err := client.StartThread(ctx, ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("start thread error: %v", err)
+	}
+	stream, err := thread.RunStreamed(ctx, []Input{TextInput("hello")}, nil)
+	if err != nil {
+		t.Fatalf("run streamed error: %v", err)
+	}
+	defer stream.Close()
+
+	// Run with -race: resume replaces the iterator while another goroutine
+	// pauses and resumes the stream.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			stream.replaceIter(client.currentClient().SubscribeNotifications(0))
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+			stream.Pause()
+			stream.Resume()
+		}
+	}
+}
+
 func TestThreadRunFailsOnTurnFailedNotification(t *testing.T) {
 	ctx := context.Background()
 	info := protocol.ClientInfo{
@@ -63,10 +199,21 @@ func TestThreadRunFailsOnTurnFailedNotification(t *testing.T) {
 		t.Fatalf("start thread error: %v", err)
 	}
 
-	_, err = thread.Run(ctx, "hello", nil)
+	result, err := thread.Run(ctx, "hello", nil)
 	if err == nil || err.Error() != "boom" {
 		t.Fatalf("expected boom error, got %v", err)
 	}
+	var partial *PartialResultError
+	if !errors.As(err, &partial) {
+		t.Fatalf("expected PartialResultError, got %T", err)
+	}
+	var turnErr *TurnError
+	if !errors.As(err, &turnErr) || turnErr.Reason != TurnFailureInternal {
+		t.Fatalf("expected classified TurnError, got %#v", err)
+	}
+	if partial.Result != result || result.TurnID != "turn_1" || len(result.Items) != 1 || result.FinalResponse != "partial" {
+		t.Fatalf("unexpected partial result: %+v", result)
+	}
 }
 
 func TestThreadRunFailsOnCompletedFailedStatus(t *testing.T) {
@@ -121,10 +268,13 @@ func TestThreadRunReturnsStreamNextError(t *testing.T) {
 
 	runCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
 	defer cancel()
-	_, err = thread.Run(runCtx, "hello", nil)
-	if err == nil || err != context.DeadlineExceeded {
+	result, err := thread.Run(runCtx, "hello", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
 		t.Fatalf("expected context deadline exceeded error, got %v", err)
 	}
+	if result == nil {
+		t.Fatalf("expected partial result alongside error")
+	}
 }
 
 func TestResumeThreadWithReplay(t *testing.T) {
@@ -239,6 +389,10 @@ func runFailedTranscript(info protocol.ClientInfo, prompt, failureMessage string
 			Method: "turn/started",
 			Params: mustRaw(map[string]any{"threadId": "thr_123", "turn": turnPayload("turn_1", "inProgress")}),
 		}),
+		readLine(rpc.JSONRPCNotification{
+			Method: "item/completed",
+			Params: mustRaw(map[string]any{"threadId": "thr_123", "item": map[string]any{"text": "partial"}}),
+		}),
 		readLine(rpc.JSONRPCNotification{
 			Method: "turn/failed",
 			Params: mustRaw(map[string]any{
@@ -368,3 +522,161 @@ func mustRaw(payload any) json.RawMessage {
 	}
 	return data
 }
+
+// syncBuffer is a bytes.Buffer safe for a logger writing from the client's
+// goroutines while the test reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestTurnStreamInterrupt(t *testing.T) {
+	var server *fakeAppServer
+	server, codex := newFakeAppServer(t, map[string]fakeHandler{
+		"thread/start": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			return map[string]any{"thread": map[string]any{"id": "thr_1"}}, nil
+		},
+		"turn/start": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			return map[string]any{"turn": map[string]any{"id": "turn_1", "status": "inProgress"}}, nil
+		},
+		"turn/interrupt": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			server.notify("turn/completed", map[string]any{"threadId": "thr_1", "turn": map[string]any{"id": "turn_1", "status": "interrupted"}})
+			return nil, nil
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	thread, err := codex.StartThread(ctx, ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("StartThread: %v", err)
+	}
+	stream, err := thread.RunStreamed(ctx, []Input{TextInput("long task")}, nil)
+	if err != nil {
+		t.Fatalf("RunStreamed: %v", err)
+	}
+	defer stream.Close()
+	if stream.TurnID() != "turn_1" {
+		t.Fatalf("TurnID = %q, want turn_1", stream.TurnID())
+	}
+
+	interrupted := make(chan error, 1)
+	go func() { interrupted <- stream.Interrupt(ctx) }()
+	note, err := stream.Next(ctx)
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	payload, err := parseTurnNotification(note)
+	if err != nil || note.Method != "turn/completed" || payload.Turn == nil || payload.Turn.Status != "interrupted" {
+		t.Fatalf("notification = %s %s, want an interrupted turn/completed", note.Method, note.Raw)
+	}
+	if err := <-interrupted; err != nil {
+		t.Fatalf("Interrupt: %v", err)
+	}
+	if got := string(server.request(t, "turn/interrupt").Params); got != `{"threadId":"thr_1","turnId":"turn_1"}` {
+		t.Fatalf("turn/interrupt params = %s", got)
+	}
+}
+
+func TestThreadInterruptNeedsTurnID(t *testing.T) {
+	_, codex := newFakeAppServer(t, map[string]fakeHandler{
+		"thread/start": func(json.RawMessage) (any, *rpc.JSONRPCErrorError) {
+			return map[string]any{"thread": map[string]any{"id": "thr_1"}}, nil
+		},
+	})
+	thread, err := codex.StartThread(context.Background(), ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("StartThread: %v", err)
+	}
+	if err := thread.Interrupt(context.Background(), ""); err == nil {
+		t.Fatalf("Interrupt succeeded without a turn id")
+	}
+}
+
+func TestTurnResultDecodeItems(t *testing.T) {
+	exitCode := 1
+	tests := []struct {
+		name string
+		raw  string
+		want protocol.ThreadItem
+	}{
+		{
+			name: "user message",
+			raw:  `{"type":"userMessage","id":"u1","content":[{"type":"text","text":"fix it"},{"type":"localImage","path":"/tmp/a.png"}]}`,
+			want: &protocol.UserMessageItem{ID: "u1", Content: []protocol.UserMessageInput{{Type: "text", Text: "fix it"}, {Type: "localImage", Path: "/tmp/a.png"}}},
+		},
+		{
+			name: "agent message",
+			raw:  `{"type":"agentMessage","id":"a1","text":"done"}`,
+			want: &protocol.AgentMessageItem{ID: "a1", Text: "done"},
+		},
+		{
+			name: "reasoning",
+			raw:  `{"type":"reasoning","id":"r1","summary":["look at tests"]}`,
+			want: &protocol.ReasoningItem{ID: "r1", Summary: []string{"look at tests"}},
+		},
+		{
+			name: "command execution",
+			raw:  `{"type":"commandExecution","id":"c1","command":"go test","cwd":"/repo","status":"failed","exitCode":1}`,
+			want: &protocol.CommandExecutionItem{ID: "c1", Command: "go test", Cwd: "/repo", Status: "failed", ExitCode: &exitCode},
+		},
+		{
+			name: "file change",
+			raw:  `{"type":"fileChange","id":"f1","status":"completed","changes":[{"path":"a.go","kind":{"type":"update"},"diff":"+x"}]}`,
+			want: &protocol.FileChangeItem{ID: "f1", Status: "completed", Changes: []protocol.FileUpdateChange{{Path: "a.go", Kind: map[string]any{"type": "update"}, Diff: "+x"}}},
+		},
+		{
+			name: "mcp tool call",
+			raw:  `{"type":"mcpToolCall","id":"m1","server":"docs","tool":"search","status":"failed","arguments":{"q":"x"},"error":{"message":"timeout"}}`,
+			want: &protocol.MCPToolCallItem{ID: "m1", Server: "docs", Tool: "search", Status: "failed", Arguments: json.RawMessage(`{"q":"x"}`), Error: &protocol.MCPToolCallError{Message: "timeout"}},
+		},
+		{
+			name: "web search",
+			raw:  `{"type":"webSearch","id":"w1","query":"go 1.25 release"}`,
+			want: &protocol.WebSearchItem{ID: "w1", Query: "go 1.25 release"},
+		},
+		{
+			name: "error",
+			raw:  `{"type":"error","id":"e1","message":"stream disconnected"}`,
+			want: &protocol.ErrorItem{ID: "e1", Message: "stream disconnected"},
+		},
+		{
+			name: "unknown",
+			raw:  `{"type":"imageGeneration","id":"x1"}`,
+			want: &protocol.UnknownItem{Type: "imageGeneration", ID: "x1", Raw: json.RawMessage(`{"type":"imageGeneration","id":"x1"}`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &TurnResult{Items: []json.RawMessage{json.RawMessage(tt.raw)}}
+			items, err := result.DecodeItems()
+			if err != nil {
+				t.Fatalf("DecodeItems: %v", err)
+			}
+			if len(items) != 1 || !reflect.DeepEqual(items[0], tt.want) {
+				t.Fatalf("items = %#v, want %#v", items, tt.want)
+			}
+			if items[0].ItemID() == "" || items[0].ItemType() == "" {
+				t.Fatalf("item %#v has no id or type", items[0])
+			}
+		})
+	}
+}
+
+func TestTurnResultDecodeItemsMalformed(t *testing.T) {
+	result := &TurnResult{Items: []json.RawMessage{json.RawMessage(`{"type":"agentMessage","id":"a1","text":42}`)}}
+	if _, err := result.DecodeItems(); err == nil {
+		t.Fatalf("DecodeItems succeeded on a malformed item")
+	}
+}
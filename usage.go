@@ -0,0 +1,85 @@
+package codex
+
+import (
+	"github.com/pmenglund/codex-sdk-go/protocol"
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+// TokenUsage is the token usage of a thread, as reported by a
+// thread/tokenUsage/updated notification.
+type TokenUsage struct {
+	ThreadID string `json:"threadId,omitempty"`
+	TurnID   string `json:"turnId,omitempty"`
+	// Last counts the tokens of the most recent model request and Total
+	// those of every request in the thread.
+	Last  TokenCounts `json:"last"`
+	Total TokenCounts `json:"total"`
+	// ContextWindow is the model's context window in tokens, or 0 when the
+	// server did not report it.
+	ContextWindow int `json:"contextWindow,omitempty"`
+}
+
+// TokenCounts breaks a token count down by kind. CachedInput is part of
+// Input, and ReasoningOutput part of Output.
+type TokenCounts struct {
+	Input           int `json:"input"`
+	CachedInput     int `json:"cachedInput"`
+	Output          int `json:"output"`
+	ReasoningOutput int `json:"reasoningOutput"`
+	Total           int `json:"total"`
+}
+
+// ContextUtilization returns the share of the context window the last
+// request filled, between 0 and 1, or 0 when the window is not known.
+func (u TokenUsage) ContextUtilization() float64 {
+	if u.ContextWindow <= 0 {
+		return 0
+	}
+	return min(float64(u.Last.Total)/float64(u.ContextWindow), 1)
+}
+
+// ParseTokenUsage returns the usage carried by a thread/tokenUsage/updated
+// notification. It reports false for other notifications and for payloads
+// that do not decode.
+func ParseTokenUsage(note rpc.Notification) (*TokenUsage, bool) {
+	payload, ok := tokenUsageParams(note)
+	if !ok {
+		return nil, false
+	}
+	usage := &TokenUsage{
+		ThreadID: payload.ThreadID,
+		TurnID:   payload.TurnID,
+		Last:     tokenCounts(payload.TokenUsage.Last),
+		Total:    tokenCounts(payload.TokenUsage.Total),
+	}
+	if window := payload.TokenUsage.ModelContextWindow; window != nil {
+		usage.ContextWindow = *window
+	}
+	return usage, true
+}
+
+// tokenUsageParams decodes the params of a thread/tokenUsage/updated
+// notification, whether or not the client already typed them.
+func tokenUsageParams(note rpc.Notification) (*protocol.ThreadTokenUsageUpdatedNotification, bool) {
+	if note.Method != "thread/tokenUsage/updated" {
+		return nil, false
+	}
+	if typed, ok := note.Params.(protocol.ThreadTokenUsageUpdatedNotification); ok {
+		return &typed, true
+	}
+	var payload protocol.ThreadTokenUsageUpdatedNotification
+	if len(note.Raw) == 0 || note.UnmarshalParams(&payload) != nil {
+		return nil, false
+	}
+	return &payload, true
+}
+
+func tokenCounts(breakdown protocol.TokenUsageBreakdown) TokenCounts {
+	return TokenCounts{
+		Input:           breakdown.InputTokens,
+		CachedInput:     breakdown.CachedInputTokens,
+		Output:          breakdown.OutputTokens,
+		ReasoningOutput: breakdown.ReasoningOutputTokens,
+		Total:           breakdown.TotalTokens,
+	}
+}
@@ -0,0 +1,83 @@
+package codex
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/pmenglund/codex-sdk-go/protocol"
+	"github.com/pmenglund/codex-sdk-go/rpc"
+)
+
+func TestParseTokenUsage(t *testing.T) {
+	window := 200000
+	typed := protocol.ThreadTokenUsageUpdatedNotification{
+		ThreadID: "thr_1",
+		TurnID:   "turn_1",
+		TokenUsage: protocol.ThreadTokenUsage{
+			Last:               protocol.TokenUsageBreakdown{InputTokens: 900, CachedInputTokens: 400, OutputTokens: 100, ReasoningOutputTokens: 60, TotalTokens: 1000},
+			Total:              protocol.TokenUsageBreakdown{InputTokens: 1800, CachedInputTokens: 400, OutputTokens: 200, ReasoningOutputTokens: 90, TotalTokens: 2000},
+			ModelContextWindow: &window,
+		},
+	}
+	want := &TokenUsage{
+		ThreadID:      "thr_1",
+		TurnID:        "turn_1",
+		Last:          TokenCounts{Input: 900, CachedInput: 400, Output: 100, ReasoningOutput: 60, Total: 1000},
+		Total:         TokenCounts{Input: 1800, CachedInput: 400, Output: 200, ReasoningOutput: 90, Total: 2000},
+		ContextWindow: 200000,
+	}
+	tests := []struct {
+		name   string
+		note   rpc.Notification
+		want   *TokenUsage
+		wantOK bool
+	}{
+		{name: "typed params", note: rpc.Notification{Method: "thread/tokenUsage/updated", Params: typed}, want: want, wantOK: true},
+		{name: "raw params", note: rpc.Notification{Method: "thread/tokenUsage/updated", Raw: MustJSON(typed)}, want: want, wantOK: true},
+		{
+			name:   "no context window",
+			note:   rpc.Notification{Method: "thread/tokenUsage/updated", Raw: json.RawMessage(`{"threadId":"thr_1","turnId":"turn_1","tokenUsage":{"last":{"totalTokens":5},"total":{"totalTokens":7}}}`)},
+			want:   &TokenUsage{ThreadID: "thr_1", TurnID: "turn_1", Last: TokenCounts{Total: 5}, Total: TokenCounts{Total: 7}},
+			wantOK: true,
+		},
+		{name: "other method", note: rpc.Notification{Method: "turn/completed", Raw: MustJSON(typed)}},
+		{name: "malformed", note: rpc.Notification{Method: "thread/tokenUsage/updated", Raw: json.RawMessage(`{"tokenUsage":"lots"}`)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseTokenUsage(tt.note)
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseTokenUsage() = %+v, %v; want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTokenUsageContextUtilization(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage TokenUsage
+		want  float64
+	}{
+		{name: "quarter", usage: TokenUsage{Last: TokenCounts{Total: 50}, Total: TokenCounts{Total: 500}, ContextWindow: 200}, want: 0.25},
+		{name: "unknown window", usage: TokenUsage{Last: TokenCounts{Total: 50}}, want: 0},
+		{name: "over window", usage: TokenUsage{Last: TokenCounts{Total: 300}, ContextWindow: 200}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.usage.ContextUtilization(); got != tt.want {
+				t.Fatalf("ContextUtilization() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTurnResultRecordsLastUsage(t *testing.T) {
+	result := &TurnResult{}
+	updateTurnResult(result, tokenUsageNotification(10))
+	updateTurnResult(result, tokenUsageNotification(25))
+	if result.Usage == nil || result.Usage.Total.Total != 25 {
+		t.Fatalf("Usage = %+v, want total 25", result.Usage)
+	}
+}